@@ -0,0 +1,655 @@
+package las
+
+//                _ _   _                    _   _         _____          _
+//     /\        (_) | | |                  | | (_)       / ____|        | |
+//    /  \   _ __ _| |_| |__  _ __ ___   ___| |_ _  ___  | |     ___   __| | ___ _ __
+//   / /\ \ | '__| | __| '_ \| '_ ` _ \ / _ \ __| |/ __| | |    / _ \ / _` |/ _ \ '__|
+//  / ____ \| |  | | |_| | | | | | | | |  __/ |_| | (__  | |___| (_) | (_| |  __/ |
+// /_/    \_\_|  |_|\__|_| |_|_| |_|_|\___|\__|_|\___|  \_____\___/ \__,_|\___|_|
+//
+//
+
+// The arithmetic coder, its adaptive models and the integer compressor follow the LASzip implementation bit by bit, as
+// the compressed output has to be interchangeable with other LASzip readers and writers.
+
+const (
+	AC_MIN_LENGTH = 0x01000000
+	AC_MAX_LENGTH = 0xFFFFFFFF
+
+	BM_LENGTH_SHIFT = 13
+	BM_MAX_COUNT    = 1 << BM_LENGTH_SHIFT
+
+	DM_LENGTH_SHIFT = 15
+	DM_MAX_COUNT    = 1 << DM_LENGTH_SHIFT
+)
+
+// arithmeticBitModel is an adaptive model for a binary symbol.
+type arithmeticBitModel struct {
+	updateCycle     uint32
+	bitsUntilUpdate uint32
+	bit0Prob        uint32
+	bit0Count       uint32
+	bitCount        uint32
+}
+
+func newArithmeticBitModel() (m *arithmeticBitModel) {
+	m = &arithmeticBitModel{}
+	m.init()
+	return
+}
+
+func (m *arithmeticBitModel) init() {
+	m.bit0Count = 1
+	m.bitCount = 2
+	m.bit0Prob = 1 << (BM_LENGTH_SHIFT - 1)
+	m.updateCycle = 4
+	m.bitsUntilUpdate = 4
+}
+
+func (m *arithmeticBitModel) update() {
+	m.bitCount += m.updateCycle
+	if m.bitCount > BM_MAX_COUNT {
+		m.bitCount = (m.bitCount + 1) >> 1
+		m.bit0Count = (m.bit0Count + 1) >> 1
+		if m.bit0Count == m.bitCount {
+			m.bitCount++
+		}
+	}
+	scale := uint32(0x80000000) / m.bitCount
+	m.bit0Prob = (m.bit0Count * scale) >> (31 - BM_LENGTH_SHIFT)
+	m.updateCycle = (5 * m.updateCycle) >> 2
+	if m.updateCycle > 64 {
+		m.updateCycle = 64
+	}
+	m.bitsUntilUpdate = m.updateCycle
+}
+
+// arithmeticModel is an adaptive model for an alphabet of up to 2048 symbols.
+type arithmeticModel struct {
+	symbols            uint32
+	compress           bool
+	distribution       []uint32
+	symbolCount        []uint32
+	decoderTable       []uint32
+	totalCount         uint32
+	updateCycle        uint32
+	symbolsUntilUpdate uint32
+	lastSymbol         uint32
+	tableSize          uint32
+	tableShift         uint32
+}
+
+func newArithmeticModel(symbols uint32, compress bool) (m *arithmeticModel) {
+	m = &arithmeticModel{symbols: symbols, compress: compress}
+	m.init()
+	return
+}
+
+func (m *arithmeticModel) init() {
+	if m.distribution == nil {
+		m.lastSymbol = m.symbols - 1
+		if !m.compress && m.symbols > 16 {
+			tableBits := uint32(3)
+			for m.symbols > (1 << (tableBits + 2)) {
+				tableBits++
+			}
+			m.tableSize = 1 << tableBits
+			m.tableShift = DM_LENGTH_SHIFT - tableBits
+			m.decoderTable = make([]uint32, m.tableSize+2)
+		} else {
+			m.tableSize = 0
+			m.tableShift = 0
+		}
+		m.distribution = make([]uint32, m.symbols)
+		m.symbolCount = make([]uint32, m.symbols)
+	}
+	m.totalCount = 0
+	m.updateCycle = m.symbols
+	for k := range m.symbolCount {
+		m.symbolCount[k] = 1
+	}
+	m.update()
+	m.updateCycle = (m.symbols + 6) >> 1
+	m.symbolsUntilUpdate = m.updateCycle
+}
+
+func (m *arithmeticModel) update() {
+	m.totalCount += m.updateCycle
+	if m.totalCount > DM_MAX_COUNT {
+		m.totalCount = 0
+		for n := range m.symbolCount {
+			m.symbolCount[n] = (m.symbolCount[n] + 1) >> 1
+			m.totalCount += m.symbolCount[n]
+		}
+	}
+	sum := uint32(0)
+	scale := uint32(0x80000000) / m.totalCount
+	if m.compress || m.tableSize == 0 {
+		for k := range m.distribution {
+			m.distribution[k] = (scale * sum) >> (31 - DM_LENGTH_SHIFT)
+			sum += m.symbolCount[k]
+		}
+	} else {
+		s := uint32(0)
+		for k := range m.distribution {
+			m.distribution[k] = (scale * sum) >> (31 - DM_LENGTH_SHIFT)
+			sum += m.symbolCount[k]
+			w := m.distribution[k] >> m.tableShift
+			for s < w {
+				s++
+				m.decoderTable[s] = uint32(k) - 1
+			}
+		}
+		m.decoderTable[0] = 0
+		for s <= m.tableSize {
+			s++
+			m.decoderTable[s] = m.symbols - 1
+		}
+	}
+	m.updateCycle = (5 * m.updateCycle) >> 2
+	maxCycle := (m.symbols + 6) << 3
+	if m.updateCycle > maxCycle {
+		m.updateCycle = maxCycle
+	}
+	m.symbolsUntilUpdate = m.updateCycle
+}
+
+//  _____                     _
+// |  __ \                   | |
+// | |  | | ___  ___ ___   __| | ___ _ __
+// | |  | |/ _ \/ __/ _ \ / _` |/ _ \ '__|
+// | |__| |  __/ (_| (_) | (_| |  __/ |
+// |_____/ \___|\___\___/ \__,_|\___|_|
+//
+//
+
+type arithmeticDecoder struct {
+	input  []byte
+	index  int
+	value  uint32
+	length uint32
+}
+
+// newArithmeticDecoder starts decoding input. Reads past the end of input return zero bytes, as the encoder only
+// flushes as many bytes as needed to disambiguate the final interval.
+func newArithmeticDecoder(input []byte) (d *arithmeticDecoder) {
+	d = &arithmeticDecoder{input: input}
+	d.init()
+	return
+}
+
+func (d *arithmeticDecoder) init() {
+	d.length = AC_MAX_LENGTH
+	d.value = uint32(d.readByte())<<24 | uint32(d.readByte())<<16 | uint32(d.readByte())<<8 | uint32(d.readByte())
+}
+
+func (d *arithmeticDecoder) readByte() (b byte) {
+	if d.index < len(d.input) {
+		b = d.input[d.index]
+	}
+	d.index++
+	return
+}
+
+func (d *arithmeticDecoder) renormDecInterval() {
+	for {
+		d.value = (d.value << 8) | uint32(d.readByte())
+		d.length <<= 8
+		if d.length >= AC_MIN_LENGTH {
+			return
+		}
+	}
+}
+
+func (d *arithmeticDecoder) decodeBit(m *arithmeticBitModel) (sym uint32) {
+	x := m.bit0Prob * (d.length >> BM_LENGTH_SHIFT)
+	if d.value < x {
+		d.length = x
+		m.bit0Count++
+	} else {
+		sym = 1
+		d.value -= x
+		d.length -= x
+	}
+	if d.length < AC_MIN_LENGTH {
+		d.renormDecInterval()
+	}
+	m.bitsUntilUpdate--
+	if m.bitsUntilUpdate == 0 {
+		m.update()
+	}
+	return
+}
+
+func (d *arithmeticDecoder) decodeSymbol(m *arithmeticModel) (sym uint32) {
+	var x uint32
+	y := d.length
+	if m.decoderTable != nil {
+		d.length >>= DM_LENGTH_SHIFT
+		dv := d.value / d.length
+		t := dv >> m.tableShift
+		sym = m.decoderTable[t]
+		n := m.decoderTable[t+1] + 1
+		for n > sym+1 {
+			k := (sym + n) >> 1
+			if m.distribution[k] > dv {
+				n = k
+			} else {
+				sym = k
+			}
+		}
+		x = m.distribution[sym] * d.length
+		if sym != m.lastSymbol {
+			y = m.distribution[sym+1] * d.length
+		}
+	} else {
+		d.length >>= DM_LENGTH_SHIFT
+		n := m.symbols
+		k := n >> 1
+		for {
+			z := d.length * m.distribution[k]
+			if z > d.value {
+				n = k
+				y = z
+			} else {
+				sym = k
+				x = z
+			}
+			k = (sym + n) >> 1
+			if k == sym {
+				break
+			}
+		}
+	}
+	d.value -= x
+	d.length = y - x
+	if d.length < AC_MIN_LENGTH {
+		d.renormDecInterval()
+	}
+	m.symbolCount[sym]++
+	m.symbolsUntilUpdate--
+	if m.symbolsUntilUpdate == 0 {
+		m.update()
+	}
+	return
+}
+
+func (d *arithmeticDecoder) readBit() (sym uint32) {
+	d.length >>= 1
+	sym = d.value / d.length
+	d.value -= d.length * sym
+	if d.length < AC_MIN_LENGTH {
+		d.renormDecInterval()
+	}
+	return
+}
+
+func (d *arithmeticDecoder) readBits(bits uint32) (sym uint32) {
+	if bits > 19 {
+		lower := d.readShort()
+		bits -= 16
+		upper := d.readBits(bits) << 16
+		return upper | lower
+	}
+	d.length >>= bits
+	sym = d.value / d.length
+	d.value -= d.length * sym
+	if d.length < AC_MIN_LENGTH {
+		d.renormDecInterval()
+	}
+	return
+}
+
+func (d *arithmeticDecoder) readShort() (sym uint32) {
+	d.length >>= 16
+	sym = d.value / d.length
+	d.value -= d.length * sym
+	if d.length < AC_MIN_LENGTH {
+		d.renormDecInterval()
+	}
+	return
+}
+
+func (d *arithmeticDecoder) readInt() (sym uint32) {
+	lower := d.readShort()
+	upper := d.readShort()
+	return upper<<16 | lower
+}
+
+func (d *arithmeticDecoder) readInt64() (sym uint64) {
+	lower := uint64(d.readInt())
+	upper := uint64(d.readInt())
+	return upper<<32 | lower
+}
+
+//  ______                     _
+// |  ____|                   | |
+// | |__   _ __   ___ ___   __| | ___ _ __
+// |  __| | '_ \ / __/ _ \ / _` |/ _ \ '__|
+// | |____| | | | (_| (_) | (_| |  __/ |
+// |______|_| |_|\___\___/ \__,_|\___|_|
+//
+//
+
+type arithmeticEncoder struct {
+	output []byte
+	base   uint32
+	length uint32
+}
+
+func newArithmeticEncoder() (e *arithmeticEncoder) {
+	e = &arithmeticEncoder{length: AC_MAX_LENGTH}
+	return
+}
+
+func (e *arithmeticEncoder) propagateCarry() {
+	for index := len(e.output) - 1; index >= 0; index-- {
+		if e.output[index] != 0xFF {
+			e.output[index]++
+			return
+		}
+		e.output[index] = 0
+	}
+}
+
+func (e *arithmeticEncoder) renormEncInterval() {
+	for {
+		e.output = append(e.output, byte(e.base>>24))
+		e.base <<= 8
+		e.length <<= 8
+		if e.length >= AC_MIN_LENGTH {
+			return
+		}
+	}
+}
+
+func (e *arithmeticEncoder) encodeBit(m *arithmeticBitModel, sym uint32) {
+	x := m.bit0Prob * (e.length >> BM_LENGTH_SHIFT)
+	if sym == 0 {
+		e.length = x
+		m.bit0Count++
+	} else {
+		initBase := e.base
+		e.base += x
+		e.length -= x
+		if initBase > e.base {
+			e.propagateCarry()
+		}
+	}
+	if e.length < AC_MIN_LENGTH {
+		e.renormEncInterval()
+	}
+	m.bitsUntilUpdate--
+	if m.bitsUntilUpdate == 0 {
+		m.update()
+	}
+}
+
+func (e *arithmeticEncoder) encodeSymbol(m *arithmeticModel, sym uint32) {
+	initBase := e.base
+	if sym == m.lastSymbol {
+		x := m.distribution[sym] * (e.length >> DM_LENGTH_SHIFT)
+		e.base += x
+		e.length -= x
+	} else {
+		e.length >>= DM_LENGTH_SHIFT
+		x := m.distribution[sym] * e.length
+		e.base += x
+		e.length = m.distribution[sym+1]*e.length - x
+	}
+	if initBase > e.base {
+		e.propagateCarry()
+	}
+	if e.length < AC_MIN_LENGTH {
+		e.renormEncInterval()
+	}
+	m.symbolCount[sym]++
+	m.symbolsUntilUpdate--
+	if m.symbolsUntilUpdate == 0 {
+		m.update()
+	}
+}
+
+func (e *arithmeticEncoder) writeBit(sym uint32) {
+	initBase := e.base
+	e.length >>= 1
+	e.base += sym * e.length
+	if initBase > e.base {
+		e.propagateCarry()
+	}
+	if e.length < AC_MIN_LENGTH {
+		e.renormEncInterval()
+	}
+}
+
+func (e *arithmeticEncoder) writeBits(bits uint32, sym uint32) {
+	if bits > 19 {
+		e.writeShort(sym & 0xFFFF)
+		sym >>= 16
+		bits -= 16
+	}
+	initBase := e.base
+	e.length >>= bits
+	e.base += sym * e.length
+	if initBase > e.base {
+		e.propagateCarry()
+	}
+	if e.length < AC_MIN_LENGTH {
+		e.renormEncInterval()
+	}
+}
+
+func (e *arithmeticEncoder) writeShort(sym uint32) {
+	initBase := e.base
+	e.length >>= 16
+	e.base += sym * e.length
+	if initBase > e.base {
+		e.propagateCarry()
+	}
+	if e.length < AC_MIN_LENGTH {
+		e.renormEncInterval()
+	}
+}
+
+func (e *arithmeticEncoder) writeInt(sym uint32) {
+	e.writeShort(sym & 0xFFFF)
+	e.writeShort(sym >> 16)
+}
+
+func (e *arithmeticEncoder) writeInt64(sym uint64) {
+	e.writeInt(uint32(sym & 0xFFFFFFFF))
+	e.writeInt(uint32(sym >> 32))
+}
+
+// done finishes the encoding and returns the encoded bytes.
+func (e *arithmeticEncoder) done() (output []byte) {
+	initBase := e.base
+	anotherByte := true
+	if e.length > 2*AC_MIN_LENGTH {
+		e.base += AC_MIN_LENGTH
+		e.length = AC_MIN_LENGTH >> 1
+	} else {
+		e.base += AC_MIN_LENGTH >> 1
+		e.length = AC_MIN_LENGTH >> 9
+		anotherByte = false
+	}
+	if initBase > e.base {
+		e.propagateCarry()
+	}
+	e.renormEncInterval()
+	e.output = append(e.output, 0, 0)
+	if anotherByte {
+		e.output = append(e.output, 0)
+	}
+	output = e.output
+	return
+}
+
+//  _____       _                          _____
+// |_   _|     | |                        / ____|
+//   | |  _ __ | |_ ___  __ _  ___ _ __  | |     ___  _ __ ___  _ __  _ __ ___  ___ ___  ___  _ __
+//   | | | '_ \| __/ _ \/ _` |/ _ \ '__| | |    / _ \| '_ ` _ \| '_ \| '__/ _ \/ __/ __|/ _ \| '__|
+//  _| |_| | | | ||  __/ (_| |  __/ |    | |___| (_) | | | | | | |_) | | |  __/\__ \__ \ (_) | |
+// |_____|_| |_|\__\___|\__, |\___|_|     \_____\___/|_| |_| |_| .__/|_|  \___||___/___/\___/|_|
+//                       __/ |                                 | |
+//                      |___/                                  |_|
+
+// integerCompressor codes integers as corrections to a prediction, as done by LASzip.
+type integerCompressor struct {
+	k           uint32
+	contexts    uint32
+	bitsHigh    uint32
+	corrBits    uint32
+	corrRange   uint32
+	corrMin     int32
+	corrMax     int32
+	mBits       []*arithmeticModel
+	mCorrector0 *arithmeticBitModel
+	mCorrector  []*arithmeticModel
+	compress    bool
+}
+
+func newIntegerCompressor(bits uint32, contexts uint32, bitsHigh uint32, rangeIn uint32, compress bool) (ic *integerCompressor) {
+	ic = &integerCompressor{contexts: contexts, bitsHigh: bitsHigh, compress: compress}
+	if rangeIn != 0 {
+		ic.corrBits = 0
+		ic.corrRange = rangeIn
+		for r := rangeIn; r != 0; r >>= 1 {
+			ic.corrBits++
+		}
+		if ic.corrRange == uint32(1)<<(ic.corrBits-1) {
+			ic.corrBits--
+		}
+		ic.corrMin = -int32(ic.corrRange / 2)
+		ic.corrMax = ic.corrMin + int32(ic.corrRange-1)
+	} else if bits != 0 && bits < 32 {
+		ic.corrBits = bits
+		ic.corrRange = uint32(1) << bits
+		ic.corrMin = -int32(ic.corrRange / 2)
+		ic.corrMax = ic.corrMin + int32(ic.corrRange-1)
+	} else {
+		ic.corrBits = 32
+		ic.corrRange = 0
+		ic.corrMin = -2147483648
+		ic.corrMax = 2147483647
+	}
+	ic.init()
+	return
+}
+
+func newDefaultIntegerCompressor(bits uint32, contexts uint32, compress bool) (ic *integerCompressor) {
+	return newIntegerCompressor(bits, contexts, 8, 0, compress)
+}
+
+func (ic *integerCompressor) init() {
+	if ic.mBits == nil {
+		ic.mBits = make([]*arithmeticModel, ic.contexts)
+		for i := range ic.mBits {
+			ic.mBits[i] = newArithmeticModel(ic.corrBits+1, ic.compress)
+		}
+		ic.mCorrector0 = newArithmeticBitModel()
+		ic.mCorrector = make([]*arithmeticModel, ic.corrBits+1)
+		for i := uint32(1); i <= ic.corrBits; i++ {
+			if i <= ic.bitsHigh {
+				ic.mCorrector[i] = newArithmeticModel(uint32(1)<<i, ic.compress)
+			} else {
+				ic.mCorrector[i] = newArithmeticModel(uint32(1)<<ic.bitsHigh, ic.compress)
+			}
+		}
+		return
+	}
+	for _, m := range ic.mBits {
+		m.init()
+	}
+	ic.mCorrector0.init()
+	for i := uint32(1); i <= ic.corrBits; i++ {
+		ic.mCorrector[i].init()
+	}
+}
+
+func (ic *integerCompressor) getK() uint32 {
+	return ic.k
+}
+
+func (ic *integerCompressor) decompress(dec *arithmeticDecoder, pred int32, context uint32) (real int32) {
+	real = pred + ic.readCorrector(dec, ic.mBits[context])
+	if real < 0 {
+		real += int32(ic.corrRange)
+	} else if uint32(real) >= ic.corrRange {
+		real -= int32(ic.corrRange)
+	}
+	return
+}
+
+func (ic *integerCompressor) readCorrector(dec *arithmeticDecoder, mBits *arithmeticModel) (c int32) {
+	ic.k = dec.decodeSymbol(mBits)
+	if ic.k != 0 {
+		if ic.k < 32 {
+			var c1 uint32
+			if ic.k <= ic.bitsHigh {
+				c1 = dec.decodeSymbol(ic.mCorrector[ic.k])
+			} else {
+				k1 := ic.k - ic.bitsHigh
+				c1 = dec.decodeSymbol(ic.mCorrector[ic.k])
+				c2 := dec.readBits(k1)
+				c1 = (c1 << k1) | c2
+			}
+			c = int32(c1)
+			if c >= int32(1)<<(ic.k-1) {
+				c += 1
+			} else {
+				c -= int32((uint32(1) << ic.k) - 1)
+			}
+		} else {
+			c = ic.corrMin
+		}
+	} else {
+		c = int32(dec.decodeBit(ic.mCorrector0))
+	}
+	return
+}
+
+func (ic *integerCompressor) compressValue(enc *arithmeticEncoder, pred int32, real int32, context uint32) {
+	corr := real - pred
+	if corr < ic.corrMin {
+		corr += int32(ic.corrRange)
+	} else if corr > ic.corrMax {
+		corr -= int32(ic.corrRange)
+	}
+	ic.writeCorrector(enc, corr, ic.mBits[context])
+}
+
+func (ic *integerCompressor) writeCorrector(enc *arithmeticEncoder, c int32, mBits *arithmeticModel) {
+	var c1 uint32
+	if c <= 0 {
+		c1 = uint32(-c)
+	} else {
+		c1 = uint32(c - 1)
+	}
+	ic.k = 0
+	for c1 != 0 {
+		c1 >>= 1
+		ic.k++
+	}
+	enc.encodeSymbol(mBits, ic.k)
+	if ic.k != 0 {
+		if ic.k < 32 {
+			if c < 0 {
+				c += int32((uint32(1) << ic.k) - 1)
+			} else {
+				c -= 1
+			}
+			if ic.k <= ic.bitsHigh {
+				enc.encodeSymbol(ic.mCorrector[ic.k], uint32(c))
+			} else {
+				k1 := ic.k - ic.bitsHigh
+				c1 = uint32(c) & ((uint32(1) << k1) - 1)
+				c = int32(uint32(c) >> k1)
+				enc.encodeSymbol(ic.mCorrector[ic.k], uint32(c))
+				enc.writeBits(k1, c1)
+			}
+		}
+	} else {
+		enc.encodeBit(ic.mCorrector0, uint32(c))
+	}
+}
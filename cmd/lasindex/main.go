@@ -0,0 +1,82 @@
+// Command lasindex writes a LAX spatial index next to every LAS and LAZ file given on the command line. Directories
+// are searched for LAS and LAZ files, recursively with -r.
+//
+//	lasindex [-cell_size 5] [-threshold 1000] [-minimum_points 100000] [-maximum_intervals -20] [-r] files or directories...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dalir/las"
+)
+
+func main() {
+	options := las.DefaultLaxOptions()
+	cellSize := flag.Float64("cell_size", float64(options.CellSize), "edge length of the finest quadtree cells")
+	threshold := flag.Uint("threshold", uint(options.Threshold), "largest difference of point indices continuing an interval")
+	minimumPoints := flag.Uint("minimum_points", uint(options.MinimumPoints), "number of points below which sibling cells are merged")
+	maximumIntervals := flag.Int("maximum_intervals", int(options.MaximumIntervals), "maximum number of intervals, per cell if negative")
+	recursive := flag.Bool("r", false, "search directories recursively")
+	verbose := flag.Bool("v", false, "print every indexed file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [options] files or directories...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	options.CellSize = float32(*cellSize)
+	options.Threshold = uint32(*threshold)
+	options.MinimumPoints = uint32(*minimumPoints)
+	options.MaximumIntervals = int32(*maximumIntervals)
+
+	failed := false
+	for _, filename := range findFiles(flag.Args(), *recursive) {
+		if err := las.WriteLaxFile(filename, options); err != nil {
+			fmt.Fprintf(os.Stderr, "error in indexing %s. %v\n", filename, err)
+			failed = true
+			continue
+		}
+		if *verbose {
+			fmt.Printf("wrote %s\n", las.LaxFilename(filename))
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// findFiles returns the files of args, and the LAS and LAZ files of the directories of args.
+func findFiles(args []string, recursive bool) (filenames []string) {
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil || !info.IsDir() {
+			filenames = append(filenames, arg)
+			continue
+		}
+		filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error in searching %s. %v\n", path, err)
+				return nil
+			}
+			if entry.IsDir() {
+				if path != arg && !recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if extension := strings.ToLower(filepath.Ext(path)); extension == ".las" || extension == ".laz" {
+				filenames = append(filenames, path)
+			}
+			return nil
+		})
+	}
+	return
+}
@@ -0,0 +1,367 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+//   _____ ____  _____   _____
+//  / ____/ __ \|  __ \ / ____|
+// | |   | |  | | |__) | |
+// | |   | |  | |  ___/| |
+// | |___| |__| | |    | |____
+//  \_____\____/|_|     \_____|
+//
+//
+
+// A Cloud Optimized Point Cloud (COPC) is a LAZ 1.4 file with point data record format 6, 7 or 8 whose points are
+// organized in an octree. Every node of the octree is stored as an independently compressed LASzip chunk, and the
+// hierarchy EVLR locates the chunk of every node, so that parts of the point cloud can be read without reading the
+// whole file.
+
+const (
+	COPC_USER_ID             = "copc"
+	COPC_INFO_RECORD_ID      = 1
+	COPC_HIERARCHY_RECORD_ID = 1000
+
+	// COPC_HIERARCHY_PAGE_POINTER is the point count of a hierarchy entry referring to a child hierarchy page.
+	COPC_HIERARCHY_PAGE_POINTER = -1
+)
+
+// CopcInfo is the record of the VLR with user ID "copc" and record ID 1. It describes the cube enclosing the octree
+// and where the root page of the hierarchy is stored.
+type CopcInfo struct {
+	CenterX        float64
+	CenterY        float64
+	CenterZ        float64
+	Halfsize       float64
+	Spacing        float64
+	RootHierOffset uint64
+	RootHierSize   uint64
+	GpsTimeMinimum float64
+	GpsTimeMaximum float64
+	Reserved       [11]uint64
+}
+
+func (c *CopcInfo) read(record []byte, offset int64) (err error) {
+	if len(record) < binary.Size(CopcInfo{}) {
+		err = fmt.Errorf("COPC info VLR of %d bytes is too short", len(record))
+		return
+	}
+	if err = binary.Read(bytes.NewReader(record), binary.LittleEndian, c); err != nil {
+		return
+	}
+	return
+}
+
+// BoundingBox is an axis aligned box in the coordinates of the point cloud, i.e. with scale and offset applied.
+type BoundingBox struct {
+	MinX float64
+	MinY float64
+	MinZ float64
+	MaxX float64
+	MaxY float64
+	MaxZ float64
+}
+
+// Intersects reports whether b and other share at least one point.
+func (b BoundingBox) Intersects(other BoundingBox) bool {
+	return b.MinX <= other.MaxX && other.MinX <= b.MaxX &&
+		b.MinY <= other.MaxY && other.MinY <= b.MaxY &&
+		b.MinZ <= other.MaxZ && other.MinZ <= b.MaxZ
+}
+
+// Contains reports whether the point x, y, z lies within b.
+func (b BoundingBox) Contains(x float64, y float64, z float64) bool {
+	return b.MinX <= x && x <= b.MaxX && b.MinY <= y && y <= b.MaxY && b.MinZ <= z && z <= b.MaxZ
+}
+
+// VoxelKey identifies a node of the octree. The root node has level 0, and the nodes of level n are numbered from 0 to
+// 2^n - 1 along every axis.
+type VoxelKey struct {
+	Level int32
+	X     int32
+	Y     int32
+	Z     int32
+}
+
+// Parent returns the key of the node containing k. The root node is its own parent.
+func (k VoxelKey) Parent() VoxelKey {
+	if k.Level == 0 {
+		return k
+	}
+	return VoxelKey{Level: k.Level - 1, X: k.X >> 1, Y: k.Y >> 1, Z: k.Z >> 1}
+}
+
+// Children returns the keys of the eight nodes k is divided into.
+func (k VoxelKey) Children() (children [8]VoxelKey) {
+	for i := range children {
+		children[i] = VoxelKey{
+			Level: k.Level + 1,
+			X:     k.X<<1 | int32(i&1),
+			Y:     k.Y<<1 | int32((i>>1)&1),
+			Z:     k.Z<<1 | int32((i>>2)&1),
+		}
+	}
+	return
+}
+
+// Bounds returns the cube covered by the node k of the octree described by info.
+func (k VoxelKey) Bounds(info CopcInfo) (bounds BoundingBox) {
+	size := 2 * info.Halfsize / float64(uint64(1)<<uint(k.Level))
+	bounds.MinX = info.CenterX - info.Halfsize + float64(k.X)*size
+	bounds.MinY = info.CenterY - info.Halfsize + float64(k.Y)*size
+	bounds.MinZ = info.CenterZ - info.Halfsize + float64(k.Z)*size
+	bounds.MaxX = bounds.MinX + size
+	bounds.MaxY = bounds.MinY + size
+	bounds.MaxZ = bounds.MinZ + size
+	return
+}
+
+// CopcEntry is an entry of a hierarchy page. If PointCount is positive, Offset and ByteSize locate the compressed
+// points of the node Key. A PointCount of COPC_HIERARCHY_PAGE_POINTER refers to the child hierarchy page at Offset.
+type CopcEntry struct {
+	Key        VoxelKey
+	Offset     uint64
+	ByteSize   int32
+	PointCount int32
+}
+
+//   _____ ____  _____   _____ _____                _
+//  / ____/ __ \|  __ \ / ____|  __ \              | |
+// | |   | |  | | |__) | |    | |__) |___  __ _  __| | ___ _ __
+// | |   | |  | |  ___/| |    |  _  // _ \/ _` |/ _` |/ _ \ '__|
+// | |___| |__| | |    | |____| | \ \  __/ (_| | (_| |  __/ |
+//  \_____\____/|_|     \_____|_|  \_\___|\__,_|\__,_|\___|_|
+//
+//
+
+// CopcReader reads the nodes of a COPC file. Hierarchy pages are read on demand, so a query only touches the pages
+// and point chunks of the nodes it returns.
+//
+//	reader, err := las.OpenCopcReader("./pointcloud.copc.laz")
+//	if err != nil {
+//		return err
+//	}
+//	defer reader.Close()
+//	entries, err := reader.QueryNodes(box, reader.LevelForResolution(0.5))
+//	for _, entry := range entries {
+//		pdrs, err := reader.ReadNode(entry)
+//		...
+//	}
+type CopcReader struct {
+	Header PublicHeaderBlock
+	Vlrs   []VLR
+	Evlrs  []EVLR
+	Info   CopcInfo
+
+	reader io.ReaderAt
+	closer io.Closer
+	lasZip *LASzip
+	nodes  map[VoxelKey]CopcEntry
+	pages  map[VoxelKey]CopcEntry
+}
+
+// OpenCopcReader opens a COPC file and reads its root hierarchy page.
+func OpenCopcReader(filename string) (r *CopcReader, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	if r, err = NewCopcReader(file); err != nil {
+		file.Close()
+		return
+	}
+	r.closer = file
+	return
+}
+
+// NewCopcReader reads the nodes of a COPC file from reader, starting with its root hierarchy page. Close does not close
+// reader.
+func NewCopcReader(reader io.ReaderAt) (r *CopcReader, err error) {
+	l := Las{}
+	if err = l.readPHB(reader); err != nil {
+		return
+	}
+	if err = l.readVLRs(reader); err != nil {
+		return
+	}
+	// the size of the file is not known, so the EVLRs are read like from a stream
+	if err = l.readEVLRs(reader, -1); err != nil {
+		return
+	}
+	if !l.isFileCompressed() {
+		err = fmt.Errorf("COPC files must be compressed")
+		return
+	}
+	info, err := l.getCopcInfo()
+	if err != nil {
+		return
+	}
+	lasZip, err := l.getLASzip()
+	if err != nil {
+		return
+	}
+	if lasZip.Compressor != LASZIP_COMPRESSOR_LAYERED_CHUNKED {
+		err = fmt.Errorf("COPC files must use the layered chunked LASzip compressor")
+		return
+	}
+
+	r = &CopcReader{
+		Header: l.Header,
+		Vlrs:   l.Vlrs,
+		Evlrs:  l.Evlrs,
+		Info:   *info,
+		reader: reader,
+		lasZip: lasZip,
+		nodes:  make(map[VoxelKey]CopcEntry),
+		pages:  make(map[VoxelKey]CopcEntry),
+	}
+	if err = r.readPage(info.RootHierOffset, info.RootHierSize); err != nil {
+		r = nil
+		return
+	}
+	return
+}
+
+// getCopcInfo returns the COPC info record of the file's VLRs.
+func (l *Las) getCopcInfo() (info *CopcInfo, err error) {
+	for _, vlr := range l.Vlrs {
+		for _, record := range vlr.record {
+			if c, ok := record.(*CopcInfo); ok {
+				info = c
+				return
+			}
+		}
+	}
+	err = fmt.Errorf("file has no COPC info VLR")
+	return
+}
+
+// Close closes the file opened by OpenCopcReader.
+func (r *CopcReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// readPage reads the hierarchy page of size bytes at offset.
+func (r *CopcReader) readPage(offset uint64, size uint64) (err error) {
+	entrySize := uint64(binary.Size(CopcEntry{}))
+	if size%entrySize != 0 {
+		err = fmt.Errorf("COPC hierarchy page of %d bytes is not a multiple of %d bytes", size, entrySize)
+		return
+	}
+	pageInBytes := make([]byte, size)
+	if err = readAt(r.reader, pageInBytes, int64(offset)); err != nil {
+		return
+	}
+	entries := make([]CopcEntry, size/entrySize)
+	if err = binary.Read(bytes.NewReader(pageInBytes), binary.LittleEndian, entries); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.PointCount == COPC_HIERARCHY_PAGE_POINTER {
+			r.pages[entry.Key] = entry
+		} else {
+			r.nodes[entry.Key] = entry
+		}
+	}
+	return
+}
+
+// getEntry returns the hierarchy entry of the node key, reading its hierarchy page if needed.
+func (r *CopcReader) getEntry(key VoxelKey) (entry CopcEntry, found bool, err error) {
+	if entry, found = r.nodes[key]; found {
+		return
+	}
+	page, isPage := r.pages[key]
+	if !isPage {
+		return
+	}
+	delete(r.pages, key)
+	if page.ByteSize < 0 {
+		err = fmt.Errorf("COPC hierarchy page of node %v has a negative size", key)
+		return
+	}
+	if err = r.readPage(page.Offset, uint64(page.ByteSize)); err != nil {
+		return
+	}
+	entry, found = r.nodes[key]
+	return
+}
+
+// LevelForResolution returns the lowest level of the octree whose point spacing is at most resolution.
+func (r *CopcReader) LevelForResolution(resolution float64) (level int32) {
+	if resolution <= 0 {
+		level = math.MaxInt32
+		return
+	}
+	if r.Info.Spacing > resolution {
+		level = int32(math.Ceil(math.Log2(r.Info.Spacing / resolution)))
+	}
+	return
+}
+
+// QueryNodes returns the entries of the nodes holding points which intersect box, down to and including maxLevel. As
+// every level of the octree adds detail to the levels above it, the points of all returned nodes together form the
+// point cloud within box at the resolution of maxLevel.
+func (r *CopcReader) QueryNodes(box BoundingBox, maxLevel int32) (entries []CopcEntry, err error) {
+	stack := []VoxelKey{{}}
+	for len(stack) != 0 {
+		key := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if key.Level > maxLevel || !key.Bounds(r.Info).Intersects(box) {
+			continue
+		}
+		var entry CopcEntry
+		var found bool
+		if entry, found, err = r.getEntry(key); err != nil {
+			return
+		}
+		if !found {
+			continue
+		}
+		if entry.PointCount > 0 {
+			entries = append(entries, entry)
+		}
+		children := key.Children()
+		stack = append(stack, children[:]...)
+	}
+	return
+}
+
+// Nodes returns the entries of all nodes holding points.
+func (r *CopcReader) Nodes() (entries []CopcEntry, err error) {
+	half := r.Info.Halfsize
+	box := BoundingBox{
+		MinX: r.Info.CenterX - half, MinY: r.Info.CenterY - half, MinZ: r.Info.CenterZ - half,
+		MaxX: r.Info.CenterX + half, MaxY: r.Info.CenterY + half, MaxZ: r.Info.CenterZ + half,
+	}
+	return r.QueryNodes(box, math.MaxInt32)
+}
+
+// ReadNode reads and decompresses the points of the node entry.
+func (r *CopcReader) ReadNode(entry CopcEntry) (pdrs PDRs, err error) {
+	if entry.PointCount <= 0 || entry.ByteSize <= 0 {
+		err = fmt.Errorf("COPC node %v holds no points", entry.Key)
+		return
+	}
+	chunk := lazChunk{offset: int64(entry.Offset), size: uint64(entry.ByteSize), numberOfPoints: uint64(entry.PointCount)}
+	raw, err := r.lasZip.readLazChunk(r.reader, chunk)
+	if err != nil {
+		return
+	}
+	if pdrs, err = newPDRs(r.Header.PointDataRecordFormat&LASZIP_FORMAT_MASK, chunk.numberOfPoints); err != nil {
+		return
+	}
+	if err = pdrs.decode(raw, uint64(r.Header.PointDataRecordLength)); err != nil {
+		return
+	}
+	return
+}
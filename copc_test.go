@@ -0,0 +1,244 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testCopcInfo describes the octree of the points of newTestCopc, a cube of 0 to 100 in x, y and z.
+var testCopcInfo = CopcInfo{CenterX: 50, CenterY: 50, CenterZ: 50, Halfsize: 50, Spacing: 10}
+
+// newTestCopc returns a COPC file of numberOfPDRs random points within 0 to 100 in x, y and z, distributed over the
+// levels 0 to 3 of the octree, and the raw point data records of every node. The nodes of levels 0 and 1 are stored in
+// the root hierarchy page, and every node of level 2 heads a child page holding its subtree.
+func newTestCopc(t *testing.T, numberOfPDRs int) (data []byte, nodes map[VoxelKey][]byte) {
+	t.Helper()
+	l := newTestLas(t, 4, 6, numberOfPDRs, 0)
+	info := testCopcInfo
+	vlr, err := NewVLR(COPC_USER_ID, COPC_INFO_RECORD_ID, "", encodeTestCopcInfo(t, info))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.AddVLR(vlr)
+
+	nodes = make(map[VoxelKey][]byte)
+	counts := make(map[VoxelKey]int32)
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < numberOfPDRs; i++ {
+		point := l.Pdrs.At(i)
+		point.SetX(random.Int31n(10000))
+		point.SetY(random.Int31n(10000))
+		point.SetZ(random.Int31n(10000))
+		level := int32(i % 4)
+		cells := int32(1) << uint(level)
+		x, y, z := l.Header.GetScaledXYZ(point)
+		key := VoxelKey{Level: level, X: int32(x / 100 * float64(cells)), Y: int32(y / 100 * float64(cells)), Z: int32(z / 100 * float64(cells))}
+		nodes[key] = appendTestPoint(t, nodes[key], point, l.Header.PointDataRecordLength)
+		counts[key]++
+	}
+	if err = l.UpdateHeaderFromPoints(); err != nil {
+		t.Fatal(err)
+	}
+	data = newTestLaz(t, l)
+
+	lasZip, err := newLASzip(6, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every ancestor of a node holding points has an entry, so that the nodes below it are found
+	entries := make(map[VoxelKey]CopcEntry)
+	for key, raw := range nodes {
+		chunk, err := lasZip.compressChunk(raw, uint64(counts[key]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[key] = CopcEntry{Key: key, Offset: uint64(len(data)), ByteSize: int32(len(chunk)), PointCount: counts[key]}
+		data = append(data, chunk...)
+		for parent := key; parent.Level > 0; {
+			parent = parent.Parent()
+			if _, ok := entries[parent]; !ok {
+				entries[parent] = CopcEntry{Key: parent}
+			}
+		}
+	}
+
+	pages := make(map[VoxelKey][]CopcEntry)
+	for key, entry := range entries {
+		page := key
+		for page.Level > 2 {
+			page = page.Parent()
+		}
+		if page.Level < 2 {
+			page = VoxelKey{}
+		}
+		pages[page] = append(pages[page], entry)
+	}
+	var root []CopcEntry
+	for page, pageEntries := range pages {
+		if page.Level == 0 {
+			root = append(root, pageEntries...)
+			continue
+		}
+		offset := len(data)
+		data = append(data, encodeTestCopcEntries(t, pageEntries)...)
+		root = append(root, CopcEntry{Key: page, Offset: uint64(offset), ByteSize: int32(len(data) - offset), PointCount: COPC_HIERARCHY_PAGE_POINTER})
+	}
+	info.RootHierOffset = uint64(len(data))
+	data = append(data, encodeTestCopcEntries(t, root)...)
+	info.RootHierSize = uint64(len(data)) - info.RootHierOffset
+
+	// the info VLR is the first VLR, its payload follows the header and the VLR header of 54 bytes
+	copy(data[l.Header.HeaderSize+54:], encodeTestCopcInfo(t, info))
+	return
+}
+
+func encodeTestCopcInfo(t *testing.T, info CopcInfo) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	if err := binary.Write(&buffer, binary.LittleEndian, info); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func encodeTestCopcEntries(t *testing.T, entries []CopcEntry) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	if err := binary.Write(&buffer, binary.LittleEndian, entries); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func sortTestVoxelKeys(keys []VoxelKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Level != b.Level {
+			return a.Level < b.Level
+		}
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.Z < b.Z
+	})
+}
+
+func TestCopcQueryNodes(t *testing.T) {
+	data, nodes := newTestCopc(t, 2000)
+	whole := BoundingBox{MinX: 0, MinY: 0, MinZ: 0, MaxX: 100, MaxY: 100, MaxZ: 100}
+	corner := BoundingBox{MinX: 0, MinY: 0, MinZ: 0, MaxX: 20, MaxY: 20, MaxZ: 20}
+	slab := BoundingBox{MinX: 30, MinY: -10, MinZ: 60, MaxX: 40, MaxY: 110, MaxZ: 65}
+
+	tests := []struct {
+		name     string
+		box      BoundingBox
+		maxLevel int32
+	}{
+		{"whole cube", whole, math.MaxInt32},
+		{"whole cube down to level 0", whole, 0},
+		{"whole cube down to level 1", whole, 1},
+		{"whole cube down to level 2", whole, 2},
+		{"corner", corner, math.MaxInt32},
+		{"corner down to level 1", corner, 1},
+		{"slab", slab, math.MaxInt32},
+		{"point", BoundingBox{MinX: 62.5, MinY: 62.5, MinZ: 62.5, MaxX: 62.5, MaxY: 62.5, MaxZ: 62.5}, 3},
+		{"outside", BoundingBox{MinX: 200, MinY: 200, MinZ: 200, MaxX: 300, MaxY: 300, MaxZ: 300}, math.MaxInt32},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := NewCopcReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries, err := r.QueryNodes(test.box, test.maxLevel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, want []VoxelKey
+			for _, entry := range entries {
+				got = append(got, entry.Key)
+			}
+			for key := range nodes {
+				if key.Level <= test.maxLevel && key.Bounds(r.Info).Intersects(test.box) {
+					want = append(want, key)
+				}
+			}
+			sortTestVoxelKeys(got)
+			sortTestVoxelKeys(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got nodes %v, want %v", got, want)
+			}
+			if test.maxLevel < 2 && len(r.pages) == 0 {
+				t.Error("child hierarchy pages below the queried levels were read")
+			}
+
+			for _, entry := range entries {
+				pdrs, err := r.ReadNode(entry)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var buffer bytes.Buffer
+				if err = pdrs.write(&buffer, uint64(r.Header.PointDataRecordLength)); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(buffer.Bytes(), nodes[entry.Key]) {
+					t.Errorf("points of node %v differ", entry.Key)
+				}
+			}
+		})
+	}
+}
+
+func TestCopcNodes(t *testing.T) {
+	data, nodes := newTestCopc(t, 1000)
+	r, err := NewCopcReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Info.Spacing != testCopcInfo.Spacing || r.Info.Halfsize != testCopcInfo.Halfsize {
+		t.Errorf("read info %+v, want %+v", r.Info, testCopcInfo)
+	}
+	entries, err := r.Nodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(nodes) {
+		t.Errorf("got %d nodes, want %d", len(entries), len(nodes))
+	}
+	var numberOfPoints int32
+	for _, entry := range entries {
+		numberOfPoints += entry.PointCount
+	}
+	if numberOfPoints != 1000 {
+		t.Errorf("nodes hold %d points, want 1000", numberOfPoints)
+	}
+	if len(r.pages) != 0 {
+		t.Errorf("%d child hierarchy pages were not read", len(r.pages))
+	}
+}
+
+func TestCopcLevelForResolution(t *testing.T) {
+	r := &CopcReader{Info: testCopcInfo}
+	tests := []struct {
+		resolution float64
+		level      int32
+	}{
+		{20, 0}, {10, 0}, {9, 1}, {5, 1}, {3, 2}, {2.5, 2}, {1, 4}, {0, math.MaxInt32}, {-1, math.MaxInt32},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.resolution), func(t *testing.T) {
+			if level := r.LevelForResolution(test.resolution); level != test.level {
+				t.Errorf("got level %d, want %d", level, test.level)
+			}
+		})
+	}
+}
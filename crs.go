@@ -3,6 +3,7 @@ package las
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 )
 
 //   _____ _____   _____
@@ -48,9 +49,17 @@ type KeyEntry struct {
 
 func (g *GeoKeyDirectoryTag) read(record []byte, offset int64) (err error) {
 	headerSize := binary.Size(GeoKeyDirectoryHeader{})
+	if len(record) < headerSize {
+		err = fmt.Errorf("GeoKeyDirectoryTag of %d bytes is shorter than its %d bytes header", len(record), headerSize)
+		return
+	}
 	if err = binary.Read(bytes.NewReader(record[:headerSize]), binary.LittleEndian, &g.header); err != nil {
 		return
 	}
+	if keysSize := int(g.header.NumberOfKeys) * binary.Size(KeyEntry{}); len(record)-headerSize < keysSize {
+		err = fmt.Errorf("GeoKeyDirectoryTag declares %d keys of %d bytes, but holds %d bytes", g.header.NumberOfKeys, keysSize, len(record)-headerSize)
+		return
+	}
 	g.keys = make([]KeyEntry, g.header.NumberOfKeys)
 	if err = binary.Read(bytes.NewReader(record[headerSize:]), binary.LittleEndian, &g.keys); err != nil {
 		return
@@ -67,9 +76,15 @@ func (g *GeoKeyDirectoryTag) read(record []byte, offset int64) (err error) {
 //                                                                                                __/ |
 //                                                                                               |___/
 
+// GeoDoubleParamsTag maps the offset of each double in the file the record was read from to its value. The offsets of
+// a record created by NewVLR or NewEVLR start at 0.
 type GeoDoubleParamsTag map[int64]float64
 
 func (g GeoDoubleParamsTag) read(record []byte, offset int64) (err error) {
+	if len(record)%8 != 0 {
+		err = fmt.Errorf("GeoDoubleParamsTag of %d bytes is not a multiple of 8 bytes", len(record))
+		return
+	}
 	for index := 0; index < len(record); index += 8 {
 		var value float64
 		if err = binary.Read(bytes.NewReader(record[index:index+8]), binary.LittleEndian, &value); err != nil {
@@ -90,6 +105,8 @@ func (g GeoDoubleParamsTag) read(record []byte, offset int64) (err error) {
 //                                                                                     __/ |
 //                                                                                    |___/
 
+// GeoAsciiParamsTag maps the offset of each NUL-terminated string in the file the record was read from to the string.
+// The offsets of a record created by NewVLR or NewEVLR start at 0.
 type GeoAsciiParamsTag map[int64]string
 
 func (g GeoAsciiParamsTag) read(record []byte, offset int64) (err error) {
@@ -161,8 +178,12 @@ type Classification struct {
 	Description [15]byte
 }
 
-func (c ClassificationLookup) read(record []byte, offset int64) (err error) {
-	if err = binary.Read(bytes.NewReader(record), binary.LittleEndian, &c); err != nil {
+func (c *ClassificationLookup) read(record []byte, offset int64) (err error) {
+	if len(record) != binary.Size(c) {
+		err = fmt.Errorf("classification lookup of %d bytes is not %d bytes", len(record), binary.Size(c))
+		return
+	}
+	if err = binary.Read(bytes.NewReader(record), binary.LittleEndian, c); err != nil {
 		return
 	}
 	return
@@ -198,30 +219,30 @@ func (t *TextAreaDescription) read(record []byte, offset int64) (err error) {
 //                                     __/ |
 //                                    |___/
 
+// ExtraBytes holds the descriptors of the LASF_Spec record 4 VLR, one per extra bytes attribute in the order the
+// attributes are stored in the extra bytes of the point data records.
 type ExtraBytes []ExtraBytesDescriptor
 
+// ExtraBytesDescriptor describes an extra bytes attribute. NoData, Min and Max hold a value of the data type of the
+// attribute, widened to 8 bytes; the second and third entries of the arrays are only used by the deprecated array
+// data types 11 to 30.
 type ExtraBytesDescriptor struct {
-	reserved    [2]uint8
-	dataType    uint8
-	options     uint8
-	name        [32]byte
-	unused      [4]uint8
-	noData      [8]byte
-	deprecated1 [16]uint8
-	min         [8]byte
-	deprecated2 [16]uint8
-	max         [8]byte
-	deprecated3 [16]uint8
-	scale       float64
-	deprecated4 [16]uint8
-	offset      float64
-	deprecated5 [16]uint8
-	description [32]byte
+	Reserved    [2]uint8
+	DataType    uint8
+	Options     uint8
+	Name        [32]byte
+	Unused      [4]uint8
+	NoData      [3][8]byte
+	Min         [3][8]byte
+	Max         [3][8]byte
+	Scale       [3]float64
+	Offset      [3]float64
+	Description [32]byte
 }
 
 func (e *ExtraBytes) read(record []byte, offset int64) (err error) {
 	sizeOfDescriptor := binary.Size(ExtraBytesDescriptor{})
-	for index := 0; index < len(record); index += sizeOfDescriptor {
+	for index := 0; index+sizeOfDescriptor <= len(record); index += sizeOfDescriptor {
 		value := ExtraBytesDescriptor{}
 		if err = binary.Read(bytes.NewReader(record[index:index+sizeOfDescriptor]), binary.LittleEndian, &value); err != nil {
 			return
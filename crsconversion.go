@@ -0,0 +1,1017 @@
+package las
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+//  ______ _____   _____  _____
+// |  ____|  __ \ / ____|/ ____|
+// | |__  | |__) | (___ | |  __
+// |  __| |  ___/ \___ \| | |_ |
+// | |____| |     ____) | |__| |
+// |______|_|    |_____/ \_____|
+//
+//
+
+// The coordinate reference systems known by their EPSG code when converting GeoKeys to WKT. GeoKeys giving other
+// codes are only converted if they also define the system with their parameters.
+
+type epsgEllipsoid struct {
+	name              string
+	semiMajorAxis     float64
+	inverseFlattening float64
+}
+
+var epsgEllipsoids = map[int]epsgEllipsoid{
+	1024: {"CGCS2000", 6378137, 298.257222101},
+	7001: {"Airy 1830", 6377563.396, 299.3249646},
+	7004: {"Bessel 1841", 6377397.155, 299.1528128},
+	7008: {"Clarke 1866", 6378206.4, 294.978698213898},
+	7019: {"GRS 1980", 6378137, 298.257222101},
+	7022: {"International 1924", 6378388, 297},
+	7030: {"WGS 84", 6378137, 298.257223563},
+}
+
+type epsgGeographicCRS struct {
+	name          string
+	datum         string
+	datumCode     int
+	ellipsoidCode int
+}
+
+var epsgGeographicCRSs = map[int]epsgGeographicCRS{
+	4152: {"NAD83(HARN)", "NAD83_High_Accuracy_Reference_Network", 6152, 7019},
+	4167: {"NZGD2000", "New_Zealand_Geodetic_Datum_2000", 6167, 7019},
+	4171: {"RGF93 v1", "Reseau_Geodesique_Francais_1993", 6171, 7019},
+	4230: {"ED50", "European_Datum_1950", 6230, 7022},
+	4258: {"ETRS89", "European_Terrestrial_Reference_System_1989", 6258, 7019},
+	4267: {"NAD27", "North_American_Datum_1927", 6267, 7008},
+	4269: {"NAD83", "North_American_Datum_1983", 6269, 7019},
+	4277: {"OSGB36", "Ordnance_Survey_of_Great_Britain_1936", 6277, 7001},
+	4283: {"GDA94", "Geocentric_Datum_of_Australia_1994", 6283, 7019},
+	4289: {"Amersfoort", "Amersfoort", 6289, 7004},
+	4326: {"WGS 84", "WGS_1984", 6326, 7030},
+	4490: {"China Geodetic Coordinate System 2000", "China_2000", 1043, 1024},
+	4612: {"JGD2000", "Japanese_Geodetic_Datum_2000", 6612, 7019},
+	4617: {"NAD83(CSRS)", "NAD83_Canadian_Spatial_Reference_System", 6140, 7019},
+	4674: {"SIRGAS 2000", "Sistema_de_Referencia_Geocentrico_para_las_AmericaS_2000", 6674, 7019},
+	4759: {"NAD83(NSRS2007)", "NAD83_National_Spatial_Reference_System_2007", 6759, 7019},
+	6318: {"NAD83(2011)", "NAD83_National_Spatial_Reference_System_2011", 1116, 7019},
+	6668: {"JGD2011", "Japanese_Geodetic_Datum_2011", 1128, 7019},
+	7844: {"GDA2020", "Geocentric_Datum_of_Australia_2020", 1168, 7019},
+}
+
+// epsgProjectedCRS defines a projected CRS by its base geographic CRS, its projection method and the values of its
+// parameters in degrees, metres and unity.
+type epsgProjectedCRS struct {
+	name       string
+	base       int
+	method     int
+	parameters map[int]float64
+}
+
+var epsgProjectedCRSs = map[int]epsgProjectedCRS{
+	2154:  {"RGF93 v1 / Lambert-93", 4171, 9802, map[int]float64{8821: 46.5, 8822: 3, 8823: 49, 8824: 44, 8826: 700000, 8827: 6600000}},
+	2193:  {"NZGD2000 / New Zealand Transverse Mercator 2000", 4167, 9807, map[int]float64{8801: 0, 8802: 173, 8805: 0.9996, 8806: 1600000, 8807: 10000000}},
+	3035:  {"ETRS89-extended / LAEA Europe", 4258, 9820, map[int]float64{8801: 52, 8802: 10, 8806: 4321000, 8807: 3210000}},
+	3395:  {"WGS 84 / World Mercator", 4326, 9804, map[int]float64{8801: 0, 8802: 0, 8805: 1, 8806: 0, 8807: 0}},
+	3857:  {"WGS 84 / Pseudo-Mercator", 4326, 1024, map[int]float64{8801: 0, 8802: 0, 8806: 0, 8807: 0}},
+	5070:  {"NAD83 / Conus Albers", 4269, 9822, map[int]float64{8821: 23, 8822: -96, 8823: 29.5, 8824: 45.5, 8826: 0, 8827: 0}},
+	27700: {"OSGB36 / British National Grid", 4277, 9807, map[int]float64{8801: 49, 8802: -2, 8805: 0.9996012717, 8806: 400000, 8807: -100000}},
+	28992: {"Amersfoort / RD New", 4289, 9809, map[int]float64{8801: 52.1561605555556, 8802: 5.38763888888889, 8805: 0.9999079, 8806: 155000, 8807: 463000}},
+}
+
+// epsgUTMZones are the ranges of EPSG codes of UTM zones, by the code of zone 1 of each base CRS.
+var epsgUTMZones = []struct {
+	first     int
+	last      int
+	zoneOne   int
+	base      int
+	south     bool
+	zoneLabel string
+}{
+	{32601, 32660, 32600, 4326, false, "UTM zone %dN"},
+	{32701, 32760, 32700, 4326, true, "UTM zone %dS"},
+	{26901, 26923, 26900, 4269, false, "UTM zone %dN"},
+	{26703, 26722, 26700, 4267, false, "UTM zone %dN"},
+	{25828, 25838, 25800, 4258, false, "UTM zone %dN"},
+	{6330, 6348, 6329, 6318, false, "UTM zone %dN"},
+	{28348, 28358, 28300, 4283, true, "MGA zone %d"},
+	{7846, 7859, 7800, 7844, true, "MGA zone %d"},
+}
+
+// getEPSGProjectedCRS returns the definition of the projected CRS with the EPSG code.
+func getEPSGProjectedCRS(code int) (definition epsgProjectedCRS, ok bool) {
+	for _, zones := range epsgUTMZones {
+		if code < zones.first || code > zones.last {
+			continue
+		}
+		zone := code - zones.zoneOne
+		definition = newUTMDefinition(zones.base, zone, zones.south)
+		definition.name = epsgGeographicCRSs[zones.base].name + " / " + fmt.Sprintf(zones.zoneLabel, zone)
+		return definition, true
+	}
+	definition, ok = epsgProjectedCRSs[code]
+	return
+}
+
+func newUTMDefinition(base int, zone int, south bool) epsgProjectedCRS {
+	falseNorthing := 0.0
+	if south {
+		falseNorthing = 10000000
+	}
+	return epsgProjectedCRS{base: base, method: 9807, parameters: map[int]float64{
+		8801: 0, 8802: float64(6*zone - 183), 8805: 0.9996, 8806: 500000, 8807: falseNorthing}}
+}
+
+type epsgVerticalCRS struct {
+	name      string
+	datum     string
+	datumCode int
+	unitCode  uint16
+}
+
+var epsgVerticalCRSs = map[int]epsgVerticalCRS{
+	3855: {"EGM2008 height", "EGM2008 geoid", 1027, 9001},
+	5701: {"ODN height", "Ordnance Datum Newlyn", 5101, 9001},
+	5702: {"NGVD29 height (ftUS)", "National Geodetic Vertical Datum 1929", 5102, 9003},
+	5703: {"NAVD88 height", "North American Vertical Datum 1988", 5103, 9001},
+	5709: {"NAP height", "Normaal Amsterdams Peil", 5109, 9001},
+	5711: {"AHD height", "Australian Height Datum", 5111, 9001},
+	5714: {"MSL height", "Mean Sea Level", 5100, 9001},
+	5773: {"EGM96 height", "EGM96 geoid", 5171, 9001},
+	5783: {"DHHN92 height", "Deutsches Haupthoehennetz 1992", 5181, 9001},
+	6360: {"NAVD88 height (ftUS)", "North American Vertical Datum 1988", 5103, 9003},
+	6647: {"CGVD2013(CGG2013) height", "Canadian Geodetic Vertical Datum of 2013 (CGG2013)", 1127, 9001},
+	7837: {"DHHN2016 height", "Deutsches Haupthoehennetz 2016", 1170, 9001},
+	7839: {"NZVD2016 height", "New Zealand Vertical Datum 2016", 1169, 9001},
+	8228: {"NAVD88 height (ft)", "North American Vertical Datum 1988", 5103, 9002},
+}
+
+func newEPSGAuthority(code int) *WKTAuthority {
+	return &WKTAuthority{Name: "EPSG", Code: strconv.Itoa(code)}
+}
+
+func newEPSGEllipsoid(code int) *WKTEllipsoid {
+	ellipsoid, ok := epsgEllipsoids[code]
+	if !ok {
+		return nil
+	}
+	return &WKTEllipsoid{Name: ellipsoid.name, SemiMajorAxis: ellipsoid.semiMajorAxis, InverseFlattening: ellipsoid.inverseFlattening,
+		Unit: newMetreUnit(), Authority: newEPSGAuthority(code)}
+}
+
+// newEPSGGeographicCRS returns the known geographic CRS with the EPSG code, or nil.
+func newEPSGGeographicCRS(code int) *WKTCRS {
+	geographic, ok := epsgGeographicCRSs[code]
+	if !ok {
+		return nil
+	}
+	return &WKTCRS{
+		Type:          WKT_CRS_GEOGRAPHIC,
+		Name:          geographic.name,
+		Datum:         &WKTDatum{Name: geographic.datum, Ellipsoid: newEPSGEllipsoid(geographic.ellipsoidCode), Authority: newEPSGAuthority(geographic.datumCode)},
+		PrimeMeridian: &WKTPrimeMeridian{Name: "Greenwich", Unit: newDegreeUnit(), Authority: newEPSGAuthority(8901)},
+		Unit:          newDegreeUnit(),
+		Authority:     newEPSGAuthority(code),
+	}
+}
+
+// newEPSGProjectedCRS returns the known projected CRS with the EPSG code, or nil.
+func newEPSGProjectedCRS(code int) *WKTCRS {
+	definition, ok := getEPSGProjectedCRS(code)
+	if !ok {
+		return nil
+	}
+	crs := &WKTCRS{Type: WKT_CRS_PROJECTED, Name: definition.name, BaseCRS: newEPSGGeographicCRS(definition.base), Unit: newMetreUnit(), Authority: newEPSGAuthority(code)}
+	method := getWKTMethod(definition.method, "")
+	crs.Projection = &WKTProjection{Method: method.name, MethodCode: method.code}
+	for _, parameter := range method.parameters {
+		value := definition.parameters[parameter.code]
+		unitType := getWKTParameterUnitType(parameter.code, "")
+		crs.Projection.Parameters = append(crs.Projection.Parameters, WKTParameter{
+			Name: wktParameterNames[parameter.code], Code: parameter.code, Value: value, Unit: crs.getImpliedParameterUnit(unitType)})
+	}
+	return crs
+}
+
+// newEPSGVerticalCRS returns the vertical CRS with the EPSG code, named after the code if it is not known.
+func newEPSGVerticalCRS(code int, unit *WKTUnit) *WKTCRS {
+	vertical, ok := epsgVerticalCRSs[code]
+	if !ok {
+		return &WKTCRS{Type: WKT_CRS_VERTICAL, Name: fmt.Sprintf("EPSG:%d", code), Datum: &WKTDatum{Name: "unknown"}, Unit: unit, Authority: newEPSGAuthority(code)}
+	}
+	return &WKTCRS{
+		Type:      WKT_CRS_VERTICAL,
+		Name:      vertical.name,
+		Datum:     &WKTDatum{Name: vertical.datum, Authority: newEPSGAuthority(vertical.datumCode)},
+		Unit:      newEPSGUnit(vertical.unitCode, nil, WKT_UNIT_LENGTH),
+		Authority: newEPSGAuthority(code),
+	}
+}
+
+// NewWKTCRSFromEPSG returns the projected, geographic or vertical CRS with the EPSG code if it is known to this
+// package: the UTM zones of WGS 84, NAD83, NAD27, ETRS89 and NAD83(2011), the MGA zones and a selection of national
+// systems. Other systems can be given by their WKT, see ParseWKT.
+func NewWKTCRSFromEPSG(code int) (crs *WKTCRS, err error) {
+	if crs = newEPSGProjectedCRS(code); crs != nil {
+		return
+	}
+	if crs = newEPSGGeographicCRS(code); crs != nil {
+		return
+	}
+	if _, ok := epsgVerticalCRSs[code]; ok {
+		crs = newEPSGVerticalCRS(code, nil)
+		return
+	}
+	err = fmt.Errorf("%w: EPSG:%d is not a known coordinate reference system", ErrNotFound, code)
+	return
+}
+
+// newEPSGUnit returns the GeoTIFF unit with the code. User-defined units take their size from sizeKey.
+func newEPSGUnit(code uint16, sizeKey *GeoKey, unitType string) *WKTUnit {
+	if code == geoTIFFDegreeUnit {
+		return newDegreeUnit()
+	}
+	name, size := getUnit(code, sizeKey)
+	unit := &WKTUnit{Type: unitType, Name: name, Factor: size}
+	if code != GEO_KEY_USER_DEFINED {
+		unit.Authority = newEPSGAuthority(int(code))
+	}
+	return unit
+}
+
+//   _____                _______ _____ ______ ______
+//  / ____|              |__   __|_   _|  ____|  ____|
+// | |  __  ___  ___        | |    | | | |__  | |__
+// | | |_ |/ _ \/ _ \       | |    | | |  __| |  __|
+// | |__| |  __/ (_) |      | |   _| |_| |    | |
+//  \_____|\___|\___/       |_|  |_____|_|    |_|
+//
+//
+
+// The values of PROJ_COORD_TRANS_GEO_KEY which can be converted to WKT.
+const (
+	COORD_TRANS_TRANSVERSE_MERCATOR         uint16 = 1
+	COORD_TRANS_OBLIQUE_MERCATOR            uint16 = 3
+	COORD_TRANS_MERCATOR                    uint16 = 7
+	COORD_TRANS_LAMBERT_CONF_CONIC_2SP      uint16 = 8
+	COORD_TRANS_LAMBERT_CONF_CONIC_1SP      uint16 = 9
+	COORD_TRANS_LAMBERT_AZIM_EQUAL_AREA     uint16 = 10
+	COORD_TRANS_ALBERS_EQUAL_AREA           uint16 = 11
+	COORD_TRANS_POLAR_STEREOGRAPHIC         uint16 = 15
+	COORD_TRANS_OBLIQUE_STEREOGRAPHIC       uint16 = 16
+	COORD_TRANS_EQUIRECTANGULAR             uint16 = 17
+	COORD_TRANS_CASSINI_SOLDNER             uint16 = 18
+	geoTIFFUTMNorthProjections              uint16 = 16000
+	geoTIFFUTMSouthProjections              uint16 = 16100
+	geoTIFFDegreeUnit                       uint16 = 9102
+	geoTIFFMetreUnit                        uint16 = 9001
+	geoTIFFGreenwichPrimeMeridian           uint16 = 8901
+	geoTIFFMaximumEPSGCode                         = 32766
+	geoTIFFDefaultVerticalUnitForMissingKey        = 9001
+)
+
+// geoTIFFProjection maps a coordinate transformation of GeoTIFF to a projection method. The keys of a parameter are
+// tried in turn when reading, as writers differ in the keys they use; the first one is written.
+type geoTIFFProjection struct {
+	coordTrans uint16
+	method     int
+	parameters []geoTIFFParameter
+}
+
+type geoTIFFParameter struct {
+	code int
+	keys []uint16
+}
+
+var (
+	geoTIFFNaturalOriginLatitude  = []uint16{PROJ_NAT_ORIGIN_LAT_GEO_KEY, PROJ_FALSE_ORIGIN_LAT_GEO_KEY, PROJ_CENTER_LAT_GEO_KEY}
+	geoTIFFNaturalOriginLongitude = []uint16{PROJ_NAT_ORIGIN_LONG_GEO_KEY, PROJ_FALSE_ORIGIN_LONG_GEO_KEY, PROJ_CENTER_LONG_GEO_KEY, PROJ_STRAIGHT_VERT_POLE_LONG_GEO_KEY}
+	geoTIFFCenterLatitude         = []uint16{PROJ_CENTER_LAT_GEO_KEY, PROJ_NAT_ORIGIN_LAT_GEO_KEY, PROJ_FALSE_ORIGIN_LAT_GEO_KEY}
+	geoTIFFCenterLongitude        = []uint16{PROJ_CENTER_LONG_GEO_KEY, PROJ_NAT_ORIGIN_LONG_GEO_KEY, PROJ_FALSE_ORIGIN_LONG_GEO_KEY}
+	geoTIFFFalseOriginLatitude    = []uint16{PROJ_FALSE_ORIGIN_LAT_GEO_KEY, PROJ_NAT_ORIGIN_LAT_GEO_KEY, PROJ_CENTER_LAT_GEO_KEY}
+	geoTIFFFalseOriginLongitude   = []uint16{PROJ_FALSE_ORIGIN_LONG_GEO_KEY, PROJ_NAT_ORIGIN_LONG_GEO_KEY, PROJ_CENTER_LONG_GEO_KEY}
+	geoTIFFFalseEasting           = []uint16{PROJ_FALSE_EASTING_GEO_KEY, PROJ_FALSE_ORIGIN_EASTING_GEO_KEY, PROJ_CENTER_EASTING_GEO_KEY}
+	geoTIFFFalseNorthing          = []uint16{PROJ_FALSE_NORTHING_GEO_KEY, PROJ_FALSE_ORIGIN_NORTHING_GEO_KEY, PROJ_CENTER_NORTHING_GEO_KEY}
+	geoTIFFFalseOriginEasting     = []uint16{PROJ_FALSE_ORIGIN_EASTING_GEO_KEY, PROJ_FALSE_EASTING_GEO_KEY, PROJ_CENTER_EASTING_GEO_KEY}
+	geoTIFFFalseOriginNorthing    = []uint16{PROJ_FALSE_ORIGIN_NORTHING_GEO_KEY, PROJ_FALSE_NORTHING_GEO_KEY, PROJ_CENTER_NORTHING_GEO_KEY}
+	geoTIFFScale                  = []uint16{PROJ_SCALE_AT_NAT_ORIGIN_GEO_KEY, PROJ_SCALE_AT_CENTER_GEO_KEY}
+	geoTIFFStandardParallel1      = []uint16{PROJ_STD_PARALLEL1_GEO_KEY}
+	geoTIFFStandardParallel2      = []uint16{PROJ_STD_PARALLEL2_GEO_KEY}
+)
+
+var geoTIFFProjections = []geoTIFFProjection{
+	{COORD_TRANS_TRANSVERSE_MERCATOR, 9807, []geoTIFFParameter{
+		{8801, geoTIFFNaturalOriginLatitude}, {8802, geoTIFFNaturalOriginLongitude}, {8805, geoTIFFScale},
+		{8806, geoTIFFFalseEasting}, {8807, geoTIFFFalseNorthing}}},
+	{COORD_TRANS_LAMBERT_CONF_CONIC_1SP, 9801, []geoTIFFParameter{
+		{8801, geoTIFFNaturalOriginLatitude}, {8802, geoTIFFNaturalOriginLongitude}, {8805, geoTIFFScale},
+		{8806, geoTIFFFalseEasting}, {8807, geoTIFFFalseNorthing}}},
+	{COORD_TRANS_LAMBERT_CONF_CONIC_2SP, 9802, []geoTIFFParameter{
+		{8821, geoTIFFFalseOriginLatitude}, {8822, geoTIFFFalseOriginLongitude}, {8823, geoTIFFStandardParallel1},
+		{8824, geoTIFFStandardParallel2}, {8826, geoTIFFFalseOriginEasting}, {8827, geoTIFFFalseOriginNorthing}}},
+	{COORD_TRANS_ALBERS_EQUAL_AREA, 9822, []geoTIFFParameter{
+		{8821, geoTIFFNaturalOriginLatitude}, {8822, geoTIFFNaturalOriginLongitude}, {8823, geoTIFFStandardParallel1},
+		{8824, geoTIFFStandardParallel2}, {8826, geoTIFFFalseEasting}, {8827, geoTIFFFalseNorthing}}},
+	// GeoTIFF tells the variants of Mercator apart by the standard parallel, see getGeoTIFFProjection
+	{COORD_TRANS_MERCATOR, 9804, []geoTIFFParameter{
+		{8801, geoTIFFNaturalOriginLatitude}, {8802, geoTIFFNaturalOriginLongitude}, {8805, geoTIFFScale},
+		{8806, geoTIFFFalseEasting}, {8807, geoTIFFFalseNorthing}}},
+	{COORD_TRANS_MERCATOR, 9805, []geoTIFFParameter{
+		{8823, geoTIFFStandardParallel1}, {8802, geoTIFFNaturalOriginLongitude},
+		{8806, geoTIFFFalseEasting}, {8807, geoTIFFFalseNorthing}}},
+	{COORD_TRANS_POLAR_STEREOGRAPHIC, 9810, []geoTIFFParameter{
+		{8801, geoTIFFNaturalOriginLatitude},
+		{8802, []uint16{PROJ_STRAIGHT_VERT_POLE_LONG_GEO_KEY, PROJ_NAT_ORIGIN_LONG_GEO_KEY, PROJ_CENTER_LONG_GEO_KEY}},
+		{8805, geoTIFFScale}, {8806, geoTIFFFalseEasting}, {8807, geoTIFFFalseNorthing}}},
+	{COORD_TRANS_OBLIQUE_STEREOGRAPHIC, 9809, []geoTIFFParameter{
+		{8801, geoTIFFNaturalOriginLatitude}, {8802, geoTIFFNaturalOriginLongitude}, {8805, geoTIFFScale},
+		{8806, geoTIFFFalseEasting}, {8807, geoTIFFFalseNorthing}}},
+	{COORD_TRANS_LAMBERT_AZIM_EQUAL_AREA, 9820, []geoTIFFParameter{
+		{8801, geoTIFFCenterLatitude}, {8802, geoTIFFCenterLongitude}, {8806, geoTIFFFalseEasting}, {8807, geoTIFFFalseNorthing}}},
+	{COORD_TRANS_EQUIRECTANGULAR, 1028, []geoTIFFParameter{
+		{8823, geoTIFFStandardParallel1}, {8801, geoTIFFCenterLatitude}, {8802, geoTIFFCenterLongitude},
+		{8806, geoTIFFFalseEasting}, {8807, geoTIFFFalseNorthing}}},
+	{COORD_TRANS_CASSINI_SOLDNER, 9806, []geoTIFFParameter{
+		{8801, geoTIFFNaturalOriginLatitude}, {8802, geoTIFFNaturalOriginLongitude}, {8806, geoTIFFFalseEasting}, {8807, geoTIFFFalseNorthing}}},
+	{COORD_TRANS_OBLIQUE_MERCATOR, 9815, []geoTIFFParameter{
+		{8811, geoTIFFCenterLatitude}, {8812, geoTIFFCenterLongitude}, {8813, []uint16{PROJ_AZIMUTH_ANGLE_GEO_KEY}},
+		{8814, []uint16{PROJ_RECTIFIED_GRID_ANGLE_GEO_KEY}}, {8815, []uint16{PROJ_SCALE_AT_CENTER_GEO_KEY, PROJ_SCALE_AT_NAT_ORIGIN_GEO_KEY}},
+		{8816, []uint16{PROJ_CENTER_EASTING_GEO_KEY, PROJ_FALSE_EASTING_GEO_KEY}}, {8817, []uint16{PROJ_CENTER_NORTHING_GEO_KEY, PROJ_FALSE_NORTHING_GEO_KEY}}}},
+}
+
+// getGeoTIFFProjection returns the projection of the coordinate transformation. Mercator with a standard parallel is
+// variant B.
+func getGeoTIFFProjection(coordTrans uint16, info *CRSInfo) *geoTIFFProjection {
+	if coordTrans == COORD_TRANS_MERCATOR && info.GetGeoKey(PROJ_STD_PARALLEL1_GEO_KEY) != nil {
+		return getGeoTIFFProjectionOfMethod(9805)
+	}
+	for index := range geoTIFFProjections {
+		if geoTIFFProjections[index].coordTrans == coordTrans {
+			return &geoTIFFProjections[index]
+		}
+	}
+	return nil
+}
+
+func getGeoTIFFProjectionOfMethod(method int) *geoTIFFProjection {
+	for index := range geoTIFFProjections {
+		if geoTIFFProjections[index].method == method {
+			return &geoTIFFProjections[index]
+		}
+	}
+	return nil
+}
+
+// newShortGeoKey, newDoubleGeoKey and newASCIIGeoKey return keys holding a value.
+func newShortGeoKey(id uint16, value uint16) GeoKey {
+	return GeoKey{ID: id, Name: GetGeoKeyName(id), Shorts: []uint16{value}}
+}
+
+func newDoubleGeoKey(id uint16, values ...float64) GeoKey {
+	return GeoKey{ID: id, Name: GetGeoKeyName(id), Doubles: values}
+}
+
+func newASCIIGeoKey(id uint16, value string) GeoKey {
+	return GeoKey{ID: id, Name: GetGeoKeyName(id), ASCII: value}
+}
+
+// EncodeGeoKeys returns the payloads of the GeoKeyDirectoryTag, GeoDoubleParamsTag and GeoAsciiParamsTag holding keys,
+// the inverse of ResolveGeoKeys. The keys are sorted by ID; doubles and ascii are nil if no key needs them. Keys with a
+// single short store it in the directory.
+func EncodeGeoKeys(keys []GeoKey) (directory []byte, doubles []byte, ascii []byte, err error) {
+	sorted := append([]GeoKey(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	if len(sorted) > math.MaxUint16 {
+		err = fmt.Errorf("%d GeoKeys exceed the maximum of %d", len(sorted), math.MaxUint16)
+		return
+	}
+
+	shorts := []uint16{1, 1, 0, uint16(len(sorted))}
+	var extraShorts []uint16
+	var doubleValues []float64
+	var asciiText []byte
+	firstExtraShort := GEO_KEY_DIRECTORY_SIZE * (len(sorted) + 1)
+	for _, key := range sorted {
+		var location uint16
+		var count, offset int
+		switch {
+		case key.ASCII != "":
+			location, count, offset = GEO_ASCII_PARAMS_TAG, len(key.ASCII)+1, len(asciiText)
+			// GeoTIFF terminates strings with a pipe
+			asciiText = append(append(asciiText, key.ASCII...), '|')
+		case len(key.Doubles) != 0:
+			location, count, offset = GEO_DOUBLE_PARAMS_TAG, len(key.Doubles), len(doubleValues)
+			doubleValues = append(doubleValues, key.Doubles...)
+		case len(key.Shorts) == 1:
+			location, count, offset = 0, 1, int(key.Shorts[0])
+		case len(key.Shorts) > 1:
+			location, count, offset = GEO_KEY_DIRECTORY_TAG, len(key.Shorts), firstExtraShort+len(extraShorts)
+			extraShorts = append(extraShorts, key.Shorts...)
+		default:
+			err = fmt.Errorf("%s holds no value", key.Name)
+			return
+		}
+		if count > math.MaxUint16 || offset > math.MaxUint16 {
+			err = fmt.Errorf("values of %s exceed the GeoTIFF records", key.Name)
+			return
+		}
+		shorts = append(shorts, key.ID, location, uint16(count), uint16(offset))
+	}
+	shorts = append(shorts, extraShorts...)
+
+	directory = make([]byte, 2*len(shorts))
+	for index, value := range shorts {
+		binary.LittleEndian.PutUint16(directory[2*index:], value)
+	}
+	if len(doubleValues) != 0 {
+		doubles = make([]byte, 8*len(doubleValues))
+		for index, value := range doubleValues {
+			binary.LittleEndian.PutUint64(doubles[8*index:], math.Float64bits(value))
+		}
+	}
+	if len(asciiText) != 0 {
+		ascii = append(asciiText, 0)
+	}
+	return
+}
+
+//   _____                              _
+//  / ____|                            (_)
+// | |     ___  _ ____   _____ _ __ ___ _  ___  _ __
+// | |    / _ \| '_ \ \ / / _ \ '__/ __| |/ _ \| '_ \
+// | |___| (_) | | | \ V /  __/ |  \__ \ | (_) | | | |
+//  \_____\___/|_| |_|\_/ \___|_|  |___/_|\___/|_| |_|
+//
+//
+
+func (c *CRSInfo) getShort(id uint16) uint16 {
+	if key := c.GetGeoKey(id); key != nil {
+		return key.GetShort()
+	}
+	return GEO_KEY_UNDEFINED
+}
+
+func (c *CRSInfo) getDouble(id uint16) (value float64, ok bool) {
+	if key := c.GetGeoKey(id); key != nil && len(key.Doubles) != 0 {
+		return key.Doubles[0], true
+	}
+	return
+}
+
+func (c *CRSInfo) getASCII(ids ...uint16) string {
+	for _, id := range ids {
+		if key := c.GetGeoKey(id); key != nil && key.ASCII != "" {
+			return key.ASCII
+		}
+	}
+	return ""
+}
+
+// NewWKTCRSFromGeoKeys converts GeoKeys to a WKT coordinate reference system: a geographic, geocentric or projected
+// CRS, combined with the vertical CRS into a compound CRS if the keys have one. Systems are taken from their EPSG code
+// if it is known to this package, or else built from the keys defining them; EPSG codes of projected and geographic
+// systems which are unknown and not defined by the keys are an error.
+func NewWKTCRSFromGeoKeys(keys []GeoKey) (crs *WKTCRS, err error) {
+	info := NewCRSInfoFromGeoKeys(keys)
+	modelType := info.ModelType
+	if modelType == GEO_KEY_UNDEFINED {
+		if info.GetGeoKey(PROJECTED_CS_TYPE_GEO_KEY) != nil {
+			modelType = MODEL_TYPE_PROJECTED
+		} else if info.GetGeoKey(GEOGRAPHIC_TYPE_GEO_KEY) != nil {
+			modelType = MODEL_TYPE_GEOGRAPHIC
+		}
+	}
+
+	var horizontal *WKTCRS
+	switch modelType {
+	case MODEL_TYPE_PROJECTED:
+		horizontal, err = newProjectedCRSFromGeoKeys(info)
+	case MODEL_TYPE_GEOGRAPHIC, MODEL_TYPE_GEOCENTRIC:
+		horizontal, err = newGeographicCRSFromGeoKeys(info, modelType == MODEL_TYPE_GEOCENTRIC)
+	case GEO_KEY_UNDEFINED:
+	default:
+		err = fmt.Errorf("GeoTIFF model type %d is not supported", modelType)
+	}
+	if err != nil {
+		return
+	}
+	vertical := newVerticalCRSFromGeoKeys(info)
+
+	switch {
+	case horizontal != nil && vertical != nil:
+		crs = NewCompoundWKTCRS(horizontal, vertical)
+	case horizontal != nil:
+		crs = horizontal
+	case vertical != nil:
+		crs = vertical
+	default:
+		err = fmt.Errorf("GeoKeys define no coordinate reference system")
+	}
+	return
+}
+
+// newGeographicCRSFromGeoKeys returns the geographic CRS of the keys, or their geocentric CRS.
+func newGeographicCRSFromGeoKeys(info *CRSInfo, geocentric bool) (crs *WKTCRS, err error) {
+	code := info.getShort(GEOGRAPHIC_TYPE_GEO_KEY)
+	angularUnits := info.getShort(GEOG_ANGULAR_UNITS_GEO_KEY)
+	if angularUnits == GEO_KEY_UNDEFINED {
+		angularUnits = geoTIFFDegreeUnit
+	}
+	angularUnit := newEPSGUnit(angularUnits, info.GetGeoKey(GEOG_ANGULAR_UNIT_SIZE_GEO_KEY), WKT_UNIT_ANGLE)
+
+	if code != GEO_KEY_UNDEFINED && code != GEO_KEY_USER_DEFINED {
+		if crs = newEPSGGeographicCRS(int(code)); crs == nil {
+			err = fmt.Errorf("geographic CRS EPSG:%d is not known", code)
+			return
+		}
+	} else {
+		crs = &WKTCRS{Type: WKT_CRS_GEOGRAPHIC, Name: info.getASCII(GEOG_CITATION_GEO_KEY, GT_CITATION_GEO_KEY), Unit: angularUnit}
+		if crs.Name == "" {
+			crs.Name = "unnamed"
+		}
+		if crs.Datum, err = newDatumFromGeoKeys(info); err != nil {
+			return
+		}
+		crs.PrimeMeridian = &WKTPrimeMeridian{Name: "Greenwich", Unit: angularUnit, Authority: newEPSGAuthority(int(geoTIFFGreenwichPrimeMeridian))}
+		if longitude, ok := info.getDouble(GEOG_PRIME_MERIDIAN_LONG_GEO_KEY); ok && longitude != 0 {
+			crs.PrimeMeridian = &WKTPrimeMeridian{Name: "unnamed", Longitude: longitude, Unit: angularUnit}
+		}
+	}
+	if key := info.GetGeoKey(GEOG_TOWGS84_GEO_KEY); key != nil && (len(key.Doubles) == 3 || len(key.Doubles) == 7) {
+		crs.Datum.ToWGS84 = append([]float64(nil), key.Doubles...)
+	}
+	if geocentric {
+		crs.Type = WKT_CRS_GEOCENTRIC
+		crs.Unit = newEPSGUnit(geoTIFFMetreUnit, nil, WKT_UNIT_LENGTH)
+		if linearUnits := info.getShort(GEOG_LINEAR_UNITS_GEO_KEY); linearUnits != GEO_KEY_UNDEFINED {
+			crs.Unit = newEPSGUnit(linearUnits, info.GetGeoKey(GEOG_LINEAR_UNIT_SIZE_GEO_KEY), WKT_UNIT_LENGTH)
+		}
+		crs.Authority = nil
+	}
+	return
+}
+
+// newDatumFromGeoKeys returns the geodetic datum of user-defined geographic keys. The ellipsoid is taken from the
+// datum or ellipsoid code if known, or else from the axes and flattening.
+func newDatumFromGeoKeys(info *CRSInfo) (datum *WKTDatum, err error) {
+	datumCode := int(info.getShort(GEOG_GEODETIC_DATUM_GEO_KEY))
+	datum = &WKTDatum{Name: "unknown"}
+	for _, geographic := range epsgGeographicCRSs {
+		if geographic.datumCode == datumCode {
+			datum = &WKTDatum{Name: geographic.datum, Ellipsoid: newEPSGEllipsoid(geographic.ellipsoidCode), Authority: newEPSGAuthority(datumCode)}
+			break
+		}
+	}
+	if datum.Authority == nil && datumCode != int(GEO_KEY_UNDEFINED) && datumCode != int(GEO_KEY_USER_DEFINED) {
+		datum.Name = fmt.Sprintf("EPSG:%d", datumCode)
+		datum.Authority = newEPSGAuthority(datumCode)
+	}
+
+	if ellipsoid := newEPSGEllipsoid(int(info.getShort(GEOG_ELLIPSOID_GEO_KEY))); ellipsoid != nil {
+		datum.Ellipsoid = ellipsoid
+	} else if semiMajorAxis, ok := info.getDouble(GEOG_SEMI_MAJOR_AXIS_GEO_KEY); ok {
+		// the axes are given in the linear units of the geographic CRS
+		linearUnits := info.getShort(GEOG_LINEAR_UNITS_GEO_KEY)
+		if linearUnits == GEO_KEY_UNDEFINED {
+			linearUnits = geoTIFFMetreUnit
+		}
+		_, size := getUnit(linearUnits, info.GetGeoKey(GEOG_LINEAR_UNIT_SIZE_GEO_KEY))
+		if size == 0 {
+			err = fmt.Errorf("linear unit %d of the ellipsoid is not known", linearUnits)
+			return
+		}
+		datum.Ellipsoid = &WKTEllipsoid{Name: "unnamed", SemiMajorAxis: semiMajorAxis * size, Unit: newMetreUnit()}
+		if inverseFlattening, ok := info.getDouble(GEOG_INV_FLATTENING_GEO_KEY); ok {
+			datum.Ellipsoid.InverseFlattening = inverseFlattening
+		} else if semiMinorAxis, ok := info.getDouble(GEOG_SEMI_MINOR_AXIS_GEO_KEY); ok && semiMinorAxis != semiMajorAxis {
+			datum.Ellipsoid.InverseFlattening = semiMajorAxis / (semiMajorAxis - semiMinorAxis)
+		}
+	}
+	if datum.Ellipsoid == nil {
+		err = fmt.Errorf("GeoKeys do not define the ellipsoid of the geographic CRS")
+	}
+	return
+}
+
+// newProjectedCRSFromGeoKeys returns the projected CRS of the keys, from its EPSG code or its projection keys.
+func newProjectedCRSFromGeoKeys(info *CRSInfo) (crs *WKTCRS, err error) {
+	code := info.getShort(PROJECTED_CS_TYPE_GEO_KEY)
+	linearUnits := info.getShort(PROJ_LINEAR_UNITS_GEO_KEY)
+	if code != GEO_KEY_UNDEFINED && code != GEO_KEY_USER_DEFINED {
+		if crs = newEPSGProjectedCRS(int(code)); crs != nil {
+			return
+		}
+		if info.GetGeoKey(PROJ_COORD_TRANS_GEO_KEY) == nil && info.GetGeoKey(PROJECTION_GEO_KEY) == nil {
+			err = fmt.Errorf("projected CRS EPSG:%d is not known and the GeoKeys do not define it", code)
+			return
+		}
+	}
+
+	crs = &WKTCRS{Type: WKT_CRS_PROJECTED, Name: info.getASCII(PCS_CITATION_GEO_KEY, GT_CITATION_GEO_KEY)}
+	if crs.Name == "" {
+		crs.Name = "unnamed"
+	}
+	if code != GEO_KEY_UNDEFINED && code != GEO_KEY_USER_DEFINED {
+		crs.Authority = newEPSGAuthority(int(code))
+	}
+	if crs.BaseCRS, err = newGeographicCRSFromGeoKeys(info, false); err != nil {
+		return
+	}
+	if linearUnits == GEO_KEY_UNDEFINED {
+		linearUnits = geoTIFFMetreUnit
+	}
+	crs.Unit = newEPSGUnit(linearUnits, info.GetGeoKey(PROJ_LINEAR_UNIT_SIZE_GEO_KEY), WKT_UNIT_LENGTH)
+
+	// ProjectionGeoKey may give a UTM zone instead of the parameters
+	if projection := info.getShort(PROJECTION_GEO_KEY); projection > geoTIFFUTMNorthProjections && projection <= geoTIFFUTMSouthProjections+60 {
+		zone := int(projection - geoTIFFUTMNorthProjections)
+		if projection > geoTIFFUTMSouthProjections {
+			zone = int(projection - geoTIFFUTMSouthProjections)
+		}
+		if zone >= 1 && zone <= 60 && info.GetGeoKey(PROJ_COORD_TRANS_GEO_KEY) == nil {
+			definition := newUTMDefinition(0, zone, projection > geoTIFFUTMSouthProjections)
+			crs.Projection = newProjectionFromDefinition(definition, crs)
+			return
+		}
+	}
+
+	coordTrans := info.getShort(PROJ_COORD_TRANS_GEO_KEY)
+	projection := getGeoTIFFProjection(coordTrans, info)
+	if projection == nil {
+		err = fmt.Errorf("GeoTIFF coordinate transformation %d is not supported", coordTrans)
+		return
+	}
+	method := getWKTMethod(projection.method, "")
+	crs.Projection = &WKTProjection{Method: method.name, MethodCode: method.code}
+	for _, parameter := range projection.parameters {
+		unitType := getWKTParameterUnitType(parameter.code, "")
+		value := 0.0
+		if unitType == WKT_UNIT_SCALE {
+			value = 1
+		}
+		for _, id := range parameter.keys {
+			if keyValue, ok := info.getDouble(id); ok {
+				value = keyValue
+				break
+			}
+		}
+		crs.Projection.Parameters = append(crs.Projection.Parameters, WKTParameter{
+			Name: wktParameterNames[parameter.code], Code: parameter.code, Value: value, Unit: crs.getImpliedParameterUnit(unitType)})
+	}
+	return
+}
+
+// newProjectionFromDefinition returns the projection of a definition in degrees and metres for the projected CRS c.
+func newProjectionFromDefinition(definition epsgProjectedCRS, c *WKTCRS) (projection *WKTProjection) {
+	method := getWKTMethod(definition.method, "")
+	projection = &WKTProjection{Method: method.name, MethodCode: method.code}
+	for _, parameter := range method.parameters {
+		unit := newUnityUnit()
+		switch getWKTParameterUnitType(parameter.code, "") {
+		case WKT_UNIT_ANGLE:
+			unit = newDegreeUnit()
+		case WKT_UNIT_LENGTH:
+			unit = newMetreUnit()
+		}
+		target := c.getImpliedParameterUnit(unit.Type)
+		projection.Parameters = append(projection.Parameters, WKTParameter{
+			Name: wktParameterNames[parameter.code], Code: parameter.code, Value: unit.convert(definition.parameters[parameter.code], target), Unit: target})
+	}
+	return
+}
+
+// newVerticalCRSFromGeoKeys returns the vertical CRS of the keys, or nil if they have none.
+func newVerticalCRSFromGeoKeys(info *CRSInfo) *WKTCRS {
+	code := info.getShort(VERTICAL_CS_TYPE_GEO_KEY)
+	if code == GEO_KEY_UNDEFINED {
+		return nil
+	}
+	var unit *WKTUnit
+	if units := info.getShort(VERTICAL_UNITS_GEO_KEY); units != GEO_KEY_UNDEFINED {
+		unit = newEPSGUnit(units, nil, WKT_UNIT_LENGTH)
+	} else {
+		unit = newEPSGUnit(geoTIFFDefaultVerticalUnitForMissingKey, nil, WKT_UNIT_LENGTH)
+	}
+	if code != GEO_KEY_USER_DEFINED {
+		crs := newEPSGVerticalCRS(int(code), unit)
+		if info.GetGeoKey(VERTICAL_UNITS_GEO_KEY) != nil {
+			crs.Unit = unit
+		}
+		return crs
+	}
+
+	crs := &WKTCRS{Type: WKT_CRS_VERTICAL, Name: info.getASCII(VERTICAL_CITATION_GEO_KEY), Datum: &WKTDatum{Name: "unknown"}, Unit: unit}
+	if crs.Name == "" {
+		crs.Name = "unnamed"
+	}
+	if datumCode := int(info.getShort(VERTICAL_DATUM_GEO_KEY)); datumCode != int(GEO_KEY_UNDEFINED) && datumCode != int(GEO_KEY_USER_DEFINED) {
+		crs.Datum = &WKTDatum{Name: fmt.Sprintf("EPSG:%d", datumCode), Authority: newEPSGAuthority(datumCode)}
+		for _, vertical := range epsgVerticalCRSs {
+			if vertical.datumCode == datumCode {
+				crs.Datum.Name = vertical.datum
+				break
+			}
+		}
+	}
+	return crs
+}
+
+// GetGeoKeys converts c to GeoKeys. Systems with an EPSG code are stored by their code, others by their definition;
+// vertical systems are stored alongside the horizontal one. Engineering systems and projection methods without a
+// GeoTIFF coordinate transformation cannot be converted.
+func (c *WKTCRS) GetGeoKeys() (keys []GeoKey, err error) {
+	horizontal, vertical := c.GetHorizontalCRS(), c.GetVerticalCRS()
+	if horizontal == nil && vertical == nil {
+		err = fmt.Errorf("%s CRS %q cannot be converted to GeoKeys", c.Type, c.Name)
+		return
+	}
+	if horizontal != nil {
+		switch horizontal.Type {
+		case WKT_CRS_PROJECTED:
+			keys, err = horizontal.getProjectedGeoKeys()
+		case WKT_CRS_GEOGRAPHIC:
+			keys = append(keys, newShortGeoKey(GT_MODEL_TYPE_GEO_KEY, MODEL_TYPE_GEOGRAPHIC), newASCIIGeoKey(GT_CITATION_GEO_KEY, horizontal.Name))
+			var geographicKeys []GeoKey
+			geographicKeys, err = horizontal.getGeographicGeoKeys()
+			keys = append(keys, geographicKeys...)
+		case WKT_CRS_GEOCENTRIC:
+			keys = append(keys, newShortGeoKey(GT_MODEL_TYPE_GEO_KEY, MODEL_TYPE_GEOCENTRIC), newASCIIGeoKey(GT_CITATION_GEO_KEY, horizontal.Name))
+			var geographicKeys []GeoKey
+			geographicKeys, err = horizontal.getGeographicGeoKeys()
+			keys = append(keys, geographicKeys...)
+			code, size := getGeoTIFFUnit(horizontal.getUnit(WKT_UNIT_LENGTH))
+			keys = append(keys, newShortGeoKey(GEOG_LINEAR_UNITS_GEO_KEY, code))
+			if code == GEO_KEY_USER_DEFINED {
+				keys = append(keys, newDoubleGeoKey(GEOG_LINEAR_UNIT_SIZE_GEO_KEY, size))
+			}
+		default:
+			err = fmt.Errorf("%s CRS %q cannot be converted to GeoKeys", horizontal.Type, horizontal.Name)
+		}
+		if err != nil {
+			keys = nil
+			return
+		}
+	}
+	if vertical != nil {
+		keys = append(keys, vertical.getVerticalGeoKeys()...)
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return
+}
+
+// getGeoTIFFCode returns the EPSG code of the authority if GeoTIFF can store it, or else GEO_KEY_USER_DEFINED.
+func getGeoTIFFCode(authority *WKTAuthority) uint16 {
+	if code := authority.getEPSG(); code > 0 && code <= geoTIFFMaximumEPSGCode {
+		return uint16(code)
+	}
+	return GEO_KEY_USER_DEFINED
+}
+
+// getGeoTIFFUnit returns the GeoTIFF code of the unit and, for user-defined units, its size.
+func getGeoTIFFUnit(unit *WKTUnit) (code uint16, size float64) {
+	code = getGeoTIFFCode(unit.Authority)
+	if code == 9122 {
+		// GeoTIFF names degrees by the older code
+		code = geoTIFFDegreeUnit
+	}
+	if epsgUnit, ok := epsgUnits[code]; ok && epsgUnit.unitType == unit.Type {
+		return
+	}
+	// the codes are tried in ascending order so that the metre and the degree win over the radian, the gon and 9122
+	codes := make([]int, 0, len(epsgUnits))
+	for epsgCode := range epsgUnits {
+		codes = append(codes, int(epsgCode))
+	}
+	sort.Ints(codes)
+	for _, epsgCode := range codes {
+		epsgUnit := epsgUnits[uint16(epsgCode)]
+		if epsgUnit.unitType == unit.Type && math.Abs(epsgUnit.size-unit.Factor) <= 1e-12*unit.Factor {
+			return uint16(epsgCode), 0
+		}
+	}
+	return GEO_KEY_USER_DEFINED, unit.Factor
+}
+
+// getGeographicGeoKeys returns the keys of the geographic CRS c: its code, or its datum, ellipsoid and units.
+func (c *WKTCRS) getGeographicGeoKeys() (keys []GeoKey, err error) {
+	angularUnit := c.getUnit(WKT_UNIT_ANGLE)
+	angularCode, angularSize := getGeoTIFFUnit(angularUnit)
+	keys = append(keys, newShortGeoKey(GEOG_ANGULAR_UNITS_GEO_KEY, angularCode))
+	if angularCode == GEO_KEY_USER_DEFINED {
+		keys = append(keys, newDoubleGeoKey(GEOG_ANGULAR_UNIT_SIZE_GEO_KEY, angularSize))
+	}
+	if c.Datum != nil && (len(c.Datum.ToWGS84) == 3 || len(c.Datum.ToWGS84) == 7) {
+		keys = append(keys, newDoubleGeoKey(GEOG_TOWGS84_GEO_KEY, c.Datum.ToWGS84...))
+	}
+	if code := getGeoTIFFCode(c.Authority); code != GEO_KEY_USER_DEFINED && c.Type == WKT_CRS_GEOGRAPHIC {
+		keys = append(keys, newShortGeoKey(GEOGRAPHIC_TYPE_GEO_KEY, code), newASCIIGeoKey(GEOG_CITATION_GEO_KEY, c.Name))
+		return
+	}
+
+	if c.Datum == nil || c.Datum.Ellipsoid == nil {
+		err = fmt.Errorf("geodetic CRS %q has no ellipsoid", c.Name)
+		return
+	}
+	ellipsoid := c.Datum.Ellipsoid
+	keys = append(keys,
+		newShortGeoKey(GEOGRAPHIC_TYPE_GEO_KEY, GEO_KEY_USER_DEFINED),
+		newASCIIGeoKey(GEOG_CITATION_GEO_KEY, c.Name),
+		newShortGeoKey(GEOG_GEODETIC_DATUM_GEO_KEY, getGeoTIFFCode(c.Datum.Authority)),
+		newShortGeoKey(GEOG_ELLIPSOID_GEO_KEY, getGeoTIFFCode(ellipsoid.Authority)),
+		newDoubleGeoKey(GEOG_SEMI_MAJOR_AXIS_GEO_KEY, ellipsoid.Unit.convert(ellipsoid.SemiMajorAxis, newMetreUnit())),
+		newDoubleGeoKey(GEOG_INV_FLATTENING_GEO_KEY, ellipsoid.InverseFlattening),
+		newShortGeoKey(GEOG_LINEAR_UNITS_GEO_KEY, geoTIFFMetreUnit))
+	if pm := c.PrimeMeridian; pm != nil && pm.Longitude != 0 {
+		keys = append(keys,
+			newShortGeoKey(GEOG_PRIME_MERIDIAN_GEO_KEY, getGeoTIFFCode(pm.Authority)),
+			newDoubleGeoKey(GEOG_PRIME_MERIDIAN_LONG_GEO_KEY, pm.Unit.convert(pm.Longitude, angularUnit)))
+	} else {
+		keys = append(keys, newShortGeoKey(GEOG_PRIME_MERIDIAN_GEO_KEY, geoTIFFGreenwichPrimeMeridian))
+	}
+	return
+}
+
+// getProjectedGeoKeys returns the keys of the projected CRS c: its code, or its projection and base CRS.
+func (c *WKTCRS) getProjectedGeoKeys() (keys []GeoKey, err error) {
+	linearUnit := c.getUnit(WKT_UNIT_LENGTH)
+	linearCode, linearSize := getGeoTIFFUnit(linearUnit)
+	keys = append(keys,
+		newShortGeoKey(GT_MODEL_TYPE_GEO_KEY, MODEL_TYPE_PROJECTED),
+		newASCIIGeoKey(GT_CITATION_GEO_KEY, c.Name),
+		newShortGeoKey(PROJ_LINEAR_UNITS_GEO_KEY, linearCode))
+	if linearCode == GEO_KEY_USER_DEFINED {
+		keys = append(keys, newDoubleGeoKey(PROJ_LINEAR_UNIT_SIZE_GEO_KEY, linearSize))
+	}
+	if c.BaseCRS == nil {
+		err = fmt.Errorf("projected CRS %q has no base CRS", c.Name)
+		return
+	}
+	geographicKeys, err := c.BaseCRS.getGeographicGeoKeys()
+	if err != nil {
+		return
+	}
+	keys = append(keys, geographicKeys...)
+	if code := getGeoTIFFCode(c.Authority); code != GEO_KEY_USER_DEFINED {
+		keys = append(keys, newShortGeoKey(PROJECTED_CS_TYPE_GEO_KEY, code), newASCIIGeoKey(PCS_CITATION_GEO_KEY, c.Name))
+		return
+	}
+
+	if c.Projection == nil {
+		err = fmt.Errorf("projected CRS %q has no projection", c.Name)
+		return
+	}
+	projection := getGeoTIFFProjectionOfMethod(c.Projection.MethodCode)
+	if projection == nil {
+		err = fmt.Errorf("projection method %q cannot be converted to GeoKeys", c.Projection.Method)
+		return
+	}
+	keys = append(keys,
+		newShortGeoKey(PROJECTED_CS_TYPE_GEO_KEY, GEO_KEY_USER_DEFINED),
+		newASCIIGeoKey(PCS_CITATION_GEO_KEY, c.Name),
+		newShortGeoKey(PROJECTION_GEO_KEY, GEO_KEY_USER_DEFINED),
+		newShortGeoKey(PROJ_COORD_TRANS_GEO_KEY, projection.coordTrans))
+	angularUnit := c.BaseCRS.getUnit(WKT_UNIT_ANGLE)
+	for _, parameter := range projection.parameters {
+		target := newUnityUnit()
+		switch getWKTParameterUnitType(parameter.code, "") {
+		case WKT_UNIT_ANGLE:
+			target = angularUnit
+		case WKT_UNIT_LENGTH:
+			target = linearUnit
+		}
+		value := 0.0
+		if wktParameter := c.Projection.GetParameter(parameter.code); wktParameter != nil {
+			value = wktParameter.Unit.convert(wktParameter.Value, target)
+		} else if target.Type == WKT_UNIT_SCALE {
+			value = 1
+		}
+		keys = append(keys, newDoubleGeoKey(parameter.keys[0], value))
+	}
+	return
+}
+
+// getVerticalGeoKeys returns the keys of the vertical CRS c.
+func (c *WKTCRS) getVerticalGeoKeys() (keys []GeoKey) {
+	unitCode, _ := getGeoTIFFUnit(c.getUnit(WKT_UNIT_LENGTH))
+	keys = append(keys,
+		newShortGeoKey(VERTICAL_CS_TYPE_GEO_KEY, getGeoTIFFCode(c.Authority)),
+		newASCIIGeoKey(VERTICAL_CITATION_GEO_KEY, c.Name),
+		newShortGeoKey(VERTICAL_UNITS_GEO_KEY, unitCode))
+	if c.Datum != nil {
+		keys = append(keys, newShortGeoKey(VERTICAL_DATUM_GEO_KEY, getGeoTIFFCode(c.Datum.Authority)))
+	}
+	return
+}
+
+//  _               _____
+// | |        /\   / ____|
+// | |       /  \ | (___
+// | |      / /\ \ \___ \
+// | |____ / ____ \____) |
+// |______/_/    \_\_____/
+//
+//
+
+// SetGeoKeys stores keys as the GeoKey records of l, replacing its GeoKey and coordinate system WKT records, and
+// clears the WKT bit of the global encoding. The point data record formats 6 to 10 of LAS 1.4 require WKT and cannot
+// hold GeoKeys.
+func (l *Las) SetGeoKeys(keys []GeoKey) (err error) {
+	if l.Header.GetVersion() == V1_4 && l.Header.PointDataRecordFormat&LASZIP_FORMAT_MASK >= 6 {
+		err = fmt.Errorf("point data record format %d requires a coordinate system WKT record instead of GeoKeys", l.Header.PointDataRecordFormat&LASZIP_FORMAT_MASK)
+		return
+	}
+	directory, doubles, ascii, err := EncodeGeoKeys(keys)
+	if err != nil {
+		return
+	}
+	var vlrs []VLR
+	for _, record := range []struct {
+		recordID    uint16
+		description string
+		payload     []byte
+	}{
+		{GEO_KEY_DIRECTORY_TAG, "GeoTiff GeoKeyDirectoryTag", directory},
+		{GEO_DOUBLE_PARAMS_TAG, "GeoTiff GeoDoubleParamsTag", doubles},
+		{GEO_ASCII_PARAMS_TAG, "GeoTiff GeoAsciiParamsTag", ascii},
+	} {
+		if record.payload == nil {
+			continue
+		}
+		var vlr VLR
+		if vlr, err = NewVLR(PROJECTION_USER_ID, record.recordID, record.description, record.payload); err != nil {
+			return
+		}
+		vlrs = append(vlrs, vlr)
+	}
+
+	l.removeCRSRecords()
+	for _, vlr := range vlrs {
+		l.AddVLR(vlr)
+	}
+	l.Header.GlobalEncoding &^= GLOBAL_ENCODING_WKT
+	l.updateCRSInfo()
+	return
+}
+
+// removeCRSRecords removes the GeoKey and coordinate system WKT VLRs and EVLRs of l.
+func (l *Las) removeCRSRecords() {
+	l.removeGeoKeyRecords()
+	l.RemoveVLRs(PROJECTION_USER_ID, COORDINATE_SYSTEM_WKT_RECORD_ID)
+	l.RemoveEVLRs(PROJECTION_USER_ID, COORDINATE_SYSTEM_WKT_RECORD_ID)
+}
+
+// removeGeoKeyRecords removes the GeoKey VLRs and EVLRs of l.
+func (l *Las) removeGeoKeyRecords() {
+	for _, recordID := range []uint16{GEO_KEY_DIRECTORY_TAG, GEO_DOUBLE_PARAMS_TAG, GEO_ASCII_PARAMS_TAG} {
+		l.RemoveVLRs(PROJECTION_USER_ID, recordID)
+		l.RemoveEVLRs(PROJECTION_USER_ID, recordID)
+	}
+}
+
+// ConvertGeoKeysToWKT replaces the GeoKey records of l by the equivalent coordinate system WKT record, e.g. before
+// upgrading a file to the point data record formats 6 to 10 of LAS 1.4. See NewWKTCRSFromGeoKeys and
+// SetCoordinateSystemWKT.
+func (l *Las) ConvertGeoKeysToWKT(version WKTVersion) (err error) {
+	keys, err := l.GetGeoKeys()
+	if err != nil {
+		return
+	}
+	crs, err := NewWKTCRSFromGeoKeys(keys)
+	if err != nil {
+		return
+	}
+	return l.SetCoordinateSystemWKT(crs, version)
+}
+
+// ConvertWKTToGeoKeys replaces the coordinate system WKT record of l by the equivalent GeoKey records, e.g. before
+// downgrading a file to LAS 1.2. See WKTCRS.GetGeoKeys and SetGeoKeys.
+func (l *Las) ConvertWKTToGeoKeys() (err error) {
+	crs, err := l.GetCoordinateSystemWKT()
+	if err != nil {
+		return
+	}
+	keys, err := crs.GetGeoKeys()
+	if err != nil {
+		return
+	}
+	return l.SetGeoKeys(keys)
+}
+
+// getCRSGeoKeys returns the GeoKeys of l, converted from its coordinate system WKT record if it has no GeoKeys.
+func (l *Las) getCRSGeoKeys() (keys []GeoKey, err error) {
+	if keys, err = l.GetGeoKeys(); !errors.Is(err, ErrNotFound) {
+		return
+	}
+	crs, err := l.GetCoordinateSystemWKT()
+	if err != nil {
+		return
+	}
+	return crs.GetGeoKeys()
+}
@@ -0,0 +1,107 @@
+package las
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestWKTGeoKeysRoundTrip(t *testing.T) {
+	tests := []struct {
+		horizontal int
+		vertical   int
+	}{
+		{4326, 0}, {4258, 0}, {32632, 0}, {25832, 0}, {3857, 0}, {27700, 0}, {2193, 0},
+		{32618, 5703}, {4326, 3855}, {27700, 5701}, {4269, 5702},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("EPSG:%d+%d", test.horizontal, test.vertical), func(t *testing.T) {
+			crs, err := NewWKTCRSFromEPSG(test.horizontal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.vertical != 0 {
+				var vertical *WKTCRS
+				if vertical, err = NewWKTCRSFromEPSG(test.vertical); err != nil {
+					t.Fatal(err)
+				}
+				crs = NewCompoundWKTCRS(crs, vertical)
+			}
+			keys, err := crs.GetGeoKeys()
+			if err != nil {
+				t.Fatal(err)
+			}
+			fromKeys, err := NewWKTCRSFromGeoKeys(keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			roundTrip, err := fromKeys.GetGeoKeys()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(roundTrip, keys) {
+				t.Errorf("GeoKeys %v differ after the round trip through WKT, want %v", roundTrip, keys)
+			}
+
+			for _, version := range []WKTVersion{WKT_VERSION_1, WKT_VERSION_2} {
+				parsed, err := ParseWKT(crs.WKT(version))
+				if err != nil {
+					t.Fatal(err)
+				}
+				fromWKT, err := parsed.GetGeoKeys()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(fromWKT, keys) {
+					t.Errorf("GeoKeys %v of the WKT version %d differ, want %v", fromWKT, version, keys)
+				}
+			}
+		})
+	}
+}
+
+func TestGeoTIFFUnit(t *testing.T) {
+	tests := []struct {
+		name string
+		unit WKTUnit
+		code uint16
+	}{
+		{"metre", WKTUnit{Type: WKT_UNIT_LENGTH, Name: "metre", Factor: 1}, 9001},
+		{"foot", WKTUnit{Type: WKT_UNIT_LENGTH, Name: "foot", Factor: 0.3048}, 9002},
+		{"degree", WKTUnit{Type: WKT_UNIT_ANGLE, Name: "degree", Factor: 0.0174532925199433}, 9102},
+		{"radian", WKTUnit{Type: WKT_UNIT_ANGLE, Name: "radian", Factor: 1}, 9101},
+		{"metre with an angle code", WKTUnit{Type: WKT_UNIT_LENGTH, Name: "metre", Factor: 1, Authority: &WKTAuthority{Name: "EPSG", Code: "9101"}}, 9001},
+		{"user-defined", WKTUnit{Type: WKT_UNIT_LENGTH, Name: "rod", Factor: 5.0292}, GEO_KEY_USER_DEFINED},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the units are looked up in a map, so the lookup is repeated to catch an unstable order
+			for i := 0; i < 20; i++ {
+				if code, _ := getGeoTIFFUnit(&test.unit); code != test.code {
+					t.Fatalf("got unit %d, want %d", code, test.code)
+				}
+			}
+		})
+	}
+}
+
+func TestGeoKeysOfWKTWithoutUnitAuthority(t *testing.T) {
+	wkt := `PROJCS["WGS 84 / UTM zone 32N",GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],` +
+		`PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433]],PROJECTION["Transverse_Mercator"],` +
+		`PARAMETER["latitude_of_origin",0],PARAMETER["central_meridian",9],PARAMETER["scale_factor",0.9996],` +
+		`PARAMETER["false_easting",500000],PARAMETER["false_northing",0],UNIT["metre",1]]`
+	crs, err := ParseWKT(wkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := crs.GetGeoKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	units := map[uint16]uint16{PROJ_LINEAR_UNITS_GEO_KEY: 9001, GEOG_ANGULAR_UNITS_GEO_KEY: 9102}
+	for _, key := range keys {
+		if want, ok := units[key.ID]; ok && key.GetShort() != want {
+			t.Errorf("%s is %d, want %d", key.Name, key.GetShort(), want)
+		}
+	}
+}
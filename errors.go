@@ -0,0 +1,76 @@
+package las
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+//  ______
+// |  ____|
+// | |__   _ __ _ __ ___  _ __ ___
+// |  __| | '__| '__/ _ \| '__/ __|
+// | |____| |  | | | (_) | |  \__ \
+// |______|_|  |_|  \___/|_|  |___/
+//
+//
+
+// The errors of parsing LAS files, to be tested with errors.Is. They are usually wrapped in a *FormatError locating
+// the invalid field.
+var (
+	// ErrNotLas is returned for files not starting with the LAS file signature.
+	ErrNotLas = errors.New("file signature is not " + LAS_FILE_SIGNATURE)
+	// ErrUnsupportedVersion is returned for LAS versions other than those of AllLasVersions.
+	ErrUnsupportedVersion = errors.New("las version is not supported")
+	// ErrCompressed is returned for LAZ files whose point data records cannot be decompressed, e.g. because the
+	// LASzip VLR is missing or describes an unsupported compressor. The header and VLRs have been read.
+	ErrCompressed = errors.New("point data records are compressed and cannot be decompressed")
+	// ErrUnknownPointFormat is returned for point data record formats other than 0 to 10.
+	ErrUnknownPointFormat = errors.New("point data record format not recognised")
+	// ErrTruncated is returned if a record extends past the end of the file.
+	ErrTruncated = errors.New("las file is truncated")
+	// ErrNotFound is returned by the lookups of VLRs and EVLRs if no record matches.
+	ErrNotFound = errors.New("record not found")
+)
+
+// FormatError reports a field of a LAS file which cannot be read or holds an invalid value. Offset is the position of
+// the field in the file, and Field names it, e.g. "HeaderSize" for the fields of the public header block or
+// "VLR payload". Err is the cause, e.g. ErrUnsupportedVersion or the error of the underlying reader.
+type FormatError struct {
+	Offset int64
+	Field  string
+	Err    error
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("las: %s at offset %d: %v", e.Field, e.Offset, e.Err)
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports reads past the end of the file as ErrTruncated, while errors.Is still matches the io.EOF or
+// io.ErrUnexpectedEOF of the reader.
+func (e *FormatError) Is(target error) bool {
+	return target == ErrTruncated && (errors.Is(e.Err, io.EOF) || errors.Is(e.Err, io.ErrUnexpectedEOF))
+}
+
+// getHeaderFieldOffset returns the offset of the field of the public header block in the file.
+func getHeaderFieldOffset(field string) (offset int64) {
+	headerType := reflect.TypeOf(PublicHeaderBlock{})
+	for i := 0; i < headerType.NumField(); i++ {
+		if headerType.Field(i).Name == field {
+			return
+		}
+		offset += int64(binary.Size(reflect.Zero(headerType.Field(i).Type).Interface()))
+	}
+	return -1
+}
+
+// newHeaderError returns a *FormatError for the field of the public header block.
+func newHeaderError(field string, err error) error {
+	return &FormatError{Offset: getHeaderFieldOffset(field), Field: field, Err: err}
+}
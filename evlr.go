@@ -3,7 +3,8 @@ package las
 import (
 	"bytes"
 	"encoding/binary"
-	"os"
+	"fmt"
+	"io"
 )
 
 //  ______ __      __ _       _____
@@ -23,26 +24,113 @@ type EVLRHeader struct {
 	Description             [32]byte
 }
 
+func (eh *EVLRHeader) getUserID() (userID string, err error) {
+	return getUserID(eh.UserID)
+}
+
 type EVLR struct {
-	header EVLRHeader
-	record []byte
+	header  EVLRHeader
+	record  []CRS
+	payload []byte
 }
 
-func (v *EVLR) read(file *os.File, offsetIn int64) (offsetOut int64, err error) {
+// read reads the EVLR at offsetIn of a file of size bytes, which is negative for streams.
+func (v *EVLR) read(reader io.ReaderAt, offsetIn int64, size int64) (offsetOut int64, err error) {
 	headerInBytes := make([]byte, binary.Size(EVLRHeader{}))
-	_, err = file.ReadAt(headerInBytes, offsetIn)
+	err = readAt(reader, headerInBytes, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "EVLR header", Err: err}
 		return
 	}
 	if err = binary.Read(bytes.NewReader(headerInBytes), binary.LittleEndian, &v.header); err != nil {
 		return
 	}
-	v.record = make([]byte, v.header.RecordLengthAfterHeader)
 	offsetToRecord := offsetIn + int64(binary.Size(EVLRHeader{}))
-	_, err = file.ReadAt(v.record, offsetToRecord)
-	if err != nil {
+	if v.payload, err = readLength(reader, offsetToRecord, v.header.RecordLengthAfterHeader, size); err != nil {
+		err = &FormatError{Offset: offsetToRecord, Field: "EVLR payload", Err: err}
 		return
 	}
 	offsetOut = offsetToRecord + int64(v.header.RecordLengthAfterHeader)
 	return
 }
+
+func (v *EVLR) size() (size uint64) {
+	size = uint64(binary.Size(EVLRHeader{}) + len(v.payload))
+	return
+}
+
+func (v *EVLR) write(w io.Writer) (err error) {
+	v.header.RecordLengthAfterHeader = uint64(len(v.payload))
+	if err = binary.Write(w, binary.LittleEndian, &v.header); err != nil {
+		return
+	}
+	if _, err = w.Write(v.payload); err != nil {
+		return
+	}
+	return
+}
+
+// NewEVLR returns an EVLR holding payload. Its record is decoded from payload like the record of a parsed EVLR with
+// the same user ID and record ID.
+func NewEVLR(userID string, recordID uint16, description string, payload []byte) (v EVLR, err error) {
+	if len(userID) > len(v.header.UserID) {
+		err = fmt.Errorf("user ID %q is longer than %d bytes", userID, len(v.header.UserID))
+		return
+	}
+	if len(description) > len(v.header.Description) {
+		err = fmt.Errorf("description %q is longer than %d bytes", description, len(v.header.Description))
+		return
+	}
+	copy(v.header.UserID[:], userID)
+	v.header.RecordID = recordID
+	v.header.RecordLengthAfterHeader = uint64(len(payload))
+	copy(v.header.Description[:], description)
+	v.payload = payload
+	err = v.decode(0)
+	return
+}
+
+// decode decodes the record of v from its payload, which starts at offset of the file v was read from, or at 0 if v
+// is not read from a file. A record which cannot be decoded is not kept, so that Record returns nil and only the
+// payload is available.
+func (v *EVLR) decode(offset int64) (err error) {
+	v.record = nil
+	userID, _ := v.header.getUserID()
+	crs := newVLRRecord(userID, v.header.RecordID)
+	if crs == nil {
+		return
+	}
+	if err = crs.read(v.payload, offset); err != nil {
+		err = fmt.Errorf("EVLR with user ID %s and record ID %d cannot be decoded: %w", userID, v.header.RecordID, err)
+		return
+	}
+	v.record = append(v.record, crs)
+	return
+}
+
+// UserID returns the user ID identifying the organisation which defined the record.
+func (v *EVLR) UserID() string {
+	userID, _ := v.header.getUserID()
+	return userID
+}
+
+// RecordID returns the ID of the record within its user ID.
+func (v *EVLR) RecordID() uint16 {
+	return v.header.RecordID
+}
+
+// Description returns the textual description of the record.
+func (v *EVLR) Description() string {
+	return getDescription(v.header.Description)
+}
+
+// Payload returns the raw bytes following the EVLR header.
+func (v *EVLR) Payload() []byte {
+	return v.payload
+}
+
+// Record returns the decoded record, e.g. a *CoordinateSystemWKT or the VLRDecoder registered for the user ID and
+// record ID, or nil if the record is not known or cannot be decoded.
+func (v *EVLR) Record() interface{} {
+	return getRecord(v.record)
+}
@@ -0,0 +1,725 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+//  ______      _               ____        _               _____      _
+// |  ____|    | |             |  _ \      | |             / ____|    | |
+// | |__  __  _| |_ _ __ __ _  | |_) |_   _| |_ ___  ___  | (___   ___| |__   ___ _ __ ___   __ _
+// |  __| \ \/ / __| '__/ _` | |  _ <| | | | __/ _ \/ __|  \___ \ / __| '_ \ / _ \ '_ ` _ \ / _` |
+// | |____ >  <| |_| | | (_| | | |_) | |_| | ||  __/\__ \  ____) | (__| | | |  __/ | | | | | (_| |
+// |______/_/\_\\__|_|  \__,_| |____/ \__, |\__\___||___/ |_____/ \___|_| |_|\___|_| |_| |_|\__,_|
+//                                     __/ |
+//                                    |___/
+
+// The data types of ExtraBytesDescriptor. The types 11 to 20 and 21 to 30 are arrays of two and three elements of the
+// types 1 to 10, deprecated since LAS 1.4 R14.
+const (
+	EXTRA_BYTES_TYPE_UNDOCUMENTED uint8 = 0
+	EXTRA_BYTES_TYPE_UCHAR        uint8 = 1
+	EXTRA_BYTES_TYPE_CHAR         uint8 = 2
+	EXTRA_BYTES_TYPE_USHORT       uint8 = 3
+	EXTRA_BYTES_TYPE_SHORT        uint8 = 4
+	EXTRA_BYTES_TYPE_ULONG        uint8 = 5
+	EXTRA_BYTES_TYPE_LONG         uint8 = 6
+	EXTRA_BYTES_TYPE_ULONGLONG    uint8 = 7
+	EXTRA_BYTES_TYPE_LONGLONG     uint8 = 8
+	EXTRA_BYTES_TYPE_FLOAT        uint8 = 9
+	EXTRA_BYTES_TYPE_DOUBLE       uint8 = 10
+)
+
+// The bits of ExtraBytesDescriptor.Options. For the undocumented data type 0, Options holds the number of bytes instead.
+const (
+	EXTRA_BYTES_OPTION_NO_DATA uint8 = 0x01
+	EXTRA_BYTES_OPTION_MIN     uint8 = 0x02
+	EXTRA_BYTES_OPTION_MAX     uint8 = 0x04
+	EXTRA_BYTES_OPTION_SCALE   uint8 = 0x08
+	EXTRA_BYTES_OPTION_OFFSET  uint8 = 0x10
+)
+
+// GetName returns the name of the attribute.
+func (d *ExtraBytesDescriptor) GetName() string {
+	return string(bytes.TrimRight(d.Name[:], "\x00"))
+}
+
+// GetDescription returns the description of the attribute.
+func (d *ExtraBytesDescriptor) GetDescription() string {
+	return string(bytes.TrimRight(d.Description[:], "\x00"))
+}
+
+// GetBaseType returns the data type of the elements of the attribute, i.e. the data type with the array types 11 to
+// 30 mapped to the types 1 to 10.
+func (d *ExtraBytesDescriptor) GetBaseType() (baseType uint8) {
+	baseType = d.DataType
+	for baseType > EXTRA_BYTES_TYPE_DOUBLE {
+		baseType -= 10
+	}
+	return
+}
+
+// GetNumberOfElements returns the number of values of the attribute per point: 1, or 2 and 3 for the array types.
+func (d *ExtraBytesDescriptor) GetNumberOfElements() int {
+	return int(d.DataType+9) / 10
+}
+
+// GetSize returns the number of extra bytes the attribute takes per point.
+func (d *ExtraBytesDescriptor) GetSize() (size int, err error) {
+	if d.DataType == EXTRA_BYTES_TYPE_UNDOCUMENTED {
+		size = int(d.Options)
+		return
+	}
+	if d.DataType > 30 {
+		err = fmt.Errorf("extra bytes data type %d of %q is not defined", d.DataType, d.GetName())
+		return
+	}
+	size = getExtraBytesTypeSize(d.GetBaseType()) * d.GetNumberOfElements()
+	return
+}
+
+func getExtraBytesTypeSize(baseType uint8) int {
+	switch baseType {
+	case EXTRA_BYTES_TYPE_UCHAR, EXTRA_BYTES_TYPE_CHAR:
+		return 1
+	case EXTRA_BYTES_TYPE_USHORT, EXTRA_BYTES_TYPE_SHORT:
+		return 2
+	case EXTRA_BYTES_TYPE_ULONG, EXTRA_BYTES_TYPE_LONG, EXTRA_BYTES_TYPE_FLOAT:
+		return 4
+	}
+	return 8
+}
+
+// HasOption reports whether the option bit of the attribute is set, e.g. EXTRA_BYTES_OPTION_NO_DATA.
+func (d *ExtraBytesDescriptor) HasOption(option uint8) bool {
+	return d.DataType != EXTRA_BYTES_TYPE_UNDOCUMENTED && d.Options&option != 0
+}
+
+// GetScale returns the scale factor of the element, 1 if the scale option is not set.
+func (d *ExtraBytesDescriptor) GetScale(element int) float64 {
+	if !d.HasOption(EXTRA_BYTES_OPTION_SCALE) {
+		return 1
+	}
+	return d.Scale[element]
+}
+
+// GetOffset returns the offset of the element, 0 if the offset option is not set.
+func (d *ExtraBytesDescriptor) GetOffset(element int) float64 {
+	if !d.HasOption(EXTRA_BYTES_OPTION_OFFSET) {
+		return 0
+	}
+	return d.Offset[element]
+}
+
+// GetNoData returns the no-data value of the element with scale and offset applied. ok is false if the no-data option
+// is not set.
+func (d *ExtraBytesDescriptor) GetNoData(element int) (value float64, ok bool) {
+	return d.getOptionValue(EXTRA_BYTES_OPTION_NO_DATA, d.NoData[element], element)
+}
+
+// GetMin returns the minimum of the element with scale and offset applied. ok is false if the min option is not set.
+func (d *ExtraBytesDescriptor) GetMin(element int) (value float64, ok bool) {
+	return d.getOptionValue(EXTRA_BYTES_OPTION_MIN, d.Min[element], element)
+}
+
+// GetMax returns the maximum of the element with scale and offset applied. ok is false if the max option is not set.
+func (d *ExtraBytesDescriptor) GetMax(element int) (value float64, ok bool) {
+	return d.getOptionValue(EXTRA_BYTES_OPTION_MAX, d.Max[element], element)
+}
+
+func (d *ExtraBytesDescriptor) getOptionValue(option uint8, raw [8]byte, element int) (value float64, ok bool) {
+	if ok = d.HasOption(option); !ok {
+		return
+	}
+	value = d.newExtraBytesValue(raw).float()*d.GetScale(element) + d.GetOffset(element)
+	return
+}
+
+// extraBytesValue holds a raw value of an attribute in its signedness, so that 64 bit integers compare exactly.
+type extraBytesValue struct {
+	kind     uint8
+	unsigned uint64
+	signed   int64
+	floating float64
+}
+
+const (
+	extraBytesUnsigned uint8 = iota
+	extraBytesSigned
+	extraBytesFloating
+)
+
+func getExtraBytesKind(baseType uint8) uint8 {
+	switch baseType {
+	case EXTRA_BYTES_TYPE_UCHAR, EXTRA_BYTES_TYPE_USHORT, EXTRA_BYTES_TYPE_ULONG, EXTRA_BYTES_TYPE_ULONGLONG:
+		return extraBytesUnsigned
+	case EXTRA_BYTES_TYPE_CHAR, EXTRA_BYTES_TYPE_SHORT, EXTRA_BYTES_TYPE_LONG, EXTRA_BYTES_TYPE_LONGLONG:
+		return extraBytesSigned
+	}
+	return extraBytesFloating
+}
+
+// newExtraBytesValue decodes the 8 byte no-data, min or max field of the descriptor. The double stored for a float
+// attribute is rounded to float, the precision of the elements it is compared with.
+func (d *ExtraBytesDescriptor) newExtraBytesValue(raw [8]byte) (value extraBytesValue) {
+	bits := binary.LittleEndian.Uint64(raw[:])
+	switch value.kind = getExtraBytesKind(d.GetBaseType()); value.kind {
+	case extraBytesUnsigned:
+		value.unsigned = bits
+	case extraBytesSigned:
+		value.signed = int64(bits)
+	default:
+		value.floating = math.Float64frombits(bits)
+		if d.GetBaseType() == EXTRA_BYTES_TYPE_FLOAT {
+			value.floating = float64(float32(value.floating))
+		}
+	}
+	return
+}
+
+// decodeElement decodes an element of the attribute stored in data.
+func (d *ExtraBytesDescriptor) decodeElement(data []byte) (value extraBytesValue) {
+	switch d.GetBaseType() {
+	case EXTRA_BYTES_TYPE_UCHAR:
+		value = extraBytesValue{kind: extraBytesUnsigned, unsigned: uint64(data[0])}
+	case EXTRA_BYTES_TYPE_CHAR:
+		value = extraBytesValue{kind: extraBytesSigned, signed: int64(int8(data[0]))}
+	case EXTRA_BYTES_TYPE_USHORT:
+		value = extraBytesValue{kind: extraBytesUnsigned, unsigned: uint64(binary.LittleEndian.Uint16(data))}
+	case EXTRA_BYTES_TYPE_SHORT:
+		value = extraBytesValue{kind: extraBytesSigned, signed: int64(int16(binary.LittleEndian.Uint16(data)))}
+	case EXTRA_BYTES_TYPE_ULONG:
+		value = extraBytesValue{kind: extraBytesUnsigned, unsigned: uint64(binary.LittleEndian.Uint32(data))}
+	case EXTRA_BYTES_TYPE_LONG:
+		value = extraBytesValue{kind: extraBytesSigned, signed: int64(int32(binary.LittleEndian.Uint32(data)))}
+	case EXTRA_BYTES_TYPE_ULONGLONG:
+		value = extraBytesValue{kind: extraBytesUnsigned, unsigned: binary.LittleEndian.Uint64(data)}
+	case EXTRA_BYTES_TYPE_LONGLONG:
+		value = extraBytesValue{kind: extraBytesSigned, signed: int64(binary.LittleEndian.Uint64(data))}
+	case EXTRA_BYTES_TYPE_FLOAT:
+		value = extraBytesValue{kind: extraBytesFloating, floating: float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))}
+	case EXTRA_BYTES_TYPE_DOUBLE:
+		value = extraBytesValue{kind: extraBytesFloating, floating: math.Float64frombits(binary.LittleEndian.Uint64(data))}
+	}
+	return
+}
+
+func (v extraBytesValue) float() float64 {
+	switch v.kind {
+	case extraBytesUnsigned:
+		return float64(v.unsigned)
+	case extraBytesSigned:
+		return float64(v.signed)
+	}
+	return v.floating
+}
+
+// equals reports whether v equals w, which is of the same kind. NaN equals NaN, so that it can be a no-data value.
+func (v extraBytesValue) equals(w extraBytesValue) bool {
+	if v.kind == extraBytesFloating {
+		return v.floating == w.floating || math.IsNaN(v.floating) && math.IsNaN(w.floating)
+	}
+	return v.unsigned == w.unsigned && v.signed == w.signed
+}
+
+// ExtraBytesSchema locates the extra bytes attributes described by the LASF_Spec record 4 VLR or EVLR within the extra
+// bytes of the point data records.
+//
+//	schema, err := l.GetExtraBytesSchema()
+//	if err != nil {
+//		return err
+//	}
+//	amplitude, valid, err := schema.Extra(l.Pdrs.At(0), "Amplitude")
+//	point := schema.Bind(l.Pdrs.At(0))
+//	amplitude, valid, err = point.Extra("Amplitude")
+type ExtraBytesSchema struct {
+	Attributes []ExtraBytesAttribute
+}
+
+// ExtraBytesAttribute is an extra bytes attribute and the position of its bytes within the extra bytes of a point.
+type ExtraBytesAttribute struct {
+	ExtraBytesDescriptor
+	Start int
+	Size  int
+}
+
+// NewExtraBytesSchema lays out the attributes described by descriptors one after another.
+func NewExtraBytesSchema(descriptors []ExtraBytesDescriptor) (schema *ExtraBytesSchema, err error) {
+	schema = &ExtraBytesSchema{Attributes: make([]ExtraBytesAttribute, len(descriptors))}
+	start := 0
+	for index, descriptor := range descriptors {
+		var size int
+		if size, err = descriptor.GetSize(); err != nil {
+			return
+		}
+		schema.Attributes[index] = ExtraBytesAttribute{ExtraBytesDescriptor: descriptor, Start: start, Size: size}
+		start += size
+	}
+	return
+}
+
+// newExtraBytesSchemaFromRecords builds the schema of the ExtraBytes records of the VLRs or EVLRs of a file. A file
+// without such records has an empty schema.
+func newExtraBytesSchemaFromRecords(records []CRS) (schema *ExtraBytesSchema, err error) {
+	var descriptors []ExtraBytesDescriptor
+	found := false
+	for _, record := range records {
+		if extraBytes, ok := record.(*ExtraBytes); ok {
+			if found {
+				err = fmt.Errorf("file holds more than one extra bytes record")
+				return
+			}
+			descriptors = *extraBytes
+			found = true
+		}
+	}
+	return NewExtraBytesSchema(descriptors)
+}
+
+// GetExtraBytesSchema returns the schema of the extra bytes attributes described by the VLRs or EVLRs of l.
+func (l *Las) GetExtraBytesSchema() (schema *ExtraBytesSchema, err error) {
+	return newExtraBytesSchemaFromRecords(append(l.getVLRRecords(), l.getEVLRRecords()...))
+}
+
+// GetExtraBytesSchema returns the schema of the extra bytes attributes described by the VLRs or EVLRs of the file.
+func (r *PointReader) GetExtraBytesSchema() (schema *ExtraBytesSchema, err error) {
+	l := Las{Vlrs: r.Vlrs, Evlrs: r.Evlrs}
+	return l.GetExtraBytesSchema()
+}
+
+// Size returns the number of extra bytes described by the schema.
+func (s *ExtraBytesSchema) Size() (size int) {
+	if len(s.Attributes) == 0 {
+		return
+	}
+	last := s.Attributes[len(s.Attributes)-1]
+	size = last.Start + last.Size
+	return
+}
+
+// Get returns the attribute called name.
+func (s *ExtraBytesSchema) Get(name string) (attribute *ExtraBytesAttribute, err error) {
+	for index := range s.Attributes {
+		if s.Attributes[index].GetName() == name {
+			attribute = &s.Attributes[index]
+			return
+		}
+	}
+	err = fmt.Errorf("%w: no extra bytes attribute %q", ErrNotFound, name)
+	return
+}
+
+// Extra returns the value of the attribute called name of point. See ExtraBytesAttribute.Value.
+func (s *ExtraBytesSchema) Extra(point Point, name string) (value float64, valid bool, err error) {
+	attribute, err := s.Get(name)
+	if err != nil {
+		return
+	}
+	value, valid = attribute.Value(point)
+	return
+}
+
+// ExtraPoint is a point bound to the schema of its file, so that it reads its extra bytes attributes by name.
+type ExtraPoint struct {
+	Point
+	Schema *ExtraBytesSchema
+}
+
+// Bind returns point bound to the schema, e.g. to read an attribute with schema.Bind(point).Extra("Amplitude").
+func (s *ExtraBytesSchema) Bind(point Point) ExtraPoint {
+	return ExtraPoint{Point: point, Schema: s}
+}
+
+// Extra returns the value of the attribute called name of the point. See ExtraBytesSchema.Extra.
+func (p ExtraPoint) Extra(name string) (value float64, valid bool, err error) {
+	return p.Schema.Extra(p.Point, name)
+}
+
+// Value returns the first element of the attribute of point. See Element.
+func (a *ExtraBytesAttribute) Value(point Point) (value float64, valid bool) {
+	return a.Element(point, 0)
+}
+
+// Element returns the element of the attribute of point with scale and offset applied. Values above 2^53 lose
+// precision. valid is false if the raw value equals the no-data value, if the point holds too few extra bytes, and for
+// undocumented extra bytes; value is NaN then. The min and max of the attribute describe the values and do not make
+// them invalid, see GetMin and GetMax.
+func (a *ExtraBytesAttribute) Element(point Point, element int) (value float64, valid bool) {
+	return a.decode(point.GetExtraBytes(), element)
+}
+
+// Column returns the element of the attribute of all pdrs. See Element.
+func (a *ExtraBytesAttribute) Column(pdrs PDRs, element int) (values []float64, valid []bool) {
+	values = make([]float64, pdrs.Len())
+	valid = make([]bool, pdrs.Len())
+	for index := range values {
+		values[index], valid[index] = a.decode(pdrs.At(index).GetExtraBytes(), element)
+	}
+	return
+}
+
+func (a *ExtraBytesAttribute) decode(extraBytes []byte, element int) (value float64, valid bool) {
+	value = math.NaN()
+	if a.DataType == EXTRA_BYTES_TYPE_UNDOCUMENTED || element < 0 || element >= a.GetNumberOfElements() {
+		return
+	}
+	elementSize := getExtraBytesTypeSize(a.GetBaseType())
+	start := a.Start + element*elementSize
+	if start+elementSize > len(extraBytes) {
+		return
+	}
+	raw := a.decodeElement(extraBytes[start : start+elementSize])
+	if a.HasOption(EXTRA_BYTES_OPTION_NO_DATA) && raw.equals(a.newExtraBytesValue(a.NoData[element])) {
+		return
+	}
+	value = raw.float()*a.GetScale(element) + a.GetOffset(element)
+	valid = true
+	return
+}
+
+// GetExtraColumn returns the first element of the extra bytes attribute called name of all point data records of l.
+// See ExtraBytesAttribute.Element.
+func (l *Las) GetExtraColumn(name string) (values []float64, valid []bool, err error) {
+	if l.Pdrs == nil {
+		err = fmt.Errorf("las file holds no point data records")
+		return
+	}
+	schema, err := l.GetExtraBytesSchema()
+	if err != nil {
+		return
+	}
+	attribute, err := schema.Get(name)
+	if err != nil {
+		return
+	}
+	values, valid = attribute.Column(l.Pdrs, 0)
+	return
+}
+
+// widen returns v as stored in the 8 byte no-data, min and max fields of a descriptor.
+func (v extraBytesValue) widen() (raw [8]byte) {
+	if v.kind == extraBytesFloating {
+		binary.LittleEndian.PutUint64(raw[:], math.Float64bits(v.floating))
+	} else {
+		binary.LittleEndian.PutUint64(raw[:], v.unsigned|uint64(v.signed))
+	}
+	return
+}
+
+// put stores v as an element of baseType in data.
+func (v extraBytesValue) put(data []byte, baseType uint8) {
+	bits := v.unsigned | uint64(v.signed)
+	switch baseType {
+	case EXTRA_BYTES_TYPE_UCHAR, EXTRA_BYTES_TYPE_CHAR:
+		data[0] = uint8(bits)
+	case EXTRA_BYTES_TYPE_USHORT, EXTRA_BYTES_TYPE_SHORT:
+		binary.LittleEndian.PutUint16(data, uint16(bits))
+	case EXTRA_BYTES_TYPE_ULONG, EXTRA_BYTES_TYPE_LONG:
+		binary.LittleEndian.PutUint32(data, uint32(bits))
+	case EXTRA_BYTES_TYPE_ULONGLONG, EXTRA_BYTES_TYPE_LONGLONG:
+		binary.LittleEndian.PutUint64(data, bits)
+	case EXTRA_BYTES_TYPE_FLOAT:
+		binary.LittleEndian.PutUint32(data, math.Float32bits(float32(v.floating)))
+	case EXTRA_BYTES_TYPE_DOUBLE:
+		binary.LittleEndian.PutUint64(data, math.Float64bits(v.floating))
+	}
+}
+
+// encodeElement returns the raw value storing value in the element with the scale and offset of the descriptor. It
+// fails if the value cannot be stored in the data type.
+func (d *ExtraBytesDescriptor) encodeElement(value float64, element int) (raw extraBytesValue, err error) {
+	baseType := d.GetBaseType()
+	scaled := (value - d.GetOffset(element)) / d.GetScale(element)
+	raw.kind = getExtraBytesKind(baseType)
+	if raw.kind == extraBytesFloating {
+		raw.floating = scaled
+		if baseType == EXTRA_BYTES_TYPE_FLOAT {
+			raw.floating = float64(float32(scaled))
+		}
+		return
+	}
+	rounded := math.Round(scaled)
+	bits := 8 * getExtraBytesTypeSize(baseType)
+	if raw.kind == extraBytesUnsigned {
+		if !(rounded >= 0 && rounded < math.Ldexp(1, bits)) {
+			err = fmt.Errorf("value %v cannot be stored in extra bytes attribute %q of data type %d", value, d.GetName(), d.DataType)
+			return
+		}
+		raw.unsigned = uint64(rounded)
+	} else {
+		if limit := math.Ldexp(1, bits-1); !(rounded >= -limit && rounded < limit) {
+			err = fmt.Errorf("value %v cannot be stored in extra bytes attribute %q of data type %d", value, d.GetName(), d.DataType)
+			return
+		}
+		raw.signed = int64(rounded)
+	}
+	return
+}
+
+//           _     _ _               ______      _               ____        _
+//     /\   | |   | (_)             |  ____|    | |             |  _ \      | |
+//    /  \  | | __| |_ _ __   __ _  | |__  __  _| |_ _ __ __ _  | |_) |_   _| |_ ___  ___
+//   / /\ \ | |/ _` | | '_ \ / _` | |  __| \ \/ / __| '__/ _` | |  _ <| | | | __/ _ \/ __|
+//  / ____ \| | (_| | | | | | (_| | | |____ >  <| |_| | | (_| | | |_) | |_| | ||  __/\__ \
+// /_/    \_\_|\__,_|_|_| |_|\__, | |______/_/\_\\__|_|  \__,_| |____/ \__, |\__\___||___/
+//                            __/ |                                     __/ |
+//                           |___/                                     |___/
+
+// NewExtraBytesDescriptor returns the descriptor of an attribute called name of the data type, one of 1 to 10, or 11
+// to 30 for the deprecated array types. Scale, offset and no-data value are set with SetScaleAndOffset and SetNoData.
+func NewExtraBytesDescriptor(name string, dataType uint8, description string) (d ExtraBytesDescriptor, err error) {
+	if name == "" || len(name) > len(d.Name) {
+		err = fmt.Errorf("extra bytes name %q must have 1 to %d bytes", name, len(d.Name))
+		return
+	}
+	if len(description) > len(d.Description) {
+		err = fmt.Errorf("description %q is longer than %d bytes", description, len(d.Description))
+		return
+	}
+	if dataType == EXTRA_BYTES_TYPE_UNDOCUMENTED || dataType > 30 {
+		err = fmt.Errorf("extra bytes data type %d is not supported", dataType)
+		return
+	}
+	copy(d.Name[:], name)
+	copy(d.Description[:], description)
+	d.DataType = dataType
+	return
+}
+
+// SetScaleAndOffset sets the scale factor and offset of all elements of the attribute, such that a value is stored as
+// (value - offset) / scale. Set them before SetNoData, which stores the no-data value with them.
+func (d *ExtraBytesDescriptor) SetScaleAndOffset(scale float64, offset float64) (err error) {
+	if scale == 0 || math.IsNaN(scale) || math.IsInf(scale, 0) {
+		err = fmt.Errorf("scale factor %v of extra bytes attribute %q is not valid", scale, d.GetName())
+		return
+	}
+	for element := 0; element < d.GetNumberOfElements(); element++ {
+		d.Scale[element] = scale
+		d.Offset[element] = offset
+	}
+	d.Options |= EXTRA_BYTES_OPTION_SCALE | EXTRA_BYTES_OPTION_OFFSET
+	return
+}
+
+// SetNoData sets the no-data value of all elements of the attribute. The value has scale and offset applied.
+func (d *ExtraBytesDescriptor) SetNoData(value float64) (err error) {
+	for element := 0; element < d.GetNumberOfElements(); element++ {
+		var raw extraBytesValue
+		if raw, err = d.encodeElement(value, element); err != nil {
+			return
+		}
+		d.NoData[element] = raw.widen()
+	}
+	d.Options |= EXTRA_BYTES_OPTION_NO_DATA
+	return
+}
+
+// SetValue sets the first element of the attribute of point. See SetElement.
+func (a *ExtraBytesAttribute) SetValue(point Point, value float64) (err error) {
+	return a.SetElement(point, 0, value)
+}
+
+// SetElement sets the element of the attribute of point to value, which has scale and offset applied. NaN stores the
+// no-data value of the attribute. It fails if the value cannot be stored in the data type of the attribute.
+func (a *ExtraBytesAttribute) SetElement(point Point, element int, value float64) (err error) {
+	if a.DataType == EXTRA_BYTES_TYPE_UNDOCUMENTED || element < 0 || element >= a.GetNumberOfElements() {
+		err = fmt.Errorf("extra bytes attribute %q has no element %d", a.GetName(), element)
+		return
+	}
+	elementSize := getExtraBytesTypeSize(a.GetBaseType())
+	start := a.Start + element*elementSize
+	extraBytes := point.GetExtraBytes()
+	if start+elementSize > len(extraBytes) {
+		err = fmt.Errorf("point holds %d extra bytes, too few for extra bytes attribute %q", len(extraBytes), a.GetName())
+		return
+	}
+	var raw extraBytesValue
+	if math.IsNaN(value) && a.HasOption(EXTRA_BYTES_OPTION_NO_DATA) {
+		raw = a.newExtraBytesValue(a.NoData[element])
+	} else if raw, err = a.encodeElement(value, element); err != nil {
+		return
+	}
+	raw.put(extraBytes[start:start+elementSize], a.GetBaseType())
+	return
+}
+
+// SetColumn sets the element of the attribute of all pdrs to values. See SetElement.
+func (a *ExtraBytesAttribute) SetColumn(pdrs PDRs, element int, values []float64) (err error) {
+	if len(values) != pdrs.Len() {
+		err = fmt.Errorf("%d values given for %d point data records", len(values), pdrs.Len())
+		return
+	}
+	for index, value := range values {
+		if err = a.SetElement(pdrs.At(index), element, value); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// AddExtraBytes appends the attribute described by descriptor to the extra bytes of all point data records of l, set
+// to its no-data value or zero, and returns it. The descriptors of the LASF_Spec record 4 VLR or EVLR and the point
+// data record length are updated; extra bytes not covered by a descriptor are described as undocumented first.
+func (l *Las) AddExtraBytes(descriptor ExtraBytesDescriptor) (attribute *ExtraBytesAttribute, err error) {
+	schema, err := l.GetExtraBytesSchema()
+	if err != nil {
+		return
+	}
+	if existing, _ := schema.Get(descriptor.GetName()); existing != nil {
+		err = fmt.Errorf("extra bytes attribute %q exists already", descriptor.GetName())
+		return
+	}
+	size, err := descriptor.GetSize()
+	if err != nil {
+		return
+	}
+	recordSize, extraLength, err := l.getExtraBytesLength()
+	if err != nil {
+		return
+	}
+	if schema.Size() > extraLength {
+		err = fmt.Errorf("extra bytes descriptors describe %d bytes, but points hold %d extra bytes", schema.Size(), extraLength)
+		return
+	}
+	if int(recordSize)+extraLength+size > math.MaxUint16 {
+		err = fmt.Errorf("point data record length cannot hold %d more extra bytes", size)
+		return
+	}
+
+	descriptors := make([]ExtraBytesDescriptor, 0, len(schema.Attributes)+1)
+	for _, attribute := range schema.Attributes {
+		descriptors = append(descriptors, attribute.ExtraBytesDescriptor)
+	}
+	for undocumented := extraLength - schema.Size(); undocumented > 0; {
+		count := undocumented
+		if count > math.MaxUint8 {
+			count = math.MaxUint8
+		}
+		gap := ExtraBytesDescriptor{DataType: EXTRA_BYTES_TYPE_UNDOCUMENTED, Options: uint8(count)}
+		copy(gap.Description[:], "undocumented extra bytes")
+		descriptors = append(descriptors, gap)
+		undocumented -= count
+	}
+	descriptors = append(descriptors, descriptor)
+
+	// initial bytes of the new attribute
+	initial := make([]byte, size)
+	if descriptor.HasOption(EXTRA_BYTES_OPTION_NO_DATA) {
+		elementSize := getExtraBytesTypeSize(descriptor.GetBaseType())
+		for element := 0; element < descriptor.GetNumberOfElements(); element++ {
+			descriptor.newExtraBytesValue(descriptor.NoData[element]).put(initial[element*elementSize:], descriptor.GetBaseType())
+		}
+	}
+	if l.Pdrs != nil {
+		for index := 0; index < l.Pdrs.Len(); index++ {
+			point := l.Pdrs.At(index)
+			extraBytes := make([]byte, extraLength+size)
+			copy(extraBytes, point.GetExtraBytes())
+			copy(extraBytes[extraLength:], initial)
+			point.SetExtraBytes(extraBytes)
+		}
+	}
+	if err = l.setExtraBytesDescriptors(descriptors); err != nil {
+		return
+	}
+	l.Header.PointDataRecordLength = recordSize + uint16(extraLength+size)
+
+	if schema, err = NewExtraBytesSchema(descriptors); err != nil {
+		return
+	}
+	attribute = &schema.Attributes[len(schema.Attributes)-1]
+	return
+}
+
+// RemoveExtraBytes removes the attribute called name from the extra bytes of all point data records of l, its
+// descriptor from the LASF_Spec record 4 VLR or EVLR, and its bytes from the point data record length. The record is
+// removed with the last descriptor.
+func (l *Las) RemoveExtraBytes(name string) (err error) {
+	schema, err := l.GetExtraBytesSchema()
+	if err != nil {
+		return
+	}
+	removed, err := schema.Get(name)
+	if err != nil {
+		return
+	}
+	recordSize, extraLength, err := l.getExtraBytesLength()
+	if err != nil {
+		return
+	}
+	if removed.Start+removed.Size > extraLength {
+		err = fmt.Errorf("extra bytes attribute %q lies outside of the %d extra bytes of the points", name, extraLength)
+		return
+	}
+
+	if l.Pdrs != nil {
+		for index := 0; index < l.Pdrs.Len(); index++ {
+			point := l.Pdrs.At(index)
+			extraBytes := point.GetExtraBytes()
+			if len(extraBytes) <= removed.Start {
+				continue
+			}
+			end := removed.Start + removed.Size
+			if end > len(extraBytes) {
+				end = len(extraBytes)
+			}
+			kept := make([]byte, 0, len(extraBytes)-(end-removed.Start))
+			kept = append(kept, extraBytes[:removed.Start]...)
+			kept = append(kept, extraBytes[end:]...)
+			point.SetExtraBytes(kept)
+		}
+	}
+	var descriptors []ExtraBytesDescriptor
+	for index := range schema.Attributes {
+		if &schema.Attributes[index] != removed {
+			descriptors = append(descriptors, schema.Attributes[index].ExtraBytesDescriptor)
+		}
+	}
+	if err = l.setExtraBytesDescriptors(descriptors); err != nil {
+		return
+	}
+	l.Header.PointDataRecordLength = recordSize + uint16(extraLength-removed.Size)
+	return
+}
+
+// getExtraBytesLength returns the size of the standard fields and the number of extra bytes of the point data records
+// of l.
+func (l *Las) getExtraBytesLength() (recordSize uint16, length int, err error) {
+	if recordSize, err = getPointDataRecordSize(l.Header.PointDataRecordFormat & LASZIP_FORMAT_MASK); err != nil {
+		return
+	}
+	if l.Header.PointDataRecordLength > recordSize {
+		length = int(l.Header.PointDataRecordLength - recordSize)
+	}
+	return
+}
+
+// setExtraBytesDescriptors replaces the LASF_Spec record 4 VLR, or EVLR if l holds one, by a record of descriptors.
+// Without descriptors the record is removed.
+func (l *Las) setExtraBytesDescriptors(descriptors []ExtraBytesDescriptor) (err error) {
+	if len(descriptors) == 0 {
+		l.RemoveVLRs("LASF_Spec", 4)
+		l.RemoveEVLRs("LASF_Spec", 4)
+		return
+	}
+	payload := new(bytes.Buffer)
+	if err = binary.Write(payload, binary.LittleEndian, descriptors); err != nil {
+		return
+	}
+	if _, err = l.GetEVLR("LASF_Spec", 4); err == nil {
+		var evlr EVLR
+		if evlr, err = NewEVLR("LASF_Spec", 4, "Extra Bytes Record", payload.Bytes()); err != nil {
+			return
+		}
+		l.ReplaceEVLR(evlr)
+		return
+	}
+	vlr, err := NewVLR("LASF_Spec", 4, "Extra Bytes Record", payload.Bytes())
+	if err != nil {
+		return
+	}
+	l.ReplaceVLR(vlr)
+	return
+}
@@ -0,0 +1,131 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestExtraBytesMinMax(t *testing.T) {
+	descriptor, err := NewExtraBytesDescriptor("Amplitude", EXTRA_BYTES_TYPE_SHORT, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = descriptor.SetScaleAndOffset(0.5, 0); err != nil {
+		t.Fatal(err)
+	}
+	minimum, maximum := int64(-10), int64(10)
+	binary.LittleEndian.PutUint64(descriptor.Min[0][:], uint64(minimum))
+	binary.LittleEndian.PutUint64(descriptor.Max[0][:], uint64(maximum))
+	descriptor.Options |= EXTRA_BYTES_OPTION_MIN | EXTRA_BYTES_OPTION_MAX
+	schema, err := NewExtraBytesSchema([]ExtraBytesDescriptor{descriptor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attribute := &schema.Attributes[0]
+	if value, ok := attribute.GetMin(0); !ok || value != -5 {
+		t.Errorf("got min %v, %t, want -5", value, ok)
+	}
+	if value, ok := attribute.GetMax(0); !ok || value != 5 {
+		t.Errorf("got max %v, %t, want 5", value, ok)
+	}
+	// values outside of min and max stay valid
+	extraBytes := binary.LittleEndian.AppendUint16(nil, 100)
+	if value, valid := attribute.decode(extraBytes, 0); !valid || value != 50 {
+		t.Errorf("got %v, %t, want 50", value, valid)
+	}
+}
+
+func TestExtraBytesNoData(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType uint8
+		noData   float64
+	}{
+		{"short", EXTRA_BYTES_TYPE_SHORT, -1},
+		{"float", EXTRA_BYTES_TYPE_FLOAT, 0.1},
+		{"double", EXTRA_BYTES_TYPE_DOUBLE, 0.1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newTestLas(t, 4, 6, 3, 0)
+			descriptor, err := NewExtraBytesDescriptor("Amplitude", test.dataType, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = descriptor.SetNoData(test.noData); err != nil {
+				t.Fatal(err)
+			}
+			attribute, err := l.AddExtraBytes(descriptor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for index, value := range []float64{test.noData, math.NaN(), 2} {
+				if err = attribute.SetValue(l.Pdrs.At(index), value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			data := writeTestLas(t, l)
+
+			parsed := &Las{}
+			if err = parsed.ParseReader(bytes.NewReader(data), int64(len(data))); err != nil {
+				t.Fatal(err)
+			}
+			values, valid, err := parsed.GetExtraColumn("Amplitude")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if valid[0] || valid[1] || !valid[2] || values[2] != 2 {
+				t.Errorf("got values %v valid %v, want no data, no data and 2", values, valid)
+			}
+		})
+	}
+}
+
+func TestExtraBytesDoubleNoDataOfFloat(t *testing.T) {
+	// the no-data value of a float attribute is stored as a double, which other writers may not round to float
+	descriptor, err := NewExtraBytesDescriptor("Amplitude", EXTRA_BYTES_TYPE_FLOAT, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint64(descriptor.NoData[0][:], math.Float64bits(0.1))
+	descriptor.Options |= EXTRA_BYTES_OPTION_NO_DATA
+	schema, err := NewExtraBytesSchema([]ExtraBytesDescriptor{descriptor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	extraBytes := binary.LittleEndian.AppendUint32(nil, math.Float32bits(0.1))
+	if value, valid := schema.Attributes[0].decode(extraBytes, 0); valid {
+		t.Errorf("no-data value decoded as valid value %v", value)
+	}
+}
+
+func TestExtraPoint(t *testing.T) {
+	l := newTestLas(t, 4, 1, 3, 0)
+	descriptor, err := NewExtraBytesDescriptor("Amplitude", EXTRA_BYTES_TYPE_SHORT, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attribute, err := l.AddExtraBytes(descriptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = attribute.SetValue(l.Pdrs.At(1), 42); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := l.GetExtraBytesSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := schema.Bind(l.Pdrs.At(1))
+	if value, valid, err := point.Extra("Amplitude"); err != nil || !valid || value != 42 {
+		t.Errorf("got %v, %t, %v, want 42", value, valid, err)
+	}
+	if _, _, err = point.Extra("Width"); err == nil {
+		t.Error("unknown attribute read without error")
+	}
+	if point.GetX() != l.Pdrs.At(1).GetX() {
+		t.Error("bound point has a different x than the point")
+	}
+}
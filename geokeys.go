@@ -0,0 +1,397 @@
+package las
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+//   _____            _  __               _____                 _       _   _
+//  / ____|          | |/ /              |  __ \               | |     | | (_)
+// | |  __  ___  ___ | ' / ___ _   _     | |__) |___  ___  ___ | |_   _| |_ _  ___  _ __
+// | | |_ |/ _ \/ _ \|  < / _ \ | | |    |  _  // _ \/ __|/ _ \| | | | | __| |/ _ \| '_ \
+// | |__| |  __/ (_) | . \  __/ |_| |    | | \ \  __/\__ \ (_) | | |_| | |_| | (_) | | | |
+//  \_____|\___|\___/|_|\_\___|\__, |    |_|  \_\___||___/\___/|_|\__,_|\__|_|\___/|_| |_|
+//                              __/ |
+//                             |___/
+
+// The record IDs of the GeoTIFF records of the LASF_Projection VLRs.
+const (
+	GEO_KEY_DIRECTORY_TAG  uint16 = 34735
+	GEO_DOUBLE_PARAMS_TAG  uint16 = 34736
+	GEO_ASCII_PARAMS_TAG   uint16 = 34737
+	PROJECTION_USER_ID            = "LASF_Projection"
+	GEO_KEY_USER_DEFINED   uint16 = 32767
+	GEO_KEY_UNDEFINED      uint16 = 0
+	GEO_KEY_DIRECTORY_SIZE        = 4
+)
+
+// The IDs of the GeoKeys of GeoTIFF 1.0, and GEOG_TOWGS84_GEO_KEY and PROJ_RECTIFIED_GRID_ANGLE_GEO_KEY of GeoTIFF 1.1.
+const (
+	GT_MODEL_TYPE_GEO_KEY                uint16 = 1024
+	GT_RASTER_TYPE_GEO_KEY               uint16 = 1025
+	GT_CITATION_GEO_KEY                  uint16 = 1026
+	GEOGRAPHIC_TYPE_GEO_KEY              uint16 = 2048
+	GEOG_CITATION_GEO_KEY                uint16 = 2049
+	GEOG_GEODETIC_DATUM_GEO_KEY          uint16 = 2050
+	GEOG_PRIME_MERIDIAN_GEO_KEY          uint16 = 2051
+	GEOG_LINEAR_UNITS_GEO_KEY            uint16 = 2052
+	GEOG_LINEAR_UNIT_SIZE_GEO_KEY        uint16 = 2053
+	GEOG_ANGULAR_UNITS_GEO_KEY           uint16 = 2054
+	GEOG_ANGULAR_UNIT_SIZE_GEO_KEY       uint16 = 2055
+	GEOG_ELLIPSOID_GEO_KEY               uint16 = 2056
+	GEOG_SEMI_MAJOR_AXIS_GEO_KEY         uint16 = 2057
+	GEOG_SEMI_MINOR_AXIS_GEO_KEY         uint16 = 2058
+	GEOG_INV_FLATTENING_GEO_KEY          uint16 = 2059
+	GEOG_AZIMUTH_UNITS_GEO_KEY           uint16 = 2060
+	GEOG_PRIME_MERIDIAN_LONG_GEO_KEY     uint16 = 2061
+	GEOG_TOWGS84_GEO_KEY                 uint16 = 2062
+	PROJECTED_CS_TYPE_GEO_KEY            uint16 = 3072
+	PCS_CITATION_GEO_KEY                 uint16 = 3073
+	PROJECTION_GEO_KEY                   uint16 = 3074
+	PROJ_COORD_TRANS_GEO_KEY             uint16 = 3075
+	PROJ_LINEAR_UNITS_GEO_KEY            uint16 = 3076
+	PROJ_LINEAR_UNIT_SIZE_GEO_KEY        uint16 = 3077
+	PROJ_STD_PARALLEL1_GEO_KEY           uint16 = 3078
+	PROJ_STD_PARALLEL2_GEO_KEY           uint16 = 3079
+	PROJ_NAT_ORIGIN_LONG_GEO_KEY         uint16 = 3080
+	PROJ_NAT_ORIGIN_LAT_GEO_KEY          uint16 = 3081
+	PROJ_FALSE_EASTING_GEO_KEY           uint16 = 3082
+	PROJ_FALSE_NORTHING_GEO_KEY          uint16 = 3083
+	PROJ_FALSE_ORIGIN_LONG_GEO_KEY       uint16 = 3084
+	PROJ_FALSE_ORIGIN_LAT_GEO_KEY        uint16 = 3085
+	PROJ_FALSE_ORIGIN_EASTING_GEO_KEY    uint16 = 3086
+	PROJ_FALSE_ORIGIN_NORTHING_GEO_KEY   uint16 = 3087
+	PROJ_CENTER_LONG_GEO_KEY             uint16 = 3088
+	PROJ_CENTER_LAT_GEO_KEY              uint16 = 3089
+	PROJ_CENTER_EASTING_GEO_KEY          uint16 = 3090
+	PROJ_CENTER_NORTHING_GEO_KEY         uint16 = 3091
+	PROJ_SCALE_AT_NAT_ORIGIN_GEO_KEY     uint16 = 3092
+	PROJ_SCALE_AT_CENTER_GEO_KEY         uint16 = 3093
+	PROJ_AZIMUTH_ANGLE_GEO_KEY           uint16 = 3094
+	PROJ_STRAIGHT_VERT_POLE_LONG_GEO_KEY uint16 = 3095
+	PROJ_RECTIFIED_GRID_ANGLE_GEO_KEY    uint16 = 3096
+	VERTICAL_CS_TYPE_GEO_KEY             uint16 = 4096
+	VERTICAL_CITATION_GEO_KEY            uint16 = 4097
+	VERTICAL_DATUM_GEO_KEY               uint16 = 4098
+	VERTICAL_UNITS_GEO_KEY               uint16 = 4099
+)
+
+// The values of GT_MODEL_TYPE_GEO_KEY.
+const (
+	MODEL_TYPE_PROJECTED  uint16 = 1
+	MODEL_TYPE_GEOGRAPHIC uint16 = 2
+	MODEL_TYPE_GEOCENTRIC uint16 = 3
+)
+
+var geoKeyNames = map[uint16]string{
+	GT_MODEL_TYPE_GEO_KEY:                "GTModelTypeGeoKey",
+	GT_RASTER_TYPE_GEO_KEY:               "GTRasterTypeGeoKey",
+	GT_CITATION_GEO_KEY:                  "GTCitationGeoKey",
+	GEOGRAPHIC_TYPE_GEO_KEY:              "GeographicTypeGeoKey",
+	GEOG_CITATION_GEO_KEY:                "GeogCitationGeoKey",
+	GEOG_GEODETIC_DATUM_GEO_KEY:          "GeogGeodeticDatumGeoKey",
+	GEOG_PRIME_MERIDIAN_GEO_KEY:          "GeogPrimeMeridianGeoKey",
+	GEOG_LINEAR_UNITS_GEO_KEY:            "GeogLinearUnitsGeoKey",
+	GEOG_LINEAR_UNIT_SIZE_GEO_KEY:        "GeogLinearUnitSizeGeoKey",
+	GEOG_ANGULAR_UNITS_GEO_KEY:           "GeogAngularUnitsGeoKey",
+	GEOG_ANGULAR_UNIT_SIZE_GEO_KEY:       "GeogAngularUnitSizeGeoKey",
+	GEOG_ELLIPSOID_GEO_KEY:               "GeogEllipsoidGeoKey",
+	GEOG_SEMI_MAJOR_AXIS_GEO_KEY:         "GeogSemiMajorAxisGeoKey",
+	GEOG_SEMI_MINOR_AXIS_GEO_KEY:         "GeogSemiMinorAxisGeoKey",
+	GEOG_INV_FLATTENING_GEO_KEY:          "GeogInvFlatteningGeoKey",
+	GEOG_AZIMUTH_UNITS_GEO_KEY:           "GeogAzimuthUnitsGeoKey",
+	GEOG_PRIME_MERIDIAN_LONG_GEO_KEY:     "GeogPrimeMeridianLongGeoKey",
+	GEOG_TOWGS84_GEO_KEY:                 "GeogTOWGS84GeoKey",
+	PROJECTED_CS_TYPE_GEO_KEY:            "ProjectedCSTypeGeoKey",
+	PCS_CITATION_GEO_KEY:                 "PCSCitationGeoKey",
+	PROJECTION_GEO_KEY:                   "ProjectionGeoKey",
+	PROJ_COORD_TRANS_GEO_KEY:             "ProjCoordTransGeoKey",
+	PROJ_LINEAR_UNITS_GEO_KEY:            "ProjLinearUnitsGeoKey",
+	PROJ_LINEAR_UNIT_SIZE_GEO_KEY:        "ProjLinearUnitSizeGeoKey",
+	PROJ_STD_PARALLEL1_GEO_KEY:           "ProjStdParallel1GeoKey",
+	PROJ_STD_PARALLEL2_GEO_KEY:           "ProjStdParallel2GeoKey",
+	PROJ_NAT_ORIGIN_LONG_GEO_KEY:         "ProjNatOriginLongGeoKey",
+	PROJ_NAT_ORIGIN_LAT_GEO_KEY:          "ProjNatOriginLatGeoKey",
+	PROJ_FALSE_EASTING_GEO_KEY:           "ProjFalseEastingGeoKey",
+	PROJ_FALSE_NORTHING_GEO_KEY:          "ProjFalseNorthingGeoKey",
+	PROJ_FALSE_ORIGIN_LONG_GEO_KEY:       "ProjFalseOriginLongGeoKey",
+	PROJ_FALSE_ORIGIN_LAT_GEO_KEY:        "ProjFalseOriginLatGeoKey",
+	PROJ_FALSE_ORIGIN_EASTING_GEO_KEY:    "ProjFalseOriginEastingGeoKey",
+	PROJ_FALSE_ORIGIN_NORTHING_GEO_KEY:   "ProjFalseOriginNorthingGeoKey",
+	PROJ_CENTER_LONG_GEO_KEY:             "ProjCenterLongGeoKey",
+	PROJ_CENTER_LAT_GEO_KEY:              "ProjCenterLatGeoKey",
+	PROJ_CENTER_EASTING_GEO_KEY:          "ProjCenterEastingGeoKey",
+	PROJ_CENTER_NORTHING_GEO_KEY:         "ProjCenterNorthingGeoKey",
+	PROJ_SCALE_AT_NAT_ORIGIN_GEO_KEY:     "ProjScaleAtNatOriginGeoKey",
+	PROJ_SCALE_AT_CENTER_GEO_KEY:         "ProjScaleAtCenterGeoKey",
+	PROJ_AZIMUTH_ANGLE_GEO_KEY:           "ProjAzimuthAngleGeoKey",
+	PROJ_STRAIGHT_VERT_POLE_LONG_GEO_KEY: "ProjStraightVertPoleLongGeoKey",
+	PROJ_RECTIFIED_GRID_ANGLE_GEO_KEY:    "ProjRectifiedGridAngleGeoKey",
+	VERTICAL_CS_TYPE_GEO_KEY:             "VerticalCSTypeGeoKey",
+	VERTICAL_CITATION_GEO_KEY:            "VerticalCitationGeoKey",
+	VERTICAL_DATUM_GEO_KEY:               "VerticalDatumGeoKey",
+	VERTICAL_UNITS_GEO_KEY:               "VerticalUnitsGeoKey",
+}
+
+// GeoKey is an entry of the GeoKeyDirectoryTag with its value resolved: short values are stored in Shorts, values of
+// the GeoDoubleParamsTag in Doubles and values of the GeoAsciiParamsTag in ASCII.
+type GeoKey struct {
+	ID      uint16
+	Name    string
+	Shorts  []uint16
+	Doubles []float64
+	ASCII   string
+}
+
+// GetShort returns the first short value of the key, or 0 if it holds no short.
+func (k *GeoKey) GetShort() uint16 {
+	if len(k.Shorts) == 0 {
+		return 0
+	}
+	return k.Shorts[0]
+}
+
+// GetDouble returns the first double value of the key, or NaN if it holds no double.
+func (k *GeoKey) GetDouble() float64 {
+	if len(k.Doubles) == 0 {
+		return math.NaN()
+	}
+	return k.Doubles[0]
+}
+
+// GetGeoKeyName returns the GeoTIFF name of the key, e.g. "ProjectedCSTypeGeoKey".
+func GetGeoKeyName(id uint16) string {
+	if name, ok := geoKeyNames[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("GeoKey%d", id)
+}
+
+// ResolveGeoKeys combines the GeoKeyDirectoryTag, GeoDoubleParamsTag and GeoAsciiParamsTag of a file into keys with
+// their values. directory, doubles and ascii are the payloads of the three records; doubles and ascii may be nil if the
+// file does not hold them.
+func ResolveGeoKeys(directory []byte, doubles []byte, ascii []byte) (keys []GeoKey, err error) {
+	if len(directory) < 2*GEO_KEY_DIRECTORY_SIZE {
+		err = fmt.Errorf("GeoKeyDirectoryTag of %d bytes is too short", len(directory))
+		return
+	}
+	shorts := make([]uint16, len(directory)/2)
+	for index := range shorts {
+		shorts[index] = binary.LittleEndian.Uint16(directory[2*index:])
+	}
+	numberOfKeys := int(shorts[3])
+	if GEO_KEY_DIRECTORY_SIZE*(numberOfKeys+1) > len(shorts) {
+		err = fmt.Errorf("GeoKeyDirectoryTag declares %d keys, but holds %d", numberOfKeys, len(shorts)/GEO_KEY_DIRECTORY_SIZE-1)
+		return
+	}
+
+	resolved := make([]GeoKey, numberOfKeys)
+	for index := range resolved {
+		entry := shorts[GEO_KEY_DIRECTORY_SIZE*(index+1):]
+		id, location, count, valueOffset := entry[0], entry[1], int(entry[2]), int(entry[3])
+		key := GeoKey{ID: id, Name: GetGeoKeyName(id)}
+		switch location {
+		case 0:
+			key.Shorts = []uint16{uint16(valueOffset)}
+		case GEO_KEY_DIRECTORY_TAG:
+			if valueOffset+count > len(shorts) {
+				err = fmt.Errorf("%s refers to shorts %d to %d, but the GeoKeyDirectoryTag holds %d", key.Name, valueOffset, valueOffset+count, len(shorts))
+				return
+			}
+			key.Shorts = append([]uint16(nil), shorts[valueOffset:valueOffset+count]...)
+		case GEO_DOUBLE_PARAMS_TAG:
+			if 8*(valueOffset+count) > len(doubles) {
+				err = fmt.Errorf("%s refers to doubles %d to %d, but the GeoDoubleParamsTag holds %d", key.Name, valueOffset, valueOffset+count, len(doubles)/8)
+				return
+			}
+			key.Doubles = make([]float64, count)
+			for i := range key.Doubles {
+				key.Doubles[i] = math.Float64frombits(binary.LittleEndian.Uint64(doubles[8*(valueOffset+i):]))
+			}
+		case GEO_ASCII_PARAMS_TAG:
+			if valueOffset+count > len(ascii) {
+				err = fmt.Errorf("%s refers to characters %d to %d, but the GeoAsciiParamsTag holds %d", key.Name, valueOffset, valueOffset+count, len(ascii))
+				return
+			}
+			// GeoTIFF terminates strings with a pipe
+			key.ASCII = strings.TrimRight(string(ascii[valueOffset:valueOffset+count]), "|\x00")
+		default:
+			err = fmt.Errorf("%s refers to the unknown TIFF tag %d", key.Name, location)
+			return
+		}
+		resolved[index] = key
+	}
+	keys = resolved
+	return
+}
+
+// GetGeoKeys returns the GeoKeys of the LASF_Projection VLRs or EVLRs of l. It fails with ErrNotFound if l holds no
+// GeoKeyDirectoryTag.
+func (l *Las) GetGeoKeys() (keys []GeoKey, err error) {
+	directory, ok := l.getRecordPayload(PROJECTION_USER_ID, GEO_KEY_DIRECTORY_TAG)
+	if !ok {
+		err = fmt.Errorf("%w: no GeoKeyDirectoryTag", ErrNotFound)
+		return
+	}
+	doubles, _ := l.getRecordPayload(PROJECTION_USER_ID, GEO_DOUBLE_PARAMS_TAG)
+	ascii, _ := l.getRecordPayload(PROJECTION_USER_ID, GEO_ASCII_PARAMS_TAG)
+	return ResolveGeoKeys(directory, doubles, ascii)
+}
+
+// getRecordPayload returns the payload of the first VLR, or else EVLR, with the user ID and record ID.
+func (l *Las) getRecordPayload(userID string, recordID uint16) (payload []byte, ok bool) {
+	if vlr, err := l.GetVLR(userID, recordID); err == nil {
+		return vlr.Payload(), true
+	}
+	if evlr, err := l.GetEVLR(userID, recordID); err == nil {
+		return evlr.Payload(), true
+	}
+	return
+}
+
+//   _____ _____   _____ _____        __
+//  / ____|  __ \ / ____|_   _|      / _|
+// | |    | |__) | (___   | |  _ __ | |_ ___
+// | |    |  _  / \___ \  | | | '_ \|  _/ _ \
+// | |____| | \ \ ____) |_| |_| | | | || (_) |
+//  \_____|_|  \_\_____/|_____|_| |_|_| \___/
+//
+//
+
+// CRSInfo summarises the coordinate reference system of a file. EPSG codes are 0 if the file does not state them or
+// defines the system itself; unit sizes are in metres for linear units and radians for angular units, 0 if unknown.
+type CRSInfo struct {
+	ModelType          uint16
+	HorizontalEPSG     int
+	VerticalEPSG       int
+	HorizontalUnits    string
+	HorizontalUnitSize float64
+	VerticalUnits      string
+	VerticalUnitSize   float64
+	Citation           string
+	GeoKeys            []GeoKey
+}
+
+// IsProjected reports whether the horizontal coordinates are projected.
+func (c *CRSInfo) IsProjected() bool {
+	return c.ModelType == MODEL_TYPE_PROJECTED
+}
+
+// IsGeographic reports whether the horizontal coordinates are longitude and latitude.
+func (c *CRSInfo) IsGeographic() bool {
+	return c.ModelType == MODEL_TYPE_GEOGRAPHIC
+}
+
+// GetGeoKey returns the key of the CRS with the ID, or nil.
+func (c *CRSInfo) GetGeoKey(id uint16) *GeoKey {
+	for index := range c.GeoKeys {
+		if c.GeoKeys[index].ID == id {
+			return &c.GeoKeys[index]
+		}
+	}
+	return nil
+}
+
+// NewCRSInfoFromGeoKeys interprets keys resolved by ResolveGeoKeys.
+func NewCRSInfoFromGeoKeys(keys []GeoKey) (info *CRSInfo) {
+	info = &CRSInfo{GeoKeys: keys}
+	short := func(id uint16) uint16 {
+		if key := info.GetGeoKey(id); key != nil {
+			return key.GetShort()
+		}
+		return GEO_KEY_UNDEFINED
+	}
+	epsg := func(id uint16) int {
+		if code := short(id); code != GEO_KEY_USER_DEFINED {
+			return int(code)
+		}
+		return 0
+	}
+
+	info.ModelType = short(GT_MODEL_TYPE_GEO_KEY)
+	switch info.ModelType {
+	case MODEL_TYPE_PROJECTED:
+		info.HorizontalEPSG = epsg(PROJECTED_CS_TYPE_GEO_KEY)
+		info.HorizontalUnits, info.HorizontalUnitSize = getUnit(short(PROJ_LINEAR_UNITS_GEO_KEY), info.GetGeoKey(PROJ_LINEAR_UNIT_SIZE_GEO_KEY))
+	case MODEL_TYPE_GEOGRAPHIC:
+		info.HorizontalEPSG = epsg(GEOGRAPHIC_TYPE_GEO_KEY)
+		angularUnits := short(GEOG_ANGULAR_UNITS_GEO_KEY)
+		if angularUnits == GEO_KEY_UNDEFINED {
+			// GeoTIFF defaults to degrees
+			angularUnits = 9102
+		}
+		info.HorizontalUnits, info.HorizontalUnitSize = getUnit(angularUnits, info.GetGeoKey(GEOG_ANGULAR_UNIT_SIZE_GEO_KEY))
+	case MODEL_TYPE_GEOCENTRIC:
+		info.HorizontalEPSG = epsg(GEOGRAPHIC_TYPE_GEO_KEY)
+		info.HorizontalUnits, info.HorizontalUnitSize = getUnit(short(GEOG_LINEAR_UNITS_GEO_KEY), info.GetGeoKey(GEOG_LINEAR_UNIT_SIZE_GEO_KEY))
+	}
+	info.VerticalEPSG = epsg(VERTICAL_CS_TYPE_GEO_KEY)
+	if verticalUnits := short(VERTICAL_UNITS_GEO_KEY); verticalUnits != GEO_KEY_UNDEFINED {
+		info.VerticalUnits, info.VerticalUnitSize = getUnit(verticalUnits, nil)
+	}
+
+	for _, id := range []uint16{PCS_CITATION_GEO_KEY, GT_CITATION_GEO_KEY, GEOG_CITATION_GEO_KEY} {
+		if key := info.GetGeoKey(id); key != nil && key.ASCII != "" {
+			info.Citation = key.ASCII
+			break
+		}
+	}
+	return
+}
+
+// getUnit returns the name and size of the EPSG unit. User-defined units take their size from sizeKey.
+func getUnit(code uint16, sizeKey *GeoKey) (name string, size float64) {
+	switch code {
+	case GEO_KEY_UNDEFINED:
+		return
+	case GEO_KEY_USER_DEFINED:
+		name = "user-defined"
+		if sizeKey != nil {
+			size = sizeKey.GetDouble()
+		}
+		return
+	}
+	if unit, ok := epsgUnits[code]; ok {
+		name, size = unit.name, unit.size
+		return
+	}
+	name = fmt.Sprintf("EPSG:%d", code)
+	return
+}
+
+type epsgUnit struct {
+	name     string
+	unitType string
+	size     float64
+}
+
+var epsgUnits = map[uint16]epsgUnit{
+	9001: {"metre", WKT_UNIT_LENGTH, 1},
+	9002: {"foot", WKT_UNIT_LENGTH, 0.3048},
+	9003: {"US survey foot", WKT_UNIT_LENGTH, 1200.0 / 3937.0},
+	9005: {"Clarke's foot", WKT_UNIT_LENGTH, 0.3047972654},
+	9014: {"fathom", WKT_UNIT_LENGTH, 1.8288},
+	9030: {"nautical mile", WKT_UNIT_LENGTH, 1852},
+	9036: {"kilometre", WKT_UNIT_LENGTH, 1000},
+	9101: {"radian", WKT_UNIT_ANGLE, 1},
+	9102: {"degree", WKT_UNIT_ANGLE, math.Pi / 180},
+	9103: {"arc-minute", WKT_UNIT_ANGLE, math.Pi / 10800},
+	9104: {"arc-second", WKT_UNIT_ANGLE, math.Pi / 648000},
+	9105: {"grad", WKT_UNIT_ANGLE, math.Pi / 200},
+	9106: {"gon", WKT_UNIT_ANGLE, math.Pi / 200},
+	9122: {"degree", WKT_UNIT_ANGLE, math.Pi / 180},
+}
+
+// ResolveCRSInfo resolves the CRS of l from its GeoKeys, or from its coordinate system WKT record converted to
+// GeoKeys if it has none, stores it in l.CRSInfo and returns it. It fails with ErrNotFound if l holds neither.
+func (l *Las) ResolveCRSInfo() (info *CRSInfo, err error) {
+	keys, err := l.getCRSGeoKeys()
+	if err != nil {
+		return
+	}
+	info = NewCRSInfoFromGeoKeys(keys)
+	l.CRSInfo = info
+	return
+}
@@ -1,6 +1,12 @@
 package las
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
 
 type LasSepcVersion string
 
@@ -9,9 +15,10 @@ const (
 	V1_3                = "1.3"
 	V1_2                = "1.2"
 	V1_1                = "1.1"
+	V1_0                = "1.0"
 )
 
-var AllLasVersions = []LasSepcVersion{V1_4, V1_3, V1_2, V1_1}
+var AllLasVersions = []LasSepcVersion{V1_4, V1_3, V1_2, V1_1, V1_0}
 
 //  _____  _    _ ____  _      _____ _____   _    _ ______          _____  ______ _____    ____  _      ____   _____ _  __
 // |  __ \| |  | |  _ \| |    |_   _/ ____| | |  | |  ____|   /\   |  __ \|  ____|  __ \  |  _ \| |    / __ \ / ____| |/ /
@@ -66,3 +73,127 @@ func (phb *PublicHeaderBlock) GetVersion() (version LasSepcVersion) {
 	version = LasSepcVersion(fmt.Sprintf("%d.%d", phb.VersionMajor, phb.VersionMinor))
 	return
 }
+
+// The bits of GlobalEncoding.
+const (
+	GLOBAL_ENCODING_GPS_TIME_TYPE            uint16 = 0x0001
+	GLOBAL_ENCODING_WAVEFORM_INTERNAL        uint16 = 0x0002
+	GLOBAL_ENCODING_WAVEFORM_EXTERNAL        uint16 = 0x0004
+	GLOBAL_ENCODING_SYNTHETIC_RETURN_NUMBERS uint16 = 0x0008
+	GLOBAL_ENCODING_WKT                      uint16 = 0x0010
+)
+
+const (
+	HEADER_SIZE_V1_2 uint16 = 227
+	HEADER_SIZE_V1_3 uint16 = 235
+	HEADER_SIZE_V1_4 uint16 = 375
+)
+
+// getStandardHeaderSize returns the size of the public header block defined by the spec for the version of the file.
+// LAS 1.0 to 1.2 share the same layout.
+func (phb *PublicHeaderBlock) getStandardHeaderSize() (size uint16) {
+	switch phb.GetVersion() {
+	case V1_4:
+		size = HEADER_SIZE_V1_4
+	case V1_3:
+		size = HEADER_SIZE_V1_3
+	default:
+		size = HEADER_SIZE_V1_2
+	}
+	return
+}
+
+// write writes the fields of the public header block defined by the version of the file, followed by userData.
+func (phb *PublicHeaderBlock) write(w io.Writer, userData []byte) (err error) {
+	headerInBytes := new(bytes.Buffer)
+	if err = binary.Write(headerInBytes, binary.LittleEndian, phb); err != nil {
+		return
+	}
+	if _, err = w.Write(headerInBytes.Bytes()[:phb.getStandardHeaderSize()]); err != nil {
+		return
+	}
+	if _, err = w.Write(userData); err != nil {
+		return
+	}
+	return
+}
+
+// GetScaledXYZ returns the coordinates of point with the scale factors and offsets of the header applied.
+func (phb *PublicHeaderBlock) GetScaledXYZ(point Point) (x float64, y float64, z float64) {
+	x = float64(point.GetX())*phb.XScaleFactor + phb.XOffset
+	y = float64(point.GetY())*phb.YScaleFactor + phb.YOffset
+	z = float64(point.GetZ())*phb.ZScaleFactor + phb.ZOffset
+	return
+}
+
+// Quantize returns the raw coordinates storing x, y, z with the scale factors and offsets of the header, rounded to
+// the nearest integer. It fails if a coordinate cannot be stored in an int32.
+func (phb *PublicHeaderBlock) Quantize(x float64, y float64, z float64) (rawX int32, rawY int32, rawZ int32, err error) {
+	if rawX, err = quantize("x", x, phb.XScaleFactor, phb.XOffset); err != nil {
+		return
+	}
+	if rawY, err = quantize("y", y, phb.YScaleFactor, phb.YOffset); err != nil {
+		return
+	}
+	if rawZ, err = quantize("z", z, phb.ZScaleFactor, phb.ZOffset); err != nil {
+		return
+	}
+	return
+}
+
+// SetScaledXYZ sets the coordinates of point to x, y, z, quantized with the scale factors and offsets of the header.
+// The point is left unchanged if a coordinate cannot be stored.
+func (phb *PublicHeaderBlock) SetScaledXYZ(point Point, x float64, y float64, z float64) (err error) {
+	rawX, rawY, rawZ, err := phb.Quantize(x, y, z)
+	if err != nil {
+		return
+	}
+	point.SetX(rawX)
+	point.SetY(rawY)
+	point.SetZ(rawZ)
+	return
+}
+
+func quantize(axis string, value float64, scale float64, offset float64) (raw int32, err error) {
+	if scale == 0 {
+		err = fmt.Errorf("%s scale factor is zero", axis)
+		return
+	}
+	rounded := math.Round((value - offset) / scale)
+	if !(rounded >= math.MinInt32 && rounded <= math.MaxInt32) {
+		err = fmt.Errorf("%s coordinate %v cannot be stored with scale factor %v and offset %v", axis, value, scale, offset)
+		return
+	}
+	raw = int32(rounded)
+	return
+}
+
+// ChooseScaleAndOffset returns a scale factor and offset for storing coordinates from min to max with the given
+// precision, e.g. 0.01 for centimetres. The scale factor is the largest power of ten not above precision, and the
+// offset is the centre of the extent rounded to a multiple of a power of ten, so that the stored values are easy to
+// read. It fails if the extent cannot be stored in an int32 with that precision.
+func ChooseScaleAndOffset(min float64, max float64, precision float64) (scale float64, offset float64, err error) {
+	if !(precision > 0) || math.IsInf(precision, 0) {
+		err = fmt.Errorf("precision must be positive. Precision: %v", precision)
+		return
+	}
+	if !(min <= max) || math.IsInf(min, 0) || math.IsInf(max, 0) {
+		err = fmt.Errorf("extent from %v to %v is not valid", min, max)
+		return
+	}
+	scale = math.Pow(10, math.Floor(math.Log10(precision)+1e-9))
+
+	center := (min + max) / 2
+	unit := scale
+	if extent := max - min; extent > scale {
+		unit = math.Pow(10, math.Floor(math.Log10(extent)))
+	}
+	offset = math.Round(center/unit) * unit
+	if _, err = quantize("minimum", min, scale, offset); err != nil {
+		return
+	}
+	if _, err = quantize("maximum", max, scale, offset); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,290 @@
+package las
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+)
+
+//   _____             _   _       _   _____           _
+//  / ____|           | | (_)     | | |_   _|         | |
+// | (___  _ __   __ _| |_ _  __ _| |   | |  _ __   __| | _____  __
+//  \___ \| '_ \ / _` | __| |/ _` | |   | | | '_ \ / _` |/ _ \ \/ /
+//  ____) | |_) | (_| | |_| | (_| | |  _| |_| | | | (_| |  __/>  <
+// |_____/| .__/ \__,_|\__|_|\__,_|_| |_____|_| |_|\__,_|\___/_/\_\
+//        | |
+//        |_|
+
+const (
+	// OCTREE_LEAF_SIZE is the number of points above which an octree node is split into octants.
+	OCTREE_LEAF_SIZE = 64
+	// OCTREE_MAX_DEPTH bounds the depth of the octree, so that many points at the same position end up in one leaf.
+	OCTREE_MAX_DEPTH = 21
+)
+
+// SpatialIndex answers box, polygon, radius and k-nearest-neighbour queries on the point data records it was built
+// from. Queries take and the index holds coordinates with scale and offset applied, and return the indices of the
+// matching records, i.e. the index passed to PDRs.At. Box and polygon queries are answered by an octree, radius and
+// nearest-neighbour queries by a KD-tree. The index does not follow changes of the PDRs it was built from.
+//
+//	index, err := l.BuildSpatialIndex()
+//	if err != nil {
+//		return err
+//	}
+//	for _, i := range index.QueryRadius(x, y, z, 2.5) {
+//		pdr := l.Pdrs.At(i)
+//		...
+//	}
+type SpatialIndex struct {
+	xyz    []float64
+	bounds BoundingBox
+	octree *octreeNode
+	// octreeIndices holds the point indices ordered by octree leaf, every node covering a contiguous range.
+	octreeIndices []int
+	kdTree        kdTree
+}
+
+// Vertex is a vertex of a polygon in the x-y plane.
+type Vertex struct {
+	X float64
+	Y float64
+}
+
+// BuildSpatialIndex builds the spatial index of the point data records of l using the scale and offset of its header.
+func (l *Las) BuildSpatialIndex() (index *SpatialIndex, err error) {
+	if l.Pdrs == nil {
+		err = fmt.Errorf("las file holds no point data records")
+		return
+	}
+	return NewSpatialIndex(l.Pdrs, l.Header)
+}
+
+// NewSpatialIndex builds the spatial index of pdrs, whose coordinates are scaled with the scale factors and offsets of
+// header.
+func NewSpatialIndex(pdrs PDRs, header PublicHeaderBlock) (index *SpatialIndex, err error) {
+	if header.XScaleFactor == 0 || header.YScaleFactor == 0 || header.ZScaleFactor == 0 {
+		err = fmt.Errorf("scale factors must not be zero. Scale factors: %v, %v, %v", header.XScaleFactor, header.YScaleFactor, header.ZScaleFactor)
+		return
+	}
+	numberOfPoints := pdrs.Len()
+	index = &SpatialIndex{xyz: make([]float64, 3*numberOfPoints)}
+	index.bounds = BoundingBox{
+		MinX: math.Inf(1), MinY: math.Inf(1), MinZ: math.Inf(1),
+		MaxX: math.Inf(-1), MaxY: math.Inf(-1), MaxZ: math.Inf(-1),
+	}
+	for i := 0; i < numberOfPoints; i++ {
+		x, y, z := header.GetScaledXYZ(pdrs.At(i))
+		index.xyz[3*i], index.xyz[3*i+1], index.xyz[3*i+2] = x, y, z
+		index.bounds.MinX, index.bounds.MaxX = math.Min(index.bounds.MinX, x), math.Max(index.bounds.MaxX, x)
+		index.bounds.MinY, index.bounds.MaxY = math.Min(index.bounds.MinY, y), math.Max(index.bounds.MaxY, y)
+		index.bounds.MinZ, index.bounds.MaxZ = math.Min(index.bounds.MinZ, z), math.Max(index.bounds.MaxZ, z)
+	}
+	if numberOfPoints == 0 {
+		index.bounds = BoundingBox{}
+	}
+	index.buildOctree()
+	index.kdTree = newKDTree(index.xyz)
+	return
+}
+
+// Len returns the number of points in the index.
+func (s *SpatialIndex) Len() int {
+	return len(s.xyz) / 3
+}
+
+// Bounds returns the bounding box of the indexed points.
+func (s *SpatialIndex) Bounds() BoundingBox {
+	return s.bounds
+}
+
+// QueryBox returns the indices of the points within box, boundary included, in ascending order.
+func (s *SpatialIndex) QueryBox(box BoundingBox) (indices []int) {
+	s.octree.query(s, box, func(i int) bool {
+		return box.Contains(s.xyz[3*i], s.xyz[3*i+1], s.xyz[3*i+2])
+	}, true, &indices)
+	sort.Ints(indices)
+	return
+}
+
+// QueryPolygon returns the indices of the points whose x and y lie within polygon, at any z, in ascending order. The
+// polygon is closed implicitly and may be concave; the even-odd rule decides for self-intersecting polygons.
+func (s *SpatialIndex) QueryPolygon(polygon []Vertex) (indices []int) {
+	if len(polygon) < 3 {
+		return
+	}
+	box := BoundingBox{
+		MinX: math.Inf(1), MinY: math.Inf(1), MinZ: math.Inf(-1),
+		MaxX: math.Inf(-1), MaxY: math.Inf(-1), MaxZ: math.Inf(1),
+	}
+	for _, vertex := range polygon {
+		box.MinX, box.MaxX = math.Min(box.MinX, vertex.X), math.Max(box.MaxX, vertex.X)
+		box.MinY, box.MaxY = math.Min(box.MinY, vertex.Y), math.Max(box.MaxY, vertex.Y)
+	}
+	s.octree.query(s, box, func(i int) bool {
+		return isInPolygon(polygon, s.xyz[3*i], s.xyz[3*i+1])
+	}, false, &indices)
+	sort.Ints(indices)
+	return
+}
+
+// QueryRadius returns the indices of the points at a distance of at most radius from x, y, z, in ascending order.
+func (s *SpatialIndex) QueryRadius(x float64, y float64, z float64, radius float64) (indices []int) {
+	if radius < 0 {
+		return
+	}
+	s.kdTree.radius([3]float64{x, y, z}, radius*radius, 0, len(s.kdTree.indices), &indices)
+	sort.Ints(indices)
+	return
+}
+
+// KNearest returns the indices of the k points closest to x, y, z, ordered by increasing distance. Fewer indices are
+// returned if the index holds less than k points.
+func (s *SpatialIndex) KNearest(x float64, y float64, z float64, k int) (indices []int) {
+	if k <= 0 {
+		return
+	}
+	neighbours := &kdNeighbours{k: k}
+	s.kdTree.nearest([3]float64{x, y, z}, 0, len(s.kdTree.indices), neighbours)
+	indices = make([]int, neighbours.Len())
+	for i := len(indices) - 1; i >= 0; i-- {
+		indices[i] = heap.Pop(neighbours).(kdNeighbour).index
+	}
+	return
+}
+
+// isInPolygon reports whether x, y lies within polygon using the even-odd rule.
+func isInPolygon(polygon []Vertex, x float64, y float64) (inside bool) {
+	j := len(polygon) - 1
+	for i := range polygon {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+		j = i
+	}
+	return
+}
+
+//   ____       _
+//  / __ \     | |
+// | |  | | ___| |_ _ __ ___  ___
+// | |  | |/ __| __| '__/ _ \/ _ \
+// | |__| | (__| |_| | |  __/  __/
+//  \____/ \___|\__|_|  \___|\___|
+//
+//
+
+// octreeNode covers the range [start, end) of SpatialIndex.octreeIndices. The children of inner nodes cover
+// consecutive sub-ranges; absent children are nil.
+type octreeNode struct {
+	bounds   BoundingBox
+	start    int
+	end      int
+	children [8]*octreeNode
+	leaf     bool
+}
+
+func (s *SpatialIndex) buildOctree() {
+	s.octreeIndices = make([]int, s.Len())
+	for i := range s.octreeIndices {
+		s.octreeIndices[i] = i
+	}
+	// the root is a cube, so that octants stay cubes
+	size := math.Max(s.bounds.MaxX-s.bounds.MinX, math.Max(s.bounds.MaxY-s.bounds.MinY, s.bounds.MaxZ-s.bounds.MinZ))
+	bounds := BoundingBox{
+		MinX: s.bounds.MinX, MinY: s.bounds.MinY, MinZ: s.bounds.MinZ,
+		MaxX: s.bounds.MinX + size, MaxY: s.bounds.MinY + size, MaxZ: s.bounds.MinZ + size,
+	}
+	s.octree = s.buildOctreeNode(bounds, 0, len(s.octreeIndices), 0)
+}
+
+func (s *SpatialIndex) buildOctreeNode(bounds BoundingBox, start int, end int, depth int) (node *octreeNode) {
+	node = &octreeNode{bounds: bounds, start: start, end: end}
+	if end-start <= OCTREE_LEAF_SIZE || depth >= OCTREE_MAX_DEPTH {
+		node.leaf = true
+		return
+	}
+	centerX := (bounds.MinX + bounds.MaxX) / 2
+	centerY := (bounds.MinY + bounds.MaxY) / 2
+	centerZ := (bounds.MinZ + bounds.MaxZ) / 2
+	octant := func(i int) (o int) {
+		if s.xyz[3*i] >= centerX {
+			o |= 1
+		}
+		if s.xyz[3*i+1] >= centerY {
+			o |= 2
+		}
+		if s.xyz[3*i+2] >= centerZ {
+			o |= 4
+		}
+		return
+	}
+
+	// counting sort of the range by octant
+	var counts [8]int
+	for _, i := range s.octreeIndices[start:end] {
+		counts[octant(i)]++
+	}
+	var starts [8]int
+	starts[0] = start
+	for o := 1; o < 8; o++ {
+		starts[o] = starts[o-1] + counts[o-1]
+	}
+	sorted := make([]int, end-start)
+	next := starts
+	for _, i := range s.octreeIndices[start:end] {
+		o := octant(i)
+		sorted[next[o]-start] = i
+		next[o]++
+	}
+	copy(s.octreeIndices[start:end], sorted)
+
+	for o := 0; o < 8; o++ {
+		if counts[o] == 0 {
+			continue
+		}
+		child := bounds
+		if o&1 != 0 {
+			child.MinX = centerX
+		} else {
+			child.MaxX = centerX
+		}
+		if o&2 != 0 {
+			child.MinY = centerY
+		} else {
+			child.MaxY = centerY
+		}
+		if o&4 != 0 {
+			child.MinZ = centerZ
+		} else {
+			child.MaxZ = centerZ
+		}
+		node.children[o] = s.buildOctreeNode(child, starts[o], starts[o]+counts[o], depth+1)
+	}
+	return
+}
+
+// query appends the indices of the points of the nodes intersecting box which pass the test. If implied is set, the
+// test is known to pass for every point within box, so the points of nodes within box are appended without testing.
+func (n *octreeNode) query(s *SpatialIndex, box BoundingBox, test func(i int) bool, implied bool, indices *[]int) {
+	if n == nil || !n.bounds.Intersects(box) {
+		return
+	}
+	if implied && box.Contains(n.bounds.MinX, n.bounds.MinY, n.bounds.MinZ) &&
+		box.Contains(n.bounds.MaxX, n.bounds.MaxY, n.bounds.MaxZ) {
+		*indices = append(*indices, s.octreeIndices[n.start:n.end]...)
+		return
+	}
+	if n.leaf {
+		for _, i := range s.octreeIndices[n.start:n.end] {
+			if test(i) {
+				*indices = append(*indices, i)
+			}
+		}
+		return
+	}
+	for _, child := range n.children {
+		child.query(s, box, test, implied, indices)
+	}
+}
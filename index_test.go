@@ -0,0 +1,169 @@
+package las
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// newTestSpatialIndex returns an index of numberOfPDRs random points within 0 to 100 in x, y and z, a tenth of them at
+// the same position, and their coordinates.
+func newTestSpatialIndex(t *testing.T, numberOfPDRs int) (index *SpatialIndex, xyz [][3]float64) {
+	t.Helper()
+	l := newTestLas(t, 4, 0, numberOfPDRs, 0)
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < numberOfPDRs; i++ {
+		point := l.Pdrs.At(i)
+		if i%10 == 0 {
+			point.SetX(5000)
+			point.SetY(5000)
+			point.SetZ(5000)
+		} else {
+			point.SetX(random.Int31n(10000))
+			point.SetY(random.Int31n(10000))
+			point.SetZ(random.Int31n(10000))
+		}
+		x, y, z := l.Header.GetScaledXYZ(point)
+		xyz = append(xyz, [3]float64{x, y, z})
+	}
+	index, err := l.BuildSpatialIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// bruteForce returns the indices of the points matching in ascending order.
+func bruteForce(xyz [][3]float64, matching func(point [3]float64) bool) (indices []int) {
+	for i, point := range xyz {
+		if matching(point) {
+			indices = append(indices, i)
+		}
+	}
+	return
+}
+
+func TestSpatialIndexQueryBox(t *testing.T) {
+	index, xyz := newTestSpatialIndex(t, 5000)
+	tests := []struct {
+		name string
+		box  BoundingBox
+	}{
+		{"all points", BoundingBox{MinX: 0, MinY: 0, MinZ: 0, MaxX: 100, MaxY: 100, MaxZ: 100}},
+		{"corner", BoundingBox{MinX: 0, MinY: 0, MinZ: 0, MaxX: 10, MaxY: 10, MaxZ: 10}},
+		{"slab", BoundingBox{MinX: 20, MinY: -10, MinZ: -10, MaxX: 21, MaxY: 110, MaxZ: 110}},
+		{"duplicate position", BoundingBox{MinX: 50, MinY: 50, MinZ: 50, MaxX: 50, MaxY: 50, MaxZ: 50}},
+		{"outside", BoundingBox{MinX: 200, MinY: 200, MinZ: 200, MaxX: 300, MaxY: 300, MaxZ: 300}},
+		{"inverted", BoundingBox{MinX: 60, MinY: 60, MinZ: 60, MaxX: 40, MaxY: 40, MaxZ: 40}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := bruteForce(xyz, func(point [3]float64) bool { return test.box.Contains(point[0], point[1], point[2]) })
+			if got := index.QueryBox(test.box); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %d points, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestSpatialIndexQueryPolygon(t *testing.T) {
+	index, xyz := newTestSpatialIndex(t, 5000)
+	tests := []struct {
+		name    string
+		polygon []Vertex
+	}{
+		{"triangle", []Vertex{{10, 10}, {90, 20}, {40, 80}}},
+		{"concave", []Vertex{{0, 0}, {60, 0}, {60, 20}, {20, 20}, {20, 60}, {0, 60}}},
+		{"self-intersecting", []Vertex{{10, 10}, {90, 90}, {90, 10}, {10, 90}}},
+		{"around the duplicate position", []Vertex{{49, 49}, {51, 49}, {51, 51}, {49, 51}}},
+		{"outside", []Vertex{{200, 200}, {300, 200}, {300, 300}}},
+		{"two vertices", []Vertex{{0, 0}, {100, 100}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var want []int
+			if len(test.polygon) >= 3 {
+				want = bruteForce(xyz, func(point [3]float64) bool { return isInPolygon(test.polygon, point[0], point[1]) })
+			}
+			if got := index.QueryPolygon(test.polygon); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %d points, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestSpatialIndexQueryRadius(t *testing.T) {
+	index, xyz := newTestSpatialIndex(t, 5000)
+	tests := []struct {
+		center [3]float64
+		radius float64
+	}{
+		{[3]float64{50, 50, 50}, 0},
+		{[3]float64{50, 50, 50}, 10},
+		{[3]float64{0, 0, 0}, 30},
+		{[3]float64{25.5, 70.25, 10}, 5.5},
+		{[3]float64{50, 50, 50}, 200},
+		{[3]float64{500, 500, 500}, 100},
+		{[3]float64{50, 50, 50}, -1},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v within %v", test.center, test.radius), func(t *testing.T) {
+			var want []int
+			if test.radius >= 0 {
+				want = bruteForce(xyz, func(point [3]float64) bool {
+					return testDistance(point, test.center) <= test.radius
+				})
+			}
+			if got := index.QueryRadius(test.center[0], test.center[1], test.center[2], test.radius); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %d points, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestSpatialIndexKNearest(t *testing.T) {
+	index, xyz := newTestSpatialIndex(t, 5000)
+	tests := []struct {
+		center [3]float64
+		k      int
+	}{
+		{[3]float64{50, 50, 50}, 1},
+		{[3]float64{50, 50, 50}, 600},
+		{[3]float64{10, 90, 30}, 10},
+		{[3]float64{-50, -50, -50}, 25},
+		{[3]float64{33.3, 66.6, 99.9}, 5000},
+		{[3]float64{0, 0, 0}, 6000},
+		{[3]float64{0, 0, 0}, 0},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d nearest to %v", test.k, test.center), func(t *testing.T) {
+			distances := make([]float64, len(xyz))
+			for i, point := range xyz {
+				distances[i] = testDistance(point, test.center)
+			}
+			sort.Float64s(distances)
+			if test.k < len(distances) {
+				distances = distances[:test.k]
+			}
+
+			got := index.KNearest(test.center[0], test.center[1], test.center[2], test.k)
+			if len(got) != len(distances) {
+				t.Fatalf("got %d points, want %d", len(got), len(distances))
+			}
+			// points at the same distance may be returned in any order, so the distances are compared
+			for i, pointIndex := range got {
+				if d := testDistance(xyz[pointIndex], test.center); d != distances[i] {
+					t.Fatalf("neighbour %d is at distance %v, want %v", i, d, distances[i])
+				}
+			}
+		})
+	}
+}
+
+// testDistance returns the euclidean distance of a and b.
+func testDistance(a [3]float64, b [3]float64) float64 {
+	return math.Sqrt((a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1]) + (a[2]-b[2])*(a[2]-b[2]))
+}
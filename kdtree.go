@@ -0,0 +1,214 @@
+package las
+
+import (
+	"container/heap"
+)
+
+//  _  _______        _______
+// | |/ /  __ \      |__   __|
+// | ' /| |  | |______ | |_ __ ___  ___
+// |  < | |  | |______|| | '__/ _ \/ _ \
+// | . \| |__| |       | | | |  __/  __/
+// |_|\_\_____/        |_|_|  \___|\___|
+//
+//
+
+// kdTree is a balanced KD-tree stored implicitly in indices: the node of the range [start, end) is the point at the
+// median m = (start+end)/2, its children are the ranges [start, m) and [m+1, end), and axes[m] is the axis it splits.
+type kdTree struct {
+	xyz     []float64
+	indices []int
+	axes    []uint8
+}
+
+func newKDTree(xyz []float64) (t kdTree) {
+	numberOfPoints := len(xyz) / 3
+	t = kdTree{xyz: xyz, indices: make([]int, numberOfPoints), axes: make([]uint8, numberOfPoints)}
+	for i := range t.indices {
+		t.indices[i] = i
+	}
+	t.build(0, numberOfPoints)
+	return
+}
+
+func (t *kdTree) coordinate(i int, axis uint8) float64 {
+	return t.xyz[3*i+int(axis)]
+}
+
+func (t *kdTree) build(start int, end int) {
+	if end-start <= 1 {
+		return
+	}
+	// split along the axis of the largest extent
+	var min, max [3]float64
+	for axis := uint8(0); axis < 3; axis++ {
+		min[axis] = t.coordinate(t.indices[start], axis)
+		max[axis] = min[axis]
+	}
+	for _, i := range t.indices[start+1 : end] {
+		for axis := uint8(0); axis < 3; axis++ {
+			c := t.coordinate(i, axis)
+			if c < min[axis] {
+				min[axis] = c
+			} else if c > max[axis] {
+				max[axis] = c
+			}
+		}
+	}
+	axis := uint8(0)
+	for a := uint8(1); a < 3; a++ {
+		if max[a]-min[a] > max[axis]-min[axis] {
+			axis = a
+		}
+	}
+
+	median := (start + end) / 2
+	t.selectNth(start, end, median, axis)
+	t.axes[median] = axis
+	t.build(start, median)
+	t.build(median+1, end)
+}
+
+// selectNth reorders indices[start:end] such that indices[n] holds the point which would be there if the range was
+// sorted along axis, with no larger point before it and no smaller point after it.
+func (t *kdTree) selectNth(start int, end int, n int, axis uint8) {
+	for end-start > 1 {
+		// median of three pivot, moved to the end of the range
+		middle := (start + end) / 2
+		last := end - 1
+		if t.coordinate(t.indices[middle], axis) < t.coordinate(t.indices[start], axis) {
+			t.indices[middle], t.indices[start] = t.indices[start], t.indices[middle]
+		}
+		if t.coordinate(t.indices[last], axis) < t.coordinate(t.indices[start], axis) {
+			t.indices[last], t.indices[start] = t.indices[start], t.indices[last]
+		}
+		if t.coordinate(t.indices[middle], axis) < t.coordinate(t.indices[last], axis) {
+			t.indices[middle], t.indices[last] = t.indices[last], t.indices[middle]
+		}
+		pivot := t.coordinate(t.indices[last], axis)
+
+		// three-way partition into [start, less) below, [less, greater) equal to and [greater, end) above the pivot,
+		// so that many equal coordinates do not degrade the selection
+		less, i, greater := start, start, end
+		for i < greater {
+			c := t.coordinate(t.indices[i], axis)
+			if c < pivot {
+				t.indices[i], t.indices[less] = t.indices[less], t.indices[i]
+				less++
+				i++
+			} else if c > pivot {
+				greater--
+				t.indices[i], t.indices[greater] = t.indices[greater], t.indices[i]
+			} else {
+				i++
+			}
+		}
+
+		if n < less {
+			end = less
+		} else if n >= greater {
+			start = greater
+		} else {
+			return
+		}
+	}
+}
+
+func (t *kdTree) distance(i int, point [3]float64) float64 {
+	dx := t.xyz[3*i] - point[0]
+	dy := t.xyz[3*i+1] - point[1]
+	dz := t.xyz[3*i+2] - point[2]
+	return dx*dx + dy*dy + dz*dz
+}
+
+// radius appends the indices of the points of the range [start, end) within the squared distance radius2 of point.
+func (t *kdTree) radius(point [3]float64, radius2 float64, start int, end int, indices *[]int) {
+	if start >= end {
+		return
+	}
+	median := (start + end) / 2
+	i := t.indices[median]
+	if t.distance(i, point) <= radius2 {
+		*indices = append(*indices, i)
+	}
+	axis := t.axes[median]
+	delta := point[axis] - t.coordinate(i, axis)
+	if delta <= 0 || delta*delta <= radius2 {
+		t.radius(point, radius2, start, median, indices)
+	}
+	if delta >= 0 || delta*delta <= radius2 {
+		t.radius(point, radius2, median+1, end, indices)
+	}
+}
+
+// nearest offers the points of the range [start, end) to neighbours, visiting the side of point first.
+func (t *kdTree) nearest(point [3]float64, start int, end int, neighbours *kdNeighbours) {
+	if start >= end {
+		return
+	}
+	median := (start + end) / 2
+	i := t.indices[median]
+	neighbours.offer(i, t.distance(i, point))
+	axis := t.axes[median]
+	delta := point[axis] - t.coordinate(i, axis)
+	near, far := [2]int{start, median}, [2]int{median + 1, end}
+	if delta > 0 {
+		near, far = far, near
+	}
+	t.nearest(point, near[0], near[1], neighbours)
+	if !neighbours.isFull() || delta*delta <= neighbours.distances[0] {
+		t.nearest(point, far[0], far[1], neighbours)
+	}
+}
+
+// kdNeighbours is a max-heap of the k closest points found so far, with the farthest at the top.
+type kdNeighbours struct {
+	k         int
+	indices   []int
+	distances []float64
+}
+
+func (n *kdNeighbours) Len() int {
+	return len(n.indices)
+}
+
+func (n *kdNeighbours) Less(i int, j int) bool {
+	return n.distances[i] > n.distances[j]
+}
+
+func (n *kdNeighbours) Swap(i int, j int) {
+	n.indices[i], n.indices[j] = n.indices[j], n.indices[i]
+	n.distances[i], n.distances[j] = n.distances[j], n.distances[i]
+}
+
+func (n *kdNeighbours) Push(x interface{}) {
+	neighbour := x.(kdNeighbour)
+	n.indices = append(n.indices, neighbour.index)
+	n.distances = append(n.distances, neighbour.distance)
+}
+
+func (n *kdNeighbours) Pop() interface{} {
+	last := len(n.indices) - 1
+	neighbour := kdNeighbour{index: n.indices[last], distance: n.distances[last]}
+	n.indices = n.indices[:last]
+	n.distances = n.distances[:last]
+	return neighbour
+}
+
+type kdNeighbour struct {
+	index    int
+	distance float64
+}
+
+func (n *kdNeighbours) isFull() bool {
+	return len(n.indices) >= n.k
+}
+
+func (n *kdNeighbours) offer(index int, distance float64) {
+	if !n.isFull() {
+		heap.Push(n, kdNeighbour{index: index, distance: distance})
+	} else if distance < n.distances[0] {
+		n.indices[0], n.distances[0] = index, distance
+		heap.Fix(n, 0)
+	}
+}
@@ -1,10 +1,12 @@
 package las
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/binary"
 	"fmt"
 	"github.com/gocarina/gocsv"
+	"io"
 	"math"
 	"os"
 )
@@ -30,11 +32,24 @@ const LAS_FILE_SIGNATURE = "LASF"
 // projection information to a LAS file without having to rewrite the entire file.
 type Las struct {
 	Header PublicHeaderBlock
-	Vlrs   []VLR
-	Pdrs   PDRs
-	Evlrs  []EVLR
+	// UserDataInHeader holds the user-defined bytes following the fields of the public header block defined by the
+	// version of the file, which are counted by HeaderSize.
+	UserDataInHeader []byte
+	Vlrs             []VLR
+	// UserDataAfterHeader holds the bytes between the VLRs and the point data records, e.g. the point data start
+	// signature of LAS 1.0 files.
+	UserDataAfterHeader []byte
+	Pdrs                PDRs
+	Evlrs               []EVLR
+	// CRSInfo is resolved from the GeoKeys or the coordinate system WKT when the file is parsed, and nil if it holds
+	// neither or they cannot be resolved. See ResolveCRSInfo.
+	CRSInfo *CRSInfo
+	// compressed is set if the point data records were read from a LAZ file, whose header describes the compressed
+	// layout.
+	compressed bool
 }
 
+// Parse reads the LAS or LAZ file filename. See ParseReader.
 func (l *Las) Parse(filename string) (err error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -42,21 +57,73 @@ func (l *Las) Parse(filename string) (err error) {
 	}
 	defer file.Close()
 
-	if err = l.readPHB(file); err != nil {
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+	if err = l.ParseReader(file, info.Size()); err != nil {
+		return
+	}
+	return
+}
+
+// ParseReader reads a LAS or LAZ file of size bytes from reader, e.g. a bytes.Reader holding the file in memory or an
+// io.SectionReader of an archive.
+func (l *Las) ParseReader(reader io.ReaderAt, size int64) (err error) {
+	if err = l.readPHB(reader); err != nil {
+		return
+	}
+	if err = l.readVLRs(reader); err != nil {
+		return
+	}
+	if err = l.readPDRs(reader, size); err != nil {
+		return
+	}
+	if err = l.readEVLRs(reader, size); err != nil {
+		return
+	}
+	l.updateCRSInfo()
+	return
+}
+
+// ParseStream reads a LAS or LAZ file front to back from reader, for sources which cannot be read at arbitrary
+// offsets such as network streams. The chunks of a LAZ file are located through the chunk table at the end of the file,
+// so a compressed stream is read into memory completely before it is parsed.
+func (l *Las) ParseStream(reader io.Reader) (err error) {
+	stream := &sequentialReader{reader: reader, recording: true}
+	if err = l.readPHB(stream); err != nil {
 		return
 	}
-	if err = l.readVLRs(file); err != nil {
+	if l.isFileCompressed() {
+		var rest []byte
+		if rest, err = io.ReadAll(reader); err != nil {
+			return
+		}
+		data := append(stream.recorded.Bytes(), rest...)
+		return l.ParseReader(bytes.NewReader(data), int64(len(data)))
+	}
+	stream.recording = false
+	stream.recorded.Reset()
+
+	if err = l.readVLRs(stream); err != nil {
 		return
 	}
-	if err = l.readPDRs(file); err != nil {
+	if err = l.readPDRs(stream, -1); err != nil {
 		return
 	}
-	if err = l.readEVLRs(file); err != nil {
+	if err = l.readEVLRs(stream, -1); err != nil {
 		return
 	}
+	l.updateCRSInfo()
 	return
 }
 
+// updateCRSInfo resolves l.CRSInfo, leaving it nil if the CRS records are missing or damaged.
+func (l *Las) updateCRSInfo() {
+	l.CRSInfo = nil
+	l.ResolveCRSInfo()
+}
+
 func (l *Las) ParseHeader(filename string) (err error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -70,21 +137,44 @@ func (l *Las) ParseHeader(filename string) (err error) {
 	return
 }
 
-func (l *Las) readPHB(file *os.File) (err error) {
+func (l *Las) readPHB(reader io.ReaderAt) (err error) {
+	// read the part common to all versions first to learn the version and header size
 	headerInBytes := make([]byte, binary.Size(PublicHeaderBlock{}))
-	_, err = file.ReadAt(headerInBytes, 0)
-	if err != nil {
+	if err = readAt(reader, headerInBytes[:HEADER_SIZE_V1_2], 0); err != nil {
+		err = &FormatError{Offset: 0, Field: "public header block", Err: err}
 		return
 	}
 	if err = binary.Read(bytes.NewReader(headerInBytes), binary.LittleEndian, &l.Header); err != nil {
 		return
 	}
-
 	err = l.checkForCompliancy()
 	if err != nil {
 		return
 	}
 
+	// only decode the fields defined by the version, the fields of later versions stay zero
+	standardSize := l.Header.getStandardHeaderSize()
+	if l.Header.HeaderSize < standardSize {
+		err = newHeaderError("HeaderSize", fmt.Errorf("header size %d is smaller than the %d bytes of a version %s header", l.Header.HeaderSize, standardSize, l.Header.GetVersion()))
+		return
+	}
+	if standardSize > HEADER_SIZE_V1_2 {
+		if err = readAt(reader, headerInBytes[HEADER_SIZE_V1_2:standardSize], int64(HEADER_SIZE_V1_2)); err != nil {
+			err = &FormatError{Offset: int64(HEADER_SIZE_V1_2), Field: "public header block", Err: err}
+			return
+		}
+		if err = binary.Read(bytes.NewReader(headerInBytes), binary.LittleEndian, &l.Header); err != nil {
+			return
+		}
+	}
+	l.UserDataInHeader, l.compressed = nil, false
+	if l.Header.HeaderSize > standardSize {
+		l.UserDataInHeader = make([]byte, l.Header.HeaderSize-standardSize)
+		if err = readAt(reader, l.UserDataInHeader, int64(standardSize)); err != nil {
+			err = &FormatError{Offset: int64(standardSize), Field: "user data in header", Err: err}
+			return
+		}
+	}
 	return
 }
 
@@ -96,7 +186,7 @@ func (l *Las) WriteHeader(filename string) (err error) {
 	defer file.Close()
 
 	headerInBytes := new(bytes.Buffer)
-	if err = binary.Write(headerInBytes, binary.LittleEndian, &l.Header); err != nil {
+	if err = l.Header.write(headerInBytes, nil); err != nil {
 		return
 	}
 
@@ -107,6 +197,236 @@ func (l *Las) WriteHeader(filename string) (err error) {
 	return
 }
 
+// Write serializes the public header block, VLRs, point data records and EVLRs to a new LAS file. See WriteTo.
+func (l *Las) Write(filename string) (err error) {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	if _, err = l.WriteTo(file); err != nil {
+		return
+	}
+	return
+}
+
+// WriteTo serializes the public header block, VLRs, point data records and EVLRs to w. Before writing, the header
+// fields describing the layout of the file (HeaderSize, OffsetToPointData, NumberOfVLRs, PointDataRecordLength,
+// point record counts and the EVLR start and count) are recomputed from the content of l, so the header of l is
+// updated as a side effect. If the compression bit of the point data record format is set, the point data records
+// are compressed with LASzip.
+func (l *Las) WriteTo(w io.Writer) (n int64, err error) {
+	if l.isFileCompressed() {
+		return l.writeLazTo(w)
+	}
+	l.removeLASzipVLRs()
+	if err = l.updateLayout(); err != nil {
+		return
+	}
+
+	counter := &countingWriter{writer: w}
+	writer := bufio.NewWriter(counter)
+	defer func() {
+		n = counter.count
+	}()
+
+	if err = l.Header.write(writer, l.UserDataInHeader); err != nil {
+		return
+	}
+	for index := range l.Vlrs {
+		if err = l.Vlrs[index].write(writer); err != nil {
+			return
+		}
+	}
+	if _, err = writer.Write(l.UserDataAfterHeader); err != nil {
+		return
+	}
+	if l.Pdrs != nil {
+		if err = l.Pdrs.write(writer, uint64(l.Header.PointDataRecordLength)); err != nil {
+			return
+		}
+	}
+	for index := range l.Evlrs {
+		if err = l.Evlrs[index].write(writer); err != nil {
+			return
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return
+	}
+	return
+}
+
+// updateLayout recomputes the header fields which depend on the VLRs, PDRs and EVLRs held by l.
+func (l *Las) updateLayout() (err error) {
+	version := l.Header.GetVersion()
+	copy(l.Header.FileSignature[:], LAS_FILE_SIGNATURE)
+	headerSize := uint64(l.Header.getStandardHeaderSize()) + uint64(len(l.UserDataInHeader))
+	if headerSize > math.MaxUint16 {
+		err = fmt.Errorf("header with %d bytes of user data exceeds %d bytes", len(l.UserDataInHeader), math.MaxUint16)
+		return
+	}
+	l.Header.HeaderSize = uint16(headerSize)
+
+	offset := headerSize
+	for index := range l.Vlrs {
+		offset += uint64(l.Vlrs[index].size())
+	}
+	offset += uint64(len(l.UserDataAfterHeader))
+	if offset > math.MaxUint32 {
+		err = fmt.Errorf("VLRs end at offset %d, which cannot be stored in the public header", offset)
+		return
+	}
+	l.Header.OffsetToPointData = uint32(offset)
+	l.Header.NumberOfVLRs = uint32(len(l.Vlrs))
+
+	recordSize, err := getPointDataRecordSize(l.Header.PointDataRecordFormat)
+	if err != nil {
+		return
+	}
+	if l.Header.PointDataRecordLength < recordSize {
+		l.Header.PointDataRecordLength = recordSize
+	}
+	if version == V1_4 && l.Header.PointDataRecordFormat&LASZIP_FORMAT_MASK >= 6 {
+		// the point data record formats 6 to 10 require the CRS to be stored as WKT
+		l.Header.GlobalEncoding |= GLOBAL_ENCODING_WKT
+	}
+
+	numberOfPDRs := uint64(0)
+	if l.Pdrs != nil {
+		numberOfPDRs = uint64(l.Pdrs.Len())
+	}
+	if version == V1_4 {
+		l.Header.NumberOfPointRecords = numberOfPDRs
+		if l.Header.PointDataRecordFormat < 6 && numberOfPDRs <= math.MaxUint32 {
+			l.Header.LegacyNumberOfPointRecords = uint32(numberOfPDRs)
+		} else {
+			l.Header.LegacyNumberOfPointRecords = 0
+		}
+	} else {
+		if numberOfPDRs > math.MaxUint32 {
+			err = fmt.Errorf("las files with version %s cannot hold %d points", version, numberOfPDRs)
+			return
+		}
+		l.Header.LegacyNumberOfPointRecords = uint32(numberOfPDRs)
+	}
+	offset += numberOfPDRs * uint64(l.Header.PointDataRecordLength)
+	err = l.updateEVLRLayout(offset)
+	return
+}
+
+// updateEVLRLayout recomputes the header fields locating the EVLRs of l, which start at offset.
+func (l *Las) updateEVLRLayout(offset uint64) (err error) {
+	version := l.Header.GetVersion()
+	if version == V1_4 {
+		l.Header.NumberOfExtendedVariableLengthRecords = uint32(len(l.Evlrs))
+		if len(l.Evlrs) != 0 {
+			l.Header.StartOfFirstExtendedVariableLengthRecord = offset
+		} else {
+			l.Header.StartOfFirstExtendedVariableLengthRecord = 0
+		}
+		if l.Header.GlobalEncoding&GLOBAL_ENCODING_WAVEFORM_INTERNAL != 0 {
+			// the waveform data packets start with the header of their EVLR
+			for index := range l.Evlrs {
+				if l.Evlrs[index].UserID() == "LASF_Spec" && l.Evlrs[index].RecordID() == WAVEFORM_DATA_PACKETS_RECORD_ID {
+					l.Header.StartOfWaveformDataPacketRecord = offset
+					break
+				}
+				offset += l.Evlrs[index].size()
+			}
+		}
+	} else if version == V1_3 {
+		// the only EVLR of LAS 1.3 holds the waveform data packets, and is located by their start
+		l.Header.StartOfWaveformDataPacketRecord = 0
+		if len(l.Evlrs) > 1 || len(l.Evlrs) == 1 && (l.Evlrs[0].UserID() != "LASF_Spec" || l.Evlrs[0].RecordID() != WAVEFORM_DATA_PACKETS_RECORD_ID) {
+			err = fmt.Errorf("las files with version %s can only hold the waveform data packets EVLR", version)
+			return
+		}
+		if len(l.Evlrs) == 1 {
+			l.Header.StartOfWaveformDataPacketRecord = offset
+		}
+	} else if len(l.Evlrs) != 0 {
+		err = fmt.Errorf("las files with version %s cannot hold EVLRs", version)
+		return
+	}
+	return
+}
+
+// UpdateHeaderFromPoints recomputes the bounds, the number of point records and the number of points by return of the
+// header from the point data records of l. LAS 1.4 files count returns 1 to 15 in NumberOfPointsByReturn; the legacy
+// fields count returns 1 to 5 and are left zero if they cannot represent the points, i.e. for the point data record
+// formats 6 to 10 or more than 2^32 - 1 points. Earlier versions only use the legacy fields.
+func (l *Las) UpdateHeaderFromPoints() (err error) {
+	if err = l.checkNumberOfPDRs(); err != nil {
+		return
+	}
+	numberOfPDRs := uint64(0)
+	if l.Pdrs != nil {
+		numberOfPDRs = uint64(l.Pdrs.Len())
+	}
+	version := l.Header.GetVersion()
+
+	var byReturn [15]uint64
+	bounds := BoundingBox{
+		MinX: math.Inf(1), MinY: math.Inf(1), MinZ: math.Inf(1),
+		MaxX: math.Inf(-1), MaxY: math.Inf(-1), MaxZ: math.Inf(-1),
+	}
+	for index := 0; index < int(numberOfPDRs); index++ {
+		point := l.Pdrs.At(index)
+		x, y, z := l.Header.GetScaledXYZ(point)
+		bounds.MinX, bounds.MaxX = math.Min(bounds.MinX, x), math.Max(bounds.MaxX, x)
+		bounds.MinY, bounds.MaxY = math.Min(bounds.MinY, y), math.Max(bounds.MaxY, y)
+		bounds.MinZ, bounds.MaxZ = math.Min(bounds.MinZ, z), math.Max(bounds.MaxZ, z)
+		if returnNumber := point.GetReturnNumber(); returnNumber >= 1 && int(returnNumber) <= len(byReturn) {
+			byReturn[returnNumber-1]++
+		}
+	}
+	if numberOfPDRs == 0 {
+		bounds = BoundingBox{}
+	}
+	l.Header.MinX, l.Header.MaxX = bounds.MinX, bounds.MaxX
+	l.Header.MinY, l.Header.MaxY = bounds.MinY, bounds.MaxY
+	l.Header.MinZ, l.Header.MaxZ = bounds.MinZ, bounds.MaxZ
+
+	legacy := numberOfPDRs <= math.MaxUint32
+	if version == V1_4 {
+		l.Header.NumberOfPointRecords = numberOfPDRs
+		l.Header.NumberOfPointsByReturn = byReturn
+		legacy = legacy && l.Header.PointDataRecordFormat&LASZIP_FORMAT_MASK < 6
+	}
+	l.Header.LegacyNumberOfPointRecords = 0
+	l.Header.LegacyNumberOfPointByReturn = [5]uint32{}
+	if legacy {
+		l.Header.LegacyNumberOfPointRecords = uint32(numberOfPDRs)
+		for index := range l.Header.LegacyNumberOfPointByReturn {
+			l.Header.LegacyNumberOfPointByReturn[index] = uint32(byReturn[index])
+		}
+	}
+	return
+}
+
+// checkNumberOfPDRs fails if the version of l cannot count its point data records.
+func (l *Las) checkNumberOfPDRs() (err error) {
+	if l.Pdrs == nil {
+		return
+	}
+	if version := l.Header.GetVersion(); version != V1_4 && uint64(l.Pdrs.Len()) > math.MaxUint32 {
+		err = fmt.Errorf("las files with version %s cannot hold %d points", version, l.Pdrs.Len())
+	}
+	return
+}
+
+// clone returns a copy of l whose header and records can be changed without changing l, so that a conversion can
+// fail without leaving l half converted. The point data records are shared and must be replaced, not changed.
+func (l *Las) clone() (c *Las) {
+	c = new(Las)
+	*c = *l
+	c.Vlrs = append([]VLR(nil), l.Vlrs...)
+	c.Evlrs = append([]EVLR(nil), l.Evlrs...)
+	return
+}
+
 func (l *Las) checkForCompliancy() (err error) {
 	if err = l.isFileLasFormat(); err != nil {
 		return
@@ -124,7 +444,7 @@ func (l *Las) isVersionOK() (err error) {
 			return
 		}
 	}
-	err = fmt.Errorf("las files with version %s is not supported", fileVersion)
+	err = newHeaderError("VersionMajor", fmt.Errorf("%w: %s", ErrUnsupportedVersion, fileVersion))
 	return
 }
 
@@ -139,30 +459,103 @@ func (l *Las) isFileCompressed() (compressed bool) {
 func (l *Las) isFileLasFormat() (err error) {
 	fileSignature := string(l.Header.FileSignature[:])
 	if fileSignature != LAS_FILE_SIGNATURE {
-		err = fmt.Errorf("las files signature is not %s. File Signature: %s", LAS_FILE_SIGNATURE, fileSignature)
-	}
-	if l.isFileCompressed() {
-		err = fmt.Errorf("file is compressed. Needs to be treated as laz format")
+		err = newHeaderError("FileSignature", fmt.Errorf("%w. File Signature: %q", ErrNotLas, fileSignature))
 	}
 	return
 }
 
-func (l *Las) readVLRs(file *os.File) (err error) {
+func (l *Las) readVLRs(reader io.ReaderAt) (err error) {
 	offset := int64(l.Header.HeaderSize)
 	for i := uint32(0); i < l.Header.NumberOfVLRs; i++ {
 		vlr := VLR{}
-		offset, err = vlr.read(file, offset)
+		offset, err = vlr.read(reader, offset)
 		if err != nil {
 			return
 		}
+		// a record which cannot be decoded is kept as raw payload, see VLR.Record
+		vlr.decode(offset - int64(len(vlr.payload)))
 		l.Vlrs = append(l.Vlrs, vlr)
 	}
-	if uint32(offset) != l.Header.OffsetToPointData {
-		err = fmt.Errorf("after reading VLRs offset : %d, doesn't match the offset set in public Header: %d", offset, l.Header.OffsetToPointData)
+	if offset > int64(l.Header.OffsetToPointData) {
+		err = newHeaderError("OffsetToPointData", fmt.Errorf("VLRs end at offset %d, after the point data records start at %d", offset, l.Header.OffsetToPointData))
+		return
+	}
+	l.UserDataAfterHeader = nil
+	if offset < int64(l.Header.OffsetToPointData) {
+		l.UserDataAfterHeader = make([]byte, int64(l.Header.OffsetToPointData)-offset)
+		if err = readAt(reader, l.UserDataAfterHeader, offset); err != nil {
+			err = &FormatError{Offset: offset, Field: "user data after header", Err: err}
+			return
+		}
+	}
+	return
+}
+
+// GetXYZ returns the coordinates of the point data record index with the scale factors and offsets of the header
+// applied.
+func (l *Las) GetXYZ(index int) (x float64, y float64, z float64) {
+	return l.Header.GetScaledXYZ(l.Pdrs.At(index))
+}
+
+// SetXYZ sets the coordinates of the point data record index to x, y, z, quantized with the scale factors and offsets
+// of the header. It fails if a coordinate cannot be stored in an int32.
+func (l *Las) SetXYZ(index int, x float64, y float64, z float64) (err error) {
+	return l.Header.SetScaledXYZ(l.Pdrs.At(index), x, y, z)
+}
+
+// GetVLR returns the first VLR with the given user ID and record ID.
+func (l *Las) GetVLR(userID string, recordID uint16) (vlr *VLR, err error) {
+	for index := range l.Vlrs {
+		if l.Vlrs[index].UserID() == userID && l.Vlrs[index].RecordID() == recordID {
+			vlr = &l.Vlrs[index]
+			return
+		}
+	}
+	err = fmt.Errorf("%w: no VLR with user ID %s and record ID %d", ErrNotFound, userID, recordID)
+	return
+}
+
+// AddVLR appends vlr to the VLRs of l. The header fields depending on the VLRs are updated when l is written.
+func (l *Las) AddVLR(vlr VLR) {
+	l.Vlrs = append(l.Vlrs, vlr)
+}
+
+// RemoveVLRs removes the VLRs with the given user ID and record ID and returns how many were removed.
+func (l *Las) RemoveVLRs(userID string, recordID uint16) (removed int) {
+	vlrs := l.Vlrs[:0]
+	for _, vlr := range l.Vlrs {
+		if vlr.UserID() == userID && vlr.RecordID() == recordID {
+			removed++
+			continue
+		}
+		vlrs = append(vlrs, vlr)
 	}
+	l.Vlrs = vlrs
 	return
 }
 
+// ReplaceVLR replaces the VLRs with the user ID and record ID of vlr by vlr, at the position of the first of them. If
+// l has no such VLR, vlr is appended.
+func (l *Las) ReplaceVLR(vlr VLR) {
+	userID, recordID := vlr.UserID(), vlr.RecordID()
+	vlrs := l.Vlrs[:0]
+	replaced := false
+	for _, v := range l.Vlrs {
+		if v.UserID() == userID && v.RecordID() == recordID {
+			if !replaced {
+				vlrs = append(vlrs, vlr)
+				replaced = true
+			}
+			continue
+		}
+		vlrs = append(vlrs, v)
+	}
+	if !replaced {
+		vlrs = append(vlrs, vlr)
+	}
+	l.Vlrs = vlrs
+}
+
 func (l *Las) getNumberOfPDRs() (numberOFPDRs uint64) {
 	version := l.Header.GetVersion()
 	if version == V1_4 {
@@ -173,65 +566,168 @@ func (l *Las) getNumberOfPDRs() (numberOFPDRs uint64) {
 	return
 }
 
-func (l *Las) readPDRs(file *os.File) (err error) {
-	numOfPDRs := l.getNumberOfPDRs()
-	switch l.Header.PointDataRecordFormat {
-	case 0:
-		l.Pdrs = make(PDR0s, numOfPDRs)
-	case 1:
-		l.Pdrs = make(PDR1s, numOfPDRs)
-	case 2:
-		l.Pdrs = make(PDR2s, numOfPDRs)
-	case 3:
-		l.Pdrs = make(PDR3s, numOfPDRs)
-	case 4:
-		l.Pdrs = make(PDR4s, numOfPDRs)
-	case 5:
-		l.Pdrs = make(PDR5s, numOfPDRs)
-	case 6:
-		l.Pdrs = make(PDR6s, numOfPDRs)
-	case 7:
-		l.Pdrs = make(PDR7s, numOfPDRs)
-	case 8:
-		l.Pdrs = make(PDR8s, numOfPDRs)
-	case 9:
-		l.Pdrs = make(PDR9s, numOfPDRs)
-	case 10:
-		l.Pdrs = make(PDR10s, numOfPDRs)
-	default:
-		err = fmt.Errorf("point data record format not recognised")
+// checkPointDataRecordFormat checks that the point data records of the header can be decoded.
+func (l *Las) checkPointDataRecordFormat() (err error) {
+	format := l.Header.PointDataRecordFormat & LASZIP_FORMAT_MASK
+	recordSize, err := getPointDataRecordSize(format)
+	if err != nil {
+		err = newHeaderError("PointDataRecordFormat", err)
 		return
 	}
-	if err = l.Pdrs.read(file, int64(l.Header.OffsetToPointData), uint64(l.Header.PointDataRecordLength)); err != nil {
+	if l.Header.PointDataRecordLength < recordSize {
+		err = newHeaderError("PointDataRecordLength", fmt.Errorf("point data record length %d is smaller than the %d bytes of format %d", l.Header.PointDataRecordLength, recordSize, format))
 		return
 	}
 	return
 }
 
-func (l *Las) getNumberOfEVLRs() (numberOFEVLRs uint32) {
-	version := l.Header.GetVersion()
-	if version == V1_4 {
-		numberOFEVLRs = l.Header.NumberOfExtendedVariableLengthRecords
-	} else {
-		numberOFEVLRs = 0
+func (l *Las) readPDRs(reader io.ReaderAt, size int64) (err error) {
+	if err = l.checkPointDataRecordFormat(); err != nil {
+		return
+	}
+	if l.isFileCompressed() {
+		return l.readLazPDRs(reader, size)
+	}
+	numberOfPDRs := l.getNumberOfPDRs()
+	if size < 0 {
+		return l.readStreamPDRs(reader, numberOfPDRs)
+	}
+	if err = l.checkPointDataSize(numberOfPDRs, size); err != nil {
+		return
+	}
+	if l.Pdrs, err = newPDRs(l.Header.PointDataRecordFormat, numberOfPDRs); err != nil {
+		return
+	}
+	if err = l.Pdrs.read(reader, int64(l.Header.OffsetToPointData), uint64(l.Header.PointDataRecordLength)); err != nil {
+		return
 	}
 	return
 }
 
-func (l *Las) readEVLRs(file *os.File) (err error) {
-	offset := int64(l.Header.StartOfFirstExtendedVariableLengthRecord)
-	numberOfEVLRs := l.getNumberOfEVLRs()
+// streamReadSize is the number of bytes read from a stream at once, see readLength.
+const streamReadSize = 1 << 26
+
+// readStreamPDRs reads the point data records from a stream of unknown size. The data is read in pieces, so that a
+// corrupt point count fails at the end of the stream instead of allocating all declared records up front.
+func (l *Las) readStreamPDRs(reader io.ReaderAt, numberOfPDRs uint64) (err error) {
+	offset := int64(l.Header.OffsetToPointData)
+	dataLength := uint64(l.Header.PointDataRecordLength)
+	if numberOfPDRs > math.MaxInt64/dataLength {
+		err = &FormatError{Offset: offset, Field: "point data records", Err: fmt.Errorf("%w: %d point data records of %d bytes exceed the size of any file", ErrTruncated, numberOfPDRs, dataLength)}
+		return
+	}
+	raw, err := readLength(reader, offset, numberOfPDRs*dataLength, -1)
+	if err != nil {
+		err = &FormatError{Offset: offset, Field: "point data records", Err: err}
+		return
+	}
+	if l.Pdrs, err = newPDRs(l.Header.PointDataRecordFormat, numberOfPDRs); err != nil {
+		return
+	}
+	err = l.Pdrs.decode(raw, dataLength)
+	return
+}
+
+// checkPointDataSize checks that numberOfPDRs point data records fit into a file of size bytes before they are
+// allocated, so that a corrupt point count fails instead of exhausting the memory.
+func (l *Las) checkPointDataSize(numberOfPDRs uint64, size int64) (err error) {
+	offset := int64(l.Header.OffsetToPointData)
+	if numberOfPDRs == 0 {
+		return
+	}
+	available := uint64(0)
+	if offset < size {
+		available = uint64(size-offset) / uint64(l.Header.PointDataRecordLength)
+	}
+	if numberOfPDRs > available {
+		err = &FormatError{Offset: offset, Field: "point data records", Err: fmt.Errorf("%w: %d point data records of %d bytes declared, the file has room for %d", ErrTruncated, numberOfPDRs, l.Header.PointDataRecordLength, available)}
+	}
+	return
+}
+
+// getEVLRLocation returns the offset and number of the EVLRs declared by the header.
+func (l *Las) getEVLRLocation() (offset int64, numberOfEVLRs uint32) {
+	switch l.Header.GetVersion() {
+	case V1_4:
+		offset, numberOfEVLRs = int64(l.Header.StartOfFirstExtendedVariableLengthRecord), l.Header.NumberOfExtendedVariableLengthRecords
+	case V1_3:
+		// the waveform data packets are the only EVLR of LAS 1.3
+		if l.Header.StartOfWaveformDataPacketRecord != 0 {
+			offset, numberOfEVLRs = int64(l.Header.StartOfWaveformDataPacketRecord), 1
+		}
+	}
+	return
+}
+
+// readEVLRs reads the EVLRs of a file of size bytes, which is negative for streams.
+func (l *Las) readEVLRs(reader io.ReaderAt, size int64) (err error) {
+	offset, numberOfEVLRs := l.getEVLRLocation()
 	for i := uint32(0); i < numberOfEVLRs; i++ {
 		evlr := EVLR{}
-		offset, err = evlr.read(file, offset)
+		offset, err = evlr.read(reader, offset, size)
 		if err != nil {
 			return
 		}
+		// a record which cannot be decoded is kept as raw payload, see EVLR.Record
+		evlr.decode(offset - int64(len(evlr.payload)))
 		l.Evlrs = append(l.Evlrs, evlr)
 	}
 	return
 }
 
+// GetEVLR returns the first EVLR with the given user ID and record ID.
+func (l *Las) GetEVLR(userID string, recordID uint16) (evlr *EVLR, err error) {
+	for index := range l.Evlrs {
+		if l.Evlrs[index].UserID() == userID && l.Evlrs[index].RecordID() == recordID {
+			evlr = &l.Evlrs[index]
+			return
+		}
+	}
+	err = fmt.Errorf("%w: no EVLR with user ID %s and record ID %d", ErrNotFound, userID, recordID)
+	return
+}
+
+// AddEVLR appends evlr to the EVLRs of l. The header fields depending on the EVLRs are updated when l is written.
+func (l *Las) AddEVLR(evlr EVLR) {
+	l.Evlrs = append(l.Evlrs, evlr)
+}
+
+// RemoveEVLRs removes the EVLRs with the given user ID and record ID and returns how many were removed.
+func (l *Las) RemoveEVLRs(userID string, recordID uint16) (removed int) {
+	evlrs := l.Evlrs[:0]
+	for _, evlr := range l.Evlrs {
+		if evlr.UserID() == userID && evlr.RecordID() == recordID {
+			removed++
+			continue
+		}
+		evlrs = append(evlrs, evlr)
+	}
+	l.Evlrs = evlrs
+	return
+}
+
+// ReplaceEVLR replaces the EVLRs with the user ID and record ID of evlr by evlr, at the position of the first of
+// them. If l has no such EVLR, evlr is appended.
+func (l *Las) ReplaceEVLR(evlr EVLR) {
+	userID, recordID := evlr.UserID(), evlr.RecordID()
+	evlrs := l.Evlrs[:0]
+	replaced := false
+	for _, v := range l.Evlrs {
+		if v.UserID() == userID && v.RecordID() == recordID {
+			if !replaced {
+				evlrs = append(evlrs, evlr)
+				replaced = true
+			}
+			continue
+		}
+		evlrs = append(evlrs, v)
+	}
+	if !replaced {
+		evlrs = append(evlrs, evlr)
+	}
+	l.Evlrs = evlrs
+}
+
 func (l *Las) Las2txt(outputFile string) (err error) {
 	file, err := os.OpenFile(outputFile, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
@@ -259,3 +755,86 @@ func (l *Las) Las2txt(outputFile string) (err error) {
 	}
 	return
 }
+
+// countingWriter keeps track of the number of bytes written to the underlying writer.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (c *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = c.writer.Write(p)
+	c.count += int64(n)
+	return
+}
+
+// sequentialReader gives io.ReaderAt access to a stream, as long as it is read front to back. Bytes between two reads
+// are skipped. While recording is set, the bytes read are kept in recorded.
+type sequentialReader struct {
+	reader    io.Reader
+	offset    int64
+	recording bool
+	recorded  bytes.Buffer
+}
+
+func (s *sequentialReader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < s.offset {
+		err = fmt.Errorf("cannot read offset %d of a stream which is already at offset %d", off, s.offset)
+		return
+	}
+	if skip := off - s.offset; skip != 0 {
+		var skipped int64
+		if s.recording {
+			skipped, err = io.CopyN(&s.recorded, s.reader, skip)
+		} else {
+			skipped, err = io.CopyN(io.Discard, s.reader, skip)
+		}
+		s.offset += skipped
+		if err != nil {
+			return
+		}
+	}
+	n, err = io.ReadFull(s.reader, p)
+	s.offset += int64(n)
+	if s.recording {
+		s.recorded.Write(p[:n])
+	}
+	return
+}
+
+// readLength reads length bytes at offset off from reader, which holds size bytes. A length exceeding the end of the
+// reader fails before it is allocated. The size of streams is unknown and negative; they are read in pieces instead,
+// so that a corrupt length fails at the end of the stream.
+func readLength(reader io.ReaderAt, off int64, length uint64, size int64) (data []byte, err error) {
+	if size >= 0 && (off > size || length > uint64(size-off)) || length > uint64(math.MaxInt64-off) {
+		err = fmt.Errorf("%w: %d bytes at offset %d exceed the end of the file at %d", ErrTruncated, length, off, size)
+		return
+	}
+	data = []byte{}
+	for remaining := length; remaining != 0; {
+		piece := remaining
+		if piece > streamReadSize {
+			piece = streamReadSize
+		}
+		start := len(data)
+		data = append(data, make([]byte, piece)...)
+		if err = readAt(reader, data[start:], off+int64(start)); err != nil {
+			return
+		}
+		remaining -= piece
+	}
+	return
+}
+
+// readAt fills p from reader at offset off. Empty reads succeed without reading, and so do reads ending exactly at the
+// end of the data, as io.ReaderAt implementations such as bytes.Reader may report io.EOF for both.
+func readAt(reader io.ReaderAt, p []byte, off int64) (err error) {
+	if len(p) == 0 {
+		return
+	}
+	n, err := reader.ReadAt(p, off)
+	if n == len(p) && err == io.EOF {
+		err = nil
+	}
+	return
+}
@@ -0,0 +1,247 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// newTestLas returns a file of version 1.minor holding numberOfPDRs random point data records of the format, each
+// followed by extraLength extra bytes.
+func newTestLas(t *testing.T, minor uint8, format uint8, numberOfPDRs int, extraLength uint16) (l *Las) {
+	t.Helper()
+	l = &Las{}
+	l.Header.VersionMajor, l.Header.VersionMinor = 1, minor
+	l.Header.PointDataRecordFormat = format
+	l.Header.XScaleFactor, l.Header.YScaleFactor, l.Header.ZScaleFactor = 0.01, 0.01, 0.01
+	recordSize, err := getPointDataRecordSize(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Header.PointDataRecordLength = recordSize + extraLength
+	if l.Pdrs, err = newPDRs(format, uint64(numberOfPDRs)); err != nil {
+		t.Fatal(err)
+	}
+	raw := make([]byte, numberOfPDRs*int(l.Header.PointDataRecordLength))
+	random := rand.New(rand.NewSource(int64(format)))
+	random.Read(raw)
+	if err = l.Pdrs.decode(raw, uint64(l.Header.PointDataRecordLength)); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.UpdateHeaderFromPoints(); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// writeTestLas returns l as written to a file.
+func writeTestLas(t *testing.T, l *Las) (data []byte) {
+	t.Helper()
+	var buffer bytes.Buffer
+	if _, err := l.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// setTestGPSTimes replaces the random GPS times of l, which need not be numbers, by adjusted standard GPS times, or by
+// GPS week times before LAS 1.2, which has no global encoding to declare the time type.
+func setTestGPSTimes(l *Las) {
+	weekTime := l.Header.VersionMajor == 1 && l.Header.VersionMinor < 2
+	start := 1e8
+	if weekTime {
+		start = 0
+	}
+	records := reflect.ValueOf(l.Pdrs)
+	for i := 0; i < records.Len(); i++ {
+		if gpsTime := records.Index(i).FieldByName("GPSTime"); gpsTime.IsValid() {
+			gpsTime.SetFloat(start + float64(i))
+		}
+	}
+	if !weekTime {
+		l.Header.GlobalEncoding |= GLOBAL_ENCODING_GPS_TIME_TYPE
+	}
+}
+
+// reparseTestLas returns l as parsed from the file it is written to.
+func reparseTestLas(t *testing.T, l *Las) (parsed *Las) {
+	t.Helper()
+	data := writeTestLas(t, l)
+	parsed = &Las{}
+	if err := parsed.ParseReader(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// encodeTestPDRs returns the point data records of l as stored in a LAS file.
+func encodeTestPDRs(t *testing.T, l *Las) (raw []byte) {
+	t.Helper()
+	if l.Pdrs == nil {
+		return
+	}
+	var buffer bytes.Buffer
+	if err := l.Pdrs.write(&buffer, uint64(l.Header.PointDataRecordLength)); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for format := uint8(0); format <= 10; format++ {
+		for _, extraLength := range []uint16{0, 3} {
+			t.Run(fmt.Sprintf("format %d with %d extra bytes", format, extraLength), func(t *testing.T) {
+				l := newTestLas(t, 4, format, 1000, extraLength)
+				data := writeTestLas(t, l)
+
+				parsed := &Las{}
+				if err := parsed.ParseReader(bytes.NewReader(data), int64(len(data))); err != nil {
+					t.Fatal(err)
+				}
+				if parsed.Header.PointDataRecordFormat != format || parsed.Pdrs.Len() != l.Pdrs.Len() {
+					t.Fatalf("read %d points of format %d, want %d of format %d", parsed.Pdrs.Len(), parsed.Header.PointDataRecordFormat, l.Pdrs.Len(), format)
+				}
+				if !bytes.Equal(encodeTestPDRs(t, parsed), encodeTestPDRs(t, l)) {
+					t.Error("point data records differ after the round trip")
+				}
+				if !bytes.Equal(writeTestLas(t, parsed), data) {
+					t.Error("file differs when written again")
+				}
+			})
+		}
+	}
+}
+
+func TestParseEmptyFiles(t *testing.T) {
+	for _, minor := range []uint8{2, 4} {
+		t.Run(fmt.Sprintf("version 1.%d", minor), func(t *testing.T) {
+			l := newTestLas(t, minor, 1, 0, 0)
+			if minor == 4 {
+				// an EVLR without payload ends the file
+				evlr, err := NewEVLR("test", 1, "empty", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				l.Evlrs = append(l.Evlrs, evlr)
+			}
+			data := writeTestLas(t, l)
+			parsed := &Las{}
+			if err := parsed.ParseReader(bytes.NewReader(data), int64(len(data))); err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Pdrs.Len() != 0 || len(parsed.Evlrs) != len(l.Evlrs) {
+				t.Errorf("read %d points and %d EVLRs, want 0 and %d", parsed.Pdrs.Len(), len(parsed.Evlrs), len(l.Evlrs))
+			}
+		})
+	}
+}
+
+func TestParseDamagedFiles(t *testing.T) {
+	data := writeTestLas(t, newTestLas(t, 4, 6, 100, 0))
+	huge := append([]byte(nil), data...)
+	// the 64-bit number of point records of LAS 1.4
+	copy(huge[247:255], []byte{0, 0, 0, 0, 0, 1, 0, 0})
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty file", nil, ErrTruncated},
+		{"partial header", data[:100], ErrTruncated},
+		{"no points", data[:375], ErrTruncated},
+		{"partial points", data[:len(data)-10], ErrTruncated},
+		{"huge point count", huge, ErrTruncated},
+		{"not a las file", append([]byte("LASX"), data[4:]...), ErrNotLas},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := &Las{}
+			err := l.ParseReader(bytes.NewReader(test.data), int64(len(test.data)))
+			if !errors.Is(err, test.want) {
+				t.Errorf("got error %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseReaderLenientTruncated(t *testing.T) {
+	l := newTestLas(t, 4, 1, 100, 0)
+	data := writeTestLas(t, l)
+	recordLength := int(l.Header.PointDataRecordLength)
+	// cut the file in the middle of the 41st record
+	cut := data[:int(l.Header.OffsetToPointData)+40*recordLength+5]
+
+	parsed := &Las{}
+	warnings, err := parsed.ParseReaderLenient(bytes.NewReader(cut), int64(len(cut)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) == 0 {
+		t.Error("no warnings for a truncated file")
+	}
+	if parsed.Pdrs.Len() != 40 {
+		t.Fatalf("read %d points, want 40", parsed.Pdrs.Len())
+	}
+	if !bytes.Equal(encodeTestPDRs(t, parsed), encodeTestPDRs(t, l)[:40*recordLength]) {
+		t.Error("recovered point data records differ")
+	}
+}
+
+func TestParseHugePointCount(t *testing.T) {
+	for _, compressed := range []bool{false, true} {
+		l := newTestLas(t, 4, 1, 100, 0)
+		var data []byte
+		if compressed {
+			data = newTestLaz(t, l)
+		} else {
+			data = writeTestLas(t, l)
+		}
+		// the 64-bit number of point records of LAS 1.4
+		binary.LittleEndian.PutUint64(data[247:], 1<<40)
+
+		parsers := map[string]func(l *Las) error{
+			"ParseReader": func(l *Las) error { return l.ParseReader(bytes.NewReader(data), int64(len(data))) },
+			"ParseStream": func(l *Las) error { return l.ParseStream(bytes.NewReader(data)) },
+		}
+		for name, parse := range parsers {
+			t.Run(fmt.Sprintf("%s compressed %t", name, compressed), func(t *testing.T) {
+				if err := parse(&Las{}); !errors.Is(err, ErrTruncated) {
+					t.Errorf("got error %v, want %v", err, ErrTruncated)
+				}
+			})
+		}
+	}
+}
+
+func TestParseHugeEVLR(t *testing.T) {
+	l := newTestLas(t, 4, 1, 100, 0)
+	evlr, err := NewEVLR("test", 1, "", make([]byte, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.AddEVLR(evlr)
+	data := writeTestLas(t, l)
+	// the 64-bit record length follows the reserved field, user ID and record ID of the EVLR header
+	binary.LittleEndian.PutUint64(data[len(data)-10-40:], 1<<62)
+
+	parsers := map[string]func() error{
+		"ParseReader": func() error { return (&Las{}).ParseReader(bytes.NewReader(data), int64(len(data))) },
+		"ParseStream": func() error { return (&Las{}).ParseStream(bytes.NewReader(data)) },
+		"NewReader": func() (err error) {
+			_, err = NewReader(bytes.NewReader(data), int64(len(data)), 10)
+			return
+		},
+	}
+	for name, parse := range parsers {
+		t.Run(name, func(t *testing.T) {
+			if err := parse(); !errors.Is(err, ErrTruncated) {
+				t.Errorf("got error %v, want %v", err, ErrTruncated)
+			}
+		})
+	}
+}
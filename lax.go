@@ -0,0 +1,605 @@
+package las
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//  _               __   __
+// | |        /\    \ \ / /
+// | |       /  \    \ V /
+// | |      / /\ \    > <
+// | |____ / ____ \  / . \
+// |______/_/    \_\/_/ \_\
+//
+//
+
+const (
+	LAX_FILE_SIGNATURE     = "LASX"
+	LAX_SPATIAL_SIGNATURE  = "LASS"
+	LAX_QUADTREE_SIGNATURE = "LASQ"
+	LAX_INTERVAL_SIGNATURE = "LASV"
+	LAX_SPATIAL_QUADTREE   = 0
+	// LAX_MAXIMUM_LEVELS bounds the quadtree levels, so that the cell indices of all levels fit an int32.
+	LAX_MAXIMUM_LEVELS    = 16
+	LAX_CELL_SIZE         = 5.0
+	LAX_THRESHOLD         = 1000
+	LAX_MINIMUM_POINTS    = 100000
+	LAX_MAXIMUM_INTERVALS = -20
+	LAX_FILE_EXTENSION    = ".lax"
+)
+
+// laxReadIntervals is the number of intervals read from a LAX file at once.
+const laxReadIntervals = 1 << 16
+
+// A LAX file is the spatial index LASindex writes next to a LAS or LAZ file. It divides the x-y extent of the file into
+// the cells of a quadtree, and lists for every cell the intervals of point indices holding its points, so that a
+// reader only needs to read the point ranges of the cells overlapping a query rectangle. A cell whose points are too
+// few is merged with its siblings into the parent cell, so cells may be of different levels.
+//
+// Cells are numbered level by level: the cells of level l start at (4^l - 1) / 3, and within a level the cell index
+// holds two bits per level from the root, bit 0 for the upper half in x and bit 1 for the upper half in y.
+type LaxIndex struct {
+	Levels         uint32
+	LevelIndex     uint32
+	ImplicitLevels uint32
+	MinX           float32
+	MaxX           float32
+	MinY           float32
+	MaxY           float32
+
+	cells     map[int32]*LaxCell
+	threshold uint32
+	lastCell  *LaxCell
+}
+
+// LaxCell lists the intervals of point indices holding the points of a quadtree cell.
+type LaxCell struct {
+	Index          int32
+	NumberOfPoints uint32
+	Intervals      []LaxInterval
+}
+
+// LaxInterval is the range of point indices from Start to End, both included.
+type LaxInterval struct {
+	Start uint32
+	End   uint32
+}
+
+// LaxOptions control how a LAX index is built. Their LASindex counterparts are -tile_size, -threshold,
+// -minimum_points and -maximum_intervals.
+type LaxOptions struct {
+	// CellSize is the edge length of the finest cells, in the coordinates of the point cloud.
+	CellSize float32
+	// Threshold is the largest difference of consecutive point indices of a cell which continue its last interval
+	// while adding points.
+	Threshold uint32
+	// MinimumPoints is the number of points below which four sibling cells are merged into their parent.
+	MinimumPoints uint32
+	// MaximumIntervals is the number of intervals to which the intervals of all cells are reduced by closing the
+	// smallest gaps. If negative, its magnitude is the number of intervals per cell; zero keeps all intervals.
+	MaximumIntervals int32
+}
+
+// DefaultLaxOptions returns the options used by LASindex by default.
+func DefaultLaxOptions() LaxOptions {
+	return LaxOptions{
+		CellSize:         LAX_CELL_SIZE,
+		Threshold:        LAX_THRESHOLD,
+		MinimumPoints:    LAX_MINIMUM_POINTS,
+		MaximumIntervals: LAX_MAXIMUM_INTERVALS,
+	}
+}
+
+// LaxFilename returns the name of the LAX file of the LAS or LAZ file filename.
+func LaxFilename(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + LAX_FILE_EXTENSION
+}
+
+// NewLaxIndex returns an empty index whose quadtree covers the rectangle from minX, minY to maxX, maxY with cells of
+// edge length options.CellSize. Points are added with Add, after which Complete merges cells and intervals.
+func NewLaxIndex(minX float64, minY float64, maxX float64, maxY float64, options LaxOptions) (index *LaxIndex, err error) {
+	if !(options.CellSize > 0) {
+		err = fmt.Errorf("cell size must be positive. Cell size: %v", options.CellSize)
+		return
+	}
+	if !(minX <= maxX && minY <= maxY) {
+		err = fmt.Errorf("rectangle from %v, %v to %v, %v is empty", minX, minY, maxX, maxY)
+		return
+	}
+	index = &LaxIndex{cells: make(map[int32]*LaxCell), threshold: options.Threshold}
+
+	// enlarge the rectangle to whole cells, computed in single precision like LASindex
+	cellSize := options.CellSize
+	snapDown := func(v float64) float32 {
+		if v >= 0 {
+			return cellSize * float32(int32(v/float64(cellSize)))
+		}
+		return cellSize * float32(int32(v/float64(cellSize))-1)
+	}
+	snapUp := func(v float64) float32 {
+		if v >= 0 {
+			return cellSize * float32(int32(v/float64(cellSize))+1)
+		}
+		return cellSize * float32(int32(v/float64(cellSize)))
+	}
+	index.MinX, index.MaxX = snapDown(minX), snapUp(maxX)
+	index.MinY, index.MaxY = snapDown(minY), snapUp(maxY)
+
+	cellsX := uint32((index.MaxX-index.MinX)/cellSize + 0.5)
+	cellsY := uint32((index.MaxY-index.MinY)/cellSize + 0.5)
+	if cellsX == 0 || cellsY == 0 {
+		err = fmt.Errorf("rectangle from %v, %v to %v, %v holds no cells of size %v", minX, minY, maxX, maxY, cellSize)
+		return
+	}
+	c := cellsY - 1
+	if cellsX > cellsY {
+		c = cellsX - 1
+	}
+	for ; c != 0; c >>= 1 {
+		index.Levels++
+	}
+	if index.Levels >= LAX_MAXIMUM_LEVELS {
+		err = fmt.Errorf("rectangle from %v, %v to %v, %v needs %d quadtree levels for cells of size %v", minX, minY, maxX, maxY, index.Levels, cellSize)
+		return
+	}
+
+	// enlarge the rectangle to the size of the quadtree
+	c = (uint32(1) << index.Levels) - cellsX
+	index.MinX -= float32(c-c/2) * cellSize
+	index.MaxX += float32(c/2) * cellSize
+	c = (uint32(1) << index.Levels) - cellsY
+	index.MinY -= float32(c-c/2) * cellSize
+	index.MaxY += float32(c/2) * cellSize
+	return
+}
+
+// BuildLaxIndex reads the points of the LAS or LAZ file filename and returns their index.
+func BuildLaxIndex(filename string, options LaxOptions) (index *LaxIndex, err error) {
+	reader, err := OpenReader(filename)
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	return reader.buildLaxIndex(options)
+}
+
+// WriteLaxFile builds the index of the LAS or LAZ file filename and writes it to the LAX file next to it.
+func WriteLaxFile(filename string, options LaxOptions) (err error) {
+	index, err := BuildLaxIndex(filename, options)
+	if err != nil {
+		return
+	}
+	return index.Write(LaxFilename(filename))
+}
+
+func (r *PointReader) buildLaxIndex(options LaxOptions) (index *LaxIndex, err error) {
+	if r.numberOfPDRs > math.MaxUint32 {
+		err = fmt.Errorf("LAX files cannot index %d points", r.numberOfPDRs)
+		return
+	}
+	header := r.Header
+	if index, err = NewLaxIndex(header.MinX, header.MinY, header.MaxX, header.MaxY, options); err != nil {
+		return
+	}
+	for r.Next() {
+		x, y, _ := header.GetScaledXYZ(r.Point())
+		index.Add(x, y, uint32(r.Index()))
+	}
+	if err = r.Err(); err != nil {
+		return
+	}
+	index.Complete(options.MinimumPoints, options.MaximumIntervals)
+	return
+}
+
+// getLevelIndex returns the index of the cell of level containing x, y, relative to the first cell of level.
+func (x *LaxIndex) getLevelIndex(pointX float64, pointY float64, level uint32) (levelIndex int32) {
+	minX, maxX, minY, maxY := x.MinX, x.MaxX, x.MinY, x.MaxY
+	for ; level != 0; level-- {
+		levelIndex <<= 2
+		midX := (minX + maxX) / 2
+		midY := (minY + maxY) / 2
+		if pointX < float64(midX) {
+			maxX = midX
+		} else {
+			minX = midX
+			levelIndex |= 1
+		}
+		if pointY < float64(midY) {
+			maxY = midY
+		} else {
+			minY = midY
+			levelIndex |= 2
+		}
+	}
+	return
+}
+
+// getLevelOffset returns the index of the first cell of level.
+func getLevelOffset(level uint32) int32 {
+	return int32(((uint64(1) << (2 * level)) - 1) / 3)
+}
+
+// getCellLevel returns the level of a cell and its index within the level.
+func getCellLevel(cellIndex int32) (level uint32, levelIndex int32, err error) {
+	for level = 0; level < LAX_MAXIMUM_LEVELS; level++ {
+		if cellIndex < getLevelOffset(level+1) {
+			levelIndex = cellIndex - getLevelOffset(level)
+			return
+		}
+	}
+	err = fmt.Errorf("cell index %d exceeds the quadtree levels", cellIndex)
+	return
+}
+
+// CellBounds returns the rectangle of the cell cellIndex.
+func (x *LaxIndex) CellBounds(cellIndex int32) (minX float32, minY float32, maxX float32, maxY float32, err error) {
+	level, levelIndex, err := getCellLevel(cellIndex)
+	if err != nil {
+		return
+	}
+	minX, maxX, minY, maxY = x.MinX, x.MaxX, x.MinY, x.MaxY
+	for shift := 2 * int(level); shift > 0; shift -= 2 {
+		quadrant := (levelIndex >> uint(shift-2)) & 3
+		midX := (minX + maxX) / 2
+		midY := (minY + maxY) / 2
+		if quadrant&1 != 0 {
+			minX = midX
+		} else {
+			maxX = midX
+		}
+		if quadrant&2 != 0 {
+			minY = midY
+		} else {
+			maxY = midY
+		}
+	}
+	return
+}
+
+// Add adds the point pointIndex at x, y to the cell of the finest level containing it. Points must be added in
+// increasing order of their indices.
+func (x *LaxIndex) Add(pointX float64, pointY float64, pointIndex uint32) {
+	cellIndex := getLevelOffset(x.Levels) + x.getLevelIndex(pointX, pointY, x.Levels)
+	cell := x.lastCell
+	if cell == nil || cell.Index != cellIndex {
+		var ok bool
+		if cell, ok = x.cells[cellIndex]; !ok {
+			cell = &LaxCell{Index: cellIndex}
+			x.cells[cellIndex] = cell
+		}
+		x.lastCell = cell
+	}
+	cell.NumberOfPoints++
+	if last := len(cell.Intervals) - 1; last >= 0 && pointIndex-cell.Intervals[last].End <= x.threshold {
+		cell.Intervals[last].End = pointIndex
+		return
+	}
+	cell.Intervals = append(cell.Intervals, LaxInterval{Start: pointIndex, End: pointIndex})
+}
+
+// Complete merges sibling cells holding less than minimumPoints points together into their parent, repeating on the
+// parents, and then closes the smallest gaps between intervals until no more than maximumIntervals remain. A negative
+// maximumIntervals is a number of intervals per cell.
+func (x *LaxIndex) Complete(minimumPoints uint32, maximumIntervals int32) {
+	x.lastCell = nil
+	if minimumPoints != 0 {
+		candidates := make([]int32, 0, len(x.cells))
+		for cellIndex := range x.cells {
+			candidates = append(candidates, cellIndex)
+		}
+		for len(candidates) != 0 {
+			sort.Slice(candidates, func(i, j int) bool { return candidates[i] < candidates[j] })
+			var merged []int32
+			for _, cellIndex := range candidates {
+				level, levelIndex, _ := getCellLevel(cellIndex)
+				if level == 0 || levelIndex&3 != 0 {
+					continue
+				}
+				first := getLevelOffset(level) + levelIndex
+				var siblings [4]*LaxCell
+				numberOfPoints := uint64(0)
+				complete := true
+				for i := range siblings {
+					if siblings[i] = x.cells[first+int32(i)]; siblings[i] == nil {
+						complete = false
+						break
+					}
+					numberOfPoints += uint64(siblings[i].NumberOfPoints)
+				}
+				if !complete || numberOfPoints >= uint64(minimumPoints) {
+					continue
+				}
+				parent := &LaxCell{Index: getLevelOffset(level-1) + levelIndex>>2, NumberOfPoints: uint32(numberOfPoints)}
+				for i, sibling := range siblings {
+					parent.Intervals = append(parent.Intervals, sibling.Intervals...)
+					delete(x.cells, first+int32(i))
+				}
+				parent.Intervals = mergeLaxIntervals(parent.Intervals)
+				x.cells[parent.Index] = parent
+				merged = append(merged, parent.Index)
+			}
+			// only the sibling groups of the new cells can be complete now
+			candidates = candidates[:0]
+			for _, cellIndex := range merged {
+				level, levelIndex, _ := getCellLevel(cellIndex)
+				if level != 0 {
+					candidates = append(candidates, getLevelOffset(level)+levelIndex&^3)
+				}
+			}
+		}
+	}
+
+	limit := int64(maximumIntervals)
+	if limit < 0 {
+		limit = -limit * int64(len(x.cells))
+	}
+	if limit != 0 {
+		x.closeGaps(limit)
+	}
+}
+
+// mergeLaxIntervals sorts intervals and joins those which overlap or touch.
+func mergeLaxIntervals(intervals []LaxInterval) (merged []LaxInterval) {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Start < intervals[j].Start })
+	for _, interval := range intervals {
+		if last := len(merged) - 1; last >= 0 && uint64(interval.Start) <= uint64(merged[last].End)+1 {
+			if interval.End > merged[last].End {
+				merged[last].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return
+}
+
+// closeGaps joins the intervals of the cells separated by the smallest gaps until at most limit intervals remain.
+// Every cell keeps at least one interval.
+func (x *LaxIndex) closeGaps(limit int64) {
+	type gap struct {
+		cell  *LaxCell
+		start uint32
+		size  uint32
+	}
+	var gaps []gap
+	for _, cellIndex := range x.sortedCellIndices() {
+		cell := x.cells[cellIndex]
+		for i := 1; i < len(cell.Intervals); i++ {
+			gaps = append(gaps, gap{cell: cell, start: cell.Intervals[i].Start, size: cell.Intervals[i].Start - cell.Intervals[i-1].End - 1})
+		}
+	}
+	limit -= int64(len(x.cells))
+	if limit < 0 {
+		limit = 0
+	}
+	if int64(len(gaps)) <= limit {
+		return
+	}
+	sort.SliceStable(gaps, func(i, j int) bool { return gaps[i].size < gaps[j].size })
+
+	closed := make(map[*LaxCell]map[uint32]bool)
+	for _, g := range gaps[:int64(len(gaps))-limit] {
+		if closed[g.cell] == nil {
+			closed[g.cell] = make(map[uint32]bool)
+		}
+		closed[g.cell][g.start] = true
+	}
+	for cell, starts := range closed {
+		intervals := cell.Intervals[:1]
+		for _, interval := range cell.Intervals[1:] {
+			if starts[interval.Start] {
+				intervals[len(intervals)-1].End = interval.End
+				continue
+			}
+			intervals = append(intervals, interval)
+		}
+		cell.Intervals = intervals
+	}
+}
+
+func (x *LaxIndex) sortedCellIndices() (cellIndices []int32) {
+	cellIndices = make([]int32, 0, len(x.cells))
+	for cellIndex := range x.cells {
+		cellIndices = append(cellIndices, cellIndex)
+	}
+	sort.Slice(cellIndices, func(i, j int) bool { return cellIndices[i] < cellIndices[j] })
+	return
+}
+
+// Cells returns the cells of the index ordered by cell index.
+func (x *LaxIndex) Cells() (cells []LaxCell) {
+	for _, cellIndex := range x.sortedCellIndices() {
+		cells = append(cells, *x.cells[cellIndex])
+	}
+	return
+}
+
+// QueryRectangle returns the sorted, disjoint intervals of point indices of the cells overlapping the rectangle from
+// minX, minY to maxX, maxY. The intervals may hold points outside of the rectangle.
+func (x *LaxIndex) QueryRectangle(minX float64, minY float64, maxX float64, maxY float64) (intervals []LaxInterval) {
+	for _, cell := range x.cells {
+		cellMinX, cellMinY, cellMaxX, cellMaxY, err := x.CellBounds(cell.Index)
+		if err != nil {
+			continue
+		}
+		if float64(cellMinX) <= maxX && minX <= float64(cellMaxX) && float64(cellMinY) <= maxY && minY <= float64(cellMaxY) {
+			intervals = append(intervals, cell.Intervals...)
+		}
+	}
+	intervals = mergeLaxIntervals(intervals)
+	return
+}
+
+// getLastPointIndex returns the largest point index of the index.
+func (x *LaxIndex) getLastPointIndex() (last uint32) {
+	for _, cell := range x.cells {
+		for _, interval := range cell.Intervals {
+			if interval.End > last {
+				last = interval.End
+			}
+		}
+	}
+	return
+}
+
+// ReadLaxIndex reads the LAX file filename.
+func ReadLaxIndex(filename string) (index *LaxIndex, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	return ParseLaxIndex(bufio.NewReader(file))
+}
+
+// ParseLaxIndex reads a LAX index from reader.
+func ParseLaxIndex(reader io.Reader) (index *LaxIndex, err error) {
+	readSignature := func(expected string) (err error) {
+		signature := make([]byte, 4)
+		if _, err = io.ReadFull(reader, signature); err != nil {
+			return
+		}
+		if string(signature) != expected {
+			err = fmt.Errorf("LAX signature %q does not match %q", signature, expected)
+		}
+		return
+	}
+	read := func(data ...interface{}) (err error) {
+		for _, value := range data {
+			if err = binary.Read(reader, binary.LittleEndian, value); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	var version, spatialType uint32
+	if err = readSignature(LAX_FILE_SIGNATURE); err != nil {
+		return
+	}
+	if err = read(&version); err != nil {
+		return
+	}
+	if err = readSignature(LAX_SPATIAL_SIGNATURE); err != nil {
+		return
+	}
+	if err = read(&spatialType); err != nil {
+		return
+	}
+	if spatialType != LAX_SPATIAL_QUADTREE {
+		err = fmt.Errorf("LAX spatial index type %d is not supported", spatialType)
+		return
+	}
+	if err = readSignature(LAX_QUADTREE_SIGNATURE); err != nil {
+		return
+	}
+	index = &LaxIndex{cells: make(map[int32]*LaxCell)}
+	if err = read(&version, &index.Levels, &index.LevelIndex, &index.ImplicitLevels, &index.MinX, &index.MaxX, &index.MinY, &index.MaxY); err != nil {
+		return
+	}
+	if index.Levels >= LAX_MAXIMUM_LEVELS {
+		err = fmt.Errorf("LAX quadtree with %d levels is not supported", index.Levels)
+		return
+	}
+	if index.LevelIndex != 0 {
+		err = fmt.Errorf("LAX quadtrees of a sub-tree are not supported")
+		return
+	}
+
+	if err = readSignature(LAX_INTERVAL_SIGNATURE); err != nil {
+		return
+	}
+	var numberOfCells int32
+	if err = read(&version, &numberOfCells); err != nil {
+		return
+	}
+	for c := int32(0); c < numberOfCells; c++ {
+		cell := &LaxCell{}
+		var numberOfIntervals uint32
+		if err = read(&cell.Index, &numberOfIntervals, &cell.NumberOfPoints); err != nil {
+			return
+		}
+		if cell.Index < 0 {
+			err = fmt.Errorf("LAX cell index %d is negative", cell.Index)
+			return
+		}
+		if _, _, err = getCellLevel(cell.Index); err != nil {
+			return
+		}
+		// the intervals are read in pieces, so that a corrupt number of intervals fails at the end of the file
+		for remaining := numberOfIntervals; remaining != 0; {
+			piece := remaining
+			if piece > laxReadIntervals {
+				piece = laxReadIntervals
+			}
+			intervals := make([]LaxInterval, piece)
+			if err = read(intervals); err != nil {
+				err = fmt.Errorf("%w: LAX cell %d declares %d intervals: %v", ErrTruncated, cell.Index, numberOfIntervals, err)
+				return
+			}
+			cell.Intervals = append(cell.Intervals, intervals...)
+			remaining -= piece
+		}
+		index.cells[cell.Index] = cell
+	}
+	return
+}
+
+// Write writes the index to the LAX file filename.
+func (x *LaxIndex) Write(filename string) (err error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return
+	}
+	if _, err = x.WriteTo(file); err != nil {
+		file.Close()
+		return
+	}
+	return file.Close()
+}
+
+// WriteTo writes the index in the LAX format to w.
+func (x *LaxIndex) WriteTo(w io.Writer) (n int64, err error) {
+	counter := &countingWriter{writer: w}
+	writer := bufio.NewWriter(counter)
+	defer func() {
+		n = counter.count
+	}()
+
+	write := func(data ...interface{}) (err error) {
+		for _, value := range data {
+			if err = binary.Write(writer, binary.LittleEndian, value); err != nil {
+				return
+			}
+		}
+		return
+	}
+	if err = write([]byte(LAX_FILE_SIGNATURE), uint32(0), []byte(LAX_SPATIAL_SIGNATURE), uint32(LAX_SPATIAL_QUADTREE)); err != nil {
+		return
+	}
+	if err = write([]byte(LAX_QUADTREE_SIGNATURE), uint32(0), x.Levels, x.LevelIndex, x.ImplicitLevels, x.MinX, x.MaxX, x.MinY, x.MaxY); err != nil {
+		return
+	}
+	if err = write([]byte(LAX_INTERVAL_SIGNATURE), uint32(0), int32(len(x.cells))); err != nil {
+		return
+	}
+	for _, cellIndex := range x.sortedCellIndices() {
+		cell := x.cells[cellIndex]
+		if err = write(cell.Index, uint32(len(cell.Intervals)), cell.NumberOfPoints, cell.Intervals); err != nil {
+			return
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return
+	}
+	return
+}
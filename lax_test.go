@@ -0,0 +1,174 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseLaxIndexHugeIntervalCount(t *testing.T) {
+	index, err := NewLaxIndex(0, 0, 100, 100, DefaultLaxOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < 100; i++ {
+		index.Add(float64(i), float64(i), i)
+	}
+	index.Complete(0, 0)
+	var buffer bytes.Buffer
+	if _, err = index.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	data := buffer.Bytes()
+	// the number of intervals of the first cell follows the version, number of cells and index of the cell
+	cells := bytes.Index(data, []byte(LAX_INTERVAL_SIGNATURE)) + 4
+	binary.LittleEndian.PutUint32(data[cells+12:], 0xFFFFFFFF)
+
+	if _, err = ParseLaxIndex(bytes.NewReader(data)); !errors.Is(err, ErrTruncated) {
+		t.Errorf("got error %v, want %v", err, ErrTruncated)
+	}
+}
+
+// newTestLaxFile writes numberOfPDRs random points within 0 to 1000 in x and y to a LAS file and returns its name and
+// the coordinates of the points.
+func newTestLaxFile(t *testing.T, numberOfPDRs int) (filename string, xy [][2]float64) {
+	t.Helper()
+	l := newTestLas(t, 2, 1, numberOfPDRs, 0)
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < numberOfPDRs; i++ {
+		point := l.Pdrs.At(i)
+		point.SetX(random.Int31n(100000))
+		point.SetY(random.Int31n(100000))
+		x, y, _ := l.Header.GetScaledXYZ(point)
+		xy = append(xy, [2]float64{x, y})
+	}
+	if err := l.UpdateHeaderFromPoints(); err != nil {
+		t.Fatal(err)
+	}
+	filename = filepath.Join(t.TempDir(), "test.las")
+	if err := l.Write(filename); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// testLaxOptions are the options of the LAX indices built by the tests.
+var testLaxOptions = []struct {
+	name    string
+	options LaxOptions
+}{
+	{"default options", DefaultLaxOptions()},
+	{"small cells", LaxOptions{CellSize: 10, Threshold: 1000, MinimumPoints: 0, MaximumIntervals: 0}},
+	{"merged cells", LaxOptions{CellSize: 10, Threshold: 0, MinimumPoints: 500, MaximumIntervals: 0}},
+	{"intervals per cell", LaxOptions{CellSize: 50, Threshold: 10, MinimumPoints: 100, MaximumIntervals: -2}},
+	{"intervals in total", LaxOptions{CellSize: 50, Threshold: 0, MinimumPoints: 0, MaximumIntervals: 1000}},
+}
+
+func TestLaxRoundTrip(t *testing.T) {
+	filename, _ := newTestLaxFile(t, 20000)
+	for _, test := range testLaxOptions {
+		t.Run(test.name, func(t *testing.T) {
+			index, err := BuildLaxIndex(filename, test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// every cell keeps at least one interval
+			if limit := int(test.options.MaximumIntervals); limit > 0 {
+				numberOfIntervals := 0
+				for _, cell := range index.Cells() {
+					numberOfIntervals += len(cell.Intervals)
+				}
+				if cells := len(index.Cells()); limit < cells {
+					limit = cells
+				}
+				if numberOfIntervals > limit {
+					t.Errorf("index holds %d intervals, want at most %d", numberOfIntervals, limit)
+				}
+			}
+			if err = index.Write(LaxFilename(filename)); err != nil {
+				t.Fatal(err)
+			}
+			read, err := ReadLaxIndex(LaxFilename(filename))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if read.Levels != index.Levels || read.MinX != index.MinX || read.MaxX != index.MaxX || read.MinY != index.MinY || read.MaxY != index.MaxY {
+				t.Errorf("read quadtree %+v, want %+v", read, index)
+			}
+			if !reflect.DeepEqual(read.Cells(), index.Cells()) {
+				t.Error("cells differ after the round trip")
+			}
+		})
+	}
+}
+
+func TestLaxQueryRectangle(t *testing.T) {
+	filename, xy := newTestLaxFile(t, 20000)
+	rectangles := []struct {
+		name                   string
+		minX, minY, maxX, maxY float64
+	}{
+		{"all points", 0, 0, 1000, 1000},
+		{"corner", 0, 0, 100, 100},
+		{"centre", 400.5, 400.5, 600.25, 600.25},
+		{"thin strip", 0, 500, 1000, 500.5},
+		{"single point", xy[7][0], xy[7][1], xy[7][0], xy[7][1]},
+		{"outside", 2000, 2000, 3000, 3000},
+	}
+	for _, test := range testLaxOptions {
+		index, err := BuildLaxIndex(filename, test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rectangle := range rectangles {
+			t.Run(test.name+" "+rectangle.name, func(t *testing.T) {
+				var want []uint64
+				for i, point := range xy {
+					if rectangle.minX <= point[0] && point[0] <= rectangle.maxX && rectangle.minY <= point[1] && point[1] <= rectangle.maxY {
+						want = append(want, uint64(i))
+					}
+				}
+
+				intervals := index.QueryRectangle(rectangle.minX, rectangle.minY, rectangle.maxX, rectangle.maxY)
+				for i := 1; i < len(intervals); i++ {
+					if intervals[i].Start <= intervals[i-1].End {
+						t.Fatalf("intervals %v and %v are not sorted and disjoint", intervals[i-1], intervals[i])
+					}
+				}
+				for _, pointIndex := range want {
+					i := sort.Search(len(intervals), func(i int) bool { return uint64(intervals[i].End) >= pointIndex })
+					if i == len(intervals) || uint64(intervals[i].Start) > pointIndex {
+						t.Fatalf("point %d within the rectangle is not covered by the intervals", pointIndex)
+					}
+				}
+
+				r, err := OpenReader(filename)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer r.Close()
+				if err = r.SetLaxIndex(index); err != nil {
+					t.Fatal(err)
+				}
+				if err = r.SetRectangle(rectangle.minX, rectangle.minY, rectangle.maxX, rectangle.maxY); err != nil {
+					t.Fatal(err)
+				}
+				var got []uint64
+				for r.Next() {
+					got = append(got, r.Index())
+				}
+				if err = r.Err(); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("reader returned %d points, want %d", len(got), len(want))
+				}
+			})
+		}
+	}
+}
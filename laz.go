@@ -0,0 +1,528 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+//  _               ______
+// | |        /\   |___  /
+// | |       /  \     / /
+// | |      / /\ \   / /
+// | |____ / ____ \ / /__
+// |______/_/    \_\_____|
+//
+//
+
+// LAZ is the LASzip compressed variant of the LAS format. The public header block, VLRs and EVLRs are stored as in a
+// LAS file, the point data records are compressed in chunks which are described by a LASzip VLR and a chunk table.
+
+const (
+	LASZIP_USER_ID   = "laszip encoded"
+	LASZIP_RECORD_ID = 22204
+
+	LASZIP_COMPRESSOR_NONE              = 0
+	LASZIP_COMPRESSOR_POINTWISE         = 1
+	LASZIP_COMPRESSOR_POINTWISE_CHUNKED = 2
+	LASZIP_COMPRESSOR_LAYERED_CHUNKED   = 3
+
+	LASZIP_CODER_ARITHMETIC = 0
+
+	LASZIP_CHUNK_SIZE_DEFAULT  = 50000
+	LASZIP_CHUNK_SIZE_VARIABLE = math.MaxUint32
+
+	// the point data record format of a LAZ file has bit 7 set, and bit 6 by some older writers
+	LASZIP_FORMAT_COMPRESSED = 0x80
+	LASZIP_FORMAT_MASK       = 0x3F
+)
+
+const (
+	LASZIP_ITEM_BYTE         = 0
+	LASZIP_ITEM_POINT10      = 6
+	LASZIP_ITEM_GPSTIME11    = 7
+	LASZIP_ITEM_RGB12        = 8
+	LASZIP_ITEM_WAVEPACKET13 = 9
+	LASZIP_ITEM_POINT14      = 10
+	LASZIP_ITEM_RGB14        = 11
+	LASZIP_ITEM_RGBNIR14     = 12
+	LASZIP_ITEM_WAVEPACKET14 = 13
+	LASZIP_ITEM_BYTE14       = 14
+)
+
+//  _               _____     _
+// | |        /\   / ____|   (_)
+// | |       /  \ | (___  _____ _ __
+// | |      / /\ \ \___ \|_  / | '_ \
+// | |____ / ____ \____) |/ /| | |_) |
+// |______/_/    \_\_____//___|_| .__/
+//                              | |
+//                              |_|
+
+// LASzip is the record of the VLR with user ID "laszip encoded" and record ID 22204 which describes how the point data
+// records of a LAZ file are compressed.
+type LASzip struct {
+	Compressor           uint16
+	Coder                uint16
+	VersionMajor         uint8
+	VersionMinor         uint8
+	VersionRevision      uint16
+	Options              uint32
+	ChunkSize            uint32
+	NumberOfSpecialEVLRs int64
+	OffsetToSpecialEVLRs int64
+	NumberOfItems        uint16
+	Items                []LASzipItem
+}
+
+type LASzipItem struct {
+	Type    uint16
+	Size    uint16
+	Version uint16
+}
+
+type lasZipHeader struct {
+	Compressor           uint16
+	Coder                uint16
+	VersionMajor         uint8
+	VersionMinor         uint8
+	VersionRevision      uint16
+	Options              uint32
+	ChunkSize            uint32
+	NumberOfSpecialEVLRs int64
+	OffsetToSpecialEVLRs int64
+	NumberOfItems        uint16
+}
+
+func (z *LASzip) read(record []byte, offset int64) (err error) {
+	header := lasZipHeader{}
+	headerSize := binary.Size(lasZipHeader{})
+	if len(record) < headerSize {
+		err = fmt.Errorf("LASzip VLR of %d bytes is too short", len(record))
+		return
+	}
+	if err = binary.Read(bytes.NewReader(record[:headerSize]), binary.LittleEndian, &header); err != nil {
+		return
+	}
+	z.Compressor = header.Compressor
+	z.Coder = header.Coder
+	z.VersionMajor = header.VersionMajor
+	z.VersionMinor = header.VersionMinor
+	z.VersionRevision = header.VersionRevision
+	z.Options = header.Options
+	z.ChunkSize = header.ChunkSize
+	z.NumberOfSpecialEVLRs = header.NumberOfSpecialEVLRs
+	z.OffsetToSpecialEVLRs = header.OffsetToSpecialEVLRs
+	z.NumberOfItems = header.NumberOfItems
+	z.Items = make([]LASzipItem, header.NumberOfItems)
+	if err = binary.Read(bytes.NewReader(record[headerSize:]), binary.LittleEndian, &z.Items); err != nil {
+		return
+	}
+	return
+}
+
+func (z *LASzip) getPointSize() (size uint64) {
+	for _, item := range z.Items {
+		size += uint64(item.Size)
+	}
+	return
+}
+
+// checkItems verifies that every item of the LASzip record can be decoded.
+func (z *LASzip) checkItems() (err error) {
+	if z.Coder != LASZIP_CODER_ARITHMETIC {
+		err = fmt.Errorf("LASzip coder %d is not supported", z.Coder)
+		return
+	}
+	if z.Compressor != LASZIP_COMPRESSOR_POINTWISE_CHUNKED && z.Compressor != LASZIP_COMPRESSOR_LAYERED_CHUNKED {
+		err = fmt.Errorf("LASzip compressor %d is not supported", z.Compressor)
+		return
+	}
+	for _, item := range z.Items {
+		supported := false
+		switch item.Type {
+		case LASZIP_ITEM_BYTE, LASZIP_ITEM_GPSTIME11, LASZIP_ITEM_RGB12:
+			supported = item.Version == 2
+		case LASZIP_ITEM_POINT10:
+			supported = item.Version == 2 && item.Size == 20
+		case LASZIP_ITEM_WAVEPACKET13:
+			supported = item.Version == 1 && item.Size == 29
+		case LASZIP_ITEM_POINT14:
+			supported = item.Version == 3 && item.Size == 30
+		case LASZIP_ITEM_RGB14, LASZIP_ITEM_RGBNIR14, LASZIP_ITEM_BYTE14:
+			supported = item.Version == 3
+		case LASZIP_ITEM_WAVEPACKET14:
+			supported = item.Version == 3 && item.Size == 29
+		}
+		if !supported {
+			err = fmt.Errorf("LASzip item of type %d, size %d and version %d is not supported", item.Type, item.Size, item.Version)
+			return
+		}
+	}
+	return
+}
+
+//   _____ _                 _      _______    _     _
+//  / ____| |               | |    |__   __|  | |   | |
+// | |    | |__  _   _ _ __ | | __    | | __ _| |__ | | ___
+// | |    | '_ \| | | | '_ \| |/ /    | |/ _` | '_ \| |/ _ \
+// | |____| | | | |_| | | | |   <     | | (_| | |_) | |  __/
+//  \_____|_| |_|\__,_|_| |_|_|\_\    |_|\__,_|_.__/|_|\___|
+//
+//
+
+// lazChunk locates a compressed chunk of point data records in a LAZ file.
+type lazChunk struct {
+	offset         int64
+	size           uint64
+	numberOfPoints uint64
+}
+
+// readChunkTable reads the chunk table of a LAZ file of size bytes. The point data starts with the offset of the chunk
+// table, followed by the chunks themselves.
+func (z *LASzip) readChunkTable(reader io.ReaderAt, size int64, offsetToPointData int64, numberOfPDRs uint64) (chunks []lazChunk, err error) {
+	offsetInBytes := make([]byte, 8)
+	if err = readAt(reader, offsetInBytes, offsetToPointData); err != nil {
+		return
+	}
+	chunkTableOffset := int64(binary.LittleEndian.Uint64(offsetInBytes))
+	if chunkTableOffset == -1 {
+		// the writer could not seek back, the offset of the chunk table is stored in the last 8 bytes of the file
+		if err = readAt(reader, offsetInBytes, size-8); err != nil {
+			return
+		}
+		chunkTableOffset = int64(binary.LittleEndian.Uint64(offsetInBytes))
+	}
+	if chunkTableOffset < offsetToPointData {
+		err = fmt.Errorf("LAZ chunk table offset %d lies before the point data at offset %d", chunkTableOffset, offsetToPointData)
+		return
+	}
+	if chunkTableOffset+8 > size {
+		err = fmt.Errorf("%w: LAZ chunk table offset %d lies after the end of the file at %d", ErrTruncated, chunkTableOffset, size)
+		return
+	}
+
+	tableHeader := make([]byte, 8)
+	if err = readAt(reader, tableHeader, chunkTableOffset); err != nil {
+		return
+	}
+	version := binary.LittleEndian.Uint32(tableHeader[0:4])
+	numberOfChunks := binary.LittleEndian.Uint32(tableHeader[4:8])
+	if version != 0 {
+		err = fmt.Errorf("LASzip chunk table version %d is not supported", version)
+		return
+	}
+	// every chunk holds at least one point and one byte, so a larger count is corrupt and must not be allocated
+	if uint64(numberOfChunks) > numberOfPDRs || int64(numberOfChunks) > chunkTableOffset-offsetToPointData-8 {
+		err = fmt.Errorf("LAZ chunk table declares %d chunks for %d points in %d bytes", numberOfChunks, numberOfPDRs, chunkTableOffset-offsetToPointData-8)
+		return
+	}
+
+	// the compressed chunk table is small, but its length is not stored. Read up to the end of the file.
+	tableInBytes := make([]byte, size-chunkTableOffset-8)
+	if err = readAt(reader, tableInBytes, chunkTableOffset+8); err != nil {
+		return
+	}
+
+	dec := newArithmeticDecoder(tableInBytes)
+	ic := newDefaultIntegerCompressor(32, 2, false)
+	chunks = make([]lazChunk, numberOfChunks)
+	offset := offsetToPointData + 8
+	remaining := numberOfPDRs
+	var lastSize, lastPoints int32
+	for index := range chunks {
+		if z.ChunkSize == LASZIP_CHUNK_SIZE_VARIABLE {
+			lastPoints = ic.decompress(dec, lastPoints, 0)
+			chunks[index].numberOfPoints = uint64(uint32(lastPoints))
+			// a chunk holding more than the remaining points is corrupt and must not be allocated
+			if chunks[index].numberOfPoints > remaining {
+				err = fmt.Errorf("LAZ chunk %d declares %d points, but only %d of %d points remain", index+1, chunks[index].numberOfPoints, remaining, numberOfPDRs)
+				return
+			}
+		} else {
+			chunks[index].numberOfPoints = uint64(z.ChunkSize)
+			if chunks[index].numberOfPoints > remaining {
+				chunks[index].numberOfPoints = remaining
+			}
+		}
+		remaining -= chunks[index].numberOfPoints
+		lastSize = ic.decompress(dec, lastSize, 1)
+		chunks[index].size = uint64(uint32(lastSize))
+		chunks[index].offset = offset
+		offset += int64(chunks[index].size)
+		if offset > chunkTableOffset {
+			err = fmt.Errorf("LAZ chunk %d of %d bytes at offset %d overruns the chunk table at offset %d", index+1, chunks[index].size, chunks[index].offset, chunkTableOffset)
+			return
+		}
+	}
+	return
+}
+
+//  _____                                                      _
+// |  __ \                                                    (_)
+// | |  | | ___  ___ ___  _ __ ___  _ __  _ __ ___  ___ ___ _  ___  _ __
+// | |  | |/ _ \/ __/ _ \| '_ ` _ \| '_ \| '__/ _ \/ __/ __| |/ _ \| '_ \
+// | |__| |  __/ (_| (_) | | | | | | |_) | | |  __/\__ \__ \ | (_) | | | |
+// |_____/ \___|\___\___/|_| |_| |_| .__/|_|  \___||___/___/_|\___/|_| |_|
+//                                 | |
+//                                 |_|
+
+// decompressChunk decompresses the point data records of a chunk into their uncompressed LAS representation.
+func (z *LASzip) decompressChunk(data []byte, numberOfPoints uint64) (raw []byte, err error) {
+	raw, _, err = z.decompressChunkData(data, numberOfPoints, false)
+	return
+}
+
+// scanChunk decompresses the chunk at the start of data, whose compressed size is not known, and returns the size. A
+// pointwise chunk cut off by the end of data yields the points decoded before the data runs out; a layered chunk cut
+// off fails, as its layers are stored one after the other.
+func (z *LASzip) scanChunk(data []byte, numberOfPoints uint64) (raw []byte, size int, err error) {
+	return z.decompressChunkData(data, numberOfPoints, true)
+}
+
+// decompressChunkData decompresses a chunk and returns the number of bytes it occupies in data. If partial is set, the
+// points of a pointwise chunk are only decoded while data lasts and raw holds just these points.
+func (z *LASzip) decompressChunkData(data []byte, numberOfPoints uint64, partial bool) (raw []byte, size int, err error) {
+	pointSize := z.getPointSize()
+	raw = make([]byte, numberOfPoints*pointSize)
+	if numberOfPoints == 0 {
+		return
+	}
+	if uint64(len(data)) < pointSize {
+		err = fmt.Errorf("LAZ chunk of %d bytes is too short", len(data))
+		return
+	}
+	copy(raw[:pointSize], data[:pointSize])
+	decoded := numberOfPoints
+	if z.Compressor == LASZIP_COMPRESSOR_LAYERED_CHUNKED {
+		size, err = z.decompressLayeredChunk(data[pointSize:], raw, numberOfPoints)
+	} else {
+		decoded, size, err = z.decompressPointwiseChunk(data[pointSize:], raw, numberOfPoints, partial)
+	}
+	size += int(pointSize)
+	if partial {
+		raw = raw[:decoded*pointSize]
+	}
+	return
+}
+
+// decompressPointwiseChunk decodes the points following the first one and returns how many points it decoded and how
+// many bytes of data the arithmetic decoder consumed. The encoder ends a chunk with the bytes the decoder reads ahead,
+// so these are the bytes of the chunk. If partial is set, decoding stops before a point reading past the end of data.
+func (z *LASzip) decompressPointwiseChunk(data []byte, raw []byte, numberOfPoints uint64, partial bool) (decoded uint64, size int, err error) {
+	pointSize := z.getPointSize()
+	items := make([]lazPointwiseItem, len(z.Items))
+	itemOffset := uint64(0)
+	for index, item := range z.Items {
+		first := raw[itemOffset : itemOffset+uint64(item.Size)]
+		switch item.Type {
+		case LASZIP_ITEM_POINT10:
+			items[index] = newLazPoint10(first, false)
+		case LASZIP_ITEM_GPSTIME11:
+			items[index] = newLazGPSTime11(first, false)
+		case LASZIP_ITEM_RGB12:
+			items[index] = newLazRGB12(first, false)
+		case LASZIP_ITEM_WAVEPACKET13:
+			items[index] = newLazWavePacket13(first, false)
+		case LASZIP_ITEM_BYTE:
+			items[index] = newLazByte(first, false)
+		default:
+			err = fmt.Errorf("LASzip item of type %d cannot be used with pointwise compression", item.Type)
+			return
+		}
+		itemOffset += uint64(item.Size)
+	}
+
+	dec := newArithmeticDecoder(data)
+	for decoded = 1; decoded < numberOfPoints; decoded++ {
+		itemOffset = decoded * pointSize
+		for index, item := range z.Items {
+			items[index].read(dec, raw[itemOffset:itemOffset+uint64(item.Size)])
+			itemOffset += uint64(item.Size)
+		}
+		if partial && dec.index > len(data) {
+			break
+		}
+	}
+	size = dec.index
+	return
+}
+
+// decompressLayeredChunk decodes the points following the first one and returns how many bytes of data the chunk
+// occupies, i.e. its point count, layer sizes and layers.
+func (z *LASzip) decompressLayeredChunk(data []byte, raw []byte, numberOfPoints uint64) (size int, err error) {
+	pointSize := z.getPointSize()
+	reader := bytes.NewReader(data)
+	var count uint32
+	if err = binary.Read(reader, binary.LittleEndian, &count); err != nil {
+		return
+	}
+	if uint64(count) < numberOfPoints {
+		numberOfPoints = uint64(count)
+	}
+
+	items := make([]lazLayeredItem, len(z.Items))
+	for index, item := range z.Items {
+		switch item.Type {
+		case LASZIP_ITEM_POINT14:
+			items[index] = newLazPoint14(false)
+		case LASZIP_ITEM_RGB14:
+			items[index] = newLazRGB14(false, false)
+		case LASZIP_ITEM_RGBNIR14:
+			items[index] = newLazRGB14(true, false)
+		case LASZIP_ITEM_WAVEPACKET14:
+			items[index] = newLazWavePacket14(false)
+		case LASZIP_ITEM_BYTE14:
+			items[index] = newLazByte14(int(item.Size), false)
+		default:
+			err = fmt.Errorf("LASzip item of type %d cannot be used with layered compression", item.Type)
+			return
+		}
+	}
+	for _, item := range items {
+		if err = item.readLayerSizes(reader); err != nil {
+			return
+		}
+	}
+	for _, item := range items {
+		if err = item.readLayers(reader); err != nil {
+			return
+		}
+	}
+	size = len(data) - reader.Len()
+
+	context := uint32(0)
+	itemOffset := uint64(0)
+	for index, item := range z.Items {
+		items[index].init(raw[itemOffset:itemOffset+uint64(item.Size)], &context)
+		itemOffset += uint64(item.Size)
+	}
+	for point := uint64(1); point < numberOfPoints; point++ {
+		itemOffset = point * pointSize
+		for index, item := range z.Items {
+			items[index].read(raw[itemOffset:itemOffset+uint64(item.Size)], &context)
+			itemOffset += uint64(item.Size)
+		}
+	}
+	return
+}
+
+// getLazChunks returns the LASzip record and the chunks of the compressed point data records of a file of size bytes.
+func (l *Las) getLazChunks(reader io.ReaderAt, size int64) (lasZip *LASzip, chunks []lazChunk, err error) {
+	if lasZip, err = l.getLazCompression(); err != nil {
+		return
+	}
+	chunks, err = l.readLazChunkTable(lasZip, reader, size)
+	return
+}
+
+// getLazCompression returns the LASzip record of l after checking that it describes the point data records.
+func (l *Las) getLazCompression() (lasZip *LASzip, err error) {
+	if lasZip, err = l.getLASzip(); err != nil {
+		return
+	}
+	if lasZip.getPointSize() != uint64(l.Header.PointDataRecordLength) {
+		err = newHeaderError("PointDataRecordLength", fmt.Errorf("LASzip items describe points of %d bytes, but the point data record length is %d", lasZip.getPointSize(), l.Header.PointDataRecordLength))
+		return
+	}
+	return
+}
+
+// readLazChunkTable returns the chunks of the compressed point data records of a file of size bytes.
+func (l *Las) readLazChunkTable(lasZip *LASzip, reader io.ReaderAt, size int64) (chunks []lazChunk, err error) {
+	if chunks, err = lasZip.readChunkTable(reader, size, int64(l.Header.OffsetToPointData), l.getNumberOfPDRs()); err != nil {
+		err = &FormatError{Offset: int64(l.Header.OffsetToPointData), Field: "LAZ chunk table", Err: err}
+		return
+	}
+	return
+}
+
+// readLazChunk reads and decompresses a chunk into its uncompressed point data records.
+func (z *LASzip) readLazChunk(reader io.ReaderAt, chunk lazChunk) (raw []byte, err error) {
+	data := make([]byte, chunk.size)
+	if err = readAt(reader, data, chunk.offset); err != nil {
+		err = &FormatError{Offset: chunk.offset, Field: "LAZ chunk", Err: err}
+		return
+	}
+	if raw, err = z.decompressChunk(data, chunk.numberOfPoints); err != nil {
+		err = &FormatError{Offset: chunk.offset, Field: "LAZ chunk", Err: err}
+		return
+	}
+	return
+}
+
+// readLazPDRs decompresses all chunks of the point data records and decodes them into l.Pdrs. Afterwards l describes
+// an uncompressed LAS file: the compression bit of the point data record format is cleared and the LASzip VLR removed.
+func (l *Las) readLazPDRs(reader io.ReaderAt, size int64) (err error) {
+	lasZip, chunks, err := l.getLazChunks(reader, size)
+	if err != nil {
+		return
+	}
+	numOfPDRs := l.getNumberOfPDRs()
+	dataLength := uint64(l.Header.PointDataRecordLength)
+	// the points are counted in the chunk table first, so that a corrupt point count is not allocated
+	inChunks := uint64(0)
+	for _, chunk := range chunks {
+		inChunks += chunk.numberOfPoints
+	}
+	if inChunks < numOfPDRs {
+		err = &FormatError{Offset: int64(l.Header.OffsetToPointData), Field: "point data records", Err: fmt.Errorf("%w: LAZ chunk table holds %d points, header declares %d", ErrTruncated, inChunks, numOfPDRs)}
+		return
+	}
+	if l.Pdrs, err = newPDRs(l.Header.PointDataRecordFormat&LASZIP_FORMAT_MASK, numOfPDRs); err != nil {
+		return
+	}
+	raw := make([]byte, 0, numOfPDRs*dataLength)
+	for _, chunk := range chunks {
+		var rawChunk []byte
+		if rawChunk, err = lasZip.readLazChunk(reader, chunk); err != nil {
+			return
+		}
+		raw = append(raw, rawChunk...)
+	}
+	if uint64(len(raw)) < numOfPDRs*dataLength {
+		err = &FormatError{Offset: int64(l.Header.OffsetToPointData), Field: "point data records", Err: fmt.Errorf("LAZ chunks hold %d bytes of point data, expected %d", len(raw), numOfPDRs*dataLength)}
+		return
+	}
+	if err = l.Pdrs.decode(raw[:numOfPDRs*dataLength], dataLength); err != nil {
+		return
+	}
+
+	l.Header.PointDataRecordFormat &= LASZIP_FORMAT_MASK
+	l.removeLASzipVLRs()
+	l.compressed = true
+	return
+}
+
+// removeLASzipVLRs removes the VLRs holding a LASzip record, and drops them from the number of VLRs declared by the
+// header, which describes the VLRs of l after a LAZ file is parsed.
+func (l *Las) removeLASzipVLRs() {
+	vlrs := l.Vlrs[:0]
+	for _, vlr := range l.Vlrs {
+		if userID, _ := vlr.header.getUserID(); userID != LASZIP_USER_ID {
+			vlrs = append(vlrs, vlr)
+		} else if l.Header.NumberOfVLRs != 0 {
+			l.Header.NumberOfVLRs--
+		}
+	}
+	l.Vlrs = vlrs
+}
+
+// getLASzip returns the LASzip record of the file's VLRs.
+func (l *Las) getLASzip() (lasZip *LASzip, err error) {
+	for _, vlr := range l.Vlrs {
+		for _, record := range vlr.record {
+			if z, ok := record.(*LASzip); ok {
+				lasZip = z
+				if err = lasZip.checkItems(); err != nil {
+					err = fmt.Errorf("%w: %v", ErrCompressed, err)
+				}
+				return
+			}
+		}
+	}
+	err = fmt.Errorf("%w: file has no LASzip VLR", ErrCompressed)
+	return
+}
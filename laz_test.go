@@ -0,0 +1,193 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestLaz returns l compressed as a LAZ file.
+func newTestLaz(t *testing.T, l *Las) (data []byte) {
+	t.Helper()
+	l.Header.PointDataRecordFormat |= LASZIP_FORMAT_COMPRESSED
+	defer func() {
+		l.Header.PointDataRecordFormat &= LASZIP_FORMAT_MASK
+	}()
+	return writeTestLas(t, l)
+}
+
+// newTestVariableLaz returns the LAZ file data with its single chunk stored as a chunk of variable size declaring
+// numberOfPoints points.
+func newTestVariableLaz(t *testing.T, data []byte, numberOfPoints uint64) (variable []byte) {
+	t.Helper()
+	variable = append([]byte(nil), data...)
+	offset := int(binary.LittleEndian.Uint16(variable[94:]))
+	for i := binary.LittleEndian.Uint32(variable[100:]); i > 0; i-- {
+		// the user ID follows the reserved field, the record length the record ID, and the payload the description
+		payloadLength := int(binary.LittleEndian.Uint16(variable[offset+20:]))
+		if string(bytes.TrimRight(variable[offset+2:offset+18], "\x00")) == LASZIP_USER_ID {
+			binary.LittleEndian.PutUint32(variable[offset+54+12:], LASZIP_CHUNK_SIZE_VARIABLE)
+		}
+		offset += 54 + payloadLength
+	}
+	offsetToPointData := int64(binary.LittleEndian.Uint32(variable[96:]))
+	chunkTableOffset := int64(binary.LittleEndian.Uint64(variable[offsetToPointData:]))
+	lasZip := &LASzip{ChunkSize: LASZIP_CHUNK_SIZE_VARIABLE}
+	chunk := lazChunk{offset: offsetToPointData + 8, size: uint64(chunkTableOffset - offsetToPointData - 8), numberOfPoints: numberOfPoints}
+	var table bytes.Buffer
+	if err := lasZip.writeChunkTable(&table, []lazChunk{chunk}); err != nil {
+		t.Fatal(err)
+	}
+	return append(variable[:chunkTableOffset], table.Bytes()...)
+}
+
+func TestLazRoundTrip(t *testing.T) {
+	for format := uint8(0); format <= 10; format++ {
+		for _, extraLength := range []uint16{0, 3} {
+			t.Run(fmt.Sprintf("format %d with %d extra bytes", format, extraLength), func(t *testing.T) {
+				l := newTestLas(t, 4, format, 1000, extraLength)
+				data := newTestLaz(t, l)
+
+				parsed := &Las{}
+				if err := parsed.ParseReader(bytes.NewReader(data), int64(len(data))); err != nil {
+					t.Fatal(err)
+				}
+				if parsed.Header.PointDataRecordFormat != format || parsed.Pdrs.Len() != l.Pdrs.Len() {
+					t.Fatalf("read %d points of format %d, want %d of format %d", parsed.Pdrs.Len(), parsed.Header.PointDataRecordFormat, l.Pdrs.Len(), format)
+				}
+				if !bytes.Equal(encodeTestPDRs(t, parsed), encodeTestPDRs(t, l)) {
+					t.Error("point data records differ after the round trip")
+				}
+			})
+		}
+	}
+}
+
+func TestParseDamagedLaz(t *testing.T) {
+	l := newTestLas(t, 4, 1, 1000, 0)
+	data := newTestLaz(t, l)
+	chunkTableOffset := int64(binary.LittleEndian.Uint64(data[l.Header.OffsetToPointData:]))
+
+	pastEnd := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(pastEnd[l.Header.OffsetToPointData:], uint64(len(data))+1000)
+	hugeChunkCount := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(hugeChunkCount[chunkTableOffset+4:], 0xFFFFFFFF)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		truncated bool
+	}{
+		{"truncated", data[:len(data)/2], true},
+		{"chunk table past the end", pastEnd, true},
+		{"huge chunk count", hugeChunkCount, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed := &Las{}
+			err := parsed.ParseReader(bytes.NewReader(test.data), int64(len(test.data)))
+			if err == nil {
+				t.Fatal("damaged file parsed without error")
+			}
+			if errors.Is(err, ErrTruncated) != test.truncated {
+				t.Errorf("got error %v, truncated %t", err, test.truncated)
+			}
+		})
+	}
+}
+
+func TestParseReaderLenientTruncatedLaz(t *testing.T) {
+	// format 1 is compressed pointwise and format 6 in layers, in chunks of 50000 points
+	for _, format := range []uint8{1, 6} {
+		t.Run(fmt.Sprintf("format %d", format), func(t *testing.T) {
+			l := newTestLas(t, 4, format, 120000, 0)
+			data := newTestLaz(t, l)
+			cut := data[:len(data)*9/10]
+
+			parsed := &Las{}
+			warnings, err := parsed.ParseReaderLenient(bytes.NewReader(cut), int64(len(cut)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(warnings) == 0 {
+				t.Error("no warnings for a truncated file")
+			}
+			// the first two chunks are complete, the third is cut off
+			read := parsed.Pdrs.Len()
+			if read < 100000 || read >= l.Pdrs.Len() {
+				t.Fatalf("recovered %d of %d points", read, l.Pdrs.Len())
+			}
+			recordLength := int(l.Header.PointDataRecordLength)
+			if !bytes.Equal(encodeTestPDRs(t, parsed), encodeTestPDRs(t, l)[:read*recordLength]) {
+				t.Error("recovered point data records differ")
+			}
+		})
+	}
+}
+
+func TestParseVariableChunkLaz(t *testing.T) {
+	l := newTestLas(t, 4, 1, 1000, 0)
+	data := newTestLaz(t, l)
+
+	tests := []struct {
+		name           string
+		numberOfPoints uint64
+		ok             bool
+	}{
+		{"all points", 1000, true},
+		{"more points than the file", 1001, false},
+		{"huge number of points", 0xFFFFFFFF, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			variable := newTestVariableLaz(t, data, test.numberOfPoints)
+			parsed := &Las{}
+			err := parsed.ParseReader(bytes.NewReader(variable), int64(len(variable)))
+			if !test.ok {
+				if err == nil {
+					t.Error("chunk with too many points parsed without error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encodeTestPDRs(t, parsed), encodeTestPDRs(t, l)) {
+				t.Error("point data records differ after the round trip")
+			}
+		})
+	}
+}
+
+// TestLASzipFixtures decodes the files in testdata compressed by LASzip, e.g. with laszip -i name.las -o name.laz, and
+// compares their points with the uncompressed file of the same name.
+func TestLASzipFixtures(t *testing.T) {
+	filenames, err := filepath.Glob(filepath.Join("testdata", "*.laz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filenames) == 0 {
+		t.Skip("no LAZ files compressed by LASzip in testdata")
+	}
+	for _, filename := range filenames {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			compressed, uncompressed := &Las{}, &Las{}
+			if err := compressed.Parse(filename); err != nil {
+				t.Fatal(err)
+			}
+			if err := uncompressed.Parse(strings.TrimSuffix(filename, ".laz") + ".las"); err != nil {
+				t.Fatal(err)
+			}
+			if compressed.Pdrs.Len() != uncompressed.Pdrs.Len() {
+				t.Fatalf("read %d points, want %d", compressed.Pdrs.Len(), uncompressed.Pdrs.Len())
+			}
+			if !bytes.Equal(encodeTestPDRs(t, compressed), encodeTestPDRs(t, uncompressed)) {
+				t.Error("point data records differ from the uncompressed file")
+			}
+		})
+	}
+}
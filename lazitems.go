@@ -0,0 +1,624 @@
+package las
+
+import (
+	"encoding/binary"
+)
+
+//  _               ______  _____ _
+// | |        /\   |___  / |_   _| |
+// | |       /  \     / /    | | | |_ ___ _ __ ___  ___
+// | |      / /\ \   / /     | | | __/ _ \ '_ ` _ \/ __|
+// | |____ / ____ \ / /__   _| |_| ||  __/ | | | | \__ \
+// |______/_/    \_\_____| |_____|\__\___|_| |_| |_|___/
+//
+//
+
+// The items of the pointwise chunked compressor used for the point data record formats 0-5: POINT10, GPSTIME11 and
+// RGB12 in version 2, WAVEPACKET13 in version 1 and BYTE in version 2 for the extra bytes.
+
+type lazPointwiseItem interface {
+	read(dec *arithmeticDecoder, item []byte)
+}
+
+var numberReturnMap = [8][8]uint8{
+	{15, 14, 13, 12, 11, 10, 9, 8},
+	{14, 0, 1, 3, 6, 10, 10, 9},
+	{13, 1, 2, 4, 7, 11, 11, 10},
+	{12, 3, 4, 5, 8, 12, 12, 11},
+	{11, 6, 7, 8, 9, 13, 13, 12},
+	{10, 10, 11, 12, 13, 14, 14, 13},
+	{9, 10, 11, 12, 13, 14, 15, 14},
+	{8, 9, 10, 11, 12, 13, 14, 15},
+}
+
+var numberReturnLevel = [8][8]uint8{
+	{0, 1, 2, 3, 4, 5, 6, 7},
+	{1, 0, 1, 2, 3, 4, 5, 6},
+	{2, 1, 0, 1, 2, 3, 4, 5},
+	{3, 2, 1, 0, 1, 2, 3, 4},
+	{4, 3, 2, 1, 0, 1, 2, 3},
+	{5, 4, 3, 2, 1, 0, 1, 2},
+	{6, 5, 4, 3, 2, 1, 0, 1},
+	{7, 6, 5, 4, 3, 2, 1, 0},
+}
+
+func u8Fold(n int32) uint8 {
+	return uint8(n)
+}
+
+func u8Clamp(n int32) int32 {
+	if n < 0 {
+		return 0
+	} else if n > 255 {
+		return 255
+	}
+	return n
+}
+
+func u32ZeroBit0(n uint32) uint32 {
+	return n & 0xFFFFFFFE
+}
+
+// streamingMedian5 keeps track of the median of the last five values.
+type streamingMedian5 struct {
+	values [5]int32
+	high   bool
+}
+
+func newStreamingMedian5() streamingMedian5 {
+	return streamingMedian5{high: true}
+}
+
+func (s *streamingMedian5) add(v int32) {
+	if s.high {
+		if v < s.values[2] {
+			s.values[4] = s.values[3]
+			s.values[3] = s.values[2]
+			if v < s.values[0] {
+				s.values[2] = s.values[1]
+				s.values[1] = s.values[0]
+				s.values[0] = v
+			} else if v < s.values[1] {
+				s.values[2] = s.values[1]
+				s.values[1] = v
+			} else {
+				s.values[2] = v
+			}
+		} else {
+			if v < s.values[3] {
+				s.values[4] = s.values[3]
+				s.values[3] = v
+			} else {
+				s.values[4] = v
+			}
+			s.high = false
+		}
+	} else {
+		if s.values[2] < v {
+			s.values[0] = s.values[1]
+			s.values[1] = s.values[2]
+			if s.values[4] < v {
+				s.values[2] = s.values[3]
+				s.values[3] = s.values[4]
+				s.values[4] = v
+			} else if s.values[3] < v {
+				s.values[2] = s.values[3]
+				s.values[3] = v
+			} else {
+				s.values[2] = v
+			}
+		} else {
+			if s.values[1] < v {
+				s.values[0] = s.values[1]
+				s.values[1] = v
+			} else {
+				s.values[0] = v
+			}
+			s.high = true
+		}
+	}
+}
+
+func (s *streamingMedian5) get() int32 {
+	return s.values[2]
+}
+
+//  _____      _       _   __  ___
+// |  __ \    (_)     | | /_ |/ _ \
+// | |__) |__  _ _ __ | |_ | | | | |
+// |  ___/ _ \| | '_ \| __|| | | | |
+// | |  | (_) | | | | | |_ | | |_| |
+// |_|   \___/|_|_| |_|\__||_|\___/
+//
+//
+
+type lazPoint10 struct {
+	compress         bool
+	lastItem         [20]byte
+	lastIntensity    [16]uint16
+	lastXDiffMedian5 [16]streamingMedian5
+	lastYDiffMedian5 [16]streamingMedian5
+	lastHeight       [8]int32
+	mChangedValues   *arithmeticModel
+	icIntensity      *integerCompressor
+	mScanAngleRank   [2]*arithmeticModel
+	icPointSourceID  *integerCompressor
+	mBitByte         [256]*arithmeticModel
+	mClassification  [256]*arithmeticModel
+	mUserData        [256]*arithmeticModel
+	icDX             *integerCompressor
+	icDY             *integerCompressor
+	icZ              *integerCompressor
+}
+
+func newLazPoint10(first []byte, compress bool) (p *lazPoint10) {
+	p = &lazPoint10{
+		compress:        compress,
+		mChangedValues:  newArithmeticModel(64, compress),
+		icIntensity:     newDefaultIntegerCompressor(16, 4, compress),
+		mScanAngleRank:  [2]*arithmeticModel{newArithmeticModel(256, compress), newArithmeticModel(256, compress)},
+		icPointSourceID: newDefaultIntegerCompressor(16, 1, compress),
+		icDX:            newDefaultIntegerCompressor(32, 2, compress),
+		icDY:            newDefaultIntegerCompressor(32, 22, compress),
+		icZ:             newDefaultIntegerCompressor(32, 20, compress),
+	}
+	for i := range p.lastXDiffMedian5 {
+		p.lastXDiffMedian5[i] = newStreamingMedian5()
+		p.lastYDiffMedian5[i] = newStreamingMedian5()
+	}
+	copy(p.lastItem[:], first)
+	// the intensity of the first point is not used as a prediction
+	p.lastItem[12] = 0
+	p.lastItem[13] = 0
+	return
+}
+
+func (p *lazPoint10) symbolModel(models *[256]*arithmeticModel, index uint8) *arithmeticModel {
+	if models[index] == nil {
+		models[index] = newArithmeticModel(256, p.compress)
+	}
+	return models[index]
+}
+
+func (p *lazPoint10) read(dec *arithmeticDecoder, item []byte) {
+	last := p.lastItem[:]
+	changedValues := dec.decodeSymbol(p.mChangedValues)
+	var r, n, m, l uint8
+	if changedValues != 0 {
+		if changedValues&32 != 0 {
+			last[14] = uint8(dec.decodeSymbol(p.symbolModel(&p.mBitByte, last[14])))
+		}
+		r = last[14] & 0x07
+		n = (last[14] >> 3) & 0x07
+		m = numberReturnMap[n][r]
+		l = numberReturnLevel[n][r]
+
+		if changedValues&16 != 0 {
+			intensity := uint16(p.icIntensity.decompress(dec, int32(p.lastIntensity[m]), uint32(minUint8(m, 3))))
+			p.lastIntensity[m] = intensity
+			binary.LittleEndian.PutUint16(last[12:14], intensity)
+		} else {
+			binary.LittleEndian.PutUint16(last[12:14], p.lastIntensity[m])
+		}
+		if changedValues&8 != 0 {
+			last[15] = uint8(dec.decodeSymbol(p.symbolModel(&p.mClassification, last[15])))
+		}
+		if changedValues&4 != 0 {
+			value := dec.decodeSymbol(p.mScanAngleRank[(last[14]>>6)&0x01])
+			last[16] = u8Fold(int32(value) + int32(last[16]))
+		}
+		if changedValues&2 != 0 {
+			last[17] = uint8(dec.decodeSymbol(p.symbolModel(&p.mUserData, last[17])))
+		}
+		if changedValues&1 != 0 {
+			pointSourceID := uint16(p.icPointSourceID.decompress(dec, int32(binary.LittleEndian.Uint16(last[18:20])), 0))
+			binary.LittleEndian.PutUint16(last[18:20], pointSourceID)
+		}
+	} else {
+		r = last[14] & 0x07
+		n = (last[14] >> 3) & 0x07
+		m = numberReturnMap[n][r]
+		l = numberReturnLevel[n][r]
+	}
+
+	single := uint32(0)
+	if n == 1 {
+		single = 1
+	}
+	median := p.lastXDiffMedian5[m].get()
+	diff := p.icDX.decompress(dec, median, single)
+	binary.LittleEndian.PutUint32(last[0:4], uint32(int32(binary.LittleEndian.Uint32(last[0:4]))+diff))
+	p.lastXDiffMedian5[m].add(diff)
+
+	median = p.lastYDiffMedian5[m].get()
+	kBits := p.icDX.getK()
+	diff = p.icDY.decompress(dec, median, single+minUint32(u32ZeroBit0(kBits), 20))
+	binary.LittleEndian.PutUint32(last[4:8], uint32(int32(binary.LittleEndian.Uint32(last[4:8]))+diff))
+	p.lastYDiffMedian5[m].add(diff)
+
+	kBits = (p.icDX.getK() + p.icDY.getK()) / 2
+	z := p.icZ.decompress(dec, p.lastHeight[l], single+minUint32(u32ZeroBit0(kBits), 18))
+	binary.LittleEndian.PutUint32(last[8:12], uint32(z))
+	p.lastHeight[l] = z
+
+	copy(item, last)
+}
+
+func minUint8(a uint8, b uint8) uint8 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func minUint32(a uint32, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+//   _____ _____   _____ _______ _                 __ __
+//  / ____|  __ \ / ____|__   __(_)               /_ /_ |
+// | |  __| |__) | (___    | |   _ _ __ ___   ___  | || |
+// | | |_ |  ___/ \___ \   | |  | | '_ ` _ \ / _ \ | || |
+// | |__| | |     ____) |  | |  | | | | | | |  __/ | || |
+//  \_____|_|    |_____/   |_|  |_|_| |_| |_|\___| |_||_|
+//
+//
+
+const (
+	LASZIP_GPSTIME_MULTI       = 500
+	LASZIP_GPSTIME_MULTI_MINUS = -10
+)
+
+// lazGPSTime predicts GPS times from up to four interleaved time sequences. It is shared by the GPSTIME11 item in
+// version 2 and the POINT14 item in version 3, which differ in how the symbols of unchanged times, full times and
+// sequence switches are assigned.
+type lazGPSTime struct {
+	version3            bool
+	last                uint32
+	next                uint32
+	lastGPSTime         [4]int64
+	lastGPSTimeDiff     [4]int32
+	multiExtremeCounter [4]int32
+	mGPSTimeMulti       *arithmeticModel
+	mGPSTime0Diff       *arithmeticModel
+	icGPSTime           *integerCompressor
+}
+
+func newLazGPSTime(first uint64, version3 bool, compress bool) (g *lazGPSTime) {
+	g = &lazGPSTime{version3: version3, icGPSTime: newDefaultIntegerCompressor(32, 9, compress)}
+	g.mGPSTimeMulti = newArithmeticModel(g.codeFull()+4, compress)
+	g.mGPSTime0Diff = newArithmeticModel(g.zeroDiffOffset()+5, compress)
+	g.lastGPSTime[0] = int64(first)
+	return
+}
+
+// codeFull is the multiplier symbol announcing a GPS time which is stored in full.
+func (g *lazGPSTime) codeFull() uint32 {
+	if g.version3 {
+		return LASZIP_GPSTIME_MULTI - LASZIP_GPSTIME_MULTI_MINUS + 1
+	}
+	return LASZIP_GPSTIME_MULTI - LASZIP_GPSTIME_MULTI_MINUS + 2
+}
+
+// unchanged is the multiplier symbol announcing an unchanged GPS time. It is only used in version 2.
+func (g *lazGPSTime) unchanged() uint32 {
+	return LASZIP_GPSTIME_MULTI - LASZIP_GPSTIME_MULTI_MINUS + 1
+}
+
+// zeroDiffOffset shifts the symbols following the first symbol of the zero difference model, as version 3 does not
+// need a symbol for unchanged GPS times.
+func (g *lazGPSTime) zeroDiffOffset() uint32 {
+	if g.version3 {
+		return 0
+	}
+	return 1
+}
+
+func (g *lazGPSTime) read(dec *arithmeticDecoder) uint64 {
+	for {
+		if g.lastGPSTimeDiff[g.last] == 0 {
+			multi := dec.decodeSymbol(g.mGPSTime0Diff)
+			if !g.version3 && multi == 0 {
+				break
+			}
+			multi -= g.zeroDiffOffset()
+			if multi == 0 {
+				g.lastGPSTimeDiff[g.last] = g.icGPSTime.decompress(dec, 0, 0)
+				g.lastGPSTime[g.last] += int64(g.lastGPSTimeDiff[g.last])
+				g.multiExtremeCounter[g.last] = 0
+			} else if multi == 1 {
+				g.readFull(dec)
+			} else {
+				g.last = (g.last + multi - 1) & 3
+				continue
+			}
+		} else {
+			multi := dec.decodeSymbol(g.mGPSTimeMulti)
+			if multi == 1 {
+				g.lastGPSTime[g.last] += int64(g.icGPSTime.decompress(dec, g.lastGPSTimeDiff[g.last], 1))
+				g.multiExtremeCounter[g.last] = 0
+			} else if multi < g.unchanged() {
+				var gpsTimeDiff int32
+				if multi == 0 {
+					gpsTimeDiff = g.icGPSTime.decompress(dec, 0, 7)
+					g.countExtreme(gpsTimeDiff)
+				} else if multi < LASZIP_GPSTIME_MULTI {
+					context := uint32(3)
+					if multi < 10 {
+						context = 2
+					}
+					gpsTimeDiff = g.icGPSTime.decompress(dec, int32(multi)*g.lastGPSTimeDiff[g.last], context)
+				} else if multi == LASZIP_GPSTIME_MULTI {
+					gpsTimeDiff = g.icGPSTime.decompress(dec, LASZIP_GPSTIME_MULTI*g.lastGPSTimeDiff[g.last], 4)
+					g.countExtreme(gpsTimeDiff)
+				} else {
+					negativeMulti := int32(LASZIP_GPSTIME_MULTI) - int32(multi)
+					if negativeMulti > LASZIP_GPSTIME_MULTI_MINUS {
+						gpsTimeDiff = g.icGPSTime.decompress(dec, negativeMulti*g.lastGPSTimeDiff[g.last], 5)
+					} else {
+						gpsTimeDiff = g.icGPSTime.decompress(dec, LASZIP_GPSTIME_MULTI_MINUS*g.lastGPSTimeDiff[g.last], 6)
+						g.countExtreme(gpsTimeDiff)
+					}
+				}
+				g.lastGPSTime[g.last] += int64(gpsTimeDiff)
+			} else if multi == g.codeFull() {
+				g.readFull(dec)
+			} else if multi > g.codeFull() {
+				g.last = (g.last + multi - g.codeFull()) & 3
+				continue
+			}
+		}
+		break
+	}
+	return uint64(g.lastGPSTime[g.last])
+}
+
+func (g *lazGPSTime) readFull(dec *arithmeticDecoder) {
+	g.next = (g.next + 1) & 3
+	upper := uint64(uint32(g.icGPSTime.decompress(dec, int32(uint64(g.lastGPSTime[g.last])>>32), 8)))
+	g.lastGPSTime[g.next] = int64(upper<<32 | uint64(dec.readInt()))
+	g.last = g.next
+	g.lastGPSTimeDiff[g.last] = 0
+	g.multiExtremeCounter[g.last] = 0
+}
+
+// countExtreme adopts gpsTimeDiff as the new difference of the sequence after more than three extreme multipliers.
+func (g *lazGPSTime) countExtreme(gpsTimeDiff int32) {
+	g.multiExtremeCounter[g.last]++
+	if g.multiExtremeCounter[g.last] > 3 {
+		g.lastGPSTimeDiff[g.last] = gpsTimeDiff
+		g.multiExtremeCounter[g.last] = 0
+	}
+}
+
+type lazGPSTime11 struct {
+	gpsTime *lazGPSTime
+}
+
+func newLazGPSTime11(first []byte, compress bool) (g *lazGPSTime11) {
+	g = &lazGPSTime11{gpsTime: newLazGPSTime(binary.LittleEndian.Uint64(first), false, compress)}
+	return
+}
+
+func (g *lazGPSTime11) read(dec *arithmeticDecoder, item []byte) {
+	binary.LittleEndian.PutUint64(item, g.gpsTime.read(dec))
+}
+
+//  _____   _____ ____    __ ___
+// |  __ \ / ____|  _ \  /_ |__ \
+// | |__) | |  __| |_) |  | |  ) |
+// |  _  /| | |_ |  _ <   | | / /
+// | | \ \| |__| | |_) |  | |/ /_
+// |_|  \_\\_____|____/   |_|____|
+//
+//
+
+// lazRGBModels are the models for the color channels shared by the RGB12, RGB14 and RGBNIR14 items.
+type lazRGBModels struct {
+	mByteUsed *arithmeticModel
+	mRGBDiff  [6]*arithmeticModel
+}
+
+func newLazRGBModels(compress bool) (m *lazRGBModels) {
+	m = &lazRGBModels{mByteUsed: newArithmeticModel(128, compress)}
+	for i := range m.mRGBDiff {
+		m.mRGBDiff[i] = newArithmeticModel(256, compress)
+	}
+	return
+}
+
+// read decodes the red, green and blue channels predicted from last.
+func (m *lazRGBModels) read(dec *arithmeticDecoder, last [3]uint16) (item [3]uint16) {
+	sym := dec.decodeSymbol(m.mByteUsed)
+	if sym&(1<<0) != 0 {
+		corr := int32(dec.decodeSymbol(m.mRGBDiff[0]))
+		item[0] = uint16(u8Fold(corr + int32(last[0]&0xFF)))
+	} else {
+		item[0] = last[0] & 0xFF
+	}
+	if sym&(1<<1) != 0 {
+		corr := int32(dec.decodeSymbol(m.mRGBDiff[1]))
+		item[0] |= uint16(u8Fold(corr+int32(last[0]>>8))) << 8
+	} else {
+		item[0] |= last[0] & 0xFF00
+	}
+	if sym&(1<<6) != 0 {
+		diff := int32(item[0]&0x00FF) - int32(last[0]&0x00FF)
+		if sym&(1<<2) != 0 {
+			corr := int32(dec.decodeSymbol(m.mRGBDiff[2]))
+			item[1] = uint16(u8Fold(corr + u8Clamp(diff+int32(last[1]&0xFF))))
+		} else {
+			item[1] = last[1] & 0xFF
+		}
+		if sym&(1<<4) != 0 {
+			corr := int32(dec.decodeSymbol(m.mRGBDiff[4]))
+			diff = (diff + (int32(item[1]&0x00FF) - int32(last[1]&0x00FF))) / 2
+			item[2] = uint16(u8Fold(corr + u8Clamp(diff+int32(last[2]&0xFF))))
+		} else {
+			item[2] = last[2] & 0xFF
+		}
+		diff = int32(item[0]>>8) - int32(last[0]>>8)
+		if sym&(1<<3) != 0 {
+			corr := int32(dec.decodeSymbol(m.mRGBDiff[3]))
+			item[1] |= uint16(u8Fold(corr+u8Clamp(diff+int32(last[1]>>8)))) << 8
+		} else {
+			item[1] |= last[1] & 0xFF00
+		}
+		if sym&(1<<5) != 0 {
+			corr := int32(dec.decodeSymbol(m.mRGBDiff[5]))
+			diff = (diff + (int32(item[1]>>8) - int32(last[1]>>8))) / 2
+			item[2] |= uint16(u8Fold(corr+u8Clamp(diff+int32(last[2]>>8)))) << 8
+		} else {
+			item[2] |= last[2] & 0xFF00
+		}
+	} else {
+		item[1] = item[0]
+		item[2] = item[0]
+	}
+	return
+}
+
+func getRGB(item []byte) (rgb [3]uint16) {
+	for i := range rgb {
+		rgb[i] = binary.LittleEndian.Uint16(item[2*i:])
+	}
+	return
+}
+
+func putRGB(item []byte, rgb [3]uint16) {
+	for i := range rgb {
+		binary.LittleEndian.PutUint16(item[2*i:], rgb[i])
+	}
+}
+
+type lazRGB12 struct {
+	lastItem [3]uint16
+	models   *lazRGBModels
+}
+
+func newLazRGB12(first []byte, compress bool) (c *lazRGB12) {
+	c = &lazRGB12{lastItem: getRGB(first), models: newLazRGBModels(compress)}
+	return
+}
+
+func (c *lazRGB12) read(dec *arithmeticDecoder, item []byte) {
+	c.lastItem = c.models.read(dec, c.lastItem)
+	putRGB(item, c.lastItem)
+}
+
+// __          __            _____           _        _     __ ____
+// \ \        / /           |  __ \         | |      | |   /_ |___ \
+//  \ \  /\  / /_ ___   ____| |__) |_ _  ___| | _____| |_   | | __) |
+//   \ \/  \/ / _` \ \ / / _ \  ___/ _` |/ __| |/ / _ \ __|  | ||__ <
+//    \  /\  / (_| |\ V /  __/ |  | (_| | (__|   <  __/ |_   | |___) |
+//     \/  \/ \__,_| \_/ \___|_|   \__,_|\___|_|\_\___|\__|  |_|____/
+//
+//
+
+// lazWavePacketModels are the models for the waveform fields shared by the WAVEPACKET13 and WAVEPACKET14 items.
+type lazWavePacketModels struct {
+	lastDiff32        int32
+	symLastOffsetDiff uint32
+	mPacketIndex      *arithmeticModel
+	mOffsetDiff       [4]*arithmeticModel
+	icOffsetDiff      *integerCompressor
+	icPacketSize      *integerCompressor
+	icReturnPoint     *integerCompressor
+	icXYZ             *integerCompressor
+}
+
+func newLazWavePacketModels(compress bool) (m *lazWavePacketModels) {
+	m = &lazWavePacketModels{
+		mPacketIndex:  newArithmeticModel(256, compress),
+		icOffsetDiff:  newDefaultIntegerCompressor(32, 1, compress),
+		icPacketSize:  newDefaultIntegerCompressor(32, 1, compress),
+		icReturnPoint: newDefaultIntegerCompressor(32, 1, compress),
+		icXYZ:         newDefaultIntegerCompressor(32, 3, compress),
+	}
+	for i := range m.mOffsetDiff {
+		m.mOffsetDiff[i] = newArithmeticModel(4, compress)
+	}
+	return
+}
+
+// read decodes the 29 bytes of a wave packet predicted from last into item.
+func (m *lazWavePacketModels) read(dec *arithmeticDecoder, last []byte, item []byte) {
+	item[0] = uint8(dec.decodeSymbol(m.mPacketIndex))
+	lastOffset := binary.LittleEndian.Uint64(last[1:9])
+	lastPacketSize := binary.LittleEndian.Uint32(last[9:13])
+	m.symLastOffsetDiff = dec.decodeSymbol(m.mOffsetDiff[m.symLastOffsetDiff])
+	var offset uint64
+	switch m.symLastOffsetDiff {
+	case 0:
+		offset = lastOffset
+	case 1:
+		offset = lastOffset + uint64(lastPacketSize)
+	case 2:
+		m.lastDiff32 = m.icOffsetDiff.decompress(dec, m.lastDiff32, 0)
+		offset = lastOffset + uint64(int64(m.lastDiff32))
+	default:
+		offset = dec.readInt64()
+	}
+	binary.LittleEndian.PutUint64(item[1:9], offset)
+	binary.LittleEndian.PutUint32(item[9:13], uint32(m.icPacketSize.decompress(dec, int32(lastPacketSize), 0)))
+	for i := 0; i < 4; i++ {
+		context := uint32(0)
+		ic := m.icReturnPoint
+		if i > 0 {
+			context = uint32(i - 1)
+			ic = m.icXYZ
+		}
+		value := ic.decompress(dec, int32(binary.LittleEndian.Uint32(last[13+4*i:])), context)
+		binary.LittleEndian.PutUint32(item[13+4*i:], uint32(value))
+	}
+}
+
+type lazWavePacket13 struct {
+	lastItem [29]byte
+	models   *lazWavePacketModels
+}
+
+func newLazWavePacket13(first []byte, compress bool) (w *lazWavePacket13) {
+	w = &lazWavePacket13{models: newLazWavePacketModels(compress)}
+	copy(w.lastItem[:], first)
+	return
+}
+
+func (w *lazWavePacket13) read(dec *arithmeticDecoder, item []byte) {
+	w.models.read(dec, w.lastItem[:], item)
+	copy(w.lastItem[:], item)
+}
+
+//  ____        _
+// |  _ \      | |
+// | |_) |_   _| |_ ___
+// |  _ <| | | | __/ _ \
+// | |_) | |_| | ||  __/
+// |____/ \__, |\__\___|
+//         __/ |
+//        |___/
+
+type lazByte struct {
+	lastItem []byte
+	mByte    []*arithmeticModel
+}
+
+func newLazByte(first []byte, compress bool) (b *lazByte) {
+	b = &lazByte{lastItem: make([]byte, len(first)), mByte: make([]*arithmeticModel, len(first))}
+	copy(b.lastItem, first)
+	for i := range b.mByte {
+		b.mByte[i] = newArithmeticModel(256, compress)
+	}
+	return
+}
+
+func (b *lazByte) read(dec *arithmeticDecoder, item []byte) {
+	for i := range b.lastItem {
+		value := int32(b.lastItem[i]) + int32(dec.decodeSymbol(b.mByte[i]))
+		item[i] = u8Fold(value)
+	}
+	copy(b.lastItem, item)
+}
@@ -0,0 +1,672 @@
+package las
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+//  _               ______  _____ _                         __ _  _
+// | |        /\   |___  / |_   _| |                       /_ | || |
+// | |       /  \     / /    | | | |_ ___ _ __ ___  ___     | | || |_
+// | |      / /\ \   / /     | | | __/ _ \ '_ ` _ \/ __|    | |__   _|
+// | |____ / ____ \ / /__   _| |_| ||  __/ | | | | \__ \    | |  | |
+// |______/_/    \_\_____| |_____|\__\___|_| |_| |_|___/    |_|  |_|
+//
+//
+
+// The items of the layered chunked compressor used for the point data record formats 6-10: POINT14, RGB14,
+// RGBNIR14, WAVEPACKET14 and BYTE14, all in version 3. Every item codes its fields into separate layers and keeps
+// one set of models per scanner channel, selected by the context the POINT14 item shares with the other items.
+
+type lazLayeredItem interface {
+	readLayerSizes(r io.Reader) error
+	readLayers(r io.Reader) error
+	init(item []byte, context *uint32)
+	read(item []byte, context *uint32)
+}
+
+// lazLayer is a separately coded stream of a layered item. A layer without bytes is unchanged within the chunk.
+type lazLayer struct {
+	size    uint32
+	changed bool
+	dec     *arithmeticDecoder
+	enc     *arithmeticEncoder
+	data    []byte
+}
+
+func (layer *lazLayer) readSize(r io.Reader) (err error) {
+	err = binary.Read(r, binary.LittleEndian, &layer.size)
+	return
+}
+
+func (layer *lazLayer) readBytes(r io.Reader) (err error) {
+	data := make([]byte, layer.size)
+	if _, err = io.ReadFull(r, data); err != nil {
+		err = fmt.Errorf("LAZ layer of %d bytes is truncated: %v", layer.size, err)
+		return
+	}
+	layer.changed = layer.size != 0
+	layer.dec = newArithmeticDecoder(data)
+	return
+}
+
+func readLayerSizes(r io.Reader, layers []*lazLayer) (err error) {
+	for _, layer := range layers {
+		if err = layer.readSize(r); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func readLayers(r io.Reader, layers []*lazLayer) (err error) {
+	for _, layer := range layers {
+		if err = layer.readBytes(r); err != nil {
+			return
+		}
+	}
+	return
+}
+
+var numberReturnMap6ctx = [16][16]uint8{
+	{0, 1, 2, 3, 4, 5, 3, 4, 4, 5, 5, 5, 5, 5, 5, 5},
+	{1, 0, 1, 3, 4, 5, 3, 4, 4, 5, 5, 5, 5, 5, 5, 5},
+	{2, 1, 2, 4, 4, 5, 4, 4, 5, 5, 5, 5, 5, 5, 5, 5},
+	{3, 3, 4, 5, 4, 5, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{4, 4, 4, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{3, 3, 4, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{4, 4, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{4, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+}
+
+// numberReturnLevel8ctx is the distance between return number r and number of returns n, capped at 7.
+func numberReturnLevel8ctx(n uint8, r uint8) uint8 {
+	level := int(n) - int(r)
+	if level < 0 {
+		level = -level
+	}
+	if level > 7 {
+		level = 7
+	}
+	return uint8(level)
+}
+
+//  _____      _       _   __ _  _
+// |  __ \    (_)     | | /_ | || |
+// | |__) |__  _ _ __ | |_ | | || |_
+// |  ___/ _ \| | '_ \| __|| |__   _|
+// | |  | (_) | | | | | |_ | |  | |
+// |_|   \___/|_|_| |_|\__||_|  |_|
+//
+//
+
+// lazPoint14Fields are the fields of a POINT14 item, which is stored like the first 30 bytes of a Format6 record.
+type lazPoint14Fields struct {
+	x                   int32
+	y                   int32
+	z                   int32
+	intensity           uint16
+	returnNumber        uint8
+	numberOfReturns     uint8
+	classificationFlags uint8
+	scannerChannel      uint8
+	scanDirectionFlag   uint8
+	edgeOfFlightLine    uint8
+	classification      uint8
+	userData            uint8
+	scanAngle           int16
+	pointSourceID       uint16
+	gpsTime             uint64
+	gpsTimeChange       bool
+}
+
+func (p *lazPoint14Fields) unpack(item []byte) {
+	p.x = int32(binary.LittleEndian.Uint32(item[0:4]))
+	p.y = int32(binary.LittleEndian.Uint32(item[4:8]))
+	p.z = int32(binary.LittleEndian.Uint32(item[8:12]))
+	p.intensity = binary.LittleEndian.Uint16(item[12:14])
+	p.returnNumber = item[14] & 0x0F
+	p.numberOfReturns = item[14] >> 4
+	p.classificationFlags = item[15] & 0x0F
+	p.scannerChannel = (item[15] >> 4) & 0x03
+	p.scanDirectionFlag = (item[15] >> 6) & 0x01
+	p.edgeOfFlightLine = item[15] >> 7
+	p.classification = item[16]
+	p.userData = item[17]
+	p.scanAngle = int16(binary.LittleEndian.Uint16(item[18:20]))
+	p.pointSourceID = binary.LittleEndian.Uint16(item[20:22])
+	p.gpsTime = binary.LittleEndian.Uint64(item[22:30])
+}
+
+func (p *lazPoint14Fields) pack(item []byte) {
+	binary.LittleEndian.PutUint32(item[0:4], uint32(p.x))
+	binary.LittleEndian.PutUint32(item[4:8], uint32(p.y))
+	binary.LittleEndian.PutUint32(item[8:12], uint32(p.z))
+	binary.LittleEndian.PutUint16(item[12:14], p.intensity)
+	item[14] = p.returnNumber | p.numberOfReturns<<4
+	item[15] = p.classificationFlags | p.scannerChannel<<4 | p.scanDirectionFlag<<6 | p.edgeOfFlightLine<<7
+	item[16] = p.classification
+	item[17] = p.userData
+	binary.LittleEndian.PutUint16(item[18:20], uint16(p.scanAngle))
+	binary.LittleEndian.PutUint16(item[20:22], p.pointSourceID)
+	binary.LittleEndian.PutUint64(item[22:30], p.gpsTime)
+}
+
+// lazPoint14Context are the models of one scanner channel.
+type lazPoint14Context struct {
+	unused               bool
+	lastItem             lazPoint14Fields
+	lastIntensity        [8]uint16
+	lastXDiffMedian5     [12]streamingMedian5
+	lastYDiffMedian5     [12]streamingMedian5
+	lastZ                [8]int32
+	mChangedValues       [8]*arithmeticModel
+	mNumberOfReturns     [16]*arithmeticModel
+	mReturnNumber        [16]*arithmeticModel
+	mReturnNumberGPSSame *arithmeticModel
+	mClassification      [64]*arithmeticModel
+	mFlags               [64]*arithmeticModel
+	mUserData            [64]*arithmeticModel
+	icDX                 *integerCompressor
+	icDY                 *integerCompressor
+	icZ                  *integerCompressor
+	icIntensity          *integerCompressor
+	icScanAngle          *integerCompressor
+	icPointSourceID      *integerCompressor
+	gpsTime              *lazGPSTime
+}
+
+type lazPoint14 struct {
+	compress              bool
+	currentContext        uint32
+	contexts              [4]lazPoint14Context
+	mScannerChannel       *arithmeticModel
+	layerChannelReturnsXY lazLayer
+	layerZ                lazLayer
+	layerClassification   lazLayer
+	layerFlags            lazLayer
+	layerIntensity        lazLayer
+	layerScanAngle        lazLayer
+	layerUserData         lazLayer
+	layerPointSource      lazLayer
+	layerGPSTime          lazLayer
+}
+
+func newLazPoint14(compress bool) (p *lazPoint14) {
+	p = &lazPoint14{compress: compress, mScannerChannel: newArithmeticModel(3, compress)}
+	return
+}
+
+func (p *lazPoint14) layers() []*lazLayer {
+	return []*lazLayer{&p.layerChannelReturnsXY, &p.layerZ, &p.layerClassification, &p.layerFlags, &p.layerIntensity,
+		&p.layerScanAngle, &p.layerUserData, &p.layerPointSource, &p.layerGPSTime}
+}
+
+func (p *lazPoint14) readLayerSizes(r io.Reader) error {
+	return readLayerSizes(r, p.layers())
+}
+
+func (p *lazPoint14) readLayers(r io.Reader) error {
+	return readLayers(r, p.layers())
+}
+
+// initContext creates the models of scanner channel context c and predicts its first point from last.
+func (p *lazPoint14) initContext(c uint32, last *lazPoint14Fields) {
+	ctx := &p.contexts[c]
+	*ctx = lazPoint14Context{
+		mReturnNumberGPSSame: newArithmeticModel(13, p.compress),
+		icDX:                 newDefaultIntegerCompressor(32, 2, p.compress),
+		icDY:                 newDefaultIntegerCompressor(32, 22, p.compress),
+		icZ:                  newDefaultIntegerCompressor(32, 20, p.compress),
+		icIntensity:          newDefaultIntegerCompressor(16, 4, p.compress),
+		icScanAngle:          newDefaultIntegerCompressor(16, 2, p.compress),
+		icPointSourceID:      newDefaultIntegerCompressor(16, 1, p.compress),
+		gpsTime:              newLazGPSTime(last.gpsTime, true, p.compress),
+	}
+	for i := range ctx.mChangedValues {
+		ctx.mChangedValues[i] = newArithmeticModel(128, p.compress)
+	}
+	for i := range ctx.lastXDiffMedian5 {
+		ctx.lastXDiffMedian5[i] = newStreamingMedian5()
+		ctx.lastYDiffMedian5[i] = newStreamingMedian5()
+	}
+	for i := range ctx.lastZ {
+		ctx.lastIntensity[i] = last.intensity
+		ctx.lastZ[i] = last.z
+	}
+	ctx.lastItem = *last
+	ctx.lastItem.gpsTimeChange = false
+}
+
+func (p *lazPoint14) init(item []byte, context *uint32) {
+	first := lazPoint14Fields{}
+	first.unpack(item)
+	for c := range p.contexts {
+		p.contexts[c].unused = true
+	}
+	p.currentContext = uint32(first.scannerChannel)
+	*context = p.currentContext
+	p.initContext(p.currentContext, &first)
+}
+
+func (p *lazPoint14) symbolModel(models []*arithmeticModel, index uint8, symbols uint32) *arithmeticModel {
+	if models[index] == nil {
+		models[index] = newArithmeticModel(symbols, p.compress)
+	}
+	return models[index]
+}
+
+func (p *lazPoint14) read(item []byte, context *uint32) {
+	dec := p.layerChannelReturnsXY.dec
+	ctx := &p.contexts[p.currentContext]
+	last := &ctx.lastItem
+
+	lpr := 0
+	if last.returnNumber == 1 {
+		lpr++
+	}
+	if last.returnNumber >= last.numberOfReturns {
+		lpr += 2
+	}
+	if last.gpsTimeChange {
+		lpr += 4
+	}
+	changedValues := dec.decodeSymbol(ctx.mChangedValues[lpr])
+
+	if changedValues&(1<<6) != 0 {
+		diff := dec.decodeSymbol(p.mScannerChannel)
+		scannerChannel := (p.currentContext + diff + 1) % 4
+		if p.contexts[scannerChannel].unused {
+			p.initContext(scannerChannel, last)
+		}
+		p.currentContext = scannerChannel
+		*context = p.currentContext
+		ctx = &p.contexts[p.currentContext]
+		last = &ctx.lastItem
+		last.scannerChannel = uint8(scannerChannel)
+	}
+
+	pointSourceChange := changedValues&(1<<5) != 0
+	gpsTimeChange := changedValues&(1<<4) != 0
+	scanAngleChange := changedValues&(1<<3) != 0
+	gpsTimeContext := 0
+	if gpsTimeChange {
+		gpsTimeContext = 1
+	}
+
+	lastN := last.numberOfReturns
+	lastR := last.returnNumber
+	n := lastN
+	if changedValues&(1<<2) != 0 {
+		n = uint8(dec.decodeSymbol(p.symbolModel(ctx.mNumberOfReturns[:], lastN, 16)))
+		last.numberOfReturns = n
+	}
+	r := lastR
+	switch changedValues & 3 {
+	case 1:
+		r = (lastR + 1) % 16
+	case 2:
+		r = (lastR + 15) % 16
+	case 3:
+		if gpsTimeChange {
+			r = uint8(dec.decodeSymbol(p.symbolModel(ctx.mReturnNumber[:], lastR, 16)))
+		} else {
+			sym := dec.decodeSymbol(ctx.mReturnNumberGPSSame)
+			r = uint8((uint32(lastR) + sym + 2) % 16)
+		}
+	}
+	last.returnNumber = r
+
+	m := int(numberReturnMap6ctx[n][r])
+	l := numberReturnLevel8ctx(n, r)
+	cpr := 0
+	if r == 1 {
+		cpr += 2
+	}
+	if r >= n {
+		cpr++
+	}
+	single := uint32(0)
+	if n == 1 {
+		single = 1
+	}
+
+	median := ctx.lastXDiffMedian5[m<<1|gpsTimeContext].get()
+	diff := ctx.icDX.decompress(dec, median, single)
+	last.x += diff
+	ctx.lastXDiffMedian5[m<<1|gpsTimeContext].add(diff)
+
+	median = ctx.lastYDiffMedian5[m<<1|gpsTimeContext].get()
+	kBits := ctx.icDX.getK()
+	diff = ctx.icDY.decompress(dec, median, single+minUint32(u32ZeroBit0(kBits), 20))
+	last.y += diff
+	ctx.lastYDiffMedian5[m<<1|gpsTimeContext].add(diff)
+
+	if p.layerZ.changed {
+		kBits = (ctx.icDX.getK() + ctx.icDY.getK()) / 2
+		last.z = ctx.icZ.decompress(p.layerZ.dec, ctx.lastZ[l], single+minUint32(u32ZeroBit0(kBits), 18))
+		ctx.lastZ[l] = last.z
+	}
+
+	if p.layerClassification.changed {
+		ccc := (last.classification & 0x1F) << 1
+		if cpr == 3 {
+			ccc++
+		}
+		last.classification = uint8(p.layerClassification.dec.decodeSymbol(p.symbolModel(ctx.mClassification[:], ccc, 256)))
+	}
+
+	if p.layerFlags.changed {
+		lastFlags := last.edgeOfFlightLine<<5 | last.scanDirectionFlag<<4 | last.classificationFlags
+		flags := p.layerFlags.dec.decodeSymbol(p.symbolModel(ctx.mFlags[:], lastFlags, 64))
+		last.edgeOfFlightLine = uint8(flags>>5) & 0x01
+		last.scanDirectionFlag = uint8(flags>>4) & 0x01
+		last.classificationFlags = uint8(flags) & 0x0F
+	}
+
+	if p.layerIntensity.changed {
+		index := cpr<<1 | gpsTimeContext
+		last.intensity = uint16(ctx.icIntensity.decompress(p.layerIntensity.dec, int32(ctx.lastIntensity[index]), uint32(cpr)))
+		ctx.lastIntensity[index] = last.intensity
+	}
+
+	if p.layerScanAngle.changed && scanAngleChange {
+		last.scanAngle = int16(ctx.icScanAngle.decompress(p.layerScanAngle.dec, int32(last.scanAngle), uint32(gpsTimeContext)))
+	}
+
+	if p.layerUserData.changed {
+		last.userData = uint8(p.layerUserData.dec.decodeSymbol(p.symbolModel(ctx.mUserData[:], last.userData/4, 256)))
+	}
+
+	if p.layerPointSource.changed && pointSourceChange {
+		last.pointSourceID = uint16(ctx.icPointSourceID.decompress(p.layerPointSource.dec, int32(last.pointSourceID), 0))
+	}
+
+	if p.layerGPSTime.changed && gpsTimeChange {
+		last.gpsTime = ctx.gpsTime.read(p.layerGPSTime.dec)
+	}
+
+	last.pack(item)
+	last.gpsTimeChange = gpsTimeChange
+}
+
+//  _____   _____ ____    __ _  _
+// |  __ \ / ____|  _ \  /_ | || |
+// | |__) | |  __| |_) |  | | || |_
+// |  _  /| | |_ |  _ <   | |__   _|
+// | | \ \| |__| | |_) |  | |  | |
+// |_|  \_\\_____|____/   |_|  |_|
+//
+//
+
+type lazRGB14Context struct {
+	unused   bool
+	lastItem [4]uint16
+	models   *lazRGBModels
+	mNIRUsed *arithmeticModel
+	mNIRDiff [2]*arithmeticModel
+}
+
+// lazRGB14 is the RGB14 item, or the RGBNIR14 item if nir is set.
+type lazRGB14 struct {
+	compress       bool
+	nir            bool
+	currentContext uint32
+	contexts       [4]lazRGB14Context
+	layerRGB       lazLayer
+	layerNIR       lazLayer
+}
+
+func newLazRGB14(nir bool, compress bool) (c *lazRGB14) {
+	c = &lazRGB14{nir: nir, compress: compress}
+	return
+}
+
+func (c *lazRGB14) layers() []*lazLayer {
+	if c.nir {
+		return []*lazLayer{&c.layerRGB, &c.layerNIR}
+	}
+	return []*lazLayer{&c.layerRGB}
+}
+
+func (c *lazRGB14) readLayerSizes(r io.Reader) error {
+	return readLayerSizes(r, c.layers())
+}
+
+func (c *lazRGB14) readLayers(r io.Reader) error {
+	return readLayers(r, c.layers())
+}
+
+func (c *lazRGB14) initContext(context uint32, last [4]uint16) {
+	ctx := &c.contexts[context]
+	*ctx = lazRGB14Context{lastItem: last, models: newLazRGBModels(c.compress), mNIRUsed: newArithmeticModel(4, c.compress)}
+	for i := range ctx.mNIRDiff {
+		ctx.mNIRDiff[i] = newArithmeticModel(256, c.compress)
+	}
+}
+
+func (c *lazRGB14) unpack(item []byte) (values [4]uint16) {
+	rgb := getRGB(item)
+	copy(values[:], rgb[:])
+	if c.nir {
+		values[3] = binary.LittleEndian.Uint16(item[6:8])
+	}
+	return
+}
+
+func (c *lazRGB14) pack(item []byte, values [4]uint16) {
+	putRGB(item, [3]uint16{values[0], values[1], values[2]})
+	if c.nir {
+		binary.LittleEndian.PutUint16(item[6:8], values[3])
+	}
+}
+
+func (c *lazRGB14) init(item []byte, context *uint32) {
+	for i := range c.contexts {
+		c.contexts[i].unused = true
+	}
+	c.currentContext = *context
+	c.initContext(c.currentContext, c.unpack(item))
+}
+
+// switchContext changes to the scanner channel context set by the POINT14 item.
+func (c *lazRGB14) switchContext(context uint32) {
+	if c.currentContext != context {
+		last := c.contexts[c.currentContext].lastItem
+		c.currentContext = context
+		if c.contexts[c.currentContext].unused {
+			c.initContext(c.currentContext, last)
+		}
+	}
+}
+
+func (c *lazRGB14) read(item []byte, context *uint32) {
+	c.switchContext(*context)
+	ctx := &c.contexts[c.currentContext]
+	if c.layerRGB.changed {
+		rgb := ctx.models.read(c.layerRGB.dec, [3]uint16{ctx.lastItem[0], ctx.lastItem[1], ctx.lastItem[2]})
+		copy(ctx.lastItem[:3], rgb[:])
+	}
+	if c.nir && c.layerNIR.changed {
+		dec := c.layerNIR.dec
+		last := ctx.lastItem[3]
+		sym := dec.decodeSymbol(ctx.mNIRUsed)
+		var nir uint16
+		if sym&(1<<0) != 0 {
+			corr := int32(dec.decodeSymbol(ctx.mNIRDiff[0]))
+			nir = uint16(u8Fold(corr + int32(last&0xFF)))
+		} else {
+			nir = last & 0xFF
+		}
+		if sym&(1<<1) != 0 {
+			corr := int32(dec.decodeSymbol(ctx.mNIRDiff[1]))
+			nir |= uint16(u8Fold(corr+int32(last>>8))) << 8
+		} else {
+			nir |= last & 0xFF00
+		}
+		ctx.lastItem[3] = nir
+	}
+	c.pack(item, ctx.lastItem)
+}
+
+// __          __            _____           _        _     __ _  _
+// \ \        / /           |  __ \         | |      | |   /_ | || |
+//  \ \  /\  / /_ ___   ____| |__) |_ _  ___| | _____| |_   | | || |_
+//   \ \/  \/ / _` \ \ / / _ \  ___/ _` |/ __| |/ / _ \ __|  | |__   _|
+//    \  /\  / (_| |\ V /  __/ |  | (_| | (__|   <  __/ |_   | |  | |
+//     \/  \/ \__,_| \_/ \___|_|   \__,_|\___|_|\_\___|\__|  |_|  |_|
+//
+//
+
+type lazWavePacket14Context struct {
+	unused   bool
+	lastItem [29]byte
+	models   *lazWavePacketModels
+}
+
+type lazWavePacket14 struct {
+	compress        bool
+	currentContext  uint32
+	contexts        [4]lazWavePacket14Context
+	layerWavePacket lazLayer
+}
+
+func newLazWavePacket14(compress bool) (w *lazWavePacket14) {
+	w = &lazWavePacket14{compress: compress}
+	return
+}
+
+func (w *lazWavePacket14) readLayerSizes(r io.Reader) error {
+	return w.layerWavePacket.readSize(r)
+}
+
+func (w *lazWavePacket14) readLayers(r io.Reader) error {
+	return w.layerWavePacket.readBytes(r)
+}
+
+func (w *lazWavePacket14) initContext(context uint32, last []byte) {
+	ctx := &w.contexts[context]
+	*ctx = lazWavePacket14Context{models: newLazWavePacketModels(w.compress)}
+	copy(ctx.lastItem[:], last)
+}
+
+func (w *lazWavePacket14) init(item []byte, context *uint32) {
+	for i := range w.contexts {
+		w.contexts[i].unused = true
+	}
+	w.currentContext = *context
+	w.initContext(w.currentContext, item)
+}
+
+func (w *lazWavePacket14) switchContext(context uint32) {
+	if w.currentContext != context {
+		last := w.contexts[w.currentContext].lastItem
+		w.currentContext = context
+		if w.contexts[w.currentContext].unused {
+			w.initContext(w.currentContext, last[:])
+		}
+	}
+}
+
+func (w *lazWavePacket14) read(item []byte, context *uint32) {
+	w.switchContext(*context)
+	ctx := &w.contexts[w.currentContext]
+	if w.layerWavePacket.changed {
+		ctx.models.read(w.layerWavePacket.dec, ctx.lastItem[:], item)
+		copy(ctx.lastItem[:], item)
+	} else {
+		copy(item, ctx.lastItem[:])
+	}
+}
+
+//  ____        _          __ _  _
+// |  _ \      | |        /_ | || |
+// | |_) |_   _| |_ ___    | | || |_
+// |  _ <| | | | __/ _ \   | |__   _|
+// | |_) | |_| | ||  __/   | |  | |
+// |____/ \__, |\__\___|   |_|  |_|
+//         __/ |
+//        |___/
+
+type lazByte14Context struct {
+	unused   bool
+	lastItem []byte
+	mBytes   []*arithmeticModel
+}
+
+type lazByte14 struct {
+	compress       bool
+	size           int
+	currentContext uint32
+	contexts       [4]lazByte14Context
+	layerBytes     []lazLayer
+}
+
+func newLazByte14(size int, compress bool) (b *lazByte14) {
+	b = &lazByte14{compress: compress, size: size, layerBytes: make([]lazLayer, size)}
+	return
+}
+
+func (b *lazByte14) layers() (layers []*lazLayer) {
+	layers = make([]*lazLayer, b.size)
+	for i := range b.layerBytes {
+		layers[i] = &b.layerBytes[i]
+	}
+	return
+}
+
+func (b *lazByte14) readLayerSizes(r io.Reader) error {
+	return readLayerSizes(r, b.layers())
+}
+
+func (b *lazByte14) readLayers(r io.Reader) error {
+	return readLayers(r, b.layers())
+}
+
+func (b *lazByte14) initContext(context uint32, last []byte) {
+	ctx := &b.contexts[context]
+	*ctx = lazByte14Context{lastItem: make([]byte, b.size), mBytes: make([]*arithmeticModel, b.size)}
+	copy(ctx.lastItem, last)
+	for i := range ctx.mBytes {
+		ctx.mBytes[i] = newArithmeticModel(256, b.compress)
+	}
+}
+
+func (b *lazByte14) init(item []byte, context *uint32) {
+	for i := range b.contexts {
+		b.contexts[i].unused = true
+	}
+	b.currentContext = *context
+	b.initContext(b.currentContext, item)
+}
+
+func (b *lazByte14) switchContext(context uint32) {
+	if b.currentContext != context {
+		last := b.contexts[b.currentContext].lastItem
+		b.currentContext = context
+		if b.contexts[b.currentContext].unused {
+			b.initContext(b.currentContext, last)
+		}
+	}
+}
+
+func (b *lazByte14) read(item []byte, context *uint32) {
+	b.switchContext(*context)
+	ctx := &b.contexts[b.currentContext]
+	for i := range ctx.lastItem {
+		if b.layerBytes[i].changed {
+			value := int32(ctx.lastItem[i]) + int32(b.layerBytes[i].dec.decodeSymbol(ctx.mBytes[i]))
+			ctx.lastItem[i] = u8Fold(value)
+		}
+		item[i] = ctx.lastItem[i]
+	}
+}
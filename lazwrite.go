@@ -0,0 +1,910 @@
+package las
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+//  _               ______  _____                                             _
+// | |        /\   |___  / / ____|                                           (_)
+// | |       /  \     / / | |     ___  _ __ ___  _ __  _ __ ___  ___ ___ _  ___  _ __
+// | |      / /\ \   / /  | |    / _ \| '_ ` _ \| '_ \| '__/ _ \/ __/ __| |/ _ \| '_ \
+// | |____ / ____ \ / /__ | |___| (_) | | | | | | |_) | | |  __/\__ \__ \ | (_) | | | |
+// |______/_/    \_\_____| \_____\___/|_| |_| |_| .__/|_|  \___||___/___/_|\___/|_| |_|
+//                                              | |
+//                                              |_|
+
+type lazPointwiseItemWriter interface {
+	write(enc *arithmeticEncoder, item []byte)
+}
+
+type lazLayeredItemWriter interface {
+	init(item []byte, context *uint32)
+	startLayers()
+	write(item []byte, context *uint32)
+	writeLayerSizes(w io.Writer) error
+	writeLayers(w io.Writer) error
+}
+
+// compressChunk compresses the uncompressed point data records raw of a chunk.
+func (z *LASzip) compressChunk(raw []byte, numberOfPoints uint64) (data []byte, err error) {
+	if numberOfPoints == 0 {
+		return
+	}
+	pointSize := z.getPointSize()
+	buffer := new(bytes.Buffer)
+	buffer.Write(raw[:pointSize])
+	if z.Compressor == LASZIP_COMPRESSOR_LAYERED_CHUNKED {
+		err = z.compressLayeredChunk(buffer, raw, numberOfPoints)
+	} else {
+		err = z.compressPointwiseChunk(buffer, raw, numberOfPoints)
+	}
+	data = buffer.Bytes()
+	return
+}
+
+func (z *LASzip) compressPointwiseChunk(buffer *bytes.Buffer, raw []byte, numberOfPoints uint64) (err error) {
+	pointSize := z.getPointSize()
+	items := make([]lazPointwiseItemWriter, len(z.Items))
+	itemOffset := uint64(0)
+	for index, item := range z.Items {
+		first := raw[itemOffset : itemOffset+uint64(item.Size)]
+		switch item.Type {
+		case LASZIP_ITEM_POINT10:
+			items[index] = newLazPoint10(first, true)
+		case LASZIP_ITEM_GPSTIME11:
+			items[index] = newLazGPSTime11(first, true)
+		case LASZIP_ITEM_RGB12:
+			items[index] = newLazRGB12(first, true)
+		case LASZIP_ITEM_WAVEPACKET13:
+			items[index] = newLazWavePacket13(first, true)
+		case LASZIP_ITEM_BYTE:
+			items[index] = newLazByte(first, true)
+		default:
+			err = fmt.Errorf("LASzip item of type %d cannot be used with pointwise compression", item.Type)
+			return
+		}
+		itemOffset += uint64(item.Size)
+	}
+
+	enc := newArithmeticEncoder()
+	for point := uint64(1); point < numberOfPoints; point++ {
+		itemOffset = point * pointSize
+		for index, item := range z.Items {
+			items[index].write(enc, raw[itemOffset:itemOffset+uint64(item.Size)])
+			itemOffset += uint64(item.Size)
+		}
+	}
+	buffer.Write(enc.done())
+	return
+}
+
+func (z *LASzip) compressLayeredChunk(buffer *bytes.Buffer, raw []byte, numberOfPoints uint64) (err error) {
+	pointSize := z.getPointSize()
+	items := make([]lazLayeredItemWriter, len(z.Items))
+	for index, item := range z.Items {
+		switch item.Type {
+		case LASZIP_ITEM_POINT14:
+			items[index] = newLazPoint14(true)
+		case LASZIP_ITEM_RGB14:
+			items[index] = newLazRGB14(false, true)
+		case LASZIP_ITEM_RGBNIR14:
+			items[index] = newLazRGB14(true, true)
+		case LASZIP_ITEM_WAVEPACKET14:
+			items[index] = newLazWavePacket14(true)
+		case LASZIP_ITEM_BYTE14:
+			items[index] = newLazByte14(int(item.Size), true)
+		default:
+			err = fmt.Errorf("LASzip item of type %d cannot be used with layered compression", item.Type)
+			return
+		}
+	}
+
+	context := uint32(0)
+	itemOffset := uint64(0)
+	for index, item := range z.Items {
+		items[index].startLayers()
+		items[index].init(raw[itemOffset:itemOffset+uint64(item.Size)], &context)
+		itemOffset += uint64(item.Size)
+	}
+	for point := uint64(1); point < numberOfPoints; point++ {
+		itemOffset = point * pointSize
+		for index, item := range z.Items {
+			items[index].write(raw[itemOffset:itemOffset+uint64(item.Size)], &context)
+			itemOffset += uint64(item.Size)
+		}
+	}
+
+	if err = binary.Write(buffer, binary.LittleEndian, uint32(numberOfPoints)); err != nil {
+		return
+	}
+	for _, item := range items {
+		if err = item.writeLayerSizes(buffer); err != nil {
+			return
+		}
+	}
+	for _, item := range items {
+		if err = item.writeLayers(buffer); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func u8Diff(a uint8, b uint8) uint32 {
+	return uint32(a - b)
+}
+
+func i32Quantize(n float32) int32 {
+	if n >= 0 {
+		return int32(n + 0.5)
+	}
+	return int32(n - 0.5)
+}
+
+func boolToUint32(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *lazPoint10) write(enc *arithmeticEncoder, item []byte) {
+	last := p.lastItem[:]
+	r := item[14] & 0x07
+	n := (item[14] >> 3) & 0x07
+	m := numberReturnMap[n][r]
+	l := numberReturnLevel[n][r]
+	intensity := binary.LittleEndian.Uint16(item[12:14])
+
+	changedValues := boolToUint32(last[14] != item[14])<<5 |
+		boolToUint32(p.lastIntensity[m] != intensity)<<4 |
+		boolToUint32(last[15] != item[15])<<3 |
+		boolToUint32(last[16] != item[16])<<2 |
+		boolToUint32(last[17] != item[17])<<1 |
+		boolToUint32(last[18] != item[18] || last[19] != item[19])
+	enc.encodeSymbol(p.mChangedValues, changedValues)
+	if changedValues&32 != 0 {
+		enc.encodeSymbol(p.symbolModel(&p.mBitByte, last[14]), uint32(item[14]))
+	}
+	if changedValues&16 != 0 {
+		p.icIntensity.compressValue(enc, int32(p.lastIntensity[m]), int32(intensity), uint32(minUint8(m, 3)))
+		p.lastIntensity[m] = intensity
+	}
+	if changedValues&8 != 0 {
+		enc.encodeSymbol(p.symbolModel(&p.mClassification, last[15]), uint32(item[15]))
+	}
+	if changedValues&4 != 0 {
+		enc.encodeSymbol(p.mScanAngleRank[(item[14]>>6)&0x01], u8Diff(item[16], last[16]))
+	}
+	if changedValues&2 != 0 {
+		enc.encodeSymbol(p.symbolModel(&p.mUserData, last[17]), uint32(item[17]))
+	}
+	if changedValues&1 != 0 {
+		p.icPointSourceID.compressValue(enc, int32(binary.LittleEndian.Uint16(last[18:20])), int32(binary.LittleEndian.Uint16(item[18:20])), 0)
+	}
+
+	single := boolToUint32(n == 1)
+	median := p.lastXDiffMedian5[m].get()
+	diff := int32(binary.LittleEndian.Uint32(item[0:4]) - binary.LittleEndian.Uint32(last[0:4]))
+	p.icDX.compressValue(enc, median, diff, single)
+	p.lastXDiffMedian5[m].add(diff)
+
+	kBits := p.icDX.getK()
+	median = p.lastYDiffMedian5[m].get()
+	diff = int32(binary.LittleEndian.Uint32(item[4:8]) - binary.LittleEndian.Uint32(last[4:8]))
+	p.icDY.compressValue(enc, median, diff, single+minUint32(u32ZeroBit0(kBits), 20))
+	p.lastYDiffMedian5[m].add(diff)
+
+	kBits = (p.icDX.getK() + p.icDY.getK()) / 2
+	z := int32(binary.LittleEndian.Uint32(item[8:12]))
+	p.icZ.compressValue(enc, p.lastHeight[l], z, single+minUint32(u32ZeroBit0(kBits), 18))
+	p.lastHeight[l] = z
+
+	copy(last, item)
+}
+
+func (g *lazGPSTime) write(enc *arithmeticEncoder, gpsTime uint64) {
+	this := int64(gpsTime)
+	for {
+		if g.lastGPSTimeDiff[g.last] == 0 {
+			if !g.version3 && this == g.lastGPSTime[g.last] {
+				enc.encodeSymbol(g.mGPSTime0Diff, 0)
+				return
+			}
+			diff64 := this - g.lastGPSTime[g.last]
+			diff := int32(diff64)
+			if diff64 == int64(diff) {
+				enc.encodeSymbol(g.mGPSTime0Diff, g.zeroDiffOffset())
+				g.icGPSTime.compressValue(enc, 0, diff, 0)
+				g.lastGPSTimeDiff[g.last] = diff
+				g.multiExtremeCounter[g.last] = 0
+			} else if other := g.otherSequence(this); other != 0 {
+				enc.encodeSymbol(g.mGPSTime0Diff, g.zeroDiffOffset()+other+1)
+				g.last = (g.last + other) & 3
+				continue
+			} else {
+				enc.encodeSymbol(g.mGPSTime0Diff, g.zeroDiffOffset()+1)
+				g.writeFull(enc, this)
+			}
+		} else {
+			if !g.version3 && this == g.lastGPSTime[g.last] {
+				enc.encodeSymbol(g.mGPSTimeMulti, g.unchanged())
+				return
+			}
+			diff64 := this - g.lastGPSTime[g.last]
+			diff := int32(diff64)
+			if diff64 == int64(diff) {
+				lastDiff := g.lastGPSTimeDiff[g.last]
+				multi := i32Quantize(float32(diff) / float32(lastDiff))
+				if multi == 1 {
+					enc.encodeSymbol(g.mGPSTimeMulti, 1)
+					g.icGPSTime.compressValue(enc, lastDiff, diff, 1)
+					g.multiExtremeCounter[g.last] = 0
+				} else if multi > 0 {
+					if multi < LASZIP_GPSTIME_MULTI {
+						enc.encodeSymbol(g.mGPSTimeMulti, uint32(multi))
+						context := uint32(3)
+						if multi < 10 {
+							context = 2
+						}
+						g.icGPSTime.compressValue(enc, multi*lastDiff, diff, context)
+					} else {
+						enc.encodeSymbol(g.mGPSTimeMulti, LASZIP_GPSTIME_MULTI)
+						g.icGPSTime.compressValue(enc, LASZIP_GPSTIME_MULTI*lastDiff, diff, 4)
+						g.countExtreme(diff)
+					}
+				} else if multi < 0 {
+					if multi > LASZIP_GPSTIME_MULTI_MINUS {
+						enc.encodeSymbol(g.mGPSTimeMulti, uint32(LASZIP_GPSTIME_MULTI-multi))
+						g.icGPSTime.compressValue(enc, multi*lastDiff, diff, 5)
+					} else {
+						enc.encodeSymbol(g.mGPSTimeMulti, LASZIP_GPSTIME_MULTI-LASZIP_GPSTIME_MULTI_MINUS)
+						g.icGPSTime.compressValue(enc, LASZIP_GPSTIME_MULTI_MINUS*lastDiff, diff, 6)
+						g.countExtreme(diff)
+					}
+				} else {
+					enc.encodeSymbol(g.mGPSTimeMulti, 0)
+					g.icGPSTime.compressValue(enc, 0, diff, 7)
+					g.countExtreme(diff)
+				}
+			} else if other := g.otherSequence(this); other != 0 {
+				enc.encodeSymbol(g.mGPSTimeMulti, g.codeFull()+other)
+				g.last = (g.last + other) & 3
+				continue
+			} else {
+				enc.encodeSymbol(g.mGPSTimeMulti, g.codeFull())
+				g.writeFull(enc, this)
+			}
+		}
+		g.lastGPSTime[g.last] = this
+		return
+	}
+}
+
+// otherSequence returns by how many sequences ahead of the current one a sequence lies whose last GPS time differs
+// from gpsTime by a 32 bit integer, or 0 if there is none.
+func (g *lazGPSTime) otherSequence(gpsTime int64) uint32 {
+	for i := uint32(1); i < 4; i++ {
+		diff64 := gpsTime - g.lastGPSTime[(g.last+i)&3]
+		if diff64 == int64(int32(diff64)) {
+			return i
+		}
+	}
+	return 0
+}
+
+func (g *lazGPSTime) writeFull(enc *arithmeticEncoder, gpsTime int64) {
+	g.icGPSTime.compressValue(enc, int32(uint64(g.lastGPSTime[g.last])>>32), int32(uint64(gpsTime)>>32), 8)
+	enc.writeInt(uint32(gpsTime))
+	g.next = (g.next + 1) & 3
+	g.last = g.next
+	g.lastGPSTimeDiff[g.last] = 0
+	g.multiExtremeCounter[g.last] = 0
+}
+
+func (g *lazGPSTime11) write(enc *arithmeticEncoder, item []byte) {
+	g.gpsTime.write(enc, binary.LittleEndian.Uint64(item))
+}
+
+// write encodes the red, green and blue channels predicted from last.
+func (m *lazRGBModels) write(enc *arithmeticEncoder, last [3]uint16, item [3]uint16) {
+	sym := boolToUint32(last[0]&0x00FF != item[0]&0x00FF)<<0 |
+		boolToUint32(last[0]&0xFF00 != item[0]&0xFF00)<<1 |
+		boolToUint32(last[1]&0x00FF != item[1]&0x00FF)<<2 |
+		boolToUint32(last[1]&0xFF00 != item[1]&0xFF00)<<3 |
+		boolToUint32(last[2]&0x00FF != item[2]&0x00FF)<<4 |
+		boolToUint32(last[2]&0xFF00 != item[2]&0xFF00)<<5 |
+		boolToUint32(item[0]&0x00FF != item[1]&0x00FF || item[0]&0x00FF != item[2]&0x00FF ||
+			item[0]&0xFF00 != item[1]&0xFF00 || item[0]&0xFF00 != item[2]&0xFF00)<<6
+	enc.encodeSymbol(m.mByteUsed, sym)
+	var diffL, diffH int32
+	if sym&(1<<0) != 0 {
+		diffL = int32(item[0]&0xFF) - int32(last[0]&0xFF)
+		enc.encodeSymbol(m.mRGBDiff[0], uint32(u8Fold(diffL)))
+	}
+	if sym&(1<<1) != 0 {
+		diffH = int32(item[0]>>8) - int32(last[0]>>8)
+		enc.encodeSymbol(m.mRGBDiff[1], uint32(u8Fold(diffH)))
+	}
+	if sym&(1<<6) != 0 {
+		if sym&(1<<2) != 0 {
+			corr := int32(item[1]&0xFF) - u8Clamp(diffL+int32(last[1]&0xFF))
+			enc.encodeSymbol(m.mRGBDiff[2], uint32(u8Fold(corr)))
+		}
+		if sym&(1<<4) != 0 {
+			diffL = (diffL + int32(item[1]&0xFF) - int32(last[1]&0xFF)) / 2
+			corr := int32(item[2]&0xFF) - u8Clamp(diffL+int32(last[2]&0xFF))
+			enc.encodeSymbol(m.mRGBDiff[4], uint32(u8Fold(corr)))
+		}
+		if sym&(1<<3) != 0 {
+			corr := int32(item[1]>>8) - u8Clamp(diffH+int32(last[1]>>8))
+			enc.encodeSymbol(m.mRGBDiff[3], uint32(u8Fold(corr)))
+		}
+		if sym&(1<<5) != 0 {
+			diffH = (diffH + int32(item[1]>>8) - int32(last[1]>>8)) / 2
+			corr := int32(item[2]>>8) - u8Clamp(diffH+int32(last[2]>>8))
+			enc.encodeSymbol(m.mRGBDiff[5], uint32(u8Fold(corr)))
+		}
+	}
+}
+
+func (c *lazRGB12) write(enc *arithmeticEncoder, item []byte) {
+	rgb := getRGB(item)
+	c.models.write(enc, c.lastItem, rgb)
+	c.lastItem = rgb
+}
+
+// write encodes the 29 bytes of a wave packet predicted from last.
+func (m *lazWavePacketModels) write(enc *arithmeticEncoder, last []byte, item []byte) {
+	enc.encodeSymbol(m.mPacketIndex, uint32(item[0]))
+	lastOffset := binary.LittleEndian.Uint64(last[1:9])
+	lastPacketSize := binary.LittleEndian.Uint32(last[9:13])
+	offset := binary.LittleEndian.Uint64(item[1:9])
+	diff64 := int64(offset - lastOffset)
+	diff := int32(diff64)
+	if diff64 == int64(diff) {
+		sym := uint32(2)
+		if diff == 0 {
+			sym = 0
+		} else if diff == int32(lastPacketSize) {
+			sym = 1
+		}
+		enc.encodeSymbol(m.mOffsetDiff[m.symLastOffsetDiff], sym)
+		if sym == 2 {
+			m.icOffsetDiff.compressValue(enc, m.lastDiff32, diff, 0)
+			m.lastDiff32 = diff
+		}
+		m.symLastOffsetDiff = sym
+	} else {
+		enc.encodeSymbol(m.mOffsetDiff[m.symLastOffsetDiff], 3)
+		enc.writeInt64(offset)
+		m.symLastOffsetDiff = 3
+	}
+	m.icPacketSize.compressValue(enc, int32(lastPacketSize), int32(binary.LittleEndian.Uint32(item[9:13])), 0)
+	for i := 0; i < 4; i++ {
+		context := uint32(0)
+		ic := m.icReturnPoint
+		if i > 0 {
+			context = uint32(i - 1)
+			ic = m.icXYZ
+		}
+		ic.compressValue(enc, int32(binary.LittleEndian.Uint32(last[13+4*i:])), int32(binary.LittleEndian.Uint32(item[13+4*i:])), context)
+	}
+}
+
+func (w *lazWavePacket13) write(enc *arithmeticEncoder, item []byte) {
+	w.models.write(enc, w.lastItem[:], item)
+	copy(w.lastItem[:], item)
+}
+
+func (b *lazByte) write(enc *arithmeticEncoder, item []byte) {
+	for i := range b.lastItem {
+		enc.encodeSymbol(b.mByte[i], u8Diff(item[i], b.lastItem[i]))
+	}
+	copy(b.lastItem, item)
+}
+
+//  _                                   _
+// | |                                 | |
+// | |     __ _ _   _  ___ _ __ ___  __| |
+// | |    / _` | | | |/ _ \ '__/ _ \/ _` |
+// | |___| (_| | |_| |  __/ | |  __/ (_| |
+// |______\__,_|\__, |\___|_|  \___|\__,_|
+//               __/ |
+//              |___/
+
+func (layer *lazLayer) startEncoding() {
+	layer.enc = newArithmeticEncoder()
+	layer.changed = false
+	layer.data = nil
+}
+
+// finishEncoding completes the layer. The bytes of a layer which is unchanged within the chunk are dropped.
+func (layer *lazLayer) finishEncoding() {
+	layer.data = layer.enc.done()
+	if !layer.changed {
+		layer.data = nil
+	}
+}
+
+func writeLayerSizes(w io.Writer, layers []*lazLayer) (err error) {
+	for _, layer := range layers {
+		layer.finishEncoding()
+		if err = binary.Write(w, binary.LittleEndian, uint32(len(layer.data))); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func writeLayers(w io.Writer, layers []*lazLayer) (err error) {
+	for _, layer := range layers {
+		if _, err = w.Write(layer.data); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func startLayers(layers []*lazLayer) {
+	for _, layer := range layers {
+		layer.startEncoding()
+	}
+}
+
+func (p *lazPoint14) startLayers() {
+	startLayers(p.layers())
+	// the layer holding the return numbers and the X and Y coordinates is always stored
+	p.layerChannelReturnsXY.changed = true
+}
+
+func (p *lazPoint14) writeLayerSizes(w io.Writer) error {
+	return writeLayerSizes(w, p.layers())
+}
+
+func (p *lazPoint14) writeLayers(w io.Writer) error {
+	return writeLayers(w, p.layers())
+}
+
+func (p *lazPoint14) write(item []byte, context *uint32) {
+	enc := p.layerChannelReturnsXY.enc
+	ctx := &p.contexts[p.currentContext]
+	last := &ctx.lastItem
+	this := lazPoint14Fields{}
+	this.unpack(item)
+
+	lpr := uint32(0)
+	if last.returnNumber == 1 {
+		lpr++
+	}
+	if last.returnNumber >= last.numberOfReturns {
+		lpr += 2
+	}
+	if last.gpsTimeChange {
+		lpr += 4
+	}
+
+	scannerChannel := uint32(this.scannerChannel)
+	if scannerChannel != p.currentContext && !p.contexts[scannerChannel].unused {
+		last = &p.contexts[scannerChannel].lastItem
+	}
+
+	pointSourceChange := this.pointSourceID != last.pointSourceID
+	gpsTimeChange := this.gpsTime != last.gpsTime
+	scanAngleChange := this.scanAngle != last.scanAngle
+	gpsTimeContext := int(boolToUint32(gpsTimeChange))
+
+	lastN := last.numberOfReturns
+	lastR := last.returnNumber
+	n := this.numberOfReturns
+	r := this.returnNumber
+
+	changedValues := boolToUint32(scannerChannel != p.currentContext)<<6 |
+		boolToUint32(pointSourceChange)<<5 |
+		boolToUint32(gpsTimeChange)<<4 |
+		boolToUint32(scanAngleChange)<<3 |
+		boolToUint32(n != lastN)<<2
+	if r != lastR {
+		if r == (lastR+1)%16 {
+			changedValues |= 1
+		} else if r == (lastR+15)%16 {
+			changedValues |= 2
+		} else {
+			changedValues |= 3
+		}
+	}
+	enc.encodeSymbol(ctx.mChangedValues[lpr], changedValues)
+
+	if changedValues&(1<<6) != 0 {
+		enc.encodeSymbol(p.mScannerChannel, (scannerChannel-p.currentContext+4-1)%4)
+		if p.contexts[scannerChannel].unused {
+			p.initContext(scannerChannel, last)
+			last = &p.contexts[scannerChannel].lastItem
+		}
+		p.currentContext = scannerChannel
+	}
+	*context = p.currentContext
+	ctx = &p.contexts[p.currentContext]
+
+	if changedValues&(1<<2) != 0 {
+		enc.encodeSymbol(p.symbolModel(ctx.mNumberOfReturns[:], lastN, 16), uint32(n))
+	}
+	if changedValues&3 == 3 {
+		if gpsTimeChange {
+			enc.encodeSymbol(p.symbolModel(ctx.mReturnNumber[:], lastR, 16), uint32(r))
+		} else {
+			enc.encodeSymbol(ctx.mReturnNumberGPSSame, (uint32(r)-uint32(lastR)+16-2)%16)
+		}
+	}
+
+	m := int(numberReturnMap6ctx[n][r])
+	l := numberReturnLevel8ctx(n, r)
+	cpr := 0
+	if r == 1 {
+		cpr += 2
+	}
+	if r >= n {
+		cpr++
+	}
+	single := boolToUint32(n == 1)
+
+	median := ctx.lastXDiffMedian5[m<<1|gpsTimeContext].get()
+	diff := this.x - last.x
+	ctx.icDX.compressValue(enc, median, diff, single)
+	ctx.lastXDiffMedian5[m<<1|gpsTimeContext].add(diff)
+
+	kBits := ctx.icDX.getK()
+	median = ctx.lastYDiffMedian5[m<<1|gpsTimeContext].get()
+	diff = this.y - last.y
+	ctx.icDY.compressValue(enc, median, diff, single+minUint32(u32ZeroBit0(kBits), 20))
+	ctx.lastYDiffMedian5[m<<1|gpsTimeContext].add(diff)
+
+	kBits = (ctx.icDX.getK() + ctx.icDY.getK()) / 2
+	ctx.icZ.compressValue(p.layerZ.enc, ctx.lastZ[l], this.z, single+minUint32(u32ZeroBit0(kBits), 18))
+	ctx.lastZ[l] = this.z
+	p.layerZ.changed = p.layerZ.changed || this.z != last.z
+
+	ccc := (last.classification & 0x1F) << 1
+	if cpr == 3 {
+		ccc++
+	}
+	p.layerClassification.enc.encodeSymbol(p.symbolModel(ctx.mClassification[:], ccc, 256), uint32(this.classification))
+	p.layerClassification.changed = p.layerClassification.changed || this.classification != last.classification
+
+	lastFlags := last.edgeOfFlightLine<<5 | last.scanDirectionFlag<<4 | last.classificationFlags
+	flags := this.edgeOfFlightLine<<5 | this.scanDirectionFlag<<4 | this.classificationFlags
+	p.layerFlags.enc.encodeSymbol(p.symbolModel(ctx.mFlags[:], lastFlags, 64), uint32(flags))
+	p.layerFlags.changed = p.layerFlags.changed || flags != lastFlags
+
+	index := cpr<<1 | gpsTimeContext
+	ctx.icIntensity.compressValue(p.layerIntensity.enc, int32(ctx.lastIntensity[index]), int32(this.intensity), uint32(cpr))
+	ctx.lastIntensity[index] = this.intensity
+	p.layerIntensity.changed = p.layerIntensity.changed || this.intensity != last.intensity
+
+	if scanAngleChange {
+		ctx.icScanAngle.compressValue(p.layerScanAngle.enc, int32(last.scanAngle), int32(this.scanAngle), uint32(gpsTimeContext))
+		p.layerScanAngle.changed = true
+	}
+
+	p.layerUserData.enc.encodeSymbol(p.symbolModel(ctx.mUserData[:], last.userData/4, 256), uint32(this.userData))
+	p.layerUserData.changed = p.layerUserData.changed || this.userData != last.userData
+
+	if pointSourceChange {
+		ctx.icPointSourceID.compressValue(p.layerPointSource.enc, int32(last.pointSourceID), int32(this.pointSourceID), 0)
+		p.layerPointSource.changed = true
+	}
+
+	if gpsTimeChange {
+		ctx.gpsTime.write(p.layerGPSTime.enc, this.gpsTime)
+		p.layerGPSTime.changed = true
+	}
+
+	*last = this
+	last.gpsTimeChange = gpsTimeChange
+}
+
+func (c *lazRGB14) startLayers() {
+	startLayers(c.layers())
+}
+
+func (c *lazRGB14) writeLayerSizes(w io.Writer) error {
+	return writeLayerSizes(w, c.layers())
+}
+
+func (c *lazRGB14) writeLayers(w io.Writer) error {
+	return writeLayers(w, c.layers())
+}
+
+func (c *lazRGB14) write(item []byte, context *uint32) {
+	c.switchContext(*context)
+	ctx := &c.contexts[c.currentContext]
+	this := c.unpack(item)
+	last := ctx.lastItem
+	ctx.models.write(c.layerRGB.enc, [3]uint16{last[0], last[1], last[2]}, [3]uint16{this[0], this[1], this[2]})
+	c.layerRGB.changed = c.layerRGB.changed || this[0] != last[0] || this[1] != last[1] || this[2] != last[2]
+	if c.nir {
+		enc := c.layerNIR.enc
+		sym := boolToUint32(last[3]&0x00FF != this[3]&0x00FF) | boolToUint32(last[3]&0xFF00 != this[3]&0xFF00)<<1
+		enc.encodeSymbol(ctx.mNIRUsed, sym)
+		if sym&(1<<0) != 0 {
+			enc.encodeSymbol(ctx.mNIRDiff[0], uint32(u8Fold(int32(this[3]&0xFF)-int32(last[3]&0xFF))))
+		}
+		if sym&(1<<1) != 0 {
+			enc.encodeSymbol(ctx.mNIRDiff[1], uint32(u8Fold(int32(this[3]>>8)-int32(last[3]>>8))))
+		}
+		c.layerNIR.changed = c.layerNIR.changed || sym != 0
+	}
+	ctx.lastItem = this
+}
+
+func (w *lazWavePacket14) startLayers() {
+	w.layerWavePacket.startEncoding()
+}
+
+func (w *lazWavePacket14) writeLayerSizes(wr io.Writer) error {
+	return writeLayerSizes(wr, []*lazLayer{&w.layerWavePacket})
+}
+
+func (w *lazWavePacket14) writeLayers(wr io.Writer) error {
+	return writeLayers(wr, []*lazLayer{&w.layerWavePacket})
+}
+
+func (w *lazWavePacket14) write(item []byte, context *uint32) {
+	w.switchContext(*context)
+	ctx := &w.contexts[w.currentContext]
+	ctx.models.write(w.layerWavePacket.enc, ctx.lastItem[:], item)
+	for i := range ctx.lastItem {
+		w.layerWavePacket.changed = w.layerWavePacket.changed || ctx.lastItem[i] != item[i]
+	}
+	copy(ctx.lastItem[:], item)
+}
+
+func (b *lazByte14) startLayers() {
+	startLayers(b.layers())
+}
+
+func (b *lazByte14) writeLayerSizes(w io.Writer) error {
+	return writeLayerSizes(w, b.layers())
+}
+
+func (b *lazByte14) writeLayers(w io.Writer) error {
+	return writeLayers(w, b.layers())
+}
+
+func (b *lazByte14) write(item []byte, context *uint32) {
+	b.switchContext(*context)
+	ctx := &b.contexts[b.currentContext]
+	for i := range ctx.lastItem {
+		b.layerBytes[i].enc.encodeSymbol(ctx.mBytes[i], u8Diff(item[i], ctx.lastItem[i]))
+		b.layerBytes[i].changed = b.layerBytes[i].changed || item[i] != ctx.lastItem[i]
+		ctx.lastItem[i] = item[i]
+	}
+}
+
+//  _               ______  __          __   _ _
+// | |        /\   |___  /  \ \        / /  (_) |
+// | |       /  \     / /    \ \  /\  / / __ _| |_ ___ _ __
+// | |      / /\ \   / /      \ \/  \/ / '__| | __/ _ \ '__|
+// | |____ / ____ \ / /__      \  /\  /| |  | | ||  __/ |
+// |______/_/    \_\_____|      \/  \/ |_|  |_|\__\___|_|
+//
+//
+
+// newLASzip returns the LASzip record for compressing the point data record format with extraBytes bytes following
+// the standard fields of each record.
+func newLASzip(format uint8, extraBytes uint16) (z *LASzip, err error) {
+	z = &LASzip{
+		Compressor:           LASZIP_COMPRESSOR_POINTWISE_CHUNKED,
+		Coder:                LASZIP_CODER_ARITHMETIC,
+		VersionMajor:         3,
+		VersionMinor:         4,
+		VersionRevision:      3,
+		ChunkSize:            LASZIP_CHUNK_SIZE_DEFAULT,
+		NumberOfSpecialEVLRs: -1,
+		OffsetToSpecialEVLRs: -1,
+	}
+	addItem := func(itemType uint16, size uint16, version uint16) {
+		z.Items = append(z.Items, LASzipItem{Type: itemType, Size: size, Version: version})
+	}
+	switch format {
+	case 0, 1, 2, 3, 4, 5:
+		addItem(LASZIP_ITEM_POINT10, 20, 2)
+		if format == 1 || format >= 3 {
+			addItem(LASZIP_ITEM_GPSTIME11, 8, 2)
+		}
+		if format == 2 || format == 3 || format == 5 {
+			addItem(LASZIP_ITEM_RGB12, 6, 2)
+		}
+		if format == 4 || format == 5 {
+			addItem(LASZIP_ITEM_WAVEPACKET13, 29, 1)
+		}
+		if extraBytes != 0 {
+			addItem(LASZIP_ITEM_BYTE, extraBytes, 2)
+		}
+	case 6, 7, 8, 9, 10:
+		z.Compressor = LASZIP_COMPRESSOR_LAYERED_CHUNKED
+		addItem(LASZIP_ITEM_POINT14, 30, 3)
+		if format == 7 {
+			addItem(LASZIP_ITEM_RGB14, 6, 3)
+		}
+		if format == 8 || format == 10 {
+			addItem(LASZIP_ITEM_RGBNIR14, 8, 3)
+		}
+		if format == 9 || format == 10 {
+			addItem(LASZIP_ITEM_WAVEPACKET14, 29, 3)
+		}
+		if extraBytes != 0 {
+			addItem(LASZIP_ITEM_BYTE14, extraBytes, 3)
+		}
+	default:
+		err = fmt.Errorf("point data record format %d cannot be compressed", format)
+		return
+	}
+	z.NumberOfItems = uint16(len(z.Items))
+	return
+}
+
+func (z *LASzip) write() (record []byte, err error) {
+	header := lasZipHeader{
+		Compressor:           z.Compressor,
+		Coder:                z.Coder,
+		VersionMajor:         z.VersionMajor,
+		VersionMinor:         z.VersionMinor,
+		VersionRevision:      z.VersionRevision,
+		Options:              z.Options,
+		ChunkSize:            z.ChunkSize,
+		NumberOfSpecialEVLRs: z.NumberOfSpecialEVLRs,
+		OffsetToSpecialEVLRs: z.OffsetToSpecialEVLRs,
+		NumberOfItems:        uint16(len(z.Items)),
+	}
+	buffer := new(bytes.Buffer)
+	if err = binary.Write(buffer, binary.LittleEndian, &header); err != nil {
+		return
+	}
+	if err = binary.Write(buffer, binary.LittleEndian, z.Items); err != nil {
+		return
+	}
+	record = buffer.Bytes()
+	return
+}
+
+// getVLR returns the VLR holding the LASzip record.
+func (z *LASzip) getVLR() (vlr VLR, err error) {
+	payload, err := z.write()
+	if err != nil {
+		return
+	}
+	vlr = VLR{record: []CRS{z}, payload: payload}
+	copy(vlr.header.UserID[:], LASZIP_USER_ID)
+	vlr.header.RecordID = LASZIP_RECORD_ID
+	copy(vlr.header.Description[:], "by laszip of www.laszip.org")
+	vlr.header.RecordLengthAfterHeader = uint16(len(payload))
+	return
+}
+
+// writeChunkTable writes the chunk table following the compressed chunks.
+func (z *LASzip) writeChunkTable(w io.Writer, chunks []lazChunk) (err error) {
+	if err = binary.Write(w, binary.LittleEndian, [2]uint32{0, uint32(len(chunks))}); err != nil {
+		return
+	}
+	enc := newArithmeticEncoder()
+	ic := newDefaultIntegerCompressor(32, 2, true)
+	var lastSize, lastPoints int32
+	for _, chunk := range chunks {
+		if z.ChunkSize == LASZIP_CHUNK_SIZE_VARIABLE {
+			ic.compressValue(enc, lastPoints, int32(chunk.numberOfPoints), 0)
+			lastPoints = int32(chunk.numberOfPoints)
+		}
+		ic.compressValue(enc, lastSize, int32(chunk.size), 1)
+		lastSize = int32(chunk.size)
+	}
+	_, err = w.Write(enc.done())
+	return
+}
+
+// WriteLaz serializes l to a new LAZ file, compressing the point data records with LASzip. See WriteTo.
+func (l *Las) WriteLaz(filename string) (err error) {
+	l.Header.PointDataRecordFormat |= LASZIP_FORMAT_COMPRESSED
+	return l.Write(filename)
+}
+
+// writeLazTo serializes l to w like WriteTo, but compresses the point data records. The LASzip VLR describing the
+// compression is added to the VLRs of l, replacing any previous one.
+func (l *Las) writeLazTo(w io.Writer) (n int64, err error) {
+	format := l.Header.PointDataRecordFormat & LASZIP_FORMAT_MASK
+	l.Header.PointDataRecordFormat = format
+	defer func() {
+		l.Header.PointDataRecordFormat |= LASZIP_FORMAT_COMPRESSED
+	}()
+
+	standardSize, err := getPointDataRecordSize(format)
+	if err != nil {
+		return
+	}
+	if l.Header.PointDataRecordLength < standardSize {
+		l.Header.PointDataRecordLength = standardSize
+	}
+	lasZip, err := newLASzip(format, l.Header.PointDataRecordLength-standardSize)
+	if err != nil {
+		return
+	}
+	vlr, err := lasZip.getVLR()
+	if err != nil {
+		return
+	}
+	l.removeLASzipVLRs()
+	l.Vlrs = append(l.Vlrs, vlr)
+	if err = l.updateLayout(); err != nil {
+		return
+	}
+
+	dataLength := uint64(l.Header.PointDataRecordLength)
+	raw := new(bytes.Buffer)
+	if l.Pdrs != nil {
+		if err = l.Pdrs.write(raw, dataLength); err != nil {
+			return
+		}
+	}
+	numberOfPDRs := uint64(raw.Len()) / dataLength
+	pointData := new(bytes.Buffer)
+	var chunks []lazChunk
+	for first := uint64(0); first < numberOfPDRs; first += uint64(lasZip.ChunkSize) {
+		chunk := lazChunk{numberOfPoints: numberOfPDRs - first}
+		if chunk.numberOfPoints > uint64(lasZip.ChunkSize) {
+			chunk.numberOfPoints = uint64(lasZip.ChunkSize)
+		}
+		var data []byte
+		if data, err = lasZip.compressChunk(raw.Bytes()[first*dataLength:], chunk.numberOfPoints); err != nil {
+			return
+		}
+		chunk.size = uint64(len(data))
+		chunks = append(chunks, chunk)
+		pointData.Write(data)
+	}
+	chunkTableOffset := int64(l.Header.OffsetToPointData) + 8 + int64(pointData.Len())
+	if err = lasZip.writeChunkTable(pointData, chunks); err != nil {
+		return
+	}
+	if err = l.updateEVLRLayout(uint64(l.Header.OffsetToPointData) + 8 + uint64(pointData.Len())); err != nil {
+		return
+	}
+
+	counter := &countingWriter{writer: w}
+	writer := bufio.NewWriter(counter)
+	defer func() {
+		n = counter.count
+	}()
+
+	header := l.Header
+	header.PointDataRecordFormat |= LASZIP_FORMAT_COMPRESSED
+	if err = header.write(writer, l.UserDataInHeader); err != nil {
+		return
+	}
+	for index := range l.Vlrs {
+		if err = l.Vlrs[index].write(writer); err != nil {
+			return
+		}
+	}
+	if _, err = writer.Write(l.UserDataAfterHeader); err != nil {
+		return
+	}
+	if err = binary.Write(writer, binary.LittleEndian, chunkTableOffset); err != nil {
+		return
+	}
+	if _, err = writer.Write(pointData.Bytes()); err != nil {
+		return
+	}
+	for index := range l.Evlrs {
+		if err = l.Evlrs[index].write(writer); err != nil {
+			return
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,307 @@
+package las
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+//  _                 _            _     _____
+// | |               (_)          | |   |  __ \
+// | |     ___ _ __   _  ___ _ __ | |_  | |__) |_ _ _ __ ___  ___
+// | |    / _ \ '_ \ | |/ _ \ '_ \| __| |  ___/ _` | '__/ __|/ _ \
+// | |___|  __/ | | || |  __/ | | | |_  | |  | (_| | |  \__ \  __/
+// |______\___|_| |_||_|\___|_| |_|\__| |_|   \__,_|_|  |___/\___|
+//
+//
+
+// ParseWarning describes damage found by ParseLenient which was repaired or skipped. Offset is the position in the file
+// at which the damage was found.
+type ParseWarning struct {
+	Offset  int64
+	Message string
+}
+
+func (w ParseWarning) String() string {
+	return fmt.Sprintf("offset %d: %s", w.Offset, w.Message)
+}
+
+// ParseLenient reads the LAS or LAZ file filename like Parse, but recovers from damage. See ParseReaderLenient.
+func (l *Las) ParseLenient(filename string) (warnings []ParseWarning, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+	return l.ParseReaderLenient(file, info.Size())
+}
+
+// ParseReaderLenient reads a LAS or LAZ file of size bytes from reader like ParseReader, but recovers from damaged or
+// truncated files instead of failing: VLRs and EVLRs which are truncated or overrun the following data are skipped,
+// records which cannot be decoded are kept as raw payload, all complete point data records of a truncated file are
+// read, and LAZ chunks which cannot be decompressed are skipped. When the LAZ chunk table is lost, chunks of a fixed
+// number of points are decompressed in order up to where the data runs out. Every repair is reported as a warning, and
+// l holds what could be read. Only a public header block which cannot be read is an error.
+//
+// The header is kept as read, so it may disagree with the records held by l; the header fields depending on the
+// records are recomputed when l is written.
+func (l *Las) ParseReaderLenient(reader io.ReaderAt, size int64) (warnings []ParseWarning, err error) {
+	if err = l.readPHB(reader); err != nil {
+		return
+	}
+	parser := &lenientParser{las: l, reader: reader, size: size}
+	parser.readVLRs()
+	if err = parser.readPDRs(); err != nil {
+		return
+	}
+	parser.readEVLRs()
+	l.updateCRSInfo()
+	warnings = parser.warnings
+	return
+}
+
+// lenientParser reads the parts of a LAS file following the public header block and collects the damage it finds.
+type lenientParser struct {
+	las      *Las
+	reader   io.ReaderAt
+	size     int64
+	warnings []ParseWarning
+}
+
+func (p *lenientParser) warn(offset int64, format string, args ...interface{}) {
+	p.warnings = append(p.warnings, ParseWarning{Offset: offset, Message: fmt.Sprintf(format, args...)})
+}
+
+// decode decodes the record of the VLR or EVLR at offset. A record which cannot be decoded, even by a registered
+// decoder which panics, is reported and kept as raw payload.
+func (p *lenientParser) decode(offset int64, decode func() error) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.warn(offset, "record cannot be decoded, kept as raw payload: %v", r)
+		}
+	}()
+	if err := decode(); err != nil {
+		p.warn(offset, "%v, kept as raw payload", err)
+	}
+}
+
+// readVLRs reads the VLRs up to the first one which overruns the point data records or the end of the file.
+func (p *lenientParser) readVLRs() {
+	l := p.las
+	l.Vlrs = nil
+	l.UserDataAfterHeader = nil
+	end := int64(l.Header.OffsetToPointData)
+	if end > p.size {
+		p.warn(end, "point data records start at offset %d, after the end of the file at %d", end, p.size)
+		end = p.size
+	}
+
+	offset := int64(l.Header.HeaderSize)
+	headerSize := int64(binary.Size(VLRHeader{}))
+	for i := uint32(0); i < l.Header.NumberOfVLRs; i++ {
+		if offset+headerSize > end {
+			p.warn(offset, "VLR %d of %d extends past offset %d, skipped %d VLRs", i+1, l.Header.NumberOfVLRs, end, l.Header.NumberOfVLRs-i)
+			return
+		}
+		header := VLRHeader{}
+		if err := binary.Read(io.NewSectionReader(p.reader, offset, headerSize), binary.LittleEndian, &header); err != nil {
+			p.warn(offset, "VLR %d of %d cannot be read, skipped %d VLRs: %v", i+1, l.Header.NumberOfVLRs, l.Header.NumberOfVLRs-i, err)
+			return
+		}
+		if recordLength := int64(header.RecordLengthAfterHeader); offset+headerSize+recordLength > end {
+			p.warn(offset, "VLR %d of %d with %d bytes extends past offset %d, skipped %d VLRs", i+1, l.Header.NumberOfVLRs, recordLength, end, l.Header.NumberOfVLRs-i)
+			return
+		}
+		vlr := VLR{}
+		next, err := vlr.read(p.reader, offset)
+		if err != nil {
+			p.warn(offset, "VLR %d of %d cannot be read, skipped %d VLRs: %v", i+1, l.Header.NumberOfVLRs, l.Header.NumberOfVLRs-i, err)
+			return
+		}
+		p.decode(offset, func() error { return vlr.decode(next - int64(len(vlr.payload))) })
+		l.Vlrs = append(l.Vlrs, vlr)
+		offset = next
+	}
+
+	if offset < end {
+		l.UserDataAfterHeader = make([]byte, end-offset)
+		if err := readAt(p.reader, l.UserDataAfterHeader, offset); err != nil {
+			p.warn(offset, "bytes between the VLRs and the point data records cannot be read: %v", err)
+			l.UserDataAfterHeader = nil
+		}
+	}
+}
+
+// readPDRs reads all complete point data records. It fails only if the point data record format or length is not
+// valid, as the records cannot be decoded then.
+func (p *lenientParser) readPDRs() (err error) {
+	l := p.las
+	format := l.Header.PointDataRecordFormat & LASZIP_FORMAT_MASK
+	if err = l.checkPointDataRecordFormat(); err != nil {
+		return
+	}
+	if l.isFileCompressed() {
+		return p.readLazPDRs()
+	}
+
+	numberOfPDRs := l.getNumberOfPDRs()
+	dataLength := uint64(l.Header.PointDataRecordLength)
+	offset := int64(l.Header.OffsetToPointData)
+	available := uint64(0)
+	if offset < p.size {
+		available = uint64(p.size-offset) / dataLength
+	}
+	if available < numberOfPDRs {
+		p.warn(p.size, "file holds %d complete point data records, header declares %d", available, numberOfPDRs)
+		numberOfPDRs = available
+	}
+	if l.Pdrs, err = newPDRs(format, numberOfPDRs); err != nil {
+		return
+	}
+	if numberOfPDRs == 0 {
+		return
+	}
+	if err = l.Pdrs.read(p.reader, offset, dataLength); err != nil {
+		p.warn(offset, "point data records cannot be read: %v", err)
+		l.Pdrs, err = newPDRs(format, 0)
+	}
+	return
+}
+
+// readLazPDRs decompresses the chunks which can be read and decoded. Without a readable chunk table, e.g. in a
+// truncated file, chunks of a fixed number of points are decompressed one after the other from the start of the point
+// data instead, and no points are recovered from chunks of variable size, which cannot be located.
+func (p *lenientParser) readLazPDRs() (err error) {
+	l := p.las
+	format := l.Header.PointDataRecordFormat & LASZIP_FORMAT_MASK
+	defer func() {
+		l.Header.PointDataRecordFormat &= LASZIP_FORMAT_MASK
+		l.removeLASzipVLRs()
+		l.compressed = true
+	}()
+	if l.Pdrs, err = newPDRs(format, 0); err != nil {
+		return
+	}
+	offset := int64(l.Header.OffsetToPointData)
+	lasZip, err := l.getLazCompression()
+	if err != nil {
+		p.warn(offset, "LAZ point data records cannot be decompressed, no point data records read: %v", err)
+		err = nil
+		return
+	}
+
+	numberOfPDRs := l.getNumberOfPDRs()
+	dataLength := uint64(l.Header.PointDataRecordLength)
+	var raw []byte
+	if chunks, tableErr := l.readLazChunkTable(lasZip, p.reader, p.size); tableErr == nil {
+		raw = p.readLazChunks(lasZip, chunks)
+	} else if lasZip.ChunkSize != LASZIP_CHUNK_SIZE_VARIABLE {
+		p.warn(offset, "LAZ chunk table cannot be read, decompressing the chunks in order: %v", tableErr)
+		raw = p.scanLazChunks(lasZip, numberOfPDRs)
+	} else {
+		p.warn(offset, "LAZ chunks cannot be located, no point data records read: %v", tableErr)
+		return
+	}
+	if read := uint64(len(raw)) / dataLength; read != numberOfPDRs {
+		p.warn(offset, "LAZ chunks hold %d point data records, header declares %d", read, numberOfPDRs)
+		if read < numberOfPDRs {
+			numberOfPDRs = read
+		}
+	}
+	if l.Pdrs, err = newPDRs(format, numberOfPDRs); err != nil {
+		return
+	}
+	err = l.Pdrs.decode(raw[:numberOfPDRs*dataLength], dataLength)
+	return
+}
+
+// readLazChunks decompresses the chunks of the chunk table, skipping those which cannot be decompressed.
+func (p *lenientParser) readLazChunks(lasZip *LASzip, chunks []lazChunk) (raw []byte) {
+	for index, chunk := range chunks {
+		if chunk.offset+int64(chunk.size) > p.size {
+			p.warn(chunk.offset, "LAZ chunk %d of %d is truncated, skipped %d chunks", index+1, len(chunks), len(chunks)-index)
+			break
+		}
+		rawChunk, err := lasZip.readLazChunk(p.reader, chunk)
+		if err != nil {
+			p.warn(chunk.offset, "LAZ chunk %d of %d with %d points cannot be decompressed, skipped: %v", index+1, len(chunks), chunk.numberOfPoints, err)
+			continue
+		}
+		raw = append(raw, rawChunk...)
+	}
+	return
+}
+
+// scanLazChunks decompresses chunks of lasZip.ChunkSize points one after the other, each starting where the previous
+// one ends, up to the first chunk which cannot be decompressed or the end of the file. The points of a pointwise
+// chunk cut off by the end of the file are recovered up to where the data runs out.
+func (p *lenientParser) scanLazChunks(lasZip *LASzip, numberOfPDRs uint64) (raw []byte) {
+	start := int64(p.las.Header.OffsetToPointData) + 8
+	if start >= p.size {
+		return
+	}
+	data := make([]byte, p.size-start)
+	if err := readAt(p.reader, data, start); err != nil {
+		p.warn(start, "LAZ chunks cannot be read: %v", err)
+		return
+	}
+	pointSize := lasZip.getPointSize()
+	position := 0
+	for index, remaining := 1, numberOfPDRs; remaining != 0 && position < len(data); index++ {
+		numberOfPoints := uint64(lasZip.ChunkSize)
+		if numberOfPoints > remaining {
+			numberOfPoints = remaining
+		}
+		rawChunk, size, err := lasZip.scanChunk(data[position:], numberOfPoints)
+		if err != nil {
+			p.warn(start+int64(position), "LAZ chunk %d cannot be decompressed, skipped the remaining chunks: %v", index, err)
+			return
+		}
+		raw = append(raw, rawChunk...)
+		if read := uint64(len(rawChunk)) / pointSize; read < numberOfPoints {
+			p.warn(start+int64(position), "LAZ chunk %d is truncated, recovered %d of its %d points", index, read, numberOfPoints)
+			return
+		}
+		position += size
+		remaining -= numberOfPoints
+	}
+	return
+}
+
+// readEVLRs reads the EVLRs up to the first one which is truncated.
+func (p *lenientParser) readEVLRs() {
+	l := p.las
+	l.Evlrs = nil
+	offset, numberOfEVLRs := l.getEVLRLocation()
+	headerSize := int64(binary.Size(EVLRHeader{}))
+	for i := uint32(0); i < numberOfEVLRs; i++ {
+		if offset < 0 || offset+headerSize > p.size {
+			p.warn(offset, "EVLR %d of %d lies after the end of the file, skipped %d EVLRs", i+1, numberOfEVLRs, numberOfEVLRs-i)
+			return
+		}
+		header := EVLRHeader{}
+		if err := binary.Read(io.NewSectionReader(p.reader, offset, headerSize), binary.LittleEndian, &header); err != nil {
+			p.warn(offset, "EVLR %d of %d cannot be read, skipped %d EVLRs: %v", i+1, numberOfEVLRs, numberOfEVLRs-i, err)
+			return
+		}
+		if recordLength := header.RecordLengthAfterHeader; recordLength > uint64(p.size-offset-headerSize) {
+			p.warn(offset, "EVLR %d of %d with %d bytes is truncated, skipped %d EVLRs", i+1, numberOfEVLRs, recordLength, numberOfEVLRs-i)
+			return
+		}
+		evlr := EVLR{}
+		next, err := evlr.read(p.reader, offset, p.size)
+		if err != nil {
+			p.warn(offset, "EVLR %d of %d cannot be read, skipped %d EVLRs: %v", i+1, numberOfEVLRs, numberOfEVLRs-i, err)
+			return
+		}
+		p.decode(offset, func() error { return evlr.decode(next - int64(len(evlr.payload))) })
+		l.Evlrs = append(l.Evlrs, evlr)
+		offset = next
+	}
+}
@@ -3,7 +3,8 @@ package las
 import (
 	"bytes"
 	"encoding/binary"
-	"os"
+	"fmt"
+	"io"
 )
 
 // _____  _____  _____
@@ -16,10 +17,132 @@ import (
 //
 
 type PDRs interface {
-	read(file *os.File, offsetIn int64, dataLength uint64) (err error)
+	read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error)
+	decode(bytesToRead []byte, dataLength uint64) (err error)
+	write(w io.Writer, dataLength uint64) (err error)
+	Len() int
+	At(index int) (point Point)
 	GetCSVList() (output []*XYZRGB)
 }
 
+// Point gives access to the attributes of a point data record independently of its format. Attributes which are not
+// defined by the format of the record are reported as zero; HasGPSTime, HasRGB, HasNIR and HasWavePacket tell whether
+// the format defines them.
+type Point interface {
+	GetPointDataRecordFormat() uint8
+	GetX() int32
+	GetY() int32
+	GetZ() int32
+	SetX(x int32)
+	SetY(y int32)
+	SetZ(z int32)
+	GetIntensity() uint16
+	GetReturnNumber() uint8
+	GetNumberOfReturns() uint8
+	GetScannerChannel() uint8
+	GetScanDirectionFlag() uint8
+	GetEdgeOfFlightLine() uint8
+	GetClassification() uint8
+	GetClassAttribute() ClassAttribute
+	IsSynthetic() bool
+	IsKeyPoint() bool
+	IsWithheld() bool
+	IsOverlap() bool
+	GetScanAngle() float64
+	GetUserData() uint8
+	GetPointSourceID() uint16
+	HasGPSTime() bool
+	GetGPSTime() float64
+	HasRGB() bool
+	GetRGB() (red uint16, green uint16, blue uint16)
+	HasNIR() bool
+	GetNIR() uint16
+	HasWavePacket() bool
+	GetWavePacket() WavePacket
+	GetExtraBytes() []byte
+	SetExtraBytes(extraBytes []byte)
+}
+
+// newPDRs allocates the PDRs slice type matching the point data record format.
+func newPDRs(format uint8, numberOfPDRs uint64) (pdrs PDRs, err error) {
+	switch format {
+	case 0:
+		pdrs = make(PDR0s, numberOfPDRs)
+	case 1:
+		pdrs = make(PDR1s, numberOfPDRs)
+	case 2:
+		pdrs = make(PDR2s, numberOfPDRs)
+	case 3:
+		pdrs = make(PDR3s, numberOfPDRs)
+	case 4:
+		pdrs = make(PDR4s, numberOfPDRs)
+	case 5:
+		pdrs = make(PDR5s, numberOfPDRs)
+	case 6:
+		pdrs = make(PDR6s, numberOfPDRs)
+	case 7:
+		pdrs = make(PDR7s, numberOfPDRs)
+	case 8:
+		pdrs = make(PDR8s, numberOfPDRs)
+	case 9:
+		pdrs = make(PDR9s, numberOfPDRs)
+	case 10:
+		pdrs = make(PDR10s, numberOfPDRs)
+	default:
+		err = fmt.Errorf("%w: %d", ErrUnknownPointFormat, format)
+	}
+	return
+}
+
+// getPointDataRecordSize returns the size in bytes of the standard fields of the point data record format, i.e. the
+// minimum PointDataRecordLength for the format.
+func getPointDataRecordSize(format uint8) (size uint16, err error) {
+	switch format {
+	case 0:
+		size = uint16(binary.Size(Format0{}))
+	case 1:
+		size = uint16(binary.Size(Format1{}))
+	case 2:
+		size = uint16(binary.Size(Format2{}))
+	case 3:
+		size = uint16(binary.Size(Format3{}))
+	case 4:
+		size = uint16(binary.Size(Format4{}))
+	case 5:
+		size = uint16(binary.Size(Format5{}))
+	case 6:
+		size = uint16(binary.Size(Format6{}))
+	case 7:
+		size = uint16(binary.Size(Format7{}))
+	case 8:
+		size = uint16(binary.Size(Format8{}))
+	case 9:
+		size = uint16(binary.Size(Format9{}))
+	case 10:
+		size = uint16(binary.Size(Format10{}))
+	default:
+		err = fmt.Errorf("%w: %d", ErrUnknownPointFormat, format)
+	}
+	return
+}
+
+// writeExtraBytes writes the extra bytes of a point, padding them with zeros up to extraLength.
+func writeExtraBytes(w io.Writer, extraBytes []byte, extraLength uint64) (err error) {
+	if uint64(len(extraBytes)) > extraLength {
+		err = fmt.Errorf("point has %d extra bytes but the point data record length only allows %d", len(extraBytes), extraLength)
+		return
+	}
+	if _, err = w.Write(extraBytes); err != nil {
+		return
+	}
+	if padding := extraLength - uint64(len(extraBytes)); padding != 0 {
+		if _, err = w.Write(make([]byte, padding)); err != nil {
+			return
+		}
+	}
+	return
+}
+
 type XYZRGB struct {
 	X float64 `csv:"X"`
 	Y float64 `csv:"Y"`
@@ -29,6 +152,17 @@ type XYZRGB struct {
 	B uint16  `csv:"B"`
 }
 
+// WavePacket holds the waveform fields of the point data record formats 4, 5, 9 and 10, see Point.GetWavePacket.
+type WavePacket struct {
+	WavePacketDescriptorIndex   uint8
+	ByteOffsetToWaveformData    uint64
+	WaveformPacketSizeInBytes   uint32
+	ReturnPointWaveformLocation float32
+	ParametricDx                float32
+	ParametricDy                float32
+	ParametricDz                float32
+}
+
 //  ______  ____   _____   __  __         _______    ___
 // |  ____|/ __ \ |  __ \ |  \/  |    /\ |__   __|  / _ \
 // | |__  | |  | || |__) || \  / |   /  \   | |    | | | |
@@ -94,6 +228,38 @@ type PDR0 struct {
 	ExtraBytes []byte
 }
 
+func (f0 *Format0) GetPointDataRecordFormat() uint8 {
+	return 0
+}
+
+func (f0 *Format0) GetX() int32 {
+	return f0.X
+}
+
+func (f0 *Format0) GetY() int32 {
+	return f0.Y
+}
+
+func (f0 *Format0) GetZ() int32 {
+	return f0.Z
+}
+
+func (f0 *Format0) SetX(x int32) {
+	f0.X = x
+}
+
+func (f0 *Format0) SetY(y int32) {
+	f0.Y = y
+}
+
+func (f0 *Format0) SetZ(z int32) {
+	f0.Z = z
+}
+
+func (f0 *Format0) GetIntensity() uint16 {
+	return f0.Intensity
+}
+
 func (f0 *Format0) GetReturnNumber() uint8 {
 	return f0.Pulse & PDR0_RETURN_NUMBER_MASK
 }
@@ -110,6 +276,15 @@ func (f0 *Format0) GetEdgeOfFlightLine() uint8 {
 	return (f0.Pulse & PDR0_EDGE_OF_FLIGHT_LINE_MASK) >> 7
 }
 
+// GetScannerChannel is always 0 as the scanner channel is only defined from format 6 onwards.
+func (f0 *Format0) GetScannerChannel() uint8 {
+	return 0
+}
+
+func (f0 *Format0) GetClassification() uint8 {
+	return f0.Classification & PDR0_CLASSIFICATION_ATTRIBUTE_MASK
+}
+
 func (f0 *Format0) GetClassAttribute() ClassAttribute {
 	return ClassAttribute(uint8(f0.Classification) & PDR0_CLASSIFICATION_ATTRIBUTE_MASK)
 }
@@ -132,14 +307,79 @@ func (f0 *Format0) IsWithheld() bool {
 	return (uint8(f0.Classification) & PDR0_CLASSIFICATION_WITHHELD_MASK) != 0
 }
 
+// IsOverlap is always false as the overlap flag is only defined from format 6 onwards. Older formats mark overlap
+// points with the Overlap_Points class instead.
+func (f0 *Format0) IsOverlap() bool {
+	return false
+}
+
+// GetScanAngle returns the scan angle in degrees.
+func (f0 *Format0) GetScanAngle() float64 {
+	return float64(f0.ScanAngleRank)
+}
+
+func (f0 *Format0) GetUserData() uint8 {
+	return f0.UserData
+}
+
+func (f0 *Format0) GetPointSourceID() uint16 {
+	return f0.PointSourceID
+}
+
+func (f0 *Format0) HasGPSTime() bool {
+	return false
+}
+
+func (f0 *Format0) GetGPSTime() float64 {
+	return 0
+}
+
+func (f0 *Format0) HasRGB() bool {
+	return false
+}
+
+func (f0 *Format0) GetRGB() (red uint16, green uint16, blue uint16) {
+	return 0, 0, 0
+}
+
+func (f0 *Format0) HasNIR() bool {
+	return false
+}
+
+func (f0 *Format0) GetNIR() uint16 {
+	return 0
+}
+
+func (f0 *Format0) HasWavePacket() bool {
+	return false
+}
+
+func (f0 *Format0) GetWavePacket() WavePacket {
+	return WavePacket{}
+}
+
+func (p *PDR0) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR0) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
+}
+
 type PDR0s []PDR0
 
-func (p0 PDR0s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p0 PDR0s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p0))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p0.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p0 PDR0s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p0 {
 		pdrSize := uint64(binary.Size(Format0{}))
 		offset := uint64(index) * dataLength
@@ -156,6 +396,27 @@ func (p0 PDR0s) read(file *os.File, offsetIn int64, dataLength uint64) (err erro
 	return
 }
 
+func (p0 PDR0s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format0{}))
+	for index := range p0 {
+		if err = binary.Write(w, binary.LittleEndian, &p0[index].Format0); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p0[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p0 PDR0s) Len() int {
+	return len(p0)
+}
+
+func (p0 PDR0s) At(index int) (point Point) {
+	return &p0[index]
+}
+
 func (p0 PDR0s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p0 {
 		csvRow := &XYZRGB{
@@ -185,19 +446,45 @@ type Format1 struct {
 	GPSTime float64
 }
 
+func (f1 *Format1) GetPointDataRecordFormat() uint8 {
+	return 1
+}
+
+func (f1 *Format1) HasGPSTime() bool {
+	return true
+}
+
+func (f1 *Format1) GetGPSTime() float64 {
+	return f1.GPSTime
+}
+
 type PDR1 struct {
 	Format1
 	ExtraBytes []byte
 }
 
+func (p *PDR1) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR1) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
+}
+
 type PDR1s []PDR1
 
-func (p1 PDR1s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p1 PDR1s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p1))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p1.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p1 PDR1s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p1 {
 		pdrSize := uint64(binary.Size(Format1{}))
 		offset := uint64(index) * dataLength
@@ -214,6 +501,27 @@ func (p1 PDR1s) read(file *os.File, offsetIn int64, dataLength uint64) (err erro
 	return
 }
 
+func (p1 PDR1s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format1{}))
+	for index := range p1 {
+		if err = binary.Write(w, binary.LittleEndian, &p1[index].Format1); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p1[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p1 PDR1s) Len() int {
+	return len(p1)
+}
+
+func (p1 PDR1s) At(index int) (point Point) {
+	return &p1[index]
+}
+
 func (p1 PDR1s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p1 {
 		csvRow := &XYZRGB{
@@ -245,19 +553,45 @@ type Format2 struct {
 	Blue  uint16
 }
 
+func (f2 *Format2) GetPointDataRecordFormat() uint8 {
+	return 2
+}
+
+func (f2 *Format2) HasRGB() bool {
+	return true
+}
+
+func (f2 *Format2) GetRGB() (red uint16, green uint16, blue uint16) {
+	return f2.Red, f2.Green, f2.Blue
+}
+
 type PDR2 struct {
 	Format2
 	ExtraBytes []byte
 }
 
+func (p *PDR2) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR2) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
+}
+
 type PDR2s []PDR2
 
-func (p2 PDR2s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p2 PDR2s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p2))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p2.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p2 PDR2s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p2 {
 		pdrSize := uint64(binary.Size(Format2{}))
 		offset := uint64(index) * dataLength
@@ -274,6 +608,27 @@ func (p2 PDR2s) read(file *os.File, offsetIn int64, dataLength uint64) (err erro
 	return
 }
 
+func (p2 PDR2s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format2{}))
+	for index := range p2 {
+		if err = binary.Write(w, binary.LittleEndian, &p2[index].Format2); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p2[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p2 PDR2s) Len() int {
+	return len(p2)
+}
+
+func (p2 PDR2s) At(index int) (point Point) {
+	return &p2[index]
+}
+
 func (p2 PDR2s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p2 {
 		csvRow := &XYZRGB{
@@ -305,19 +660,45 @@ type Format3 struct {
 	Blue  uint16
 }
 
+func (f3 *Format3) GetPointDataRecordFormat() uint8 {
+	return 3
+}
+
+func (f3 *Format3) HasRGB() bool {
+	return true
+}
+
+func (f3 *Format3) GetRGB() (red uint16, green uint16, blue uint16) {
+	return f3.Red, f3.Green, f3.Blue
+}
+
 type PDR3 struct {
 	Format3
 	ExtraBytes []byte
 }
 
+func (p *PDR3) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR3) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
+}
+
 type PDR3s []PDR3
 
-func (p3 PDR3s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p3 PDR3s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p3))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p3.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p3 PDR3s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p3 {
 		pdrSize := uint64(binary.Size(Format3{}))
 		offset := uint64(index) * dataLength
@@ -334,6 +715,27 @@ func (p3 PDR3s) read(file *os.File, offsetIn int64, dataLength uint64) (err erro
 	return
 }
 
+func (p3 PDR3s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format3{}))
+	for index := range p3 {
+		if err = binary.Write(w, binary.LittleEndian, &p3[index].Format3); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p3[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p3 PDR3s) Len() int {
+	return len(p3)
+}
+
+func (p3 PDR3s) At(index int) (point Point) {
+	return &p3[index]
+}
+
 func (p3 PDR3s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p3 {
 		csvRow := &XYZRGB{
@@ -368,19 +770,63 @@ type Format4 struct {
 	ParametricDz                float32
 }
 
+func (f4 *Format4) GetPointDataRecordFormat() uint8 {
+	return 4
+}
+
+func (f4 *Format4) HasWavePacket() bool {
+	return true
+}
+
+func (f4 *Format4) GetWavePacket() WavePacket {
+	return WavePacket{
+		WavePacketDescriptorIndex:   f4.WavePacketDescriptorIndex,
+		ByteOffsetToWaveformData:    f4.ByteOffsetToWaveformData,
+		WaveformPacketSizeInBytes:   f4.WaveformPacketSizeInBytes,
+		ReturnPointWaveformLocation: f4.ReturnPointWaveformLocation,
+		ParametricDx:                f4.ParametricDx,
+		ParametricDy:                f4.ParametricDy,
+		ParametricDz:                f4.ParametricDz,
+	}
+}
+
+func (f4 *Format4) setWavePacket(wavePacket WavePacket) {
+	f4.WavePacketDescriptorIndex = wavePacket.WavePacketDescriptorIndex
+	f4.ByteOffsetToWaveformData = wavePacket.ByteOffsetToWaveformData
+	f4.WaveformPacketSizeInBytes = wavePacket.WaveformPacketSizeInBytes
+	f4.ReturnPointWaveformLocation = wavePacket.ReturnPointWaveformLocation
+	f4.ParametricDx = wavePacket.ParametricDx
+	f4.ParametricDy = wavePacket.ParametricDy
+	f4.ParametricDz = wavePacket.ParametricDz
+}
+
 type PDR4 struct {
 	Format4
 	ExtraBytes []byte
 }
 
+func (p *PDR4) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR4) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
+}
+
 type PDR4s []PDR4
 
-func (p4 PDR4s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p4 PDR4s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p4))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p4.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p4 PDR4s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p4 {
 		pdrSize := uint64(binary.Size(Format4{}))
 		offset := uint64(index) * dataLength
@@ -397,6 +843,27 @@ func (p4 PDR4s) read(file *os.File, offsetIn int64, dataLength uint64) (err erro
 	return
 }
 
+func (p4 PDR4s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format4{}))
+	for index := range p4 {
+		if err = binary.Write(w, binary.LittleEndian, &p4[index].Format4); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p4[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p4 PDR4s) Len() int {
+	return len(p4)
+}
+
+func (p4 PDR4s) At(index int) (point Point) {
+	return &p4[index]
+}
+
 func (p4 PDR4s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p4 {
 		csvRow := &XYZRGB{
@@ -432,19 +899,63 @@ type Format5 struct {
 	ParametricDz                float32
 }
 
+func (f5 *Format5) GetPointDataRecordFormat() uint8 {
+	return 5
+}
+
+func (f5 *Format5) HasWavePacket() bool {
+	return true
+}
+
+func (f5 *Format5) GetWavePacket() WavePacket {
+	return WavePacket{
+		WavePacketDescriptorIndex:   f5.WavePacketDescriptorIndex,
+		ByteOffsetToWaveformData:    f5.ByteOffsetToWaveformData,
+		WaveformPacketSizeInBytes:   f5.WaveformPacketSizeInBytes,
+		ReturnPointWaveformLocation: f5.ReturnPointWaveformLocation,
+		ParametricDx:                f5.ParametricDx,
+		ParametricDy:                f5.ParametricDy,
+		ParametricDz:                f5.ParametricDz,
+	}
+}
+
+func (f5 *Format5) setWavePacket(wavePacket WavePacket) {
+	f5.WavePacketDescriptorIndex = wavePacket.WavePacketDescriptorIndex
+	f5.ByteOffsetToWaveformData = wavePacket.ByteOffsetToWaveformData
+	f5.WaveformPacketSizeInBytes = wavePacket.WaveformPacketSizeInBytes
+	f5.ReturnPointWaveformLocation = wavePacket.ReturnPointWaveformLocation
+	f5.ParametricDx = wavePacket.ParametricDx
+	f5.ParametricDy = wavePacket.ParametricDy
+	f5.ParametricDz = wavePacket.ParametricDz
+}
+
 type PDR5 struct {
 	Format5
 	ExtraBytes []byte
 }
 
+func (p *PDR5) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR5) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
+}
+
 type PDR5s []PDR5
 
-func (p5 PDR5s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p5 PDR5s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p5))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p5.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p5 PDR5s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p5 {
 		pdrSize := uint64(binary.Size(Format5{}))
 		offset := uint64(index) * dataLength
@@ -461,6 +972,27 @@ func (p5 PDR5s) read(file *os.File, offsetIn int64, dataLength uint64) (err erro
 	return
 }
 
+func (p5 PDR5s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format5{}))
+	for index := range p5 {
+		if err = binary.Write(w, binary.LittleEndian, &p5[index].Format5); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p5[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p5 PDR5s) Len() int {
+	return len(p5)
+}
+
+func (p5 PDR5s) At(index int) (point Point) {
+	return &p5[index]
+}
+
 func (p5 PDR5s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p5 {
 		csvRow := &XYZRGB{
@@ -489,12 +1021,14 @@ const (
 	PDR6_RETURN_NUMBER_MASK            = 0x0F
 	PDR6_NUMBER_OF_RETURNS_MASK        = 0xF0
 	PDR6_CLASSIFICATION_FLAGS_MASK     = 0x0F
-	PDR6_SCAN_DIRECTION_FLAG_MASK      = 0x03
-	PDR6_EDGE_OF_FLIGHT_LINE_MASK      = 0x0C
-	PDR6_CLASSIFICATION_ATTRIBUTE_MASK = 0x1F
-	PDR6_CLASSIFICATION_SYNTHETIC_MASK = 0x20
-	PDR6_CLASSIFICATION_KEYPOINT_MASK  = 0x40
-	PDR6_CLASSIFICATION_WITHHELD_MASK  = 0x80
+	PDR6_CLASSIFICATION_SYNTHETIC_MASK = 0x01
+	PDR6_CLASSIFICATION_KEYPOINT_MASK  = 0x02
+	PDR6_CLASSIFICATION_WITHHELD_MASK  = 0x04
+	PDR6_CLASSIFICATION_OVERLAP_MASK   = 0x08
+	PDR6_SCANNER_CHANNEL_MASK          = 0x30
+	PDR6_SCAN_DIRECTION_FLAG_MASK      = 0x40
+	PDR6_EDGE_OF_FLIGHT_LINE_MASK      = 0x80
+	PDR6_SCAN_ANGLE_SCALE              = 0.006
 )
 
 type Format6 struct {
@@ -516,6 +1050,38 @@ type PDR6 struct {
 	ExtraBytes []byte
 }
 
+func (f6 *Format6) GetPointDataRecordFormat() uint8 {
+	return 6
+}
+
+func (f6 *Format6) GetX() int32 {
+	return f6.X
+}
+
+func (f6 *Format6) GetY() int32 {
+	return f6.Y
+}
+
+func (f6 *Format6) GetZ() int32 {
+	return f6.Z
+}
+
+func (f6 *Format6) SetX(x int32) {
+	f6.X = x
+}
+
+func (f6 *Format6) SetY(y int32) {
+	f6.Y = y
+}
+
+func (f6 *Format6) SetZ(z int32) {
+	f6.Z = z
+}
+
+func (f6 *Format6) GetIntensity() uint16 {
+	return f6.Intensity
+}
+
 func (f6 *Format6) GetReturnNumber() uint8 {
 	return f6.PulseReturns & PDR6_RETURN_NUMBER_MASK
 }
@@ -528,44 +1094,116 @@ func (f6 *Format6) GetClassificationFlag() uint8 {
 	return f6.PulseFlags & PDR6_CLASSIFICATION_FLAGS_MASK
 }
 
+func (f6 *Format6) GetScannerChannel() uint8 {
+	return (f6.PulseFlags & PDR6_SCANNER_CHANNEL_MASK) >> 4
+}
+
 func (f6 *Format6) GetScanDirectionFlag() uint8 {
-	return (f6.PulseFlags & PDR6_SCAN_DIRECTION_FLAG_MASK) >> 4
+	return (f6.PulseFlags & PDR6_SCAN_DIRECTION_FLAG_MASK) >> 6
 }
 
 func (f6 *Format6) GetEdgeOfFlightLine() uint8 {
-	return (f6.PulseFlags & PDR6_EDGE_OF_FLIGHT_LINE_MASK) >> 2
+	return (f6.PulseFlags & PDR6_EDGE_OF_FLIGHT_LINE_MASK) >> 7
+}
+
+func (f6 *Format6) GetClassification() uint8 {
+	return f6.Classification
 }
 
 func (f6 *Format6) GetClassAttribute() ClassAttribute {
-	return ClassAttribute(uint8(f6.Classification) & PDR6_CLASSIFICATION_ATTRIBUTE_MASK)
+	return ClassAttribute(f6.Classification)
 }
 
 // IsSynthetic if set, this point was created by a technique other than direct observation such as digitized from a photogrammetric
 // stereo model or by traversing a waveform. Point attribute interpretation might differ from non-Synthetic points.
 // Unused attributes must be set to the appropriate default value.
 func (f6 *Format6) IsSynthetic() bool {
-	return (uint8(f6.Classification) & PDR6_CLASSIFICATION_SYNTHETIC_MASK) != 0
+	return (f6.PulseFlags & PDR6_CLASSIFICATION_SYNTHETIC_MASK) != 0
 }
 
 // IsKeyPoint if set, this point is considered to be a model keypoint and therefore generally should not be withheld in a
 // thinning algorithm.
 func (f6 *Format6) IsKeyPoint() bool {
-	return (uint8(f6.Classification) & PDR6_CLASSIFICATION_KEYPOINT_MASK) != 0
+	return (f6.PulseFlags & PDR6_CLASSIFICATION_KEYPOINT_MASK) != 0
 }
 
 // IsWithheld if set, this point should not be included in processing (synonymous with Deleted).
 func (f6 *Format6) IsWithheld() bool {
-	return (uint8(f6.Classification) & PDR6_CLASSIFICATION_WITHHELD_MASK) != 0
+	return (f6.PulseFlags & PDR6_CLASSIFICATION_WITHHELD_MASK) != 0
+}
+
+// IsOverlap if set, this point is within the overlap region of two or more swaths or takes.
+func (f6 *Format6) IsOverlap() bool {
+	return (f6.PulseFlags & PDR6_CLASSIFICATION_OVERLAP_MASK) != 0
+}
+
+// GetScanAngle returns the scan angle in degrees.
+func (f6 *Format6) GetScanAngle() float64 {
+	return float64(f6.ScanAngleRank) * PDR6_SCAN_ANGLE_SCALE
+}
+
+func (f6 *Format6) GetUserData() uint8 {
+	return f6.UserData
+}
+
+func (f6 *Format6) GetPointSourceID() uint16 {
+	return f6.PointSourceID
+}
+
+func (f6 *Format6) HasGPSTime() bool {
+	return true
+}
+
+func (f6 *Format6) GetGPSTime() float64 {
+	return f6.GPSTime
+}
+
+func (f6 *Format6) HasRGB() bool {
+	return false
+}
+
+func (f6 *Format6) GetRGB() (red uint16, green uint16, blue uint16) {
+	return 0, 0, 0
+}
+
+func (f6 *Format6) HasNIR() bool {
+	return false
+}
+
+func (f6 *Format6) GetNIR() uint16 {
+	return 0
+}
+
+func (f6 *Format6) HasWavePacket() bool {
+	return false
+}
+
+func (f6 *Format6) GetWavePacket() WavePacket {
+	return WavePacket{}
+}
+
+func (p *PDR6) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR6) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
 }
 
 type PDR6s []PDR6
 
-func (p6 PDR6s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p6 PDR6s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p6))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p6.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p6 PDR6s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p6 {
 		pdrSize := uint64(binary.Size(Format6{}))
 		offset := uint64(index) * dataLength
@@ -582,6 +1220,27 @@ func (p6 PDR6s) read(file *os.File, offsetIn int64, dataLength uint64) (err erro
 	return
 }
 
+func (p6 PDR6s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format6{}))
+	for index := range p6 {
+		if err = binary.Write(w, binary.LittleEndian, &p6[index].Format6); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p6[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p6 PDR6s) Len() int {
+	return len(p6)
+}
+
+func (p6 PDR6s) At(index int) (point Point) {
+	return &p6[index]
+}
+
 func (p6 PDR6s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p6 {
 		csvRow := &XYZRGB{
@@ -613,19 +1272,45 @@ type Format7 struct {
 	Blue  uint16
 }
 
+func (f7 *Format7) GetPointDataRecordFormat() uint8 {
+	return 7
+}
+
+func (f7 *Format7) HasRGB() bool {
+	return true
+}
+
+func (f7 *Format7) GetRGB() (red uint16, green uint16, blue uint16) {
+	return f7.Red, f7.Green, f7.Blue
+}
+
 type PDR7 struct {
 	Format7
 	ExtraBytes []byte
 }
 
+func (p *PDR7) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR7) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
+}
+
 type PDR7s []PDR7
 
-func (p7 PDR7s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p7 PDR7s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p7))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p7.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p7 PDR7s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p7 {
 		pdrSize := uint64(binary.Size(Format7{}))
 		offset := uint64(index) * dataLength
@@ -642,6 +1327,27 @@ func (p7 PDR7s) read(file *os.File, offsetIn int64, dataLength uint64) (err erro
 	return
 }
 
+func (p7 PDR7s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format7{}))
+	for index := range p7 {
+		if err = binary.Write(w, binary.LittleEndian, &p7[index].Format7); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p7[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p7 PDR7s) Len() int {
+	return len(p7)
+}
+
+func (p7 PDR7s) At(index int) (point Point) {
+	return &p7[index]
+}
+
 func (p7 PDR7s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p7 {
 		csvRow := &XYZRGB{
@@ -671,19 +1377,45 @@ type Format8 struct {
 	NIR uint16
 }
 
+func (f8 *Format8) GetPointDataRecordFormat() uint8 {
+	return 8
+}
+
+func (f8 *Format8) HasNIR() bool {
+	return true
+}
+
+func (f8 *Format8) GetNIR() uint16 {
+	return f8.NIR
+}
+
 type PDR8 struct {
 	Format8
 	ExtraBytes []byte
 }
 
+func (p *PDR8) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR8) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
+}
+
 type PDR8s []PDR8
 
-func (p8 PDR8s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p8 PDR8s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p8))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p8.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p8 PDR8s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p8 {
 		pdrSize := uint64(binary.Size(Format8{}))
 		offset := uint64(index) * dataLength
@@ -700,6 +1432,27 @@ func (p8 PDR8s) read(file *os.File, offsetIn int64, dataLength uint64) (err erro
 	return
 }
 
+func (p8 PDR8s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format8{}))
+	for index := range p8 {
+		if err = binary.Write(w, binary.LittleEndian, &p8[index].Format8); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p8[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p8 PDR8s) Len() int {
+	return len(p8)
+}
+
+func (p8 PDR8s) At(index int) (point Point) {
+	return &p8[index]
+}
+
 func (p8 PDR8s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p8 {
 		csvRow := &XYZRGB{
@@ -735,19 +1488,63 @@ type Format9 struct {
 	ParametricDz                float32
 }
 
+func (f9 *Format9) GetPointDataRecordFormat() uint8 {
+	return 9
+}
+
+func (f9 *Format9) HasWavePacket() bool {
+	return true
+}
+
+func (f9 *Format9) GetWavePacket() WavePacket {
+	return WavePacket{
+		WavePacketDescriptorIndex:   f9.WavePacketDescriptorIndex,
+		ByteOffsetToWaveformData:    f9.ByteOffsetToWaveformData,
+		WaveformPacketSizeInBytes:   f9.WaveformPacketSizeInBytes,
+		ReturnPointWaveformLocation: f9.ReturnPointWaveformLocation,
+		ParametricDx:                f9.ParametricDx,
+		ParametricDy:                f9.ParametricDy,
+		ParametricDz:                f9.ParametricDz,
+	}
+}
+
+func (f9 *Format9) setWavePacket(wavePacket WavePacket) {
+	f9.WavePacketDescriptorIndex = wavePacket.WavePacketDescriptorIndex
+	f9.ByteOffsetToWaveformData = wavePacket.ByteOffsetToWaveformData
+	f9.WaveformPacketSizeInBytes = wavePacket.WaveformPacketSizeInBytes
+	f9.ReturnPointWaveformLocation = wavePacket.ReturnPointWaveformLocation
+	f9.ParametricDx = wavePacket.ParametricDx
+	f9.ParametricDy = wavePacket.ParametricDy
+	f9.ParametricDz = wavePacket.ParametricDz
+}
+
 type PDR9 struct {
 	Format9
 	ExtraBytes []byte
 }
 
+func (p *PDR9) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR9) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
+}
+
 type PDR9s []PDR9
 
-func (p9 PDR9s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p9 PDR9s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p9))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p9.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p9 PDR9s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p9 {
 		pdrSize := uint64(binary.Size(Format9{}))
 		offset := uint64(index) * dataLength
@@ -764,6 +1561,27 @@ func (p9 PDR9s) read(file *os.File, offsetIn int64, dataLength uint64) (err erro
 	return
 }
 
+func (p9 PDR9s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format9{}))
+	for index := range p9 {
+		if err = binary.Write(w, binary.LittleEndian, &p9[index].Format9); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p9[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p9 PDR9s) Len() int {
+	return len(p9)
+}
+
+func (p9 PDR9s) At(index int) (point Point) {
+	return &p9[index]
+}
+
 func (p9 PDR9s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p9 {
 		csvRow := &XYZRGB{
@@ -799,19 +1617,63 @@ type Format10 struct {
 	ParametricDz                float32
 }
 
+func (f10 *Format10) GetPointDataRecordFormat() uint8 {
+	return 10
+}
+
+func (f10 *Format10) HasWavePacket() bool {
+	return true
+}
+
+func (f10 *Format10) GetWavePacket() WavePacket {
+	return WavePacket{
+		WavePacketDescriptorIndex:   f10.WavePacketDescriptorIndex,
+		ByteOffsetToWaveformData:    f10.ByteOffsetToWaveformData,
+		WaveformPacketSizeInBytes:   f10.WaveformPacketSizeInBytes,
+		ReturnPointWaveformLocation: f10.ReturnPointWaveformLocation,
+		ParametricDx:                f10.ParametricDx,
+		ParametricDy:                f10.ParametricDy,
+		ParametricDz:                f10.ParametricDz,
+	}
+}
+
+func (f10 *Format10) setWavePacket(wavePacket WavePacket) {
+	f10.WavePacketDescriptorIndex = wavePacket.WavePacketDescriptorIndex
+	f10.ByteOffsetToWaveformData = wavePacket.ByteOffsetToWaveformData
+	f10.WaveformPacketSizeInBytes = wavePacket.WaveformPacketSizeInBytes
+	f10.ReturnPointWaveformLocation = wavePacket.ReturnPointWaveformLocation
+	f10.ParametricDx = wavePacket.ParametricDx
+	f10.ParametricDy = wavePacket.ParametricDy
+	f10.ParametricDz = wavePacket.ParametricDz
+}
+
 type PDR10 struct {
 	Format10
 	ExtraBytes []byte
 }
 
+func (p *PDR10) GetExtraBytes() []byte {
+	return p.ExtraBytes
+}
+
+func (p *PDR10) SetExtraBytes(extraBytes []byte) {
+	p.ExtraBytes = extraBytes
+}
+
 type PDR10s []PDR10
 
-func (p10 PDR10s) read(file *os.File, offsetIn int64, dataLength uint64) (err error) {
+func (p10 PDR10s) read(reader io.ReaderAt, offsetIn int64, dataLength uint64) (err error) {
 	bytesToRead := make([]byte, uint64(len(p10))*dataLength)
-	_, err = file.ReadAt(bytesToRead, offsetIn)
+	err = readAt(reader, bytesToRead, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "point data records", Err: err}
 		return
 	}
+	err = p10.decode(bytesToRead, dataLength)
+	return
+}
+
+func (p10 PDR10s) decode(bytesToRead []byte, dataLength uint64) (err error) {
 	for index := range p10 {
 		pdrSize := uint64(binary.Size(Format10{}))
 		offset := uint64(index) * dataLength
@@ -828,6 +1690,27 @@ func (p10 PDR10s) read(file *os.File, offsetIn int64, dataLength uint64) (err er
 	return
 }
 
+func (p10 PDR10s) write(w io.Writer, dataLength uint64) (err error) {
+	pdrSize := uint64(binary.Size(Format10{}))
+	for index := range p10 {
+		if err = binary.Write(w, binary.LittleEndian, &p10[index].Format10); err != nil {
+			return
+		}
+		if err = writeExtraBytes(w, p10[index].ExtraBytes, dataLength-pdrSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p10 PDR10s) Len() int {
+	return len(p10)
+}
+
+func (p10 PDR10s) At(index int) (point Point) {
+	return &p10[index]
+}
+
 func (p10 PDR10s) GetCSVList() (output []*XYZRGB) {
 	for _, p := range p10 {
 		csvRow := &XYZRGB{
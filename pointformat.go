@@ -0,0 +1,334 @@
+package las
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+//  _____      _       _      ______                         _
+// |  __ \    (_)     | |    |  ____|                       | |
+// | |__) |__  _ _ __ | |_   | |__ ___  _ __ _ __ ___   __ _| |_ ___
+// |  ___/ _ \| | '_ \| __|  |  __/ _ \| '__| '_ ` _ \ / _` | __/ __|
+// | |  | (_) | | | | | |_   | | | (_) | |  | | | | | | (_| | |_\__ \
+// |_|   \___/|_|_| |_|\__|  |_|  \___/|_|  |_| |_| |_|\__,_|\__|___/
+//
+//
+
+// The fields reported by PointFormatConversionReport.
+const (
+	POINT_FIELD_RETURN_NUMBER     = "return_number"
+	POINT_FIELD_NUMBER_OF_RETURNS = "number_of_returns"
+	POINT_FIELD_CLASSIFICATION    = "classification"
+	POINT_FIELD_OVERLAP           = "overlap"
+	POINT_FIELD_SCANNER_CHANNEL   = "scanner_channel"
+	POINT_FIELD_SCAN_ANGLE        = "scan_angle"
+	POINT_FIELD_GPS_TIME          = "gps_time"
+	POINT_FIELD_RGB               = "rgb"
+	POINT_FIELD_NIR               = "nir"
+	POINT_FIELD_WAVE_PACKET       = "wave_packet"
+)
+
+// PointFormatLoss counts the points whose Field could not be represented by the target format of a conversion.
+type PointFormatLoss struct {
+	Field   string `json:"field"`
+	Points  uint64 `json:"points"`
+	Message string `json:"message"`
+}
+
+func (p PointFormatLoss) String() string {
+	return fmt.Sprintf("%s: %d points: %s", p.Field, p.Points, p.Message)
+}
+
+// PointFormatConversionReport lists the information lost by ConvertPointFormat. It can be marshalled to JSON for
+// machine processing.
+type PointFormatConversionReport struct {
+	SourceFormat uint8             `json:"source_format"`
+	TargetFormat uint8             `json:"target_format"`
+	Losses       []PointFormatLoss `json:"losses"`
+}
+
+// IsLossless reports whether every point was converted without losing information.
+func (r *PointFormatConversionReport) IsLossless() bool {
+	return len(r.Losses) == 0
+}
+
+func (r *PointFormatConversionReport) String() string {
+	lines := []string{fmt.Sprintf("point data record format %d to %d", r.SourceFormat, r.TargetFormat)}
+	for _, loss := range r.Losses {
+		lines = append(lines, loss.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pointFormatLosses counts the points losing each field while converting.
+type pointFormatLosses struct {
+	counts   map[string]uint64
+	messages map[string]string
+	order    []string
+}
+
+func (p *pointFormatLosses) add(field string, message string) {
+	if p.counts == nil {
+		p.counts = make(map[string]uint64)
+		p.messages = make(map[string]string)
+	}
+	if _, ok := p.counts[field]; !ok {
+		p.order = append(p.order, field)
+		p.messages[field] = message
+	}
+	p.counts[field]++
+}
+
+func (p *pointFormatLosses) getLosses() (losses []PointFormatLoss) {
+	for _, field := range p.order {
+		losses = append(losses, PointFormatLoss{Field: field, Points: p.counts[field], Message: p.messages[field]})
+	}
+	return
+}
+
+// ConvertPointFormat converts the point data records of l to the point data record format target and reports the
+// information which the target format cannot hold. The fields are mapped as follows:
+//   - return numbers and numbers of returns above 7 are clamped to 7 by the formats 0 to 5;
+//   - classes above 31 become 0 in the formats 0 to 5, and the overlap flag of the formats 6 to 10 becomes class 12 for
+//     unclassified points and is dropped otherwise; class 12 becomes class 1 with the overlap flag;
+//   - scan angles are rounded to whole degrees by the formats 0 to 5 and to 0.006 degrees otherwise;
+//   - the scanner channel, GPS time, colors, NIR and wave packets are dropped by formats without them, and are zero in
+//     formats which add them;
+//   - the synthetic, key-point and withheld flags, the scan direction and edge of flight line flags, the user data,
+//     the point source ID and the extra bytes are kept.
+//
+// The header is updated, including the record length and the point counts. The waveform bits of the global encoding
+// are cleared by formats without wave packets, and converting to the formats 6 to 10 stores GeoKeys as a coordinate
+// system WKT record, see ConvertGeoKeysToWKT, or drops them if l already holds one. The version of l must define
+// target; l is left unchanged on error.
+func (l *Las) ConvertPointFormat(target uint8) (report PointFormatConversionReport, err error) {
+	source := l.Header.PointDataRecordFormat & LASZIP_FORMAT_MASK
+	report = PointFormatConversionReport{SourceFormat: source, TargetFormat: target}
+	targetSize, err := getPointDataRecordSize(target)
+	if err != nil {
+		return
+	}
+	if l.Header.VersionMajor == 1 && l.Header.VersionMinor < getMinimumVersion(target) {
+		err = fmt.Errorf("point data record format %d requires LAS 1.%d, but the file is LAS %s", target, getMinimumVersion(target), l.Header.GetVersion())
+		return
+	}
+	extraLength := uint16(0)
+	if sourceSize, sizeErr := getPointDataRecordSize(source); sizeErr == nil && l.Header.PointDataRecordLength > sourceSize {
+		extraLength = l.Header.PointDataRecordLength - sourceSize
+	}
+	if uint32(targetSize)+uint32(extraLength) > math.MaxUint16 {
+		err = fmt.Errorf("point data record format %d with %d extra bytes exceeds the maximum record length", target, extraLength)
+		return
+	}
+
+	// the CRS is converted first to fail before converting the points
+	var crs *WKTCRS
+	hasWKT := false
+	if l.Header.GetVersion() == V1_4 && target >= 6 {
+		_, wktErr := l.GetCoordinateSystemWKT()
+		hasWKT = wktErr == nil
+		if !hasWKT {
+			if keys, keysErr := l.GetGeoKeys(); keysErr == nil {
+				if crs, err = NewWKTCRSFromGeoKeys(keys); err != nil {
+					err = fmt.Errorf("point data record format %d requires WKT: %w", target, err)
+					return
+				}
+			}
+		}
+	}
+
+	numberOfPDRs := 0
+	if l.Pdrs != nil {
+		numberOfPDRs = l.Pdrs.Len()
+	}
+	pdrs, err := newPDRs(target, uint64(numberOfPDRs))
+	if err != nil {
+		return
+	}
+	probe, _ := newPDRs(target, 1)
+	targetPoint := probe.At(0)
+	losses := &pointFormatLosses{}
+	for index := 0; index < numberOfPDRs; index++ {
+		convertPoint(l.Pdrs.At(index), pdrs, index, targetPoint, losses)
+	}
+	report.Losses = losses.getLosses()
+
+	converted := l.clone()
+	converted.Pdrs = pdrs
+	converted.Header.PointDataRecordFormat = l.Header.PointDataRecordFormat&^LASZIP_FORMAT_MASK | target
+	converted.Header.PointDataRecordLength = targetSize + extraLength
+	if !targetPoint.HasWavePacket() {
+		converted.Header.GlobalEncoding &^= GLOBAL_ENCODING_WAVEFORM_INTERNAL | GLOBAL_ENCODING_WAVEFORM_EXTERNAL
+	}
+	if err = converted.UpdateHeaderFromPoints(); err != nil {
+		return
+	}
+	if crs != nil {
+		if err = converted.SetCoordinateSystemWKT(crs, WKT_VERSION_1); err != nil {
+			return
+		}
+	} else if hasWKT {
+		// the formats 6 to 10 locate the points by the WKT alone
+		converted.removeGeoKeyRecords()
+		converted.Header.GlobalEncoding |= GLOBAL_ENCODING_WKT
+		converted.updateCRSInfo()
+	}
+	*l = *converted
+	return
+}
+
+// convertPoint stores the point as the record index of pdrs, whose format is that of targetPoint.
+func convertPoint(point Point, pdrs PDRs, index int, targetPoint Point, losses *pointFormatLosses) {
+	legacy := targetPoint.GetPointDataRecordFormat() < 6
+	var format0 Format0
+	var format6 Format6
+	if legacy {
+		format0 = newLegacyFormat(point, losses)
+	} else {
+		format6 = newExtendedFormat(point, losses)
+	}
+
+	gpsTime := point.GetGPSTime()
+	if !targetPoint.HasGPSTime() && gpsTime != 0 {
+		losses.add(POINT_FIELD_GPS_TIME, "GPS time dropped")
+	}
+	red, green, blue := point.GetRGB()
+	if !targetPoint.HasRGB() && (red != 0 || green != 0 || blue != 0) {
+		losses.add(POINT_FIELD_RGB, "colors dropped")
+	}
+	nir := point.GetNIR()
+	if !targetPoint.HasNIR() && nir != 0 {
+		losses.add(POINT_FIELD_NIR, "NIR dropped")
+	}
+	wavePacket := point.GetWavePacket()
+	if !targetPoint.HasWavePacket() && wavePacket != (WavePacket{}) {
+		losses.add(POINT_FIELD_WAVE_PACKET, "wave packet dropped")
+	}
+	// the extra bytes are copied, so that the converted points do not share them with the source
+	extraBytes := append([]byte(nil), point.GetExtraBytes()...)
+
+	format1 := Format1{Format0: format0, GPSTime: gpsTime}
+	format3 := Format3{Format1: format1, Red: red, Green: green, Blue: blue}
+	format6.GPSTime = gpsTime
+	format7 := Format7{Format6: format6, Red: red, Green: green, Blue: blue}
+	format8 := Format8{Format7: format7, NIR: nir}
+	switch records := pdrs.(type) {
+	case PDR0s:
+		records[index] = PDR0{Format0: format0, ExtraBytes: extraBytes}
+	case PDR1s:
+		records[index] = PDR1{Format1: format1, ExtraBytes: extraBytes}
+	case PDR2s:
+		records[index] = PDR2{Format2: Format2{Format0: format0, Red: red, Green: green, Blue: blue}, ExtraBytes: extraBytes}
+	case PDR3s:
+		records[index] = PDR3{Format3: format3, ExtraBytes: extraBytes}
+	case PDR4s:
+		records[index] = PDR4{Format4: Format4{Format1: format1}, ExtraBytes: extraBytes}
+		records[index].setWavePacket(wavePacket)
+	case PDR5s:
+		records[index] = PDR5{Format5: Format5{Format3: format3}, ExtraBytes: extraBytes}
+		records[index].setWavePacket(wavePacket)
+	case PDR6s:
+		records[index] = PDR6{Format6: format6, ExtraBytes: extraBytes}
+	case PDR7s:
+		records[index] = PDR7{Format7: format7, ExtraBytes: extraBytes}
+	case PDR8s:
+		records[index] = PDR8{Format8: format8, ExtraBytes: extraBytes}
+	case PDR9s:
+		records[index] = PDR9{Format9: Format9{Format6: format6}, ExtraBytes: extraBytes}
+		records[index].setWavePacket(wavePacket)
+	case PDR10s:
+		records[index] = PDR10{Format10: Format10{Format8: format8}, ExtraBytes: extraBytes}
+		records[index].setWavePacket(wavePacket)
+	}
+}
+
+// newLegacyFormat returns the fields of the point data record formats 0 to 5 shared by all of them.
+func newLegacyFormat(point Point, losses *pointFormatLosses) (f Format0) {
+	f.X, f.Y, f.Z = point.GetX(), point.GetY(), point.GetZ()
+	f.Intensity = point.GetIntensity()
+	f.UserData = point.GetUserData()
+	f.PointSourceID = point.GetPointSourceID()
+
+	returnNumber, numberOfReturns := point.GetReturnNumber(), point.GetNumberOfReturns()
+	if returnNumber > PDR0_RETURN_NUMBER_MASK {
+		losses.add(POINT_FIELD_RETURN_NUMBER, "return numbers above 7 clamped to 7")
+		returnNumber = PDR0_RETURN_NUMBER_MASK
+	}
+	if numberOfReturns > PDR0_RETURN_NUMBER_MASK {
+		losses.add(POINT_FIELD_NUMBER_OF_RETURNS, "numbers of returns above 7 clamped to 7")
+		numberOfReturns = PDR0_RETURN_NUMBER_MASK
+	}
+	f.Pulse = returnNumber | numberOfReturns<<3 | point.GetScanDirectionFlag()<<6 | point.GetEdgeOfFlightLine()<<7
+	if point.GetScannerChannel() != 0 {
+		losses.add(POINT_FIELD_SCANNER_CHANNEL, "scanner channel dropped")
+	}
+
+	class := point.GetClassification()
+	if class > PDR0_CLASSIFICATION_ATTRIBUTE_MASK {
+		losses.add(POINT_FIELD_CLASSIFICATION, "classes above 31 set to 0")
+		class = 0
+	}
+	if point.IsOverlap() {
+		if class <= uint8(Uncalassified) {
+			class = uint8(Overlap_Points)
+		} else {
+			losses.add(POINT_FIELD_OVERLAP, "overlap flag of classified points dropped")
+		}
+	}
+	f.Classification = class
+	if point.IsSynthetic() {
+		f.Classification |= PDR0_CLASSIFICATION_SYNTHETIC_MASK
+	}
+	if point.IsKeyPoint() {
+		f.Classification |= PDR0_CLASSIFICATION_KEYPOINT_MASK
+	}
+	if point.IsWithheld() {
+		f.Classification |= PDR0_CLASSIFICATION_WITHHELD_MASK
+	}
+
+	scanAngle := point.GetScanAngle()
+	rank := math.Max(math.MinInt8, math.Min(math.MaxInt8, math.Round(scanAngle)))
+	if math.Abs(rank-scanAngle) > PDR6_SCAN_ANGLE_SCALE/2 {
+		losses.add(POINT_FIELD_SCAN_ANGLE, "scan angles rounded to whole degrees")
+	}
+	f.ScanAngleRank = int8(rank)
+	return
+}
+
+// newExtendedFormat returns the fields of the point data record format 6, shared by the formats 6 to 10.
+func newExtendedFormat(point Point, losses *pointFormatLosses) (f Format6) {
+	f.X, f.Y, f.Z = point.GetX(), point.GetY(), point.GetZ()
+	f.Intensity = point.GetIntensity()
+	f.UserData = point.GetUserData()
+	f.PointSourceID = point.GetPointSourceID()
+	f.PulseReturns = point.GetReturnNumber()&PDR6_RETURN_NUMBER_MASK | point.GetNumberOfReturns()<<4
+
+	f.Classification = point.GetClassification()
+	overlap := point.IsOverlap()
+	if point.GetPointDataRecordFormat() < 6 && f.Classification == uint8(Overlap_Points) {
+		f.Classification = uint8(Uncalassified)
+		overlap = true
+	}
+	f.PulseFlags = point.GetScannerChannel()<<4 | point.GetScanDirectionFlag()<<6 | point.GetEdgeOfFlightLine()<<7
+	if point.IsSynthetic() {
+		f.PulseFlags |= PDR6_CLASSIFICATION_SYNTHETIC_MASK
+	}
+	if point.IsKeyPoint() {
+		f.PulseFlags |= PDR6_CLASSIFICATION_KEYPOINT_MASK
+	}
+	if point.IsWithheld() {
+		f.PulseFlags |= PDR6_CLASSIFICATION_WITHHELD_MASK
+	}
+	if overlap {
+		f.PulseFlags |= PDR6_CLASSIFICATION_OVERLAP_MASK
+	}
+
+	scanAngle := point.GetScanAngle()
+	rank := math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(scanAngle/PDR6_SCAN_ANGLE_SCALE)))
+	if math.Abs(rank*PDR6_SCAN_ANGLE_SCALE-scanAngle) > PDR6_SCAN_ANGLE_SCALE/2 {
+		losses.add(POINT_FIELD_SCAN_ANGLE, "scan angles beyond the range of the format clamped")
+	}
+	f.ScanAngleRank = int16(rank)
+	return
+}
@@ -0,0 +1,125 @@
+package las
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestConvertPointFormatDropsGeoKeysBesideWKT(t *testing.T) {
+	l := newTestLas(t, 4, 1, 10, 0)
+	crs, err := NewWKTCRSFromEPSG(32632)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := crs.GetGeoKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = l.SetGeoKeys(keys); err != nil {
+		t.Fatal(err)
+	}
+	wkt, err := NewVLR(PROJECTION_USER_ID, COORDINATE_SYSTEM_WKT_RECORD_ID, "", append([]byte(crs.WKT(WKT_VERSION_1)), 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.AddVLR(wkt)
+	l.updateCRSInfo()
+
+	if _, err = l.ConvertPointFormat(6); err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Vlrs) != 1 || l.Vlrs[0].RecordID() != COORDINATE_SYSTEM_WKT_RECORD_ID {
+		t.Errorf("format 6 holds %d VLRs, want the WKT only", len(l.Vlrs))
+	}
+	report := l.Validate()
+	for _, issue := range report.Issues {
+		if issue.Code == ISSUE_CRS_ENCODING {
+			t.Errorf("format 6 reports %s", issue)
+		}
+	}
+}
+
+func TestConvertPointFormatCopiesExtraBytes(t *testing.T) {
+	l := newTestLas(t, 4, 1, 10, 3)
+	source := l.Pdrs
+	want := append([]byte(nil), source.At(0).GetExtraBytes()...)
+	if _, err := l.ConvertPointFormat(6); err != nil {
+		t.Fatal(err)
+	}
+	converted := l.Pdrs.At(0).GetExtraBytes()
+	if !bytes.Equal(converted, want) {
+		t.Fatalf("converted extra bytes are %v, want %v", converted, want)
+	}
+	converted[0] ^= 0xFF
+	if !bytes.Equal(source.At(0).GetExtraBytes(), want) {
+		t.Error("changing the extra bytes of a converted point changes the source point")
+	}
+}
+
+func TestConvertPointFormatRoundTrip(t *testing.T) {
+	for source := uint8(0); source <= 10; source++ {
+		for target := uint8(0); target <= 10; target++ {
+			t.Run(fmt.Sprintf("format %d to %d", source, target), func(t *testing.T) {
+				l := newTestLas(t, 4, source, 200, 3)
+				setTestGPSTimes(l)
+				want := encodeTestPDRs(t, l)
+				sourcePoint, targetPoint := l.Pdrs.At(0), newTestLas(t, 4, target, 1, 0).Pdrs.At(0)
+
+				report, err := l.ConvertPointFormat(target)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if l.Pdrs.Len() != 200 || l.Header.PointDataRecordFormat != target {
+					t.Fatalf("converted to %d points of format %d", l.Pdrs.Len(), l.Header.PointDataRecordFormat)
+				}
+				// the random points hold every field, so each one the target lacks is reported
+				dropped := map[string]bool{}
+				for field, lost := range map[string]bool{
+					POINT_FIELD_GPS_TIME:    sourcePoint.HasGPSTime() && !targetPoint.HasGPSTime(),
+					POINT_FIELD_RGB:         sourcePoint.HasRGB() && !targetPoint.HasRGB(),
+					POINT_FIELD_NIR:         sourcePoint.HasNIR() && !targetPoint.HasNIR(),
+					POINT_FIELD_WAVE_PACKET: sourcePoint.HasWavePacket() && !targetPoint.HasWavePacket(),
+				} {
+					if lost {
+						dropped[field] = true
+					}
+				}
+				keepsFields := len(dropped) == 0
+				for _, loss := range report.Losses {
+					delete(dropped, loss.Field)
+				}
+				for field := range dropped {
+					t.Errorf("dropping the field %s is not reported", field)
+				}
+				if report := reparseTestLas(t, l).Validate(); report.HasErrors() {
+					t.Errorf("format %d reports errors:\n%s", target, report.String())
+				}
+
+				if _, err = l.ConvertPointFormat(source); err != nil {
+					t.Fatal(err)
+				}
+				if sameFamily := (source < 6) == (target < 6); keepsFields && sameFamily {
+					if !report.IsLossless() || !bytes.Equal(encodeTestPDRs(t, l), want) {
+						t.Errorf("point data records differ after a round trip keeping every field:\n%s", report.String())
+					}
+				}
+
+				// a round trip leaves the points representable by both formats, so repeating it changes nothing
+				want = encodeTestPDRs(t, l)
+				if report, err = l.ConvertPointFormat(target); err != nil {
+					t.Fatal(err)
+				}
+				if !report.IsLossless() {
+					t.Errorf("repeated conversion reports losses:\n%s", report.String())
+				}
+				if report, err = l.ConvertPointFormat(source); err != nil {
+					t.Fatal(err)
+				}
+				if !report.IsLossless() || !bytes.Equal(encodeTestPDRs(t, l), want) {
+					t.Errorf("point data records differ after the repeated round trip:\n%s", report.String())
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package las
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+//  _____      _       _   _____                _
+// |  __ \    (_)     | | |  __ \              | |
+// | |__) |__  _ _ __ | |_| |__) |___  __ _  __| | ___ _ __
+// |  ___/ _ \| | '_ \| __|  _  // _ \/ _` |/ _` |/ _ \ '__|
+// | |  | (_) | | | | | |_| | \ \  __/ (_| | (_| |  __/ |
+// |_|   \___/|_|_| |_|\__|_|  \_\___|\__,_|\__,_|\___|_|
+//
+//
+
+// POINT_READER_CHUNK_SIZE is the default number of point data records decoded at once by a PointReader.
+const POINT_READER_CHUNK_SIZE = 65536
+
+// PointReader streams the point data records of a LAS file in fixed-size chunks, so that files of arbitrary size can be
+// processed in bounded memory. The public header block, VLRs and EVLRs are read when the reader is opened. LAZ files
+// are decompressed one LASzip chunk at a time, so their chunk size is the one the file was compressed with.
+//
+// SetRectangle restricts the reader to the points within a rectangle. If the reader has a LAX index, either the LAX
+// file next to the file opened by OpenReader or one set with SetLaxIndex, only the point ranges of the index cells
+// overlapping the rectangle are read.
+//
+//	reader, err := las.OpenReader("./pointcloud.las")
+//	if err != nil {
+//		return err
+//	}
+//	defer reader.Close()
+//	for reader.Next() {
+//		pdr := reader.Point()
+//		...
+//	}
+//	return reader.Err()
+type PointReader struct {
+	Header PublicHeaderBlock
+	Vlrs   []VLR
+	Evlrs  []EVLR
+
+	reader       io.ReaderAt
+	closer       io.Closer
+	chunkSize    uint64
+	numberOfPDRs uint64
+	pdrsRead     uint64
+	chunk        PDRs
+	chunkStart   uint64
+	index        int
+	err          error
+	lasZip       *LASzip
+	lazChunks    []lazChunk
+	laxIndex     *LaxIndex
+	rectangle    *BoundingBox
+	ranges       []LaxInterval
+}
+
+// OpenReader opens a LAS file for streaming its point data records with a chunk size of POINT_READER_CHUNK_SIZE.
+func OpenReader(filename string) (r *PointReader, err error) {
+	return OpenReaderWithChunkSize(filename, POINT_READER_CHUNK_SIZE)
+}
+
+// OpenReaderWithChunkSize opens a LAS file for streaming its point data records, decoding chunkSize records at once.
+func OpenReaderWithChunkSize(filename string, chunkSize int) (r *PointReader, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return
+	}
+	if r, err = NewReader(file, info.Size(), chunkSize); err != nil {
+		file.Close()
+		return
+	}
+	r.closer = file
+	laxIndex, err := ReadLaxIndex(LaxFilename(filename))
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err == nil {
+		err = r.SetLaxIndex(laxIndex)
+	}
+	if err != nil {
+		err = fmt.Errorf("LAX file of %s: %v", filename, err)
+		file.Close()
+		r = nil
+		return
+	}
+	return
+}
+
+// NewReader streams the point data records of a LAS file of size bytes from reader, decoding chunkSize records at
+// once. Close does not close reader.
+func NewReader(reader io.ReaderAt, size int64, chunkSize int) (r *PointReader, err error) {
+	if chunkSize <= 0 {
+		err = fmt.Errorf("chunk size must be positive. Chunk size: %d", chunkSize)
+		return
+	}
+
+	l := Las{}
+	if err = l.readPHB(reader); err != nil {
+		return
+	}
+	if err = l.readVLRs(reader); err != nil {
+		return
+	}
+	if err = l.readEVLRs(reader, size); err != nil {
+		return
+	}
+	if err = l.checkPointDataRecordFormat(); err != nil {
+		return
+	}
+	var lasZip *LASzip
+	var lazChunks []lazChunk
+	if l.isFileCompressed() {
+		if lasZip, lazChunks, err = l.getLazChunks(reader, size); err != nil {
+			return
+		}
+	}
+
+	r = &PointReader{
+		Header:       l.Header,
+		Vlrs:         l.Vlrs,
+		Evlrs:        l.Evlrs,
+		reader:       reader,
+		chunkSize:    uint64(chunkSize),
+		numberOfPDRs: l.getNumberOfPDRs(),
+		lasZip:       lasZip,
+		lazChunks:    lazChunks,
+	}
+	return
+}
+
+// Next advances the reader to the next point data record. It returns false when all records are read or an error
+// occurred, which is then reported by Err.
+func (r *PointReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	for {
+		r.index++
+		if r.chunk == nil || r.index >= r.chunk.Len() {
+			if r.pdrsRead >= r.numberOfPDRs {
+				return false
+			}
+			if r.err = r.readChunk(); r.err != nil {
+				return false
+			}
+			if r.chunk == nil {
+				return false
+			}
+		}
+		if r.isInRectangle(r.chunk.At(r.index)) {
+			return true
+		}
+	}
+}
+
+// Index returns the index of the current point data record within the file.
+func (r *PointReader) Index() uint64 {
+	return r.chunkStart + uint64(r.index)
+}
+
+// SetLaxIndex sets the LAX index used to find the point ranges overlapping the rectangle set with SetRectangle.
+func (r *PointReader) SetLaxIndex(index *LaxIndex) (err error) {
+	if r.chunk != nil {
+		err = fmt.Errorf("LAX index must be set before reading point data records")
+		return
+	}
+	if len(index.cells) != 0 && uint64(index.getLastPointIndex()) >= r.numberOfPDRs {
+		err = fmt.Errorf("LAX index refers to point %d, but the file holds %d points", index.getLastPointIndex(), r.numberOfPDRs)
+		return
+	}
+	r.laxIndex = index
+	r.updateRanges()
+	return
+}
+
+// SetRectangle restricts the reader to the point data records whose x and y, with scale and offset applied, lie
+// within the rectangle from minX, minY to maxX, maxY, boundary included. It must be called before reading.
+func (r *PointReader) SetRectangle(minX float64, minY float64, maxX float64, maxY float64) (err error) {
+	if r.chunk != nil {
+		err = fmt.Errorf("rectangle must be set before reading point data records")
+		return
+	}
+	r.rectangle = &BoundingBox{MinX: minX, MinY: minY, MinZ: math.Inf(-1), MaxX: maxX, MaxY: maxY, MaxZ: math.Inf(1)}
+	r.updateRanges()
+	return
+}
+
+// updateRanges looks up the point ranges of the LAX index cells overlapping the rectangle.
+func (r *PointReader) updateRanges() {
+	r.ranges = nil
+	if r.laxIndex != nil && r.rectangle != nil {
+		box := r.rectangle
+		r.ranges = append([]LaxInterval{}, r.laxIndex.QueryRectangle(box.MinX, box.MinY, box.MaxX, box.MaxY)...)
+	}
+}
+
+func (r *PointReader) isInRectangle(point Point) bool {
+	if r.rectangle == nil {
+		return true
+	}
+	x, y, _ := r.Header.GetScaledXYZ(point)
+	return r.rectangle.Contains(x, y, 0)
+}
+
+// nextRange returns the first point data record to read from start on and the number of records following it which
+// are to be read, according to the point ranges of the LAX index.
+func (r *PointReader) nextRange(start uint64) (first uint64, count uint64) {
+	if r.ranges == nil {
+		return start, r.numberOfPDRs - start
+	}
+	for len(r.ranges) != 0 && uint64(r.ranges[0].End) < start {
+		r.ranges = r.ranges[1:]
+	}
+	if len(r.ranges) == 0 {
+		return r.numberOfPDRs, 0
+	}
+	first = start
+	if uint64(r.ranges[0].Start) > first {
+		first = uint64(r.ranges[0].Start)
+	}
+	count = uint64(r.ranges[0].End) + 1 - first
+	return
+}
+
+// Point returns the current point data record. The returned value is a pointer to the PDRn type of the file's point
+// data record format, e.g. *PDR3, and is only valid until the next call to Next.
+func (r *PointReader) Point() Point {
+	return r.chunk.At(r.index)
+}
+
+// Err returns the first error encountered while reading point data records.
+func (r *PointReader) Err() error {
+	return r.err
+}
+
+// Close closes the file opened by OpenReader or OpenReaderWithChunkSize.
+func (r *PointReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+func (r *PointReader) readChunk() (err error) {
+	if r.lasZip != nil {
+		return r.readLazChunk()
+	}
+	start, count := r.nextRange(r.pdrsRead)
+	if count == 0 {
+		r.pdrsRead = r.numberOfPDRs
+		r.chunk = nil
+		return
+	}
+	if count > r.chunkSize {
+		count = r.chunkSize
+	}
+	if r.chunk == nil || uint64(r.chunk.Len()) != count {
+		if r.chunk, err = newPDRs(r.Header.PointDataRecordFormat, count); err != nil {
+			return
+		}
+	}
+	dataLength := uint64(r.Header.PointDataRecordLength)
+	offset := int64(uint64(r.Header.OffsetToPointData) + start*dataLength)
+	if err = r.chunk.read(r.reader, offset, dataLength); err != nil {
+		return
+	}
+	r.chunkStart = start
+	r.pdrsRead = start + count
+	r.index = 0
+	return
+}
+
+func (r *PointReader) readLazChunk() (err error) {
+	// skip the chunks before the next point range
+	for len(r.lazChunks) != 0 {
+		start, count := r.nextRange(r.pdrsRead)
+		if count == 0 {
+			r.pdrsRead = r.numberOfPDRs
+			r.chunk = nil
+			return
+		}
+		if start < r.pdrsRead+r.lazChunks[0].numberOfPoints {
+			break
+		}
+		r.pdrsRead += r.lazChunks[0].numberOfPoints
+		r.lazChunks = r.lazChunks[1:]
+	}
+	if len(r.lazChunks) == 0 {
+		err = fmt.Errorf("LAZ chunks hold %d point data records, expected %d", r.pdrsRead, r.numberOfPDRs)
+		return
+	}
+	chunk := r.lazChunks[0]
+	r.lazChunks = r.lazChunks[1:]
+	count := chunk.numberOfPoints
+	if count > r.numberOfPDRs-r.pdrsRead {
+		count = r.numberOfPDRs - r.pdrsRead
+	}
+	raw, err := r.lasZip.readLazChunk(r.reader, chunk)
+	if err != nil {
+		return
+	}
+	if r.chunk, err = newPDRs(r.Header.PointDataRecordFormat&LASZIP_FORMAT_MASK, count); err != nil {
+		return
+	}
+	dataLength := uint64(r.Header.PointDataRecordLength)
+	if err = r.chunk.decode(raw[:count*dataLength], dataLength); err != nil {
+		return
+	}
+	r.chunkStart = r.pdrsRead
+	r.pdrsRead += count
+	r.index = 0
+	return
+}
@@ -0,0 +1,104 @@
+package las
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// appendTestPoint appends point as stored in a LAS file of the point data record length to raw.
+func appendTestPoint(t *testing.T, raw []byte, point Point, dataLength uint16) []byte {
+	t.Helper()
+	single, err := newPDRs(point.GetPointDataRecordFormat(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reflect.ValueOf(single).Index(0).Set(reflect.ValueOf(point).Elem())
+	buffer := bytes.NewBuffer(raw)
+	if err = single.write(buffer, uint64(dataLength)); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestPointReader(t *testing.T) {
+	tests := []struct {
+		name         string
+		format       uint8
+		numberOfPDRs int
+		extraLength  uint16
+		chunkSize    int
+		compressed   bool
+	}{
+		{"empty file", 1, 0, 0, 10, false},
+		{"single point", 0, 1, 0, 1, false},
+		{"chunks of one point", 3, 100, 0, 1, false},
+		{"chunk size dividing the points", 1, 1000, 0, 100, false},
+		{"chunk size leaving a remainder", 6, 1000, 0, 7, false},
+		{"chunk size equal to the points", 7, 1000, 0, 1000, false},
+		{"chunk size exceeding the points", 8, 1000, 0, 5000, false},
+		{"extra bytes", 10, 100, 5, 30, false},
+		{"empty LAZ file", 1, 0, 0, 10, true},
+		{"LAZ single point", 0, 1, 0, 1, true},
+		{"LAZ in one chunk", 3, 1000, 0, 7, true},
+		{"LAZ in several chunks", 6, 120000, 0, 100, true},
+		{"LAZ with extra bytes", 7, 100, 5, 30, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newTestLas(t, 4, test.format, test.numberOfPDRs, test.extraLength)
+			filename := filepath.Join(t.TempDir(), "test.las")
+			if test.compressed {
+				l.Header.PointDataRecordFormat |= LASZIP_FORMAT_COMPRESSED
+			}
+			if err := l.Write(filename); err != nil {
+				t.Fatal(err)
+			}
+			parsed := &Las{}
+			if err := parsed.Parse(filename); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := OpenReaderWithChunkSize(filename, test.chunkSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			// the reader keeps the LASzip VLR and the compression bit of the file header
+			header := r.Header
+			header.PointDataRecordFormat &= LASZIP_FORMAT_MASK
+			header.NumberOfVLRs = parsed.Header.NumberOfVLRs
+			if header != parsed.Header || int(r.Header.NumberOfVLRs) != len(r.Vlrs) {
+				t.Errorf("reader header %+v differs from the parsed header %+v", r.Header, parsed.Header)
+			}
+			var raw []byte
+			read := 0
+			for r.Next() {
+				if r.Index() != uint64(read) {
+					t.Fatalf("point %d has index %d", read, r.Index())
+				}
+				raw = appendTestPoint(t, raw, r.Point(), r.Header.PointDataRecordLength)
+				read++
+			}
+			if err = r.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(raw, encodeTestPDRs(t, parsed)) {
+				t.Error("points read by the reader differ from the parsed points")
+			}
+			if read != test.numberOfPDRs {
+				t.Errorf("read %d points, want %d", read, test.numberOfPDRs)
+			}
+		})
+	}
+}
+
+func TestNewReaderChunkSize(t *testing.T) {
+	data := writeTestLas(t, newTestLas(t, 4, 1, 10, 0))
+	for _, chunkSize := range []int{0, -1} {
+		if _, err := NewReader(bytes.NewReader(data), int64(len(data)), chunkSize); err == nil {
+			t.Errorf("opened a reader with chunk size %d", chunkSize)
+		}
+	}
+}
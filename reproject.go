@@ -0,0 +1,823 @@
+package las
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+//   _____                _
+//  / ____|              | |
+// | |  __  ___  ___   __| | ___  ___ _   _
+// | | |_ |/ _ \/ _ \ / _` |/ _ \/ __| | | |
+// | |__| |  __/ (_) | (_| |  __/\__ \ |_| |
+//  \_____|\___|\___/ \__,_|\___||___/\__, |
+//                                     __/ |
+//                                    |___/
+
+// The map projections follow the formulas of IOGP Guidance Note 7-2, except for Transverse Mercator, which uses the
+// series of Krüger to the sixth order in n as given by Karney (2011) and is accurate to a few nanometres within 4000 km
+// of the central meridian. Angles are in radians and lengths in metres.
+
+type ellipsoid struct {
+	a  float64
+	f  float64
+	e  float64
+	e2 float64
+}
+
+func newEllipsoid(wktEllipsoid *WKTEllipsoid) (e ellipsoid, err error) {
+	if wktEllipsoid == nil {
+		err = fmt.Errorf("datum has no ellipsoid")
+		return
+	}
+	e.a = wktEllipsoid.SemiMajorAxis
+	if wktEllipsoid.Unit != nil {
+		e.a *= wktEllipsoid.Unit.Factor
+	}
+	if !(e.a > 0) {
+		err = fmt.Errorf("semi-major axis of ellipsoid %q is not positive", wktEllipsoid.Name)
+		return
+	}
+	if wktEllipsoid.InverseFlattening != 0 {
+		e.f = 1 / wktEllipsoid.InverseFlattening
+	}
+	e.e2 = e.f * (2 - e.f)
+	e.e = math.Sqrt(e.e2)
+	return
+}
+
+// isometricLatitude returns the isometric latitude of lat, the inverse of latitudeFromIsometric.
+func (e *ellipsoid) isometricLatitude(lat float64) float64 {
+	sinLat := math.Sin(lat)
+	return math.Atanh(sinLat) - e.e*math.Atanh(e.e*sinLat)
+}
+
+func (e *ellipsoid) latitudeFromIsometric(psi float64) (lat float64) {
+	lat = 2*math.Atan(math.Exp(psi)) - math.Pi/2
+	for iteration := 0; iteration < 15; iteration++ {
+		sinLat := e.e * math.Sin(lat)
+		next := 2*math.Atan(math.Exp(psi)*math.Pow((1+sinLat)/(1-sinLat), e.e/2)) - math.Pi/2
+		if math.Abs(next-lat) < 1e-14 {
+			return next
+		}
+		lat = next
+	}
+	return
+}
+
+// m returns cos(lat) divided by the radius of curvature in the prime vertical in units of a.
+func (e *ellipsoid) m(lat float64) float64 {
+	sinLat := math.Sin(lat)
+	return math.Cos(lat) / math.Sqrt(1-e.e2*sinLat*sinLat)
+}
+
+// toGeocentric returns the geocentric coordinates of a longitude, latitude and ellipsoidal height.
+func (e *ellipsoid) toGeocentric(lon float64, lat float64, h float64) (x float64, y float64, z float64) {
+	sinLat, cosLat := math.Sincos(lat)
+	n := e.a / math.Sqrt(1-e.e2*sinLat*sinLat)
+	x = (n + h) * cosLat * math.Cos(lon)
+	y = (n + h) * cosLat * math.Sin(lon)
+	z = (n*(1-e.e2) + h) * sinLat
+	return
+}
+
+func (e *ellipsoid) fromGeocentric(x float64, y float64, z float64) (lon float64, lat float64, h float64) {
+	p := math.Hypot(x, y)
+	lon = math.Atan2(y, x)
+	lat = math.Atan2(z, p*(1-e.e2))
+	for iteration := 0; iteration < 10; iteration++ {
+		sinLat := math.Sin(lat)
+		n := e.a / math.Sqrt(1-e.e2*sinLat*sinLat)
+		next := math.Atan2(z+e.e2*n*sinLat, p)
+		if math.Abs(next-lat) < 1e-14 {
+			lat = next
+			break
+		}
+		lat = next
+	}
+	sinLat, cosLat := math.Sincos(lat)
+	h = p*cosLat + z*sinLat - e.a*math.Sqrt(1-e.e2*sinLat*sinLat)
+	return
+}
+
+// helmert is a Helmert transformation to WGS 84 in the position vector convention, with the rotations in radians and
+// the scale as a factor.
+type helmert struct {
+	tx, ty, tz float64
+	rx, ry, rz float64
+	scale      float64
+}
+
+func newHelmert(toWGS84 []float64) (h helmert) {
+	h.scale = 1
+	h.tx, h.ty, h.tz = toWGS84[0], toWGS84[1], toWGS84[2]
+	if len(toWGS84) == 7 {
+		h.rx, h.ry, h.rz = toWGS84[3]*ARC_SECOND, toWGS84[4]*ARC_SECOND, toWGS84[5]*ARC_SECOND
+		h.scale = 1 + toWGS84[6]*1e-6
+	}
+	return
+}
+
+func (h *helmert) forward(x float64, y float64, z float64) (float64, float64, float64) {
+	return h.tx + h.scale*(x-h.rz*y+h.ry*z),
+		h.ty + h.scale*(h.rz*x+y-h.rx*z),
+		h.tz + h.scale*(-h.ry*x+h.rx*y+z)
+}
+
+// inverse solves the linear system of forward rather than negating the parameters, so that a round trip is exact.
+func (h *helmert) inverse(x float64, y float64, z float64) (float64, float64, float64) {
+	x, y, z = (x-h.tx)/h.scale, (y-h.ty)/h.scale, (z-h.tz)/h.scale
+	det := 1 + h.rx*h.rx + h.ry*h.ry + h.rz*h.rz
+	return ((1+h.rx*h.rx)*x + (h.rx*h.ry+h.rz)*y + (h.rx*h.rz-h.ry)*z) / det,
+		((h.rx*h.ry-h.rz)*x + (1+h.ry*h.ry)*y + (h.ry*h.rz+h.rx)*z) / det,
+		((h.rx*h.rz+h.ry)*x + (h.ry*h.rz-h.rx)*y + (1+h.rz*h.rz)*z) / det
+}
+
+// mapProjection converts between longitude and latitude relative to the prime meridian and projected coordinates.
+type mapProjection interface {
+	forward(lon float64, lat float64) (x float64, y float64)
+	inverse(x float64, y float64) (lon float64, lat float64)
+}
+
+// projectionParameters gives the parameters of a projection in radians, metres and unity.
+type projectionParameters struct {
+	projection *WKTProjection
+}
+
+func (p projectionParameters) get(code int, defaultValue float64) float64 {
+	parameter := p.projection.GetParameter(code)
+	if parameter == nil {
+		return defaultValue
+	}
+	if parameter.Unit == nil {
+		return parameter.Value
+	}
+	return parameter.Value * parameter.Unit.Factor
+}
+
+func (p projectionParameters) has(code int) bool {
+	return p.projection.GetParameter(code) != nil
+}
+
+// newMapProjection returns the projection of a projected CRS on the ellipsoid e. Transverse Mercator, Lambert
+// Conic Conformal, Albers Equal Area and Mercator, including Popular Visualisation Pseudo-Mercator, are supported.
+func newMapProjection(projection *WKTProjection, e ellipsoid) (m mapProjection, err error) {
+	parameters := projectionParameters{projection: projection}
+	method := getWKTMethod(projection.MethodCode, projection.Method)
+	code := 0
+	if method != nil {
+		code = method.code
+	}
+	switch code {
+	case 9807:
+		m = newTransverseMercator(e, parameters.get(8801, 0), parameters.get(8802, 0), parameters.get(8805, 1),
+			parameters.get(8806, 0), parameters.get(8807, 0))
+	case 9801:
+		lat0 := parameters.get(8801, 0)
+		m = newLambertConicConformal(e, lat0, parameters.get(8802, 0), lat0, lat0, parameters.get(8805, 1),
+			parameters.get(8806, 0), parameters.get(8807, 0))
+	case 9802:
+		m = newLambertConicConformal(e, parameters.get(8821, 0), parameters.get(8822, 0), parameters.get(8823, 0),
+			parameters.get(8824, 0), 1, parameters.get(8826, 0), parameters.get(8827, 0))
+	case 9822:
+		m = newAlbersEqualArea(e, parameters.get(8821, 0), parameters.get(8822, 0), parameters.get(8823, 0),
+			parameters.get(8824, 0), parameters.get(8826, 0), parameters.get(8827, 0))
+	case 9804, 9805:
+		scale := parameters.get(8805, 1)
+		if code == 9805 || (parameters.has(8823) && !parameters.has(8805)) {
+			scale = e.m(parameters.get(8823, 0))
+		}
+		m = &mercator{e: e, lon0: parameters.get(8802, 0), k0: scale, falseEasting: parameters.get(8806, 0), falseNorthing: parameters.get(8807, 0)}
+	case 1024:
+		// the spherical formulas applied to the ellipsoidal coordinates
+		sphere := ellipsoid{a: e.a}
+		m = &mercator{e: sphere, lon0: parameters.get(8802, 0), k0: 1, falseEasting: parameters.get(8806, 0), falseNorthing: parameters.get(8807, 0)}
+	default:
+		err = fmt.Errorf("projection method %q is not supported for reprojection", projection.Method)
+	}
+	return
+}
+
+type transverseMercator struct {
+	lon0          float64
+	k0            float64
+	falseEasting  float64
+	falseNorthing float64
+	e             ellipsoid
+	rectifyingA   float64
+	m0            float64
+	alpha         [6]float64
+	beta          [6]float64
+}
+
+func newTransverseMercator(e ellipsoid, lat0 float64, lon0 float64, k0 float64, falseEasting float64, falseNorthing float64) *transverseMercator {
+	t := &transverseMercator{lon0: lon0, k0: k0, falseEasting: falseEasting, falseNorthing: falseNorthing, e: e}
+	n := e.f / (2 - e.f)
+	n2 := n * n
+	n3, n4, n5, n6 := n2*n, n2*n2, n2*n2*n, n2*n2*n2
+	t.rectifyingA = e.a / (1 + n) * (1 + n2/4 + n4/64 + n6/256)
+	t.alpha = [6]float64{
+		n/2 - 2*n2/3 + 5*n3/16 + 41*n4/180 - 127*n5/288 + 7891*n6/37800,
+		13*n2/48 - 3*n3/5 + 557*n4/1440 + 281*n5/630 - 1983433*n6/1935360,
+		61*n3/240 - 103*n4/140 + 15061*n5/26880 + 167603*n6/181440,
+		49561*n4/161280 - 179*n5/168 + 6601661*n6/7257600,
+		34729*n5/80640 - 3418889*n6/1995840,
+		212378941 * n6 / 319334400,
+	}
+	t.beta = [6]float64{
+		n/2 - 2*n2/3 + 37*n3/96 - n4/360 - 81*n5/512 + 96199*n6/604800,
+		n2/48 + n3/15 - 437*n4/1440 + 46*n5/105 - 1118711*n6/3870720,
+		17*n3/480 - 37*n4/840 - 209*n5/4480 + 5569*n6/90720,
+		4397*n4/161280 - 11*n5/504 - 830251*n6/7257600,
+		4583*n5/161280 - 108847*n6/3991680,
+		20648693 * n6 / 638668800,
+	}
+	// the meridian distance of the latitude of origin
+	xi, _ := t.gaussKruger(0, lat0)
+	t.m0 = t.rectifyingA * xi
+	return t
+}
+
+// gaussKruger returns the ellipsoidal coordinates xi and eta of the projection for a longitude relative to the
+// central meridian.
+func (t *transverseMercator) gaussKruger(lon float64, lat float64) (xi float64, eta float64) {
+	tau := math.Sinh(t.e.isometricLatitude(lat))
+	xiPrime := math.Atan2(tau, math.Cos(lon))
+	etaPrime := math.Atanh(math.Sin(lon) / math.Sqrt(1+tau*tau))
+	xi, eta = xiPrime, etaPrime
+	for j, alpha := range t.alpha {
+		k := 2 * float64(j+1)
+		xi += alpha * math.Sin(k*xiPrime) * math.Cosh(k*etaPrime)
+		eta += alpha * math.Cos(k*xiPrime) * math.Sinh(k*etaPrime)
+	}
+	return
+}
+
+func (t *transverseMercator) forward(lon float64, lat float64) (x float64, y float64) {
+	xi, eta := t.gaussKruger(lon-t.lon0, lat)
+	x = t.falseEasting + t.k0*t.rectifyingA*eta
+	y = t.falseNorthing + t.k0*(t.rectifyingA*xi-t.m0)
+	return
+}
+
+func (t *transverseMercator) inverse(x float64, y float64) (lon float64, lat float64) {
+	xi := (y - t.falseNorthing + t.k0*t.m0) / (t.k0 * t.rectifyingA)
+	eta := (x - t.falseEasting) / (t.k0 * t.rectifyingA)
+	xiPrime, etaPrime := xi, eta
+	for j, beta := range t.beta {
+		k := 2 * float64(j+1)
+		xiPrime -= beta * math.Sin(k*xi) * math.Cosh(k*eta)
+		etaPrime -= beta * math.Cos(k*xi) * math.Sinh(k*eta)
+	}
+	conformal := math.Asin(math.Sin(xiPrime) / math.Cosh(etaPrime))
+	lon = t.lon0 + math.Atan2(math.Sinh(etaPrime), math.Cos(xiPrime))
+	lat = t.e.latitudeFromIsometric(math.Asinh(math.Tan(conformal)))
+	return
+}
+
+// lambertConicConformal is the 2SP variant; the 1SP variant has both standard parallels at the latitude of origin
+// and a scale factor.
+type lambertConicConformal struct {
+	lon0          float64
+	falseEasting  float64
+	falseNorthing float64
+	e             ellipsoid
+	n             float64
+	aF            float64
+	r0            float64
+}
+
+func newLambertConicConformal(e ellipsoid, lat0 float64, lon0 float64, lat1 float64, lat2 float64, k0 float64, falseEasting float64, falseNorthing float64) *lambertConicConformal {
+	l := &lambertConicConformal{lon0: lon0, falseEasting: falseEasting, falseNorthing: falseNorthing, e: e}
+	t := func(lat float64) float64 { return math.Exp(-e.isometricLatitude(lat)) }
+	if math.Abs(lat1-lat2) < 1e-12 {
+		l.n = math.Sin(lat1)
+	} else {
+		l.n = (math.Log(e.m(lat1)) - math.Log(e.m(lat2))) / (math.Log(t(lat1)) - math.Log(t(lat2)))
+	}
+	l.aF = e.a * k0 * e.m(lat1) / (l.n * math.Pow(t(lat1), l.n))
+	l.r0 = l.aF * math.Pow(t(lat0), l.n)
+	return l
+}
+
+func (l *lambertConicConformal) forward(lon float64, lat float64) (x float64, y float64) {
+	r := l.aF * math.Pow(math.Exp(-l.e.isometricLatitude(lat)), l.n)
+	theta := l.n * normalizeLongitude(lon-l.lon0)
+	x = l.falseEasting + r*math.Sin(theta)
+	y = l.falseNorthing + l.r0 - r*math.Cos(theta)
+	return
+}
+
+func (l *lambertConicConformal) inverse(x float64, y float64) (lon float64, lat float64) {
+	dx, dy := x-l.falseEasting, l.r0-(y-l.falseNorthing)
+	if l.n < 0 {
+		dx, dy = -dx, -dy
+	}
+	r := math.Copysign(math.Hypot(dx, dy), l.n)
+	t := math.Pow(r/l.aF, 1/l.n)
+	lon = l.lon0 + math.Atan2(dx, dy)/l.n
+	lat = l.e.latitudeFromIsometric(-math.Log(t))
+	return
+}
+
+type albersEqualArea struct {
+	lon0          float64
+	falseEasting  float64
+	falseNorthing float64
+	e             ellipsoid
+	n             float64
+	c             float64
+	rho0          float64
+	qPole         float64
+}
+
+func newAlbersEqualArea(e ellipsoid, lat0 float64, lon0 float64, lat1 float64, lat2 float64, falseEasting float64, falseNorthing float64) *albersEqualArea {
+	a := &albersEqualArea{lon0: lon0, falseEasting: falseEasting, falseNorthing: falseNorthing, e: e}
+	m1, m2 := e.m(lat1), e.m(lat2)
+	q1, q2 := a.q(lat1), a.q(lat2)
+	if math.Abs(lat1-lat2) < 1e-12 {
+		a.n = math.Sin(lat1)
+	} else {
+		a.n = (m1*m1 - m2*m2) / (q2 - q1)
+	}
+	a.c = m1*m1 + a.n*q1
+	a.rho0 = e.a * math.Sqrt(a.c-a.n*a.q(lat0)) / a.n
+	a.qPole = a.q(math.Pi / 2)
+	return a
+}
+
+// q returns the authalic function of the latitude, called alpha by the guidance note.
+func (a *albersEqualArea) q(lat float64) float64 {
+	sinLat := math.Sin(lat)
+	if a.e.e == 0 {
+		return 2 * sinLat
+	}
+	e := a.e.e
+	return (1 - a.e.e2) * (sinLat/(1-a.e.e2*sinLat*sinLat) - math.Log((1-e*sinLat)/(1+e*sinLat))/(2*e))
+}
+
+func (a *albersEqualArea) forward(lon float64, lat float64) (x float64, y float64) {
+	rho := a.e.a * math.Sqrt(a.c-a.n*a.q(lat)) / a.n
+	theta := a.n * normalizeLongitude(lon-a.lon0)
+	x = a.falseEasting + rho*math.Sin(theta)
+	y = a.falseNorthing + a.rho0 - rho*math.Cos(theta)
+	return
+}
+
+func (a *albersEqualArea) inverse(x float64, y float64) (lon float64, lat float64) {
+	dx, dy := x-a.falseEasting, a.rho0-(y-a.falseNorthing)
+	if a.n < 0 {
+		dx, dy = -dx, -dy
+	}
+	rho := math.Hypot(dx, dy)
+	q := (a.c - rho*rho*a.n*a.n/(a.e.a*a.e.a)) / a.n
+	lon = a.lon0 + math.Atan2(dx, dy)/a.n
+	authalic := math.Asin(math.Max(-1, math.Min(1, q/a.qPole)))
+	e2 := a.e.e2
+	e4, e6 := e2*e2, e2*e2*e2
+	lat = authalic +
+		(e2/3+31*e4/180+517*e6/5040)*math.Sin(2*authalic) +
+		(23*e4/360+251*e6/3780)*math.Sin(4*authalic) +
+		(761*e6/45360)*math.Sin(6*authalic)
+	if a.e.e == 0 {
+		return
+	}
+	// the series is accurate to a millimetre; Newton's method refines it
+	for iteration := 0; iteration < 5; iteration++ {
+		sinLat, cosLat := math.Sincos(lat)
+		w := 1 - e2*sinLat*sinLat
+		if cosLat == 0 {
+			break
+		}
+		step := w * w / (2 * cosLat) * (q - a.q(lat)) / (1 - e2)
+		lat += step
+		if math.Abs(step) < 1e-15 {
+			break
+		}
+	}
+	return
+}
+
+// mercator is variant A, with the scale factor of variant B computed from its standard parallel.
+type mercator struct {
+	e             ellipsoid
+	lon0          float64
+	k0            float64
+	falseEasting  float64
+	falseNorthing float64
+}
+
+func (m *mercator) forward(lon float64, lat float64) (x float64, y float64) {
+	x = m.falseEasting + m.e.a*m.k0*normalizeLongitude(lon-m.lon0)
+	y = m.falseNorthing + m.e.a*m.k0*m.e.isometricLatitude(lat)
+	return
+}
+
+func (m *mercator) inverse(x float64, y float64) (lon float64, lat float64) {
+	lon = m.lon0 + (x-m.falseEasting)/(m.e.a*m.k0)
+	lat = m.e.latitudeFromIsometric((y - m.falseNorthing) / (m.e.a * m.k0))
+	return
+}
+
+// normalizeLongitude wraps a difference of longitudes into [-pi, pi].
+func normalizeLongitude(lon float64) float64 {
+	if lon < -math.Pi || lon > math.Pi {
+		lon = math.Remainder(lon, 2*math.Pi)
+	}
+	return lon
+}
+
+//  _____                      _           _   _
+// |  __ \                    (_)         | | (_)
+// | |__) |___ _ __  _ __ ___  _  ___  ___| |_ _  ___  _ __
+// |  _  // _ \ '_ \| '__/ _ \| |/ _ \/ __| __| |/ _ \| '_ \
+// | | \ \  __/ |_) | | | (_) | |  __/ (__| |_| | (_) | | | |
+// |_|  \_\___| .__/|_|  \___/| |\___|\___|\__|_|\___/|_| |_|
+//            | |            _/ |
+//            |_|           |__/
+
+// CoordinateTransformation transforms coordinates between two coordinate reference systems. Horizontal coordinates
+// are converted through longitude and latitude, with a Helmert transformation between the datums if they differ.
+// Heights are only converted between units, as transforming them between vertical datums requires geoid models;
+// geocentric coordinates, whose Z is not a height, are transformed in three dimensions. Following LAS, X is the
+// easting or longitude and Y the northing or latitude regardless of the axis order of the systems.
+type CoordinateTransformation struct {
+	Source *WKTCRS
+	Target *WKTCRS
+
+	source        crsEndpoint
+	target        crsEndpoint
+	shiftDatum    bool
+	sourceHelmert helmert
+	targetHelmert helmert
+	zFactor       float64
+	geocentric    bool
+}
+
+// crsEndpoint holds what it takes to convert the coordinates of a horizontal CRS to and from longitude and latitude.
+type crsEndpoint struct {
+	crs           *WKTCRS
+	e             ellipsoid
+	projection    mapProjection
+	primeMeridian float64
+	// unitFactor converts the horizontal coordinates to radians for geographic systems and to metres otherwise
+	unitFactor float64
+	zFactor    float64
+}
+
+// NewCoordinateTransformation returns the transformation from source to target. Both need a horizontal CRS; a vertical
+// CRS of the target must have the same datum as that of the source. Datums differ unless they have the same EPSG code
+// or name and ellipsoid, and are then transformed through WGS 84 with their ToWGS84 parameters. If ballparkDatumShift
+// is true, datums without them are taken to coincide with WGS 84 like the ballpark transformations of PROJ, which is
+// only accurate to a few metres; otherwise they are an error.
+func NewCoordinateTransformation(source *WKTCRS, target *WKTCRS, ballparkDatumShift bool) (t *CoordinateTransformation, err error) {
+	t = &CoordinateTransformation{Source: source, Target: target}
+	if t.source, err = newCRSEndpoint(source); err != nil {
+		t = nil
+		return
+	}
+	if t.target, err = newCRSEndpoint(target); err != nil {
+		t = nil
+		return
+	}
+	sourceVertical, targetVertical := source.GetVerticalCRS(), target.GetVerticalCRS()
+	if sourceVertical != nil && targetVertical != nil && !isSameDatum(sourceVertical.Datum, targetVertical.Datum) {
+		err = fmt.Errorf("transforming heights from %q to %q requires a geoid model", sourceVertical.Name, targetVertical.Name)
+		t = nil
+		return
+	}
+	t.zFactor = t.source.zFactor / t.target.zFactor
+	t.geocentric = t.source.crs.Type == WKT_CRS_GEOCENTRIC || t.target.crs.Type == WKT_CRS_GEOCENTRIC
+
+	sourceDatum, targetDatum := source.getGeodeticDatum(), target.getGeodeticDatum()
+	if isSameDatum(sourceDatum, targetDatum) && t.source.e == t.target.e {
+		return
+	}
+	t.shiftDatum = true
+	var toWGS84 []float64
+	if toWGS84, err = getToWGS84(sourceDatum, ballparkDatumShift); err != nil {
+		t = nil
+		return
+	}
+	t.sourceHelmert = newHelmert(toWGS84)
+	if toWGS84, err = getToWGS84(targetDatum, ballparkDatumShift); err != nil {
+		t = nil
+		return
+	}
+	t.targetHelmert = newHelmert(toWGS84)
+	return
+}
+
+func newCRSEndpoint(crs *WKTCRS) (endpoint crsEndpoint, err error) {
+	if crs == nil {
+		err = fmt.Errorf("coordinate reference system is missing")
+		return
+	}
+	endpoint.crs = crs.GetHorizontalCRS()
+	if endpoint.crs == nil {
+		err = fmt.Errorf("%s CRS %q has no horizontal CRS", crs.Type, crs.Name)
+		return
+	}
+	geographic := crs.GetGeographicCRS()
+	if geographic == nil || geographic.Datum == nil {
+		err = fmt.Errorf("%s CRS %q has no geodetic datum", endpoint.crs.Type, endpoint.crs.Name)
+		return
+	}
+	if endpoint.e, err = newEllipsoid(geographic.Datum.Ellipsoid); err != nil {
+		return
+	}
+	if pm := geographic.PrimeMeridian; pm != nil && pm.Unit != nil {
+		endpoint.primeMeridian = pm.Longitude * pm.Unit.Factor
+	}
+	switch endpoint.crs.Type {
+	case WKT_CRS_PROJECTED:
+		if endpoint.crs.Projection == nil {
+			err = fmt.Errorf("projected CRS %q has no projection", endpoint.crs.Name)
+			return
+		}
+		if endpoint.projection, err = newMapProjection(endpoint.crs.Projection, endpoint.e); err != nil {
+			return
+		}
+		endpoint.unitFactor = endpoint.crs.getUnit(WKT_UNIT_LENGTH).Factor
+	case WKT_CRS_GEOGRAPHIC:
+		endpoint.unitFactor = endpoint.crs.getUnit(WKT_UNIT_ANGLE).Factor
+	case WKT_CRS_GEOCENTRIC:
+		endpoint.unitFactor = endpoint.crs.getUnit(WKT_UNIT_LENGTH).Factor
+	default:
+		err = fmt.Errorf("%s CRS %q is not supported for reprojection", endpoint.crs.Type, endpoint.crs.Name)
+		return
+	}
+	if !(endpoint.unitFactor > 0) {
+		err = fmt.Errorf("unit of CRS %q has no valid factor", endpoint.crs.Name)
+		return
+	}
+
+	// heights are in the unit of the vertical CRS, or else in that of the horizontal CRS if it is a length
+	endpoint.zFactor = 1
+	if vertical := crs.GetVerticalCRS(); vertical != nil {
+		endpoint.zFactor = vertical.getUnit(WKT_UNIT_LENGTH).Factor
+	} else if endpoint.crs.Type != WKT_CRS_GEOGRAPHIC {
+		endpoint.zFactor = endpoint.unitFactor
+	}
+	if !(endpoint.zFactor > 0) {
+		err = fmt.Errorf("vertical unit of CRS %q has no valid factor", crs.Name)
+	}
+	return
+}
+
+// isSameDatum reports whether the datums are the same by their EPSG code, or else by their name and ToWGS84.
+func isSameDatum(a *WKTDatum, b *WKTDatum) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if codeA, codeB := a.Authority.getEPSG(), b.Authority.getEPSG(); codeA != 0 && codeB != 0 {
+		return codeA == codeB
+	}
+	if normalizeWKTName(a.Name) != normalizeWKTName(b.Name) || len(a.ToWGS84) != len(b.ToWGS84) {
+		return false
+	}
+	for index := range a.ToWGS84 {
+		if a.ToWGS84[index] != b.ToWGS84[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// getToWGS84 returns the parameters of the Helmert transformation of the datum to WGS 84.
+func getToWGS84(datum *WKTDatum, ballpark bool) (toWGS84 []float64, err error) {
+	switch {
+	case len(datum.ToWGS84) == 3 || len(datum.ToWGS84) == 7:
+		toWGS84 = datum.ToWGS84
+	case datum.Authority.getEPSG() == 6326 || normalizeWKTName(datum.Name) == "wgs1984" || normalizeWKTName(datum.Name) == "worldgeodeticsystem1984":
+		toWGS84 = []float64{0, 0, 0}
+	case ballpark:
+		toWGS84 = []float64{0, 0, 0}
+	default:
+		err = fmt.Errorf("no transformation from datum %q to WGS 84 is known", datum.Name)
+	}
+	return
+}
+
+// Transform returns the coordinates x, y, z of the source CRS in the target CRS. It fails for coordinates outside the
+// domain of the projections.
+func (t *CoordinateTransformation) Transform(x float64, y float64, z float64) (tx float64, ty float64, tz float64, err error) {
+	lon, lat, h := t.source.toGeodetic(x, y, z)
+	if t.shiftDatum {
+		gx, gy, gz := t.source.e.toGeocentric(lon+t.source.primeMeridian, lat, h)
+		gx, gy, gz = t.sourceHelmert.forward(gx, gy, gz)
+		gx, gy, gz = t.targetHelmert.inverse(gx, gy, gz)
+		var shiftedHeight float64
+		lon, lat, shiftedHeight = t.target.e.fromGeocentric(gx, gy, gz)
+		lon -= t.target.primeMeridian
+		if t.geocentric {
+			h = shiftedHeight
+		}
+	} else {
+		lon += t.source.primeMeridian - t.target.primeMeridian
+	}
+	tx, ty, tz = t.target.fromGeodetic(lon, lat, h)
+	if !t.geocentric {
+		tz = z * t.zFactor
+	}
+	if math.IsNaN(tx) || math.IsNaN(ty) || math.IsNaN(tz) || math.IsInf(tx, 0) || math.IsInf(ty, 0) || math.IsInf(tz, 0) {
+		err = fmt.Errorf("coordinates (%v, %v, %v) cannot be transformed from %q to %q", x, y, z, t.Source.Name, t.Target.Name)
+	}
+	return
+}
+
+// toGeodetic returns the longitude and latitude relative to the prime meridian and the height in metres.
+func (c *crsEndpoint) toGeodetic(x float64, y float64, z float64) (lon float64, lat float64, h float64) {
+	switch {
+	case c.projection != nil:
+		lon, lat = c.projection.inverse(x*c.unitFactor, y*c.unitFactor)
+		h = z * c.zFactor
+	case c.crs.Type == WKT_CRS_GEOCENTRIC:
+		lon, lat, h = c.e.fromGeocentric(x*c.unitFactor, y*c.unitFactor, z*c.unitFactor)
+		lon -= c.primeMeridian
+	default:
+		lon, lat, h = x*c.unitFactor, y*c.unitFactor, z*c.zFactor
+	}
+	return
+}
+
+func (c *crsEndpoint) fromGeodetic(lon float64, lat float64, h float64) (x float64, y float64, z float64) {
+	switch {
+	case c.projection != nil:
+		x, y = c.projection.forward(lon, lat)
+		x, y, z = x/c.unitFactor, y/c.unitFactor, h/c.zFactor
+	case c.crs.Type == WKT_CRS_GEOCENTRIC:
+		x, y, z = c.e.toGeocentric(lon+c.primeMeridian, lat, h)
+		x, y, z = x/c.unitFactor, y/c.unitFactor, z/c.unitFactor
+	default:
+		x, y, z = normalizeLongitude(lon)/c.unitFactor, lat/c.unitFactor, h/c.zFactor
+	}
+	return
+}
+
+// getMetresPerUnit returns the approximate length in metres of one horizontal unit of the endpoint, used to carry the
+// precision of coordinates between systems.
+func (c *crsEndpoint) getMetresPerUnit() float64 {
+	if c.crs.Type == WKT_CRS_GEOGRAPHIC {
+		return c.unitFactor * c.e.a
+	}
+	return c.unitFactor
+}
+
+//  _               _____
+// | |        /\   / ____|
+// | |       /  \ | (___
+// | |      / /\ \ \___ \
+// | |____ / ____ \____) |
+// |______/_/    \_\_____/
+//
+//
+
+// ReprojectOptions are the options of Reproject.
+type ReprojectOptions struct {
+	// XYPrecision and ZPrecision are the precision of the coordinates in the units of the target CRS, used to choose
+	// the scale factors. If zero, they are carried over from the scale factors of the source, or else are 1 cm.
+	XYPrecision float64
+	ZPrecision  float64
+	// BallparkDatumShift treats datums without a transformation to WGS 84 as WGS 84, see NewCoordinateTransformation.
+	BallparkDatumShift bool
+	// WKTVersion is the version of the coordinate system WKT record written to LAS 1.4 files, WKT_VERSION_1 if zero.
+	WKTVersion WKTVersion
+}
+
+// GetCRS returns the coordinate reference system of l from its coordinate system WKT record, or converted from its
+// GeoKeys if it has none. It fails with ErrNotFound if l holds neither.
+func (l *Las) GetCRS() (crs *WKTCRS, err error) {
+	if crs, err = l.GetCoordinateSystemWKT(); !errors.Is(err, ErrNotFound) {
+		return
+	}
+	keys, err := l.GetGeoKeys()
+	if err != nil {
+		return
+	}
+	return NewWKTCRSFromGeoKeys(keys)
+}
+
+// Reproject transforms the points of l from its CRS, see GetCRS, to target. The scale factors and offsets are chosen
+// anew for the transformed coordinates, the bounds of the header are updated, and the CRS records are replaced: by a
+// coordinate system WKT record if l already uses WKT or target cannot be stored as GeoKeys, else by GeoKeys. If
+// target has no vertical CRS, that of the source is kept, and a vertical target keeps the horizontal CRS of the source,
+// e.g. to convert heights from metres to feet. l is left unchanged on error.
+func (l *Las) Reproject(target *WKTCRS, options ReprojectOptions) (err error) {
+	source, err := l.GetCRS()
+	if err != nil {
+		return
+	}
+	switch {
+	case target.GetHorizontalCRS() == nil && source.GetHorizontalCRS() != nil:
+		target = NewCompoundWKTCRS(source.GetHorizontalCRS(), target)
+	case target.GetVerticalCRS() == nil && source.GetVerticalCRS() != nil:
+		target = NewCompoundWKTCRS(target, source.GetVerticalCRS())
+	}
+	transformation, err := NewCoordinateTransformation(source, target, options.BallparkDatumShift)
+	if err != nil {
+		return
+	}
+
+	// the GeoKeys are prepared first to fail before transforming the points
+	version := l.Header.GetVersion()
+	useWKT := version == V1_4 && (l.Header.GlobalEncoding&GLOBAL_ENCODING_WKT != 0 || l.Header.PointDataRecordFormat&LASZIP_FORMAT_MASK >= 6)
+	var keys []GeoKey
+	if !useWKT {
+		if keys, err = target.GetGeoKeys(); err != nil {
+			if version != V1_4 {
+				return
+			}
+			err = nil
+			useWKT = true
+		}
+	}
+	wktVersion := options.WKTVersion
+	if wktVersion == 0 {
+		wktVersion = WKT_VERSION_1
+	}
+
+	header := l.Header
+	xyPrecision, zPrecision := options.XYPrecision, options.ZPrecision
+	if xyPrecision == 0 {
+		xyPrecision = 0.01 / transformation.target.getMetresPerUnit()
+		if scale := math.Min(math.Abs(header.XScaleFactor), math.Abs(header.YScaleFactor)); scale > 0 {
+			xyPrecision = scale * transformation.source.getMetresPerUnit() / transformation.target.getMetresPerUnit()
+		}
+	}
+	if zPrecision == 0 {
+		zPrecision = 0.01 / transformation.target.zFactor
+		if scale := math.Abs(header.ZScaleFactor); scale > 0 {
+			zPrecision = scale * transformation.source.zFactor / transformation.target.zFactor
+		}
+	}
+
+	numberOfPDRs := 0
+	if l.Pdrs != nil {
+		numberOfPDRs = l.Pdrs.Len()
+	}
+	coordinates := make([][3]float64, numberOfPDRs)
+	bounds := BoundingBox{
+		MinX: math.Inf(1), MinY: math.Inf(1), MinZ: math.Inf(1),
+		MaxX: math.Inf(-1), MaxY: math.Inf(-1), MaxZ: math.Inf(-1),
+	}
+	for index := range coordinates {
+		x, y, z := header.GetScaledXYZ(l.Pdrs.At(index))
+		if x, y, z, err = transformation.Transform(x, y, z); err != nil {
+			err = fmt.Errorf("point %d: %w", index, err)
+			return
+		}
+		coordinates[index] = [3]float64{x, y, z}
+		bounds.MinX, bounds.MaxX = math.Min(bounds.MinX, x), math.Max(bounds.MaxX, x)
+		bounds.MinY, bounds.MaxY = math.Min(bounds.MinY, y), math.Max(bounds.MaxY, y)
+		bounds.MinZ, bounds.MaxZ = math.Min(bounds.MinZ, z), math.Max(bounds.MaxZ, z)
+	}
+	if numberOfPDRs == 0 {
+		bounds = BoundingBox{}
+	}
+	if header.XScaleFactor, header.XOffset, err = ChooseScaleAndOffset(bounds.MinX, bounds.MaxX, xyPrecision); err != nil {
+		return
+	}
+	if header.YScaleFactor, header.YOffset, err = ChooseScaleAndOffset(bounds.MinY, bounds.MaxY, xyPrecision); err != nil {
+		return
+	}
+	if header.ZScaleFactor, header.ZOffset, err = ChooseScaleAndOffset(bounds.MinZ, bounds.MaxZ, zPrecision); err != nil {
+		return
+	}
+
+	// the records are replaced on a copy first, as changing the points cannot be undone
+	reprojected := l.clone()
+	reprojected.Header = header
+	if useWKT {
+		err = reprojected.SetCoordinateSystemWKT(target, wktVersion)
+	} else {
+		err = reprojected.SetGeoKeys(keys)
+	}
+	if err != nil {
+		return
+	}
+	if err = reprojected.checkNumberOfPDRs(); err != nil {
+		return
+	}
+	for index, xyz := range coordinates {
+		if err = header.SetScaledXYZ(l.Pdrs.At(index), xyz[0], xyz[1], xyz[2]); err != nil {
+			// cannot happen as the scale factors and offsets fit the bounds
+			return
+		}
+	}
+	if err = reprojected.UpdateHeaderFromPoints(); err != nil {
+		return
+	}
+	*l = *reprojected
+	return
+}
+
+// ReprojectToEPSG reprojects l to the CRS with the EPSG code, see NewWKTCRSFromEPSG and Reproject.
+func (l *Las) ReprojectToEPSG(code int, options ReprojectOptions) (err error) {
+	target, err := NewWKTCRSFromEPSG(code)
+	if err != nil {
+		return
+	}
+	return l.Reproject(target, options)
+}
@@ -0,0 +1,230 @@
+package las
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The reference coordinates are the examples of IOGP Guidance Note 7-2 unless noted otherwise.
+const (
+	testNAD27 = `GEOGCS["NAD27",DATUM["North_American_Datum_1927",SPHEROID["Clarke 1866",6378206.4,294.9786982138982]],` +
+		`PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433]]`
+	testTexasSouthCentral = `PROJCS["NAD27 / Texas South Central",` + testNAD27 + `,PROJECTION["Lambert_Conformal_Conic_2SP"],` +
+		`PARAMETER["standard_parallel_1",28.38333333333333],PARAMETER["standard_parallel_2",30.28333333333333],` +
+		`PARAMETER["latitude_of_origin",27.83333333333333],PARAMETER["central_meridian",-99],` +
+		`PARAMETER["false_easting",2000000],PARAMETER["false_northing",0],UNIT["US survey foot",0.3048006096012192]]`
+	testJAD69 = `GEOGCS["JAD69",DATUM["Jamaica_1969",SPHEROID["Clarke 1866",6378206.4,294.9786982138982]],` +
+		`PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433]]`
+	testJamaicaNationalGrid = `PROJCS["JAD69 / Jamaica National Grid",` + testJAD69 + `,PROJECTION["Lambert_Conformal_Conic_1SP"],` +
+		`PARAMETER["latitude_of_origin",18],PARAMETER["central_meridian",-77],PARAMETER["scale_factor",1],` +
+		`PARAMETER["false_easting",250000],PARAMETER["false_northing",150000],UNIT["metre",1]]`
+	testBatavia = `GEOGCS["Batavia",DATUM["Batavia",SPHEROID["Bessel 1841",6377397.155,299.1528128]],` +
+		`PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433]]`
+	testBataviaMercator = `PROJCS["Batavia / NEIEZ",` + testBatavia + `,PROJECTION["Mercator_1SP"],` +
+		`PARAMETER["central_meridian",110],PARAMETER["scale_factor",0.997],` +
+		`PARAMETER["false_easting",3900000],PARAMETER["false_northing",900000],UNIT["metre",1]]`
+	testWGS84Geocentric = `GEOCCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],` +
+		`PRIMEM["Greenwich",0],UNIT["metre",1]]`
+	testWGS72Geocentric = `GEOCCS["WGS 72",DATUM["WGS_1972",SPHEROID["WGS 72",6378135,298.26],` +
+		`TOWGS84[0,0,4.5,0,0,0.554,0.219]],PRIMEM["Greenwich",0],UNIT["metre",1]]`
+	testED50Geocentric = `GEOCCS["ED50",DATUM["European_Datum_1950",SPHEROID["International 1924",6378388,297],` +
+		`TOWGS84[-84.87,-96.49,-116.95]],PRIMEM["Greenwich",0],UNIT["metre",1]]`
+)
+
+// newTestCRS returns the CRS given as "EPSG:code" or as WKT.
+func newTestCRS(t *testing.T, definition string) (crs *WKTCRS) {
+	t.Helper()
+	var err error
+	if code := strings.TrimPrefix(definition, "EPSG:"); code != definition {
+		var epsg int
+		if epsg, err = strconv.Atoi(code); err != nil {
+			t.Fatal(err)
+		}
+		crs, err = NewWKTCRSFromEPSG(epsg)
+	} else {
+		crs, err = ParseWKT(definition)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// testDegrees returns the decimal degrees of an angle given in degrees, minutes and seconds.
+func testDegrees(degrees float64, minutes float64, seconds float64) float64 {
+	return math.Copysign(math.Abs(degrees)+minutes/60+seconds/3600, degrees)
+}
+
+func TestCoordinateTransformation(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		target    string
+		xyz       [3]float64
+		want      [3]float64
+		tolerance float64
+	}{
+		{"Popular Visualisation Pseudo-Mercator", "EPSG:4326", "EPSG:3857",
+			[3]float64{testDegrees(-100, 20, 0), testDegrees(24, 22, 54.433), 0}, [3]float64{-11169055.58, 2800000, 0}, 0.01},
+		{"Transverse Mercator", "EPSG:4277", "EPSG:27700",
+			[3]float64{0.5, 50.5, 0}, [3]float64{577274.99, 69740.50, 0}, 0.01},
+		// from the documentation of PROJ
+		{"Universal Transverse Mercator", "EPSG:4326", "EPSG:32632",
+			[3]float64{12, 56, 0}, [3]float64{687071.44, 6210141.33, 0}, 0.01},
+		{"Transverse Mercator on the central meridian", "EPSG:4326", "EPSG:32631",
+			[3]float64{3, 45, 0}, [3]float64{500000, 4982950.40, 0}, 0.01},
+		{"Lambert Conic Conformal (1SP)", testJAD69, testJamaicaNationalGrid,
+			[3]float64{testDegrees(-76, 56, 37.26), testDegrees(17, 55, 55.80), 0}, [3]float64{255966.58, 142493.51, 0}, 0.01},
+		{"Lambert Conic Conformal (2SP) in US survey feet", testNAD27, testTexasSouthCentral,
+			[3]float64{-96, 28.5, 0}, [3]float64{2963503.91, 254759.80, 0}, 0.01},
+		{"Lambert Conic Conformal (2SP) at the origin", "EPSG:4171", "EPSG:2154",
+			[3]float64{3, 46.5, 0}, [3]float64{700000, 6600000, 0}, 0.01},
+		{"Albers Equal Area at the origin", "EPSG:4269", "EPSG:5070",
+			[3]float64{-96, 23, 0}, [3]float64{0, 0, 0}, 0.01},
+		{"Mercator (variant A)", testBatavia, testBataviaMercator,
+			[3]float64{120, -3, 0}, [3]float64{5009726.58, 569150.82, 0}, 0.01},
+		{"geographic to geocentric", "EPSG:4326", testWGS84Geocentric,
+			[3]float64{testDegrees(2, 7, 46.38), testDegrees(53, 48, 33.82), 73}, [3]float64{3771793.968, 140253.342, 5124304.349}, 0.001},
+		{"geocentric translations", testWGS84Geocentric, testED50Geocentric,
+			[3]float64{3771793.97, 140253.34, 5124304.35}, [3]float64{3771878.84, 140349.83, 5124421.30}, 0.01},
+		{"position vector transformation", testWGS72Geocentric, testWGS84Geocentric,
+			[3]float64{3657660.66, 255768.55, 5201382.11}, [3]float64{3657660.78, 255778.43, 5201387.75}, 0.01},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, target := newTestCRS(t, test.source), newTestCRS(t, test.target)
+			forward, err := NewCoordinateTransformation(source, target, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			x, y, z, err := forward.Transform(test.xyz[0], test.xyz[1], test.xyz[2])
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := [3]float64{x, y, z}
+			for axis := range got {
+				if math.Abs(got[axis]-test.want[axis]) > test.tolerance {
+					t.Errorf("transformed %v to %v, want %v", test.xyz, got, test.want)
+					break
+				}
+			}
+
+			inverse, err := NewCoordinateTransformation(target, source, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if x, y, z, err = inverse.Transform(test.want[0], test.want[1], test.want[2]); err != nil {
+				t.Fatal(err)
+			}
+			// the reference coordinates are rounded, to 0.01 seconds of arc for angles
+			tolerance := [3]float64{test.tolerance, test.tolerance, test.tolerance}
+			if source.Type == WKT_CRS_GEOGRAPHIC {
+				tolerance[0], tolerance[1] = 0.01/3600, 0.01/3600
+			}
+			got = [3]float64{x, y, z}
+			for axis := range got {
+				if math.Abs(got[axis]-test.xyz[axis]) > tolerance[axis] {
+					t.Errorf("transformed %v back to %v, want %v", test.want, got, test.xyz)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestNewCoordinateTransformationErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		target string
+	}{
+		{"unsupported projection", "EPSG:4289", "EPSG:28992"},
+		{"datum without a transformation to WGS 84", "EPSG:4326", "EPSG:25832"},
+		{"heights between vertical datums", "EPSG:5703", "EPSG:5701"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, target := newTestCRS(t, test.source), newTestCRS(t, test.target)
+			if test.source == "EPSG:5703" {
+				source = NewCompoundWKTCRS(newTestCRS(t, "EPSG:4326"), source)
+				target = NewCompoundWKTCRS(newTestCRS(t, "EPSG:4326"), target)
+			}
+			if _, err := NewCoordinateTransformation(source, target, false); err == nil {
+				t.Error("transformation created without error")
+			}
+		})
+	}
+}
+
+func TestReprojectToEPSG(t *testing.T) {
+	tests := []struct {
+		name   string
+		minor  uint8
+		format uint8
+		wkt    bool
+	}{
+		{"GeoKeys of LAS 1.2", 2, 1, false},
+		{"GeoKeys of LAS 1.4", 4, 1, false},
+		{"WKT of format 6", 4, 6, true},
+	}
+	// the point of the Pseudo-Mercator example of TestCoordinateTransformation
+	lon, lat := testDegrees(-100, 20, 0), testDegrees(24, 22, 54.433)
+	want := [2]float64{-11169055.58, 2800000}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newTestLas(t, test.minor, test.format, 10, 0)
+			wgs84 := newTestCRS(t, "EPSG:4326")
+			var err error
+			if test.wkt {
+				err = l.SetCoordinateSystemWKT(wgs84, WKT_VERSION_1)
+			} else {
+				var keys []GeoKey
+				if keys, err = wgs84.GetGeoKeys(); err != nil {
+					t.Fatal(err)
+				}
+				err = l.SetGeoKeys(keys)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Header.XScaleFactor, l.Header.YScaleFactor = 1e-9, 1e-9
+			l.Header.XOffset, l.Header.YOffset, l.Header.ZOffset = -100, 24, 0
+			for i := 0; i < l.Pdrs.Len(); i++ {
+				if err = l.Header.SetScaledXYZ(l.Pdrs.At(i), lon, lat, float64(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = l.ReprojectToEPSG(3857, ReprojectOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			crs, err := l.GetCRS()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if crs.GetEPSG() != 3857 {
+				t.Errorf("CRS is EPSG:%d, want EPSG:3857", crs.GetEPSG())
+			}
+			if _, err = l.GetCoordinateSystemWKT(); (err == nil) != test.wkt {
+				t.Errorf("coordinate system WKT record present %t, want %t", err == nil, test.wkt)
+			}
+			for i := 0; i < l.Pdrs.Len(); i++ {
+				x, y, z := l.Header.GetScaledXYZ(l.Pdrs.At(i))
+				if math.Abs(x-want[0]) > 0.01 || math.Abs(y-want[1]) > 0.01 || math.Abs(z-float64(i)) > 0.01 {
+					t.Fatalf("point %d is (%v, %v, %v), want (%v, %v, %d)", i, x, y, z, want[0], want[1], i)
+				}
+			}
+			data := writeTestLas(t, l)
+			parsed := &Las{}
+			if err = parsed.ParseReader(bytes.NewReader(data), int64(len(data))); err != nil {
+				t.Fatal(err)
+			}
+			if report := parsed.Validate(); report.HasErrors() {
+				t.Errorf("reprojected file is invalid:\n%s", report.String())
+			}
+		})
+	}
+}
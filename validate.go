@@ -0,0 +1,449 @@
+package las
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// __      __   _ _     _       _   _
+// \ \    / /  | (_)   | |     | | (_)
+//  \ \  / /_ _| |_  __| | __ _| |_ _  ___  _ __
+//   \ \/ / _` | | |/ _` |/ _` | __| |/ _ \| '_ \
+//    \  / (_| | | | (_| | (_| | |_| | (_) | | | |
+//     \/ \__,_|_|_|\__,_|\__,_|\__|_|\___/|_| |_|
+//
+//
+
+// Severity grades the issues of a ValidationReport.
+type Severity int
+
+const (
+	// SEVERITY_INFO marks unusual content which is allowed by the spec.
+	SEVERITY_INFO Severity = iota
+	// SEVERITY_WARNING marks content which violates the spec but which readers commonly accept.
+	SEVERITY_WARNING
+	// SEVERITY_ERROR marks content which violates the spec and may be misread.
+	SEVERITY_ERROR
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SEVERITY_INFO:
+		return "info"
+	case SEVERITY_WARNING:
+		return "warning"
+	case SEVERITY_ERROR:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// MarshalText writes the severity by name, e.g. in JSON reports.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText reads a severity written by MarshalText.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, severity := range []Severity{SEVERITY_INFO, SEVERITY_WARNING, SEVERITY_ERROR} {
+		if string(text) == severity.String() {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("severity %q not recognised", text)
+}
+
+// The codes of the issues found by Validate.
+const (
+	ISSUE_SIGNATURE             = "signature"
+	ISSUE_VERSION               = "version"
+	ISSUE_HEADER_SIZE           = "header_size"
+	ISSUE_FORMAT_VERSION        = "format_version"
+	ISSUE_FORMAT_MISMATCH       = "format_mismatch"
+	ISSUE_RECORD_LENGTH         = "record_length"
+	ISSUE_SCALE_FACTOR          = "scale_factor"
+	ISSUE_POINT_COUNT           = "point_count"
+	ISSUE_LEGACY_POINT_COUNT    = "legacy_point_count"
+	ISSUE_POINTS_BY_RETURN      = "points_by_return"
+	ISSUE_BOUNDS                = "bounds"
+	ISSUE_RETURN_NUMBER         = "return_number"
+	ISSUE_GLOBAL_ENCODING       = "global_encoding"
+	ISSUE_CRS_ENCODING          = "crs_encoding"
+	ISSUE_GPS_TIME_TYPE         = "gps_time_type"
+	ISSUE_VLR_COUNT             = "vlr_count"
+	ISSUE_VLR_SIZE              = "vlr_size"
+	ISSUE_DUPLICATE_VLR         = "duplicate_vlr"
+	ISSUE_EVLR_COUNT            = "evlr_count"
+	ISSUE_EVLR_VERSION          = "evlr_version"
+	ISSUE_OVERLAPPING_RECORDS   = "overlapping_records"
+	ISSUE_WAVEFORM_DATA_PACKETS = "waveform_data_packets"
+)
+
+// ValidationIssue is a violation of the LAS spec found by Validate. Code identifies the kind of issue, e.g.
+// ISSUE_BOUNDS, and Message describes it.
+type ValidationIssue struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Code, i.Message)
+}
+
+// ValidationReport lists the issues found by Validate. It can be marshalled to JSON for machine processing.
+type ValidationReport struct {
+	Issues []ValidationIssue `json:"issues"`
+}
+
+func (r *ValidationReport) add(severity Severity, code string, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{Severity: severity, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// MaxSeverity returns the highest severity of the issues, or -1 if the report holds no issues.
+func (r *ValidationReport) MaxSeverity() (severity Severity) {
+	severity = -1
+	for _, issue := range r.Issues {
+		if issue.Severity > severity {
+			severity = issue.Severity
+		}
+	}
+	return
+}
+
+// HasErrors reports whether the report holds an issue of severity SEVERITY_ERROR.
+func (r *ValidationReport) HasErrors() bool {
+	return r.MaxSeverity() >= SEVERITY_ERROR
+}
+
+// Filter returns the issues of at least the given severity.
+func (r *ValidationReport) Filter(severity Severity) (issues []ValidationIssue) {
+	for _, issue := range r.Issues {
+		if issue.Severity >= severity {
+			issues = append(issues, issue)
+		}
+	}
+	return
+}
+
+func (r *ValidationReport) String() string {
+	lines := make([]string, len(r.Issues))
+	for index, issue := range r.Issues {
+		lines[index] = issue.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks l against the LAS spec and reports all issues found: inconsistencies between the header and the
+// point data records, VLRs and EVLRs, point data record formats not allowed by the version, invalid return numbers,
+// global encoding bits contradicting the CRS records or GPS times, and overlapping records. The layout checks assume
+// the header describes the file l was read from; l is not modified.
+func (l *Las) Validate() (report ValidationReport) {
+	l.validateHeader(&report)
+	l.validatePoints(&report)
+	l.validateCRS(&report)
+	l.validateLayout(&report)
+	return
+}
+
+// getMinimumVersion returns the first minor version of LAS 1 defining the point data record format.
+func getMinimumVersion(format uint8) (minor uint8) {
+	switch {
+	case format <= 1:
+		return 0
+	case format <= 3:
+		return 2
+	case format <= 5:
+		return 3
+	}
+	return 4
+}
+
+func (l *Las) validateHeader(report *ValidationReport) {
+	header := &l.Header
+	if string(header.FileSignature[:]) != LAS_FILE_SIGNATURE {
+		report.add(SEVERITY_ERROR, ISSUE_SIGNATURE, "file signature %q is not %q", header.FileSignature[:], LAS_FILE_SIGNATURE)
+	}
+	if err := l.isVersionOK(); err != nil {
+		report.add(SEVERITY_ERROR, ISSUE_VERSION, "%v", err)
+	}
+	if standardSize := header.getStandardHeaderSize(); header.HeaderSize < standardSize {
+		report.add(SEVERITY_ERROR, ISSUE_HEADER_SIZE, "header size %d is smaller than the %d bytes of a version %s header", header.HeaderSize, standardSize, header.GetVersion())
+	}
+
+	format := header.PointDataRecordFormat & LASZIP_FORMAT_MASK
+	recordSize, err := getPointDataRecordSize(format)
+	if err != nil {
+		report.add(SEVERITY_ERROR, ISSUE_FORMAT_VERSION, "point data record format %d is not defined", format)
+	} else {
+		if header.VersionMajor == 1 && header.VersionMinor < getMinimumVersion(format) {
+			report.add(SEVERITY_ERROR, ISSUE_FORMAT_VERSION, "point data record format %d is not allowed in version %s files", format, header.GetVersion())
+		}
+		if header.PointDataRecordLength < recordSize {
+			report.add(SEVERITY_ERROR, ISSUE_RECORD_LENGTH, "point data record length %d is smaller than the %d bytes of format %d", header.PointDataRecordLength, recordSize, format)
+		}
+	}
+	if l.Pdrs != nil && l.Pdrs.Len() != 0 {
+		if pdrsFormat := l.Pdrs.At(0).GetPointDataRecordFormat(); pdrsFormat != format {
+			report.add(SEVERITY_ERROR, ISSUE_FORMAT_MISMATCH, "point data records are of format %d, but the header declares format %d", pdrsFormat, format)
+		}
+	}
+	scales := [3]float64{header.XScaleFactor, header.YScaleFactor, header.ZScaleFactor}
+	for axis, scale := range scales {
+		if scale == 0 || math.IsNaN(scale) || math.IsInf(scale, 0) {
+			report.add(SEVERITY_ERROR, ISSUE_SCALE_FACTOR, "%s scale factor %v is not valid", []string{"x", "y", "z"}[axis], scale)
+		}
+	}
+
+	// global encoding was reserved before LAS 1.2, and bits 5 to 15 are still reserved
+	encoding := header.GlobalEncoding
+	if header.VersionMajor == 1 && header.VersionMinor < 2 && encoding != 0 {
+		report.add(SEVERITY_WARNING, ISSUE_GLOBAL_ENCODING, "global encoding %#04x is set in a version %s file, where it is reserved", encoding, header.GetVersion())
+	} else if encoding&^0x1F != 0 {
+		report.add(SEVERITY_WARNING, ISSUE_GLOBAL_ENCODING, "reserved bits of global encoding %#04x are set", encoding)
+	}
+	if header.VersionMajor == 1 && header.VersionMinor < 3 && encoding&(GLOBAL_ENCODING_WAVEFORM_INTERNAL|GLOBAL_ENCODING_WAVEFORM_EXTERNAL) != 0 {
+		report.add(SEVERITY_WARNING, ISSUE_GLOBAL_ENCODING, "waveform bits of global encoding are set in a version %s file", header.GetVersion())
+	}
+	if encoding&GLOBAL_ENCODING_WAVEFORM_INTERNAL != 0 && encoding&GLOBAL_ENCODING_WAVEFORM_EXTERNAL != 0 {
+		report.add(SEVERITY_ERROR, ISSUE_GLOBAL_ENCODING, "global encoding declares internal and external waveform data packets")
+	}
+}
+
+func (l *Las) validatePoints(report *ValidationReport) {
+	header := &l.Header
+	version := header.GetVersion()
+	format := header.PointDataRecordFormat & LASZIP_FORMAT_MASK
+	numberOfPDRs := uint64(0)
+	if l.Pdrs != nil {
+		numberOfPDRs = uint64(l.Pdrs.Len())
+	}
+
+	// point counts
+	if version == V1_4 {
+		if header.NumberOfPointRecords != numberOfPDRs {
+			report.add(SEVERITY_ERROR, ISSUE_POINT_COUNT, "header declares %d point records, found %d", header.NumberOfPointRecords, numberOfPDRs)
+		}
+		if format >= 6 || numberOfPDRs > math.MaxUint32 {
+			if header.LegacyNumberOfPointRecords != 0 || header.LegacyNumberOfPointByReturn != [5]uint32{} {
+				report.add(SEVERITY_ERROR, ISSUE_LEGACY_POINT_COUNT, "legacy point counts must be zero for format %d with %d points", format, numberOfPDRs)
+			}
+		} else if header.LegacyNumberOfPointRecords != uint32(numberOfPDRs) {
+			report.add(SEVERITY_WARNING, ISSUE_LEGACY_POINT_COUNT, "header declares %d legacy point records, found %d", header.LegacyNumberOfPointRecords, numberOfPDRs)
+		}
+	} else if uint64(header.LegacyNumberOfPointRecords) != numberOfPDRs {
+		report.add(SEVERITY_ERROR, ISSUE_POINT_COUNT, "header declares %d point records, found %d", header.LegacyNumberOfPointRecords, numberOfPDRs)
+	}
+
+	var byReturn [15]uint64
+	var returnNumberZero, numberOfReturnsZero, returnAboveNumber, outsideBounds uint64
+	var gpsTimesOutsideWeek, gpsTimesInvalid uint64
+	bounds := BoundingBox{
+		MinX: math.Inf(1), MinY: math.Inf(1), MinZ: math.Inf(1),
+		MaxX: math.Inf(-1), MaxY: math.Inf(-1), MaxZ: math.Inf(-1),
+	}
+	tolerance := [3]float64{math.Abs(header.XScaleFactor) / 2, math.Abs(header.YScaleFactor) / 2, math.Abs(header.ZScaleFactor) / 2}
+	headerBounds := BoundingBox{
+		MinX: header.MinX - tolerance[0], MinY: header.MinY - tolerance[1], MinZ: header.MinZ - tolerance[2],
+		MaxX: header.MaxX + tolerance[0], MaxY: header.MaxY + tolerance[1], MaxZ: header.MaxZ + tolerance[2],
+	}
+	for index := 0; index < int(numberOfPDRs); index++ {
+		point := l.Pdrs.At(index)
+		x, y, z := header.GetScaledXYZ(point)
+		bounds.MinX, bounds.MaxX = math.Min(bounds.MinX, x), math.Max(bounds.MaxX, x)
+		bounds.MinY, bounds.MaxY = math.Min(bounds.MinY, y), math.Max(bounds.MaxY, y)
+		bounds.MinZ, bounds.MaxZ = math.Min(bounds.MinZ, z), math.Max(bounds.MaxZ, z)
+		if !headerBounds.Contains(x, y, z) {
+			outsideBounds++
+		}
+
+		returnNumber, numberOfReturns := point.GetReturnNumber(), point.GetNumberOfReturns()
+		if returnNumber == 0 {
+			returnNumberZero++
+		} else if int(returnNumber) <= len(byReturn) {
+			byReturn[returnNumber-1]++
+		}
+		if numberOfReturns == 0 {
+			numberOfReturnsZero++
+		} else if returnNumber > numberOfReturns {
+			returnAboveNumber++
+		}
+
+		if point.HasGPSTime() {
+			gpsTime := point.GetGPSTime()
+			if math.IsNaN(gpsTime) || math.IsInf(gpsTime, 0) {
+				gpsTimesInvalid++
+			} else if gpsTime < 0 || gpsTime >= 604800 {
+				gpsTimesOutsideWeek++
+			}
+		}
+	}
+
+	// bounds
+	if outsideBounds != 0 {
+		report.add(SEVERITY_ERROR, ISSUE_BOUNDS, "%d points lie outside of the header bounds", outsideBounds)
+	} else if numberOfPDRs != 0 {
+		actual := [6]float64{bounds.MinX, bounds.MaxX, bounds.MinY, bounds.MaxY, bounds.MinZ, bounds.MaxZ}
+		declared := [6]float64{header.MinX, header.MaxX, header.MinY, header.MaxY, header.MinZ, header.MaxZ}
+		names := [6]string{"min x", "max x", "min y", "max y", "min z", "max z"}
+		for i := range actual {
+			if math.Abs(actual[i]-declared[i]) > tolerance[i/2] {
+				report.add(SEVERITY_WARNING, ISSUE_BOUNDS, "header %s is %v, but the points reach %v", names[i], declared[i], actual[i])
+			}
+		}
+	}
+
+	// returns
+	if version == V1_4 && header.NumberOfPointsByReturn != byReturn {
+		report.add(SEVERITY_WARNING, ISSUE_POINTS_BY_RETURN, "header declares %v points by return, found %v", header.NumberOfPointsByReturn, byReturn)
+	}
+	if legacy := header.LegacyNumberOfPointByReturn; legacy != [5]uint32{} || version != V1_4 {
+		for index := range legacy {
+			if uint64(legacy[index]) != byReturn[index] {
+				report.add(SEVERITY_WARNING, ISSUE_POINTS_BY_RETURN, "header declares %v legacy points by return, found %v", legacy, byReturn[:5])
+				break
+			}
+		}
+	}
+	if returnNumberZero != 0 {
+		report.add(SEVERITY_WARNING, ISSUE_RETURN_NUMBER, "%d points have return number 0", returnNumberZero)
+	}
+	if numberOfReturnsZero != 0 {
+		report.add(SEVERITY_WARNING, ISSUE_RETURN_NUMBER, "%d points have 0 returns", numberOfReturnsZero)
+	}
+	if returnAboveNumber != 0 {
+		report.add(SEVERITY_WARNING, ISSUE_RETURN_NUMBER, "%d points have a return number greater than their number of returns", returnAboveNumber)
+	}
+
+	// GPS time type
+	if gpsTimesInvalid != 0 {
+		report.add(SEVERITY_ERROR, ISSUE_GPS_TIME_TYPE, "%d points have a GPS time which is not a number", gpsTimesInvalid)
+	}
+	if header.GlobalEncoding&GLOBAL_ENCODING_GPS_TIME_TYPE == 0 && gpsTimesOutsideWeek != 0 {
+		report.add(SEVERITY_WARNING, ISSUE_GPS_TIME_TYPE, "global encoding declares GPS week time, but %d points have a GPS time outside of a week", gpsTimesOutsideWeek)
+	}
+	if version == V1_4 && format >= 6 && header.GlobalEncoding&GLOBAL_ENCODING_GPS_TIME_TYPE == 0 {
+		report.add(SEVERITY_WARNING, ISSUE_GPS_TIME_TYPE, "point data record format %d should use adjusted standard GPS time", format)
+	}
+}
+
+func (l *Las) validateCRS(report *ValidationReport) {
+	header := &l.Header
+	format := header.PointDataRecordFormat & LASZIP_FORMAT_MASK
+	var geoKeys, wkt int
+	for _, records := range [][]CRS{l.getVLRRecords(), l.getEVLRRecords()} {
+		for _, record := range records {
+			switch record.(type) {
+			case *GeoKeyDirectoryTag:
+				geoKeys++
+			case *CoordinateSystemWKT:
+				wkt++
+			}
+		}
+	}
+
+	// records which may only appear once
+	counts := make(map[vlrDecoderKey]int)
+	for _, vlr := range l.Vlrs {
+		counts[vlrDecoderKey{userID: vlr.UserID(), recordID: vlr.RecordID()}]++
+	}
+	for _, evlr := range l.Evlrs {
+		counts[vlrDecoderKey{userID: evlr.UserID(), recordID: evlr.RecordID()}]++
+	}
+	for _, key := range []vlrDecoderKey{{"LASF_Projection", 34735}, {"LASF_Projection", 2112}, {"LASF_Spec", 4}} {
+		if counts[key] > 1 {
+			report.add(SEVERITY_ERROR, ISSUE_DUPLICATE_VLR, "file holds %d records with user ID %s and record ID %d", counts[key], key.userID, key.recordID)
+		}
+	}
+
+	wktBit := header.GlobalEncoding&GLOBAL_ENCODING_WKT != 0
+	switch {
+	case wktBit && header.GetVersion() != V1_4:
+		report.add(SEVERITY_ERROR, ISSUE_CRS_ENCODING, "WKT bit of global encoding is set in a version %s file", header.GetVersion())
+	case format >= 6 && !wktBit:
+		report.add(SEVERITY_ERROR, ISSUE_CRS_ENCODING, "point data record format %d requires the WKT bit of global encoding", format)
+	case wktBit && geoKeys != 0:
+		report.add(SEVERITY_WARNING, ISSUE_CRS_ENCODING, "WKT bit of global encoding is set, but the file holds a GeoTIFF GeoKeyDirectoryTag")
+	case !wktBit && wkt != 0 && geoKeys == 0:
+		report.add(SEVERITY_WARNING, ISSUE_CRS_ENCODING, "file holds an OGC WKT coordinate system, but the WKT bit of global encoding is not set")
+	}
+	if wktBit && wkt == 0 && geoKeys == 0 {
+		report.add(SEVERITY_INFO, ISSUE_CRS_ENCODING, "WKT bit of global encoding is set, but the file holds no coordinate system")
+	}
+}
+
+func (l *Las) getVLRRecords() (records []CRS) {
+	for _, vlr := range l.Vlrs {
+		records = append(records, vlr.record...)
+	}
+	return
+}
+
+func (l *Las) getEVLRRecords() (records []CRS) {
+	for _, evlr := range l.Evlrs {
+		records = append(records, evlr.record...)
+	}
+	return
+}
+
+// getPointDataEnd returns the offset following the point data records declared by the header. The size of compressed
+// point data is unknown, so only the chunk table offset preceding the chunks is counted for LAZ files.
+func (l *Las) getPointDataEnd() (end uint64) {
+	end = uint64(l.Header.OffsetToPointData)
+	if l.compressed || l.Header.PointDataRecordFormat&LASZIP_FORMAT_COMPRESSED != 0 {
+		end += 8
+		return
+	}
+	numberOfPDRs, length := l.getNumberOfPDRs(), uint64(l.Header.PointDataRecordLength)
+	if length != 0 && numberOfPDRs > (math.MaxUint64-end)/length {
+		end = math.MaxUint64
+		return
+	}
+	end += numberOfPDRs * length
+	return
+}
+
+func (l *Las) validateLayout(report *ValidationReport) {
+	header := &l.Header
+	version := header.GetVersion()
+	if header.NumberOfVLRs != uint32(len(l.Vlrs)) {
+		report.add(SEVERITY_ERROR, ISSUE_VLR_COUNT, "header declares %d VLRs, found %d", header.NumberOfVLRs, len(l.Vlrs))
+	}
+	vlrsEnd := uint64(header.HeaderSize)
+	for _, vlr := range l.Vlrs {
+		if len(vlr.payload) > math.MaxUint16 {
+			report.add(SEVERITY_ERROR, ISSUE_VLR_SIZE, "VLR with user ID %s and record ID %d holds %d bytes, more than %d", vlr.UserID(), vlr.RecordID(), len(vlr.payload), math.MaxUint16)
+		}
+		vlrsEnd += uint64(vlr.size())
+	}
+	if vlrsEnd > uint64(header.OffsetToPointData) {
+		report.add(SEVERITY_ERROR, ISSUE_OVERLAPPING_RECORDS, "VLRs end at offset %d, after the point data records start at %d", vlrsEnd, header.OffsetToPointData)
+	}
+
+	if version == V1_4 && header.NumberOfExtendedVariableLengthRecords != uint32(len(l.Evlrs)) {
+		report.add(SEVERITY_ERROR, ISSUE_EVLR_COUNT, "header declares %d EVLRs, found %d", header.NumberOfExtendedVariableLengthRecords, len(l.Evlrs))
+	}
+	if len(l.Evlrs) != 0 {
+		if header.VersionMajor == 1 && header.VersionMinor < 3 {
+			report.add(SEVERITY_ERROR, ISSUE_EVLR_VERSION, "version %s files cannot hold EVLRs", version)
+		} else if version == V1_3 && (len(l.Evlrs) > 1 || l.Evlrs[0].UserID() != "LASF_Spec" || l.Evlrs[0].RecordID() != 65535) {
+			report.add(SEVERITY_ERROR, ISSUE_EVLR_VERSION, "version 1.3 files can only hold the waveform data packets EVLR")
+		}
+		if pointsEnd := l.getPointDataEnd(); version == V1_4 && header.StartOfFirstExtendedVariableLengthRecord < pointsEnd {
+			report.add(SEVERITY_ERROR, ISSUE_OVERLAPPING_RECORDS, "EVLRs start at offset %d, before the point data records end at %d", header.StartOfFirstExtendedVariableLengthRecord, pointsEnd)
+		}
+	}
+
+	waveformBits := header.GlobalEncoding & (GLOBAL_ENCODING_WAVEFORM_INTERNAL | GLOBAL_ENCODING_WAVEFORM_EXTERNAL)
+	format := header.PointDataRecordFormat & LASZIP_FORMAT_MASK
+	hasWavePackets := format == 4 || format == 5 || format == 9 || format == 10
+	if hasWavePackets && waveformBits == 0 {
+		report.add(SEVERITY_WARNING, ISSUE_WAVEFORM_DATA_PACKETS, "point data record format %d holds wave packets, but global encoding declares no waveform data packets", format)
+	}
+	if pointsEnd := l.getPointDataEnd(); header.GlobalEncoding&GLOBAL_ENCODING_WAVEFORM_INTERNAL != 0 && header.StartOfWaveformDataPacketRecord != 0 &&
+		header.StartOfWaveformDataPacketRecord < pointsEnd {
+		report.add(SEVERITY_ERROR, ISSUE_OVERLAPPING_RECORDS, "waveform data packets start at offset %d, before the point data records end at %d", header.StartOfWaveformDataPacketRecord, pointsEnd)
+	}
+}
@@ -0,0 +1,272 @@
+package las
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestValidateParsedLaz(t *testing.T) {
+	for _, format := range []uint8{1, 6} {
+		t.Run(fmt.Sprintf("format %d", format), func(t *testing.T) {
+			l := newTestLas(t, 4, format, 100, 0)
+			vlr, err := NewVLR("test", 1, "", []byte{1, 2, 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.AddVLR(vlr)
+			uncompressed, compressed := writeTestLas(t, l), newTestLaz(t, l)
+
+			fromLas, fromLaz := &Las{}, &Las{}
+			if err = fromLas.ParseReader(bytes.NewReader(uncompressed), int64(len(uncompressed))); err != nil {
+				t.Fatal(err)
+			}
+			if err = fromLaz.ParseReader(bytes.NewReader(compressed), int64(len(compressed))); err != nil {
+				t.Fatal(err)
+			}
+			if len(fromLaz.Vlrs) != 1 || fromLaz.Header.NumberOfVLRs != 1 {
+				t.Errorf("header declares %d VLRs, found %d, want 1", fromLaz.Header.NumberOfVLRs, len(fromLaz.Vlrs))
+			}
+			// the LASzip VLR is stripped, so both files are reported alike
+			lasReport, lazReport := fromLas.Validate(), fromLaz.Validate()
+			if !reflect.DeepEqual(lazReport, lasReport) {
+				t.Errorf("LAZ file reports\n%s\nwant\n%s", lazReport.String(), lasReport.String())
+			}
+			for _, issue := range lazReport.Issues {
+				if issue.Code == ISSUE_VLR_COUNT || issue.Code == ISSUE_OVERLAPPING_RECORDS {
+					t.Errorf("LAZ file reports %s", issue)
+				}
+			}
+		})
+	}
+}
+
+// newValidTestLas returns a file of version 1.minor holding 100 points of the format which Validate reports no issues
+// for: the points have a single return and adjusted standard GPS times, and the formats 6 to 10 have a WKT.
+func newValidTestLas(t *testing.T, minor uint8, format uint8) (l *Las) {
+	t.Helper()
+	l = newTestLas(t, minor, format, 100, 0)
+	setTestGPSTimes(l)
+	for i := 0; i < l.Pdrs.Len(); i++ {
+		setTestPointField(l, i, "Pulse", uint8(0x09))
+		setTestPointField(l, i, "PulseReturns", uint8(0x11))
+	}
+	if format >= 6 {
+		crs, err := NewWKTCRSFromEPSG(32632)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = l.SetCoordinateSystemWKT(crs, WKT_VERSION_1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if format == 4 || format == 5 || format >= 9 {
+		l.Header.GlobalEncoding |= GLOBAL_ENCODING_WAVEFORM_EXTERNAL
+	}
+	if err := l.UpdateHeaderFromPoints(); err != nil {
+		t.Fatal(err)
+	}
+	return reparseTestLas(t, l)
+}
+
+// setTestPointField sets the field of the point index to value if its format has the field.
+func setTestPointField(l *Las, index int, field string, value interface{}) {
+	if target := reflect.ValueOf(l.Pdrs).Index(index).FieldByName(field); target.IsValid() {
+		target.Set(reflect.ValueOf(value))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	newTestEVLR := func(t *testing.T, userID string, recordID uint16) EVLR {
+		evlr, err := NewEVLR(userID, recordID, "", []byte{1, 2, 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return evlr
+	}
+	addTestGeoKeys := func(t *testing.T, l *Las) {
+		crs, err := NewWKTCRSFromEPSG(32632)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, err := crs.GetGeoKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = l.SetGeoKeys(keys); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		minor    uint8
+		format   uint8
+		modify   func(t *testing.T, l *Las)
+		code     string
+		severity Severity
+	}{
+		{"valid LAS 1.0", 0, 1, nil, "", 0},
+		{"valid LAS 1.2", 2, 3, nil, "", 0},
+		{"valid LAS 1.3", 3, 4, nil, "", 0},
+		{"valid LAS 1.4", 4, 1, nil, "", 0},
+		{"valid LAS 1.4 with WKT", 4, 7, nil, "", 0},
+
+		{"signature", 2, 1, func(t *testing.T, l *Las) { l.Header.FileSignature[0] = 'X' }, ISSUE_SIGNATURE, SEVERITY_ERROR},
+		{"unknown version", 2, 1, func(t *testing.T, l *Las) { l.Header.VersionMinor = 9 }, ISSUE_VERSION, SEVERITY_ERROR},
+		{"header size", 4, 1, func(t *testing.T, l *Las) { l.Header.HeaderSize = 227 }, ISSUE_HEADER_SIZE, SEVERITY_ERROR},
+		{"format of a later version", 2, 3, func(t *testing.T, l *Las) { l.Header.VersionMinor = 1 }, ISSUE_FORMAT_VERSION, SEVERITY_ERROR},
+		{"undefined format", 4, 1, func(t *testing.T, l *Las) { l.Header.PointDataRecordFormat = 11 }, ISSUE_FORMAT_VERSION, SEVERITY_ERROR},
+		{"format mismatch", 2, 1, func(t *testing.T, l *Las) { l.Header.PointDataRecordFormat = 0 }, ISSUE_FORMAT_MISMATCH, SEVERITY_ERROR},
+		{"record length", 2, 1, func(t *testing.T, l *Las) { l.Header.PointDataRecordLength = 20 }, ISSUE_RECORD_LENGTH, SEVERITY_ERROR},
+		{"zero scale factor", 2, 1, func(t *testing.T, l *Las) { l.Header.YScaleFactor = 0 }, ISSUE_SCALE_FACTOR, SEVERITY_ERROR},
+		{"scale factor not a number", 2, 1, func(t *testing.T, l *Las) { l.Header.ZScaleFactor = math.NaN() }, ISSUE_SCALE_FACTOR, SEVERITY_ERROR},
+
+		{"legacy point count", 2, 1, func(t *testing.T, l *Las) { l.Header.LegacyNumberOfPointRecords-- }, ISSUE_POINT_COUNT, SEVERITY_ERROR},
+		{"point count of LAS 1.4", 4, 1, func(t *testing.T, l *Las) { l.Header.NumberOfPointRecords++ }, ISSUE_POINT_COUNT, SEVERITY_ERROR},
+		{"legacy point count of format 6", 4, 6, func(t *testing.T, l *Las) { l.Header.LegacyNumberOfPointRecords = 100 }, ISSUE_LEGACY_POINT_COUNT, SEVERITY_ERROR},
+		{"legacy point count of LAS 1.4", 4, 1, func(t *testing.T, l *Las) { l.Header.LegacyNumberOfPointRecords = 0 }, ISSUE_LEGACY_POINT_COUNT, SEVERITY_WARNING},
+		{"points by return", 4, 6, func(t *testing.T, l *Las) { l.Header.NumberOfPointsByReturn[1] = 5 }, ISSUE_POINTS_BY_RETURN, SEVERITY_WARNING},
+		{"legacy points by return", 2, 1, func(t *testing.T, l *Las) { l.Header.LegacyNumberOfPointByReturn[0] = 0 }, ISSUE_POINTS_BY_RETURN, SEVERITY_WARNING},
+		{"points outside of the bounds", 2, 1, func(t *testing.T, l *Las) { l.Header.MaxX -= 1 }, ISSUE_BOUNDS, SEVERITY_ERROR},
+		{"bounds exceeding the points", 2, 1, func(t *testing.T, l *Las) { l.Header.MinZ -= 1 }, ISSUE_BOUNDS, SEVERITY_WARNING},
+		{"return number 0", 2, 1, func(t *testing.T, l *Las) { setTestPointField(l, 3, "Pulse", uint8(0x08)) }, ISSUE_RETURN_NUMBER, SEVERITY_WARNING},
+		{"0 returns", 4, 6, func(t *testing.T, l *Las) { setTestPointField(l, 3, "PulseReturns", uint8(0x01)) }, ISSUE_RETURN_NUMBER, SEVERITY_WARNING},
+		{"return number above the returns", 2, 1, func(t *testing.T, l *Las) { setTestPointField(l, 3, "Pulse", uint8(0x0A)) }, ISSUE_RETURN_NUMBER, SEVERITY_WARNING},
+
+		{"global encoding of LAS 1.1", 1, 1, func(t *testing.T, l *Las) { l.Header.GlobalEncoding = GLOBAL_ENCODING_GPS_TIME_TYPE }, ISSUE_GLOBAL_ENCODING, SEVERITY_WARNING},
+		{"reserved global encoding bits", 4, 1, func(t *testing.T, l *Las) { l.Header.GlobalEncoding |= 0x100 }, ISSUE_GLOBAL_ENCODING, SEVERITY_WARNING},
+		{"waveform bits of LAS 1.2", 2, 1, func(t *testing.T, l *Las) { l.Header.GlobalEncoding |= GLOBAL_ENCODING_WAVEFORM_EXTERNAL }, ISSUE_GLOBAL_ENCODING, SEVERITY_WARNING},
+		{"internal and external waveform", 3, 4, func(t *testing.T, l *Las) { l.Header.GlobalEncoding |= GLOBAL_ENCODING_WAVEFORM_INTERNAL }, ISSUE_GLOBAL_ENCODING, SEVERITY_ERROR},
+
+		{"WKT bit of LAS 1.2", 2, 1, func(t *testing.T, l *Las) { l.Header.GlobalEncoding |= GLOBAL_ENCODING_WKT }, ISSUE_CRS_ENCODING, SEVERITY_ERROR},
+		{"format 6 without the WKT bit", 4, 6, func(t *testing.T, l *Las) { l.Header.GlobalEncoding &^= GLOBAL_ENCODING_WKT }, ISSUE_CRS_ENCODING, SEVERITY_ERROR},
+		{"WKT bit beside GeoKeys", 4, 1, func(t *testing.T, l *Las) {
+			addTestGeoKeys(t, l)
+			l.Header.GlobalEncoding |= GLOBAL_ENCODING_WKT
+		}, ISSUE_CRS_ENCODING, SEVERITY_WARNING},
+		{"WKT without the WKT bit", 4, 1, func(t *testing.T, l *Las) {
+			crs, err := NewWKTCRSFromEPSG(32632)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = l.SetCoordinateSystemWKT(crs, WKT_VERSION_1); err != nil {
+				t.Fatal(err)
+			}
+			l.Header.GlobalEncoding &^= GLOBAL_ENCODING_WKT
+		}, ISSUE_CRS_ENCODING, SEVERITY_WARNING},
+		{"WKT bit without a CRS", 4, 1, func(t *testing.T, l *Las) { l.Header.GlobalEncoding |= GLOBAL_ENCODING_WKT }, ISSUE_CRS_ENCODING, SEVERITY_INFO},
+		{"GPS time not a number", 2, 1, func(t *testing.T, l *Las) { setTestPointField(l, 3, "GPSTime", math.NaN()) }, ISSUE_GPS_TIME_TYPE, SEVERITY_ERROR},
+		{"GPS week time outside of a week", 2, 1, func(t *testing.T, l *Las) { l.Header.GlobalEncoding = 0 }, ISSUE_GPS_TIME_TYPE, SEVERITY_WARNING},
+		{"GPS week time of format 6", 4, 6, func(t *testing.T, l *Las) {
+			l.Header.GlobalEncoding &^= GLOBAL_ENCODING_GPS_TIME_TYPE
+			for i := 0; i < l.Pdrs.Len(); i++ {
+				setTestPointField(l, i, "GPSTime", float64(i))
+			}
+		}, ISSUE_GPS_TIME_TYPE, SEVERITY_WARNING},
+
+		{"VLR count", 2, 1, func(t *testing.T, l *Las) { l.Header.NumberOfVLRs = 3 }, ISSUE_VLR_COUNT, SEVERITY_ERROR},
+		{"VLR size", 4, 1, func(t *testing.T, l *Las) { l.Vlrs = []VLR{{payload: make([]byte, math.MaxUint16+1)}} }, ISSUE_VLR_SIZE, SEVERITY_ERROR},
+		{"duplicate GeoKeyDirectoryTag", 2, 1, func(t *testing.T, l *Las) {
+			addTestGeoKeys(t, l)
+			l.Vlrs = append(l.Vlrs, l.Vlrs[0])
+		}, ISSUE_DUPLICATE_VLR, SEVERITY_ERROR},
+		{"EVLR count", 4, 1, func(t *testing.T, l *Las) { l.Header.NumberOfExtendedVariableLengthRecords = 1 }, ISSUE_EVLR_COUNT, SEVERITY_ERROR},
+		{"EVLR of LAS 1.2", 2, 1, func(t *testing.T, l *Las) { l.AddEVLR(newTestEVLR(t, "test", 1)) }, ISSUE_EVLR_VERSION, SEVERITY_ERROR},
+		{"EVLR of LAS 1.3", 3, 1, func(t *testing.T, l *Las) { l.AddEVLR(newTestEVLR(t, "test", 1)) }, ISSUE_EVLR_VERSION, SEVERITY_ERROR},
+		{"VLRs overlapping the points", 2, 1, func(t *testing.T, l *Las) { l.Header.OffsetToPointData = uint32(l.Header.HeaderSize) - 1 }, ISSUE_OVERLAPPING_RECORDS, SEVERITY_ERROR},
+		{"EVLRs overlapping the points", 4, 1, func(t *testing.T, l *Las) {
+			l.AddEVLR(newTestEVLR(t, "test", 1))
+			l.Header.NumberOfExtendedVariableLengthRecords = 1
+			l.Header.StartOfFirstExtendedVariableLengthRecord = uint64(l.Header.OffsetToPointData)
+		}, ISSUE_OVERLAPPING_RECORDS, SEVERITY_ERROR},
+		{"waveform data packets overlapping the points", 3, 1, func(t *testing.T, l *Las) {
+			l.Header.GlobalEncoding |= GLOBAL_ENCODING_WAVEFORM_INTERNAL
+			l.Header.StartOfWaveformDataPacketRecord = uint64(l.Header.OffsetToPointData)
+		}, ISSUE_OVERLAPPING_RECORDS, SEVERITY_ERROR},
+		{"wave packets without waveform data packets", 3, 4, func(t *testing.T, l *Las) { l.Header.GlobalEncoding &^= GLOBAL_ENCODING_WAVEFORM_EXTERNAL }, ISSUE_WAVEFORM_DATA_PACKETS, SEVERITY_WARNING},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newValidTestLas(t, test.minor, test.format)
+			if test.modify == nil {
+				if report := l.Validate(); len(report.Issues) != 0 {
+					t.Errorf("valid file reports\n%s", report.String())
+				}
+				return
+			}
+			test.modify(t, l)
+			report := l.Validate()
+			found := false
+			for _, issue := range report.Issues {
+				found = found || issue.Code == test.code && issue.Severity == test.severity
+			}
+			if !found {
+				t.Errorf("got\n%s\nwant an issue %s of severity %s", report.String(), test.code, test.severity)
+			}
+		})
+	}
+}
+
+func TestValidationReport(t *testing.T) {
+	report := ValidationReport{}
+	if report.MaxSeverity() != -1 || report.HasErrors() || report.String() != "" {
+		t.Errorf("empty report has severity %s, errors %t and text %q", report.MaxSeverity(), report.HasErrors(), report.String())
+	}
+	report.add(SEVERITY_WARNING, ISSUE_BOUNDS, "header %s is %v", "min x", 1.5)
+	report.add(SEVERITY_INFO, ISSUE_CRS_ENCODING, "no CRS")
+	if report.MaxSeverity() != SEVERITY_WARNING || report.HasErrors() {
+		t.Errorf("report has severity %s and errors %t, want a warning", report.MaxSeverity(), report.HasErrors())
+	}
+	report.add(SEVERITY_ERROR, ISSUE_SIGNATURE, "not LASF")
+	if report.MaxSeverity() != SEVERITY_ERROR || !report.HasErrors() {
+		t.Errorf("report has severity %s and errors %t, want an error", report.MaxSeverity(), report.HasErrors())
+	}
+
+	tests := []struct {
+		severity Severity
+		codes    []string
+	}{
+		{SEVERITY_INFO, []string{ISSUE_BOUNDS, ISSUE_CRS_ENCODING, ISSUE_SIGNATURE}},
+		{SEVERITY_WARNING, []string{ISSUE_BOUNDS, ISSUE_SIGNATURE}},
+		{SEVERITY_ERROR, []string{ISSUE_SIGNATURE}},
+	}
+	for _, test := range tests {
+		var codes []string
+		for _, issue := range report.Filter(test.severity) {
+			codes = append(codes, issue.Code)
+		}
+		if !reflect.DeepEqual(codes, test.codes) {
+			t.Errorf("issues of at least severity %s are %v, want %v", test.severity, codes, test.codes)
+		}
+	}
+
+	want := "warning: bounds: header min x is 1.5\ninfo: crs_encoding: no CRS\nerror: signature: not LASF"
+	if report.String() != want {
+		t.Errorf("report is\n%s\nwant\n%s", report.String(), want)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`{"severity":"warning","code":"bounds","message":"header min x is 1.5"}`)) {
+		t.Errorf("JSON report %s does not name the severity", data)
+	}
+	var parsed ValidationReport
+	if err = json.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, report) {
+		t.Errorf("JSON round trip gives %+v, want %+v", parsed, report)
+	}
+	var severity Severity
+	if err = severity.UnmarshalText([]byte("fatal")); err == nil {
+		t.Error("unknown severity read without error")
+	}
+	if text := Severity(7).String(); text != "severity(7)" {
+		t.Errorf("unknown severity is %q", text)
+	}
+}
@@ -0,0 +1,264 @@
+package las
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// __      __           _
+// \ \    / /          (_)
+//  \ \  / /__ _ __ ___ _  ___  _ __  ___
+//   \ \/ / _ \ '__/ __| |/ _ \| '_ \/ __|
+//    \  /  __/ |  \__ \ | (_) | | | \__ \
+//     \/ \___|_|  |___/_|\___/|_| |_|___/
+//
+//
+
+// WAVEFORM_DATA_PACKETS_RECORD_ID is the record ID of the LASF_Spec EVLR holding the waveform data packets of LAS 1.3
+// and 1.4 files. The wave packets of the points locate their data relative to it.
+const WAVEFORM_DATA_PACKETS_RECORD_ID uint16 = 65535
+
+// VersionConversionOptions tell ConvertVersion how to handle what the target version cannot represent. By default
+// ConvertVersion fails instead of losing information.
+type VersionConversionOptions struct {
+	// DegradePointFormat converts point data record formats not defined by the target version to the closest format
+	// it defines, e.g. 6 to 1 and 7 or 8 to 3. See ConvertPointFormat.
+	DegradePointFormat bool
+	// DropRecords drops the EVLRs which cannot be stored as VLRs and the coordinate system WKT which cannot be
+	// expressed as GeoKeys when downgrading from LAS 1.4.
+	DropRecords bool
+	// MoveVLRsToEVLRs moves the VLRs which LAS 1.4 allows to be stored as EVLRs when upgrading to LAS 1.4. The GeoKey,
+	// LASzip and COPC records stay VLRs, as readers expect them there.
+	MoveVLRsToEVLRs bool
+}
+
+// VersionConversionReport lists the changes made by ConvertVersion. It can be marshalled to JSON for machine
+// processing.
+type VersionConversionReport struct {
+	SourceVersion LasSepcVersion               `json:"source_version"`
+	TargetVersion LasSepcVersion               `json:"target_version"`
+	PointFormat   *PointFormatConversionReport `json:"point_format,omitempty"`
+	Changes       []string                     `json:"changes"`
+}
+
+func (r *VersionConversionReport) add(format string, args ...interface{}) {
+	r.Changes = append(r.Changes, fmt.Sprintf(format, args...))
+}
+
+func (r *VersionConversionReport) String() string {
+	lines := []string{fmt.Sprintf("version %s to %s", r.SourceVersion, r.TargetVersion)}
+	lines = append(lines, r.Changes...)
+	if r.PointFormat != nil {
+		lines = append(lines, r.PointFormat.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ConvertVersion rewrites l as a LAS file of version target, e.g. to deliver a LAS 1.4 file as LAS 1.2 and back. The
+// public header block is resized, the 64-bit point count and the 15 returns counts of LAS 1.4 are filled or the legacy
+// fields used instead, and the waveform and EVLR start fields are updated.
+//
+// Upgrading to LAS 1.4 keeps the point data record format, which can be changed afterwards with ConvertPointFormat,
+// and may move VLRs to EVLRs. Downgrading from LAS 1.4 moves the EVLRs to VLRs, except for the waveform data packets
+// which LAS 1.3 keeps in its single EVLR, and converts the coordinate system WKT to GeoKeys. It fails if l holds more
+// than 2^32 - 1 points, and, unless allowed by options, if the point data record format is not defined by target, an
+// EVLR exceeds the 65535 bytes of a VLR or holds waveform data packets below LAS 1.3, or the WKT has no GeoKey
+// equivalent. l is left unchanged on error.
+func (l *Las) ConvertVersion(target LasSepcVersion, options VersionConversionOptions) (report VersionConversionReport, err error) {
+	source := l.Header.GetVersion()
+	report = VersionConversionReport{SourceVersion: source, TargetVersion: target}
+	if err = l.isVersionOK(); err != nil {
+		return
+	}
+	known := false
+	for _, version := range AllLasVersions {
+		known = known || version == target
+	}
+	if !known {
+		err = fmt.Errorf("%w: %s", ErrUnsupportedVersion, target)
+		return
+	}
+	var targetMinor uint8
+	if _, err = fmt.Sscanf(string(target), "1.%d", &targetMinor); err != nil {
+		return
+	}
+
+	numberOfPDRs := uint64(0)
+	if l.Pdrs != nil {
+		numberOfPDRs = uint64(l.Pdrs.Len())
+	}
+	if target != V1_4 && numberOfPDRs > math.MaxUint32 {
+		err = fmt.Errorf("las files with version %s cannot hold %d points", target, numberOfPDRs)
+		return
+	}
+	format := l.Header.PointDataRecordFormat & LASZIP_FORMAT_MASK
+	targetFormat := getLegacyPointFormat(format, targetMinor)
+	if targetFormat != format && !options.DegradePointFormat {
+		err = fmt.Errorf("point data record format %d is not defined by version %s", format, target)
+		return
+	}
+
+	var keys []GeoKey
+	convertWKT, dropWKT := false, false
+	if source == V1_4 && target != V1_4 {
+		if crs, wktErr := l.GetCoordinateSystemWKT(); wktErr == nil {
+			convertWKT = true
+			if keys, err = crs.GetGeoKeys(); err != nil {
+				if !options.DropRecords {
+					err = fmt.Errorf("version %s requires GeoKeys: %w", target, err)
+					return
+				}
+				report.add("dropped the coordinate system WKT: %v", err)
+				dropWKT, err = true, nil
+			}
+		}
+	}
+	if target != V1_4 {
+		for index := range l.Evlrs {
+			if reason := getEVLRDowngradeError(&l.Evlrs[index], target); reason != "" && !options.DropRecords {
+				err = fmt.Errorf("version %s cannot hold the EVLR with user ID %s and record ID %d: %s", target, l.Evlrs[index].UserID(), l.Evlrs[index].RecordID(), reason)
+				return
+			}
+		}
+	}
+
+	// the conversion works on a copy, so that failing leaves l unchanged
+	converted := l.clone()
+	if targetFormat != format {
+		var pointFormat PointFormatConversionReport
+		if pointFormat, err = converted.ConvertPointFormat(targetFormat); err != nil {
+			return
+		}
+		report.PointFormat = &pointFormat
+	}
+	if convertWKT {
+		if dropWKT {
+			converted.removeCRSRecords()
+			converted.updateCRSInfo()
+		} else {
+			if err = converted.SetGeoKeys(keys); err != nil {
+				return
+			}
+			report.add("converted the coordinate system WKT to GeoKeys")
+		}
+	}
+	if target != V1_4 {
+		converted.moveEVLRsToVLRs(&report, target)
+	}
+	if target == V1_4 && source != V1_4 && options.MoveVLRsToEVLRs {
+		if err = converted.moveVLRsToEVLRs(&report); err != nil {
+			return
+		}
+	}
+
+	converted.Header.VersionMajor, converted.Header.VersionMinor = 1, targetMinor
+	if targetMinor < 4 {
+		converted.Header.GlobalEncoding &^= GLOBAL_ENCODING_WKT
+		converted.Header.StartOfFirstExtendedVariableLengthRecord = 0
+		converted.Header.NumberOfExtendedVariableLengthRecords = 0
+		converted.Header.NumberOfPointRecords = 0
+		converted.Header.NumberOfPointsByReturn = [15]uint64{}
+	}
+	if targetMinor < 3 {
+		converted.Header.GlobalEncoding &^= GLOBAL_ENCODING_WAVEFORM_INTERNAL | GLOBAL_ENCODING_WAVEFORM_EXTERNAL | GLOBAL_ENCODING_SYNTHETIC_RETURN_NUMBERS
+		converted.Header.StartOfWaveformDataPacketRecord = 0
+	}
+	if targetMinor < 2 {
+		converted.Header.GlobalEncoding = 0
+	}
+	if err = converted.UpdateHeaderFromPoints(); err != nil {
+		return
+	}
+	if err = converted.updateLayout(); err != nil {
+		return
+	}
+	*l = *converted
+	return
+}
+
+// getLegacyPointFormat returns the point data record format closest to format among those defined by LAS 1.minor.
+func getLegacyPointFormat(format uint8, minor uint8) uint8 {
+	for getMinimumVersion(format) > minor {
+		switch format {
+		case 2:
+			format = 0
+		case 3, 4, 6:
+			format = 1
+		case 5, 7, 8:
+			format = 3
+		case 9:
+			format = 4
+		case 10:
+			format = 5
+		default:
+			return format
+		}
+	}
+	return format
+}
+
+// getEVLRDowngradeError returns why evlr can neither be kept by a file of version target below 1.4 nor stored as a VLR,
+// or an empty string if it can.
+func getEVLRDowngradeError(evlr *EVLR, target LasSepcVersion) (reason string) {
+	if evlr.UserID() == "LASF_Spec" && evlr.RecordID() == WAVEFORM_DATA_PACKETS_RECORD_ID {
+		if target != V1_3 {
+			reason = "waveform data packets are located relative to their EVLR"
+		}
+	} else if len(evlr.payload) > math.MaxUint16 {
+		reason = fmt.Sprintf("its %d bytes exceed the %d bytes of a VLR", len(evlr.payload), math.MaxUint16)
+	}
+	return
+}
+
+// moveEVLRsToVLRs appends the EVLRs of l to its VLRs and drops those which cannot be stored as VLRs. The waveform data
+// packets stay in the EVLR which LAS 1.3 allows for them.
+func (l *Las) moveEVLRsToVLRs(report *VersionConversionReport, target LasSepcVersion) {
+	var evlrs []EVLR
+	for index := range l.Evlrs {
+		evlr := &l.Evlrs[index]
+		if target == V1_3 && evlr.UserID() == "LASF_Spec" && evlr.RecordID() == WAVEFORM_DATA_PACKETS_RECORD_ID {
+			evlrs = append(evlrs, *evlr)
+			continue
+		}
+		if reason := getEVLRDowngradeError(evlr, target); reason != "" {
+			report.add("dropped the EVLR with user ID %s and record ID %d: %s", evlr.UserID(), evlr.RecordID(), reason)
+			if evlr.UserID() == "LASF_Spec" && evlr.RecordID() == WAVEFORM_DATA_PACKETS_RECORD_ID {
+				l.Header.GlobalEncoding &^= GLOBAL_ENCODING_WAVEFORM_INTERNAL
+				l.Header.StartOfWaveformDataPacketRecord = 0
+			}
+			continue
+		}
+		vlr, err := NewVLR(evlr.UserID(), evlr.RecordID(), evlr.Description(), evlr.payload)
+		if err != nil {
+			report.add("dropped the EVLR with user ID %s and record ID %d: %v", evlr.UserID(), evlr.RecordID(), err)
+			continue
+		}
+		l.AddVLR(vlr)
+		report.add("moved the EVLR with user ID %s and record ID %d to the VLRs", evlr.UserID(), evlr.RecordID())
+	}
+	l.Evlrs = evlrs
+}
+
+// moveVLRsToEVLRs moves the VLRs of l which may be stored as EVLRs to its EVLRs.
+func (l *Las) moveVLRsToEVLRs(report *VersionConversionReport) (err error) {
+	var vlrs []VLR
+	var evlrs []EVLR
+	for _, vlr := range l.Vlrs {
+		userID, recordID := vlr.UserID(), vlr.RecordID()
+		isGeoKey := userID == PROJECTION_USER_ID && recordID >= GEO_KEY_DIRECTORY_TAG && recordID <= GEO_ASCII_PARAMS_TAG
+		if isGeoKey || userID == LASZIP_USER_ID || userID == COPC_USER_ID {
+			vlrs = append(vlrs, vlr)
+			continue
+		}
+		var evlr EVLR
+		if evlr, err = NewEVLR(userID, recordID, vlr.Description(), vlr.payload); err != nil {
+			return
+		}
+		evlrs = append(evlrs, evlr)
+		report.add("moved the VLR with user ID %s and record ID %d to the EVLRs", userID, recordID)
+	}
+	l.Vlrs = vlrs
+	l.Evlrs = append(l.Evlrs, evlrs...)
+	return
+}
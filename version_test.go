@@ -0,0 +1,134 @@
+package las
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestConvertVersionWaveformDataPackets(t *testing.T) {
+	l := newTestLas(t, 4, 4, 100, 0)
+	l.Header.GlobalEncoding |= GLOBAL_ENCODING_WAVEFORM_INTERNAL
+	packets := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	for _, record := range []struct {
+		userID   string
+		recordID uint16
+	}{{"test", 1}, {"LASF_Spec", WAVEFORM_DATA_PACKETS_RECORD_ID}} {
+		evlr, err := NewEVLR(record.userID, record.recordID, "", packets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.AddEVLR(evlr)
+	}
+
+	downgraded := l.clone()
+	if _, err := downgraded.ConvertVersion(V1_2, VersionConversionOptions{DegradePointFormat: true}); err == nil {
+		t.Error("converted the waveform data packets to version 1.2 without dropping them")
+	}
+	if _, err := downgraded.ConvertVersion(V1_3, VersionConversionOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(downgraded.Vlrs) != 1 || len(downgraded.Evlrs) != 1 {
+		t.Fatalf("version 1.3 holds %d VLRs and %d EVLRs, want 1 of each", len(downgraded.Vlrs), len(downgraded.Evlrs))
+	}
+
+	for name, data := range map[string][]byte{"LAS": writeTestLas(t, downgraded), "LAZ": newTestLaz(t, downgraded)} {
+		t.Run(name, func(t *testing.T) {
+			parsed := &Las{}
+			if err := parsed.ParseReader(bytes.NewReader(data), int64(len(data))); err != nil {
+				t.Fatal(err)
+			}
+			if len(parsed.Evlrs) != 1 || parsed.Evlrs[0].RecordID() != WAVEFORM_DATA_PACKETS_RECORD_ID || !bytes.Equal(parsed.Evlrs[0].Payload(), packets) {
+				t.Fatalf("read EVLRs %v, want the waveform data packets", parsed.Evlrs)
+			}
+			start := parsed.Header.StartOfWaveformDataPacketRecord
+			if start == 0 || !bytes.Equal(data[start+60:], packets) {
+				t.Errorf("waveform data packets do not start at offset %d", start)
+			}
+			if report := parsed.Validate(); report.HasErrors() {
+				t.Errorf("version 1.3 file reports errors:\n%s", report.String())
+			}
+		})
+	}
+
+	if _, err := downgraded.ConvertVersion(V1_2, VersionConversionOptions{DegradePointFormat: true, DropRecords: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(downgraded.Evlrs) != 0 || downgraded.Header.StartOfWaveformDataPacketRecord != 0 {
+		t.Errorf("version 1.2 holds %d EVLRs starting at %d", len(downgraded.Evlrs), downgraded.Header.StartOfWaveformDataPacketRecord)
+	}
+}
+
+// getTestMinor returns the minor number of the version.
+func getTestMinor(t *testing.T, version LasSepcVersion) (minor uint8) {
+	t.Helper()
+	if _, err := fmt.Sscanf(string(version), "1.%d", &minor); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestConvertVersionRoundTrip(t *testing.T) {
+	crs, err := NewWKTCRSFromEPSG(32632)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := crs.GetGeoKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range AllLasVersions {
+		sourceMinor := getTestMinor(t, source)
+		for format := uint8(0); format <= 10; format++ {
+			if getMinimumVersion(format) > sourceMinor {
+				continue
+			}
+			for _, target := range AllLasVersions {
+				t.Run(fmt.Sprintf("%s format %d to %s", source, format, target), func(t *testing.T) {
+					l := newTestLas(t, sourceMinor, format, 100, 2)
+					setTestGPSTimes(l)
+					var err error
+					if format >= 6 {
+						err = l.SetCoordinateSystemWKT(crs, WKT_VERSION_1)
+					} else {
+						err = l.SetGeoKeys(keys)
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					want := encodeTestPDRs(t, l)
+
+					report, err := l.ConvertVersion(target, VersionConversionOptions{DegradePointFormat: true})
+					if err != nil {
+						t.Fatal(err)
+					}
+					parsed := reparseTestLas(t, l)
+					if parsed.Header.GetVersion() != target || parsed.Pdrs.Len() != 100 {
+						t.Fatalf("converted to %d points of version %s", parsed.Pdrs.Len(), parsed.Header.GetVersion())
+					}
+					if validation := parsed.Validate(); validation.HasErrors() {
+						t.Errorf("version %s reports errors:\n%s", target, validation.String())
+					}
+
+					if _, err = l.ConvertVersion(source, VersionConversionOptions{DegradePointFormat: true}); err != nil {
+						t.Fatal(err)
+					}
+					parsed = reparseTestLas(t, l)
+					if validation := parsed.Validate(); validation.HasErrors() {
+						t.Errorf("version %s reports errors after the round trip:\n%s", source, validation.String())
+					}
+					if got, err := parsed.GetCRS(); err != nil || got.GetEPSG() != 32632 {
+						t.Errorf("CRS is %v after the round trip, want EPSG:32632: %v", got, err)
+					}
+					// the points are only changed if the target version does not define their format
+					if report.PointFormat == nil && !bytes.Equal(encodeTestPDRs(t, parsed), want) {
+						t.Error("point data records differ after the round trip")
+					}
+					if degraded := getLegacyPointFormat(format, getTestMinor(t, target)) != format; (report.PointFormat != nil) != degraded {
+						t.Errorf("converted the point format %t, want %t", report.PointFormat != nil, degraded)
+					}
+				})
+			}
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"os"
+	"io"
+	"math"
+	"sync"
 )
 
 type VLRHeader struct {
@@ -16,7 +18,11 @@ type VLRHeader struct {
 }
 
 func (vh *VLRHeader) getUserID() (userID string, err error) {
-	chunks := bytes.Split(vh.UserID[:], []byte("\x00"))
+	return getUserID(vh.UserID)
+}
+
+func getUserID(id [16]byte) (userID string, err error) {
+	chunks := bytes.Split(id[:], []byte("\x00"))
 	for _, chunk := range chunks {
 		if len(chunk) != 0 {
 			userID = string(chunk)
@@ -28,14 +34,16 @@ func (vh *VLRHeader) getUserID() (userID string, err error) {
 }
 
 type VLR struct {
-	header VLRHeader
-	record []CRS
+	header  VLRHeader
+	record  []CRS
+	payload []byte
 }
 
-func (v *VLR) read(file *os.File, offsetIn int64) (offsetOut int64, err error) {
+func (v *VLR) read(reader io.ReaderAt, offsetIn int64) (offsetOut int64, err error) {
 	headerInBytes := make([]byte, binary.Size(VLRHeader{}))
-	_, err = file.ReadAt(headerInBytes, offsetIn)
+	err = readAt(reader, headerInBytes, offsetIn)
 	if err != nil {
+		err = &FormatError{Offset: offsetIn, Field: "VLR header", Err: err}
 		return
 	}
 	if err = binary.Read(bytes.NewReader(headerInBytes), binary.LittleEndian, &v.header); err != nil {
@@ -43,27 +51,129 @@ func (v *VLR) read(file *os.File, offsetIn int64) (offsetOut int64, err error) {
 	}
 	bytesInRecord := make([]byte, v.header.RecordLengthAfterHeader)
 	offsetToRecord := offsetIn + int64(binary.Size(VLRHeader{}))
-	crs, err := v.getCRSFormat()
+	err = readAt(reader, bytesInRecord, offsetToRecord)
 	if err != nil {
+		err = &FormatError{Offset: offsetToRecord, Field: "VLR payload", Err: err}
 		return
 	}
-	_, err = file.ReadAt(bytesInRecord, offsetToRecord)
-	if err != nil {
+	v.payload = bytesInRecord
+	offsetOut = offsetToRecord + int64(v.header.RecordLengthAfterHeader)
+	return
+}
+
+func (v *VLR) size() (size uint32) {
+	size = uint32(binary.Size(VLRHeader{}) + len(v.payload))
+	return
+}
+
+func (v *VLR) write(w io.Writer) (err error) {
+	if len(v.payload) > math.MaxUint16 {
+		err = fmt.Errorf("VLR payload of %d bytes exceeds the maximum of %d bytes", len(v.payload), math.MaxUint16)
+		return
+	}
+	v.header.RecordLengthAfterHeader = uint16(len(v.payload))
+	if err = binary.Write(w, binary.LittleEndian, &v.header); err != nil {
+		return
+	}
+	if _, err = w.Write(v.payload); err != nil {
+		return
+	}
+	return
+}
+
+// NewVLR returns a VLR holding payload. Its record is decoded from payload like the record of a parsed VLR with the
+// same user ID and record ID.
+func NewVLR(userID string, recordID uint16, description string, payload []byte) (v VLR, err error) {
+	if len(userID) > len(v.header.UserID) {
+		err = fmt.Errorf("user ID %q is longer than %d bytes", userID, len(v.header.UserID))
+		return
+	}
+	if len(description) > len(v.header.Description) {
+		err = fmt.Errorf("description %q is longer than %d bytes", description, len(v.header.Description))
+		return
+	}
+	if len(payload) > math.MaxUint16 {
+		err = fmt.Errorf("VLR payload of %d bytes exceeds the maximum of %d bytes", len(payload), math.MaxUint16)
+		return
+	}
+	copy(v.header.UserID[:], userID)
+	v.header.RecordID = recordID
+	v.header.RecordLengthAfterHeader = uint16(len(payload))
+	copy(v.header.Description[:], description)
+	v.payload = payload
+	err = v.decode(0)
+	return
+}
+
+// decode decodes the record of v from its payload, which starts at offset of the file v was read from, or at 0 if v
+// is not read from a file. A record which cannot be decoded is not kept, so that Record returns nil and only the
+// payload is available.
+func (v *VLR) decode(offset int64) (err error) {
+	v.record = nil
+	userID, _ := v.header.getUserID()
+	crs := newVLRRecord(userID, v.header.RecordID)
+	if crs == nil {
+		return
+	}
+	if err = crs.read(v.payload, offset); err != nil {
+		err = fmt.Errorf("VLR with user ID %s and record ID %d cannot be decoded: %w", userID, v.header.RecordID, err)
 		return
 	}
-	crs.read(bytesInRecord, offsetToRecord)
 	v.record = append(v.record, crs)
-	offsetOut = offsetToRecord + int64(v.header.RecordLengthAfterHeader)
 	return
 }
 
-func (v *VLR) getCRSFormat() (crs CRS, err error) {
-	userID, err := v.header.getUserID()
-	if err != nil {
+// UserID returns the user ID identifying the organisation which defined the record.
+func (v *VLR) UserID() string {
+	userID, _ := v.header.getUserID()
+	return userID
+}
+
+// RecordID returns the ID of the record within its user ID.
+func (v *VLR) RecordID() uint16 {
+	return v.header.RecordID
+}
+
+// Description returns the textual description of the record.
+func (v *VLR) Description() string {
+	return getDescription(v.header.Description)
+}
+
+// Payload returns the raw bytes following the VLR header.
+func (v *VLR) Payload() []byte {
+	return v.payload
+}
+
+// Record returns the decoded record, e.g. a *GeoKeyDirectoryTag or the VLRDecoder registered for the user ID and
+// record ID, or nil if the record is not known or cannot be decoded.
+func (v *VLR) Record() interface{} {
+	return getRecord(v.record)
+}
+
+func getDescription(description [32]byte) string {
+	return string(bytes.TrimRight(description[:], "\x00"))
+}
+
+func getRecord(records []CRS) interface{} {
+	if len(records) == 0 {
+		return nil
+	}
+	if r, ok := records[0].(*registeredRecord); ok {
+		return r.decoder
+	}
+	return records[0]
+}
+
+// newVLRRecord returns an empty record for the user ID and record ID of a VLR or EVLR. Decoders registered with
+// RegisterVLRDecoder take precedence over the records known to this package. It returns nil for unknown records, whose
+// payload is then kept as raw bytes only.
+func newVLRRecord(userID string, recordID uint16) (crs CRS) {
+	if factory := getVLRDecoder(userID, recordID); factory != nil {
+		crs = &registeredRecord{decoder: factory()}
 		return
 	}
 	if userID == "LASF_Projection" {
-		switch v.header.RecordID {
+		switch recordID {
 		case 34735:
 			crs = &GeoKeyDirectoryTag{}
 		case 34736:
@@ -74,30 +184,89 @@ func (v *VLR) getCRSFormat() (crs CRS, err error) {
 			crs = &MathTransformWKT{}
 		case 2112:
 			crs = &CoordinateSystemWKT{}
-		default:
-			err = fmt.Errorf("CRS format not defined for LASF Projection")
 		}
 	} else if userID == "LASF_Spec" {
-		switch v.header.RecordID {
+		switch recordID {
 		case 0:
 			crs = &ClassificationLookup{}
 		case 3:
 			crs = &TextAreaDescription{}
 		case 4:
 			crs = &ExtraBytes{}
-		default:
-			err = fmt.Errorf("CRS format not defined for LASF Spec")
 		}
-
+	} else if userID == LASZIP_USER_ID {
+		switch recordID {
+		case LASZIP_RECORD_ID:
+			crs = &LASzip{}
+		}
+	} else if userID == COPC_USER_ID {
+		switch recordID {
+		case COPC_INFO_RECORD_ID:
+			crs = &CopcInfo{}
+		}
 	} else if userID == "liblas" {
-		switch v.header.RecordID {
+		switch recordID {
 		case 2112:
 			crs = &MathTransformWKT{}
-		default:
-			err = fmt.Errorf("CRS format not defined for liblas")
 		}
-	} else {
-		err = fmt.Errorf("CRS format with userid %s not defined", userID)
 	}
 	return
 }
+
+// __      ___      _____    _____                     _
+// \ \    / / |    |  __ \  |  __ \                   | |
+//  \ \  / /| |    | |__) | | |  | | ___  ___ ___   __| | ___ _ __ ___
+//   \ \/ / | |    |  _  /  | |  | |/ _ \/ __/ _ \ / _` |/ _ \ '__/ __|
+//    \  /  | |____| | \ \  | |__| |  __/ (_| (_) | (_| |  __/ |  \__ \
+//     \/   |______|_|  \_\ |_____/ \___|\___\___/ \__,_|\___|_|  |___/
+//
+//
+
+// VLRDecoder decodes the payload of a VLR or EVLR. Record types outside this package implement it to be registered
+// with RegisterVLRDecoder.
+type VLRDecoder interface {
+	Decode(payload []byte) (err error)
+}
+
+// VLRDecoderFactory returns a new, empty VLRDecoder for every VLR or EVLR to decode.
+type VLRDecoderFactory func() VLRDecoder
+
+type vlrDecoderKey struct {
+	userID   string
+	recordID uint16
+}
+
+var (
+	vlrDecodersMutex sync.RWMutex
+	vlrDecoders      = make(map[vlrDecoderKey]VLRDecoderFactory)
+)
+
+// RegisterVLRDecoder registers the factory decoding the VLRs and EVLRs with the given user ID and record ID. It
+// replaces any previous registration for the same IDs, including the records known to this package, and a nil factory
+// removes the registration. Records for which no decoder exists are kept as raw bytes.
+func RegisterVLRDecoder(userID string, recordID uint16, factory VLRDecoderFactory) {
+	vlrDecodersMutex.Lock()
+	defer vlrDecodersMutex.Unlock()
+	key := vlrDecoderKey{userID: userID, recordID: recordID}
+	if factory == nil {
+		delete(vlrDecoders, key)
+		return
+	}
+	vlrDecoders[key] = factory
+}
+
+func getVLRDecoder(userID string, recordID uint16) (factory VLRDecoderFactory) {
+	vlrDecodersMutex.RLock()
+	defer vlrDecodersMutex.RUnlock()
+	factory = vlrDecoders[vlrDecoderKey{userID: userID, recordID: recordID}]
+	return
+}
+
+// registeredRecord adapts a VLRDecoder to the records of a VLR or EVLR.
+type registeredRecord struct {
+	decoder VLRDecoder
+}
+
+func (r *registeredRecord) read(record []byte, offset int64) (err error) {
+	return r.decoder.Decode(record)
+}
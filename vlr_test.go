@@ -0,0 +1,133 @@
+package las
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// newTestGeoKeyDirectory returns the payload of a GeoKeyDirectoryTag declaring numberOfKeys keys followed by keys.
+func newTestGeoKeyDirectory(numberOfKeys uint16, keys ...uint16) (payload []byte) {
+	for _, value := range append([]uint16{1, 1, 0, numberOfKeys}, keys...) {
+		payload = binary.LittleEndian.AppendUint16(payload, value)
+	}
+	return
+}
+
+func TestNewVLRCorruptPayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   string
+		recordID uint16
+		payload  []byte
+	}{
+		{"GeoDoubleParamsTag of 5 bytes", PROJECTION_USER_ID, GEO_DOUBLE_PARAMS_TAG, make([]byte, 5)},
+		{"GeoKeyDirectoryTag without keys", PROJECTION_USER_ID, GEO_KEY_DIRECTORY_TAG, newTestGeoKeyDirectory(4)},
+		{"GeoKeyDirectoryTag with a partial header", PROJECTION_USER_ID, GEO_KEY_DIRECTORY_TAG, make([]byte, 3)},
+		{"classification lookup of 100 bytes", "LASF_Spec", 0, make([]byte, 100)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vlr, err := NewVLR(test.userID, test.recordID, "", test.payload)
+			if err == nil {
+				t.Error("corrupt VLR decoded without error")
+			}
+			if vlr.Record() != nil {
+				t.Errorf("corrupt VLR holds the record %v", vlr.Record())
+			}
+			evlr, err := NewEVLR(test.userID, test.recordID, "", test.payload)
+			if err == nil {
+				t.Error("corrupt EVLR decoded without error")
+			}
+			if evlr.Record() != nil {
+				t.Errorf("corrupt EVLR holds the record %v", evlr.Record())
+			}
+		})
+	}
+}
+
+func TestClassificationLookup(t *testing.T) {
+	payload := make([]byte, binary.Size(ClassificationLookup{}))
+	payload[16] = 2
+	copy(payload[17:], "Ground")
+	vlr, err := NewVLR("LASF_Spec", 0, "", payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup, ok := vlr.Record().(*ClassificationLookup)
+	if !ok {
+		t.Fatalf("record is %T, want *ClassificationLookup", vlr.Record())
+	}
+	if lookup[1].ClassNumber != 2 || string(bytes.TrimRight(lookup[1].Description[:], "\x00")) != "Ground" {
+		t.Errorf("second class is %+v, want 2 Ground", lookup[1])
+	}
+}
+
+func TestParseReaderLenientCorruptVLR(t *testing.T) {
+	l := newTestLas(t, 4, 1, 10, 0)
+	payload := []byte{1, 2, 3, 4, 5}
+	vlr, err := NewVLR(PROJECTION_USER_ID, GEO_DOUBLE_PARAMS_TAG, "", payload)
+	if err == nil {
+		t.Fatal("GeoDoubleParamsTag of 5 bytes decoded without error")
+	}
+	l.AddVLR(vlr)
+	data := writeTestLas(t, l)
+
+	strict := &Las{}
+	if err = strict.ParseReader(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	lenient := &Las{}
+	warnings, err := lenient.ParseReaderLenient(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("got warnings %v, want one for the VLR", warnings)
+	}
+	for _, parsed := range []*Las{strict, lenient} {
+		if len(parsed.Vlrs) != 1 || !bytes.Equal(parsed.Vlrs[0].Payload(), payload) || parsed.Vlrs[0].Record() != nil {
+			t.Error("corrupt VLR is not kept as raw payload")
+		}
+		if parsed.Pdrs.Len() != 10 {
+			t.Errorf("read %d points, want 10", parsed.Pdrs.Len())
+		}
+	}
+}
+
+func TestGeoParamsTagOffsets(t *testing.T) {
+	doubles := binary.LittleEndian.AppendUint64(nil, math.Float64bits(1.5))
+	doubles = binary.LittleEndian.AppendUint64(doubles, math.Float64bits(2.5))
+	ascii := []byte("WGS 84|\x00metre|\x00")
+	l := newTestLas(t, 4, 1, 10, 0)
+	for _, record := range []struct {
+		recordID uint16
+		payload  []byte
+	}{{GEO_DOUBLE_PARAMS_TAG, doubles}, {GEO_ASCII_PARAMS_TAG, ascii}} {
+		vlr, err := NewVLR(PROJECTION_USER_ID, record.recordID, "", record.payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.AddVLR(vlr)
+	}
+	if want := (GeoDoubleParamsTag{0: 1.5, 8: 2.5}); !reflect.DeepEqual(*l.Vlrs[0].Record().(*GeoDoubleParamsTag), want) {
+		t.Errorf("new GeoDoubleParamsTag is %v, want %v", l.Vlrs[0].Record(), want)
+	}
+	data := writeTestLas(t, l)
+
+	parsed := &Las{}
+	if err := parsed.ParseReader(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	// the values are keyed by their offset in the file, the payloads follow the VLR headers of 54 bytes
+	doublesOffset := int64(parsed.Header.HeaderSize) + 54
+	asciiOffset := doublesOffset + int64(len(doubles)) + 54
+	if want := (GeoDoubleParamsTag{doublesOffset: 1.5, doublesOffset + 8: 2.5}); !reflect.DeepEqual(*parsed.Vlrs[0].Record().(*GeoDoubleParamsTag), want) {
+		t.Errorf("parsed GeoDoubleParamsTag is %v, want %v", parsed.Vlrs[0].Record(), want)
+	}
+	if want := (GeoAsciiParamsTag{asciiOffset: "WGS 84|", asciiOffset + 8: "metre|"}); !reflect.DeepEqual(*parsed.Vlrs[1].Record().(*GeoAsciiParamsTag), want) {
+		t.Errorf("parsed GeoAsciiParamsTag is %v, want %v", parsed.Vlrs[1].Record(), want)
+	}
+}
@@ -0,0 +1,1413 @@
+package las
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// __          ___  _________
+// \ \        / / |/ /__   __|
+//  \ \  /\  / /| ' /   | |
+//   \ \/  \/ / |  <    | |
+//    \  /\  /  | . \   | |
+//     \/  \/   |_|\_\  |_|
+//
+//
+
+const (
+	COORDINATE_SYSTEM_WKT_RECORD_ID uint16 = 2112
+	MATH_TRANSFORM_WKT_RECORD_ID    uint16 = 2111
+)
+
+// WKTVersion selects the OGC WKT dialect written by WKTCRS.WKT. WKT_VERSION_1 is OGC 01-009, as required by LAS 1.4,
+// and WKT_VERSION_2 is ISO 19162:2019.
+type WKTVersion int
+
+const (
+	WKT_VERSION_1 WKTVersion = 1
+	WKT_VERSION_2 WKTVersion = 2
+)
+
+// WKTCRSType is the kind of coordinate reference system described by a WKTCRS.
+type WKTCRSType int
+
+const (
+	WKT_CRS_UNKNOWN WKTCRSType = iota
+	WKT_CRS_GEOGRAPHIC
+	WKT_CRS_GEOCENTRIC
+	WKT_CRS_PROJECTED
+	WKT_CRS_VERTICAL
+	WKT_CRS_COMPOUND
+	WKT_CRS_ENGINEERING
+)
+
+func (t WKTCRSType) String() string {
+	switch t {
+	case WKT_CRS_GEOGRAPHIC:
+		return "geographic"
+	case WKT_CRS_GEOCENTRIC:
+		return "geocentric"
+	case WKT_CRS_PROJECTED:
+		return "projected"
+	case WKT_CRS_VERTICAL:
+		return "vertical"
+	case WKT_CRS_COMPOUND:
+		return "compound"
+	case WKT_CRS_ENGINEERING:
+		return "engineering"
+	}
+	return "unknown"
+}
+
+// The keywords of WKTUnit.Type. Units of WKT1 files are given the type implied by their context.
+const (
+	WKT_UNIT_LENGTH = "LENGTHUNIT"
+	WKT_UNIT_ANGLE  = "ANGLEUNIT"
+	WKT_UNIT_SCALE  = "SCALEUNIT"
+)
+
+//  _   _           _
+// | \ | |         | |
+// |  \| | ___   __| | ___  ___
+// | . ` |/ _ \ / _` |/ _ \/ __|
+// | |\  | (_) | (_| |  __/\__ \
+// |_| \_|\___/ \__,_|\___||___/
+//
+//
+
+// WKTValueKind is the kind of a value of a WKTNode.
+type WKTValueKind int
+
+const (
+	WKT_VALUE_STRING WKTValueKind = iota
+	WKT_VALUE_NUMBER
+	WKT_VALUE_ENUM
+	WKT_VALUE_NODE
+)
+
+// WKTNode is a keyword with its bracketed values, e.g. UNIT["metre",1], as read from WKT text. Keywords are upper
+// case.
+type WKTNode struct {
+	Keyword string
+	Values  []WKTValue
+}
+
+// WKTValue is a quoted string, a number, an enumeration such as NORTH, or a nested node. Text holds strings and
+// enumerations, and the literal of numbers; Number holds the value of numbers.
+type WKTValue struct {
+	Kind   WKTValueKind
+	Text   string
+	Number float64
+	Node   *WKTNode
+}
+
+// wktEnum is an unquoted value passed to newWKTNode.
+type wktEnum string
+
+// newWKTNode returns a node with values of type string, wktEnum, float64, int or *WKTNode. Nil nodes are skipped.
+func newWKTNode(keyword string, values ...interface{}) (node *WKTNode) {
+	node = &WKTNode{Keyword: keyword}
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			node.Values = append(node.Values, WKTValue{Kind: WKT_VALUE_STRING, Text: v})
+		case wktEnum:
+			node.Values = append(node.Values, WKTValue{Kind: WKT_VALUE_ENUM, Text: string(v)})
+		case float64:
+			node.Values = append(node.Values, WKTValue{Kind: WKT_VALUE_NUMBER, Number: v})
+		case int:
+			node.Values = append(node.Values, WKTValue{Kind: WKT_VALUE_NUMBER, Number: float64(v)})
+		case *WKTNode:
+			if v != nil {
+				node.Values = append(node.Values, WKTValue{Kind: WKT_VALUE_NODE, Node: v})
+			}
+		}
+	}
+	return
+}
+
+// ParseWKTNode parses WKT1 or WKT2 text into its tree of nodes without interpreting the keywords. Trailing NUL bytes
+// are ignored.
+func ParseWKTNode(wkt string) (node *WKTNode, err error) {
+	parser := &wktParser{text: strings.TrimRight(wkt, "\x00")}
+	if node, err = parser.parseNode(); err != nil {
+		node = nil
+		return
+	}
+	parser.skipSpace()
+	if parser.index != len(parser.text) {
+		err = parser.errorf("unexpected text after the end of %s", node.Keyword)
+		node = nil
+	}
+	return
+}
+
+// GetName returns the first value of n if it is a string, the name of most WKT nodes.
+func (n *WKTNode) GetName() string {
+	if len(n.Values) != 0 && n.Values[0].Kind == WKT_VALUE_STRING {
+		return n.Values[0].Text
+	}
+	return ""
+}
+
+// GetChild returns the first nested node with one of the keywords, or nil.
+func (n *WKTNode) GetChild(keywords ...string) *WKTNode {
+	for _, value := range n.Values {
+		if value.Kind == WKT_VALUE_NODE && isWKTKeyword(value.Node.Keyword, keywords) {
+			return value.Node
+		}
+	}
+	return nil
+}
+
+// GetChildren returns the nested nodes with one of the keywords.
+func (n *WKTNode) GetChildren(keywords ...string) (children []*WKTNode) {
+	for _, value := range n.Values {
+		if value.Kind == WKT_VALUE_NODE && isWKTKeyword(value.Node.Keyword, keywords) {
+			children = append(children, value.Node)
+		}
+	}
+	return
+}
+
+// getNumber returns the index-th value of n if it is a number.
+func (n *WKTNode) getNumber(index int) (number float64, ok bool) {
+	if index < len(n.Values) && n.Values[index].Kind == WKT_VALUE_NUMBER {
+		return n.Values[index].Number, true
+	}
+	return
+}
+
+// getText returns the index-th value of n if it is a string, an enumeration or a number.
+func (n *WKTNode) getText(index int) string {
+	if index < len(n.Values) && n.Values[index].Kind != WKT_VALUE_NODE {
+		return n.Values[index].Text
+	}
+	return ""
+}
+
+// String returns n as WKT text, with strings quoted and the literals of parsed numbers kept.
+func (n *WKTNode) String() string {
+	builder := &strings.Builder{}
+	n.write(builder)
+	return builder.String()
+}
+
+func (n *WKTNode) write(builder *strings.Builder) {
+	builder.WriteString(n.Keyword)
+	builder.WriteByte('[')
+	for index, value := range n.Values {
+		if index != 0 {
+			builder.WriteByte(',')
+		}
+		switch value.Kind {
+		case WKT_VALUE_STRING:
+			builder.WriteByte('"')
+			builder.WriteString(strings.ReplaceAll(value.Text, `"`, `""`))
+			builder.WriteByte('"')
+		case WKT_VALUE_NUMBER:
+			if value.Text != "" {
+				builder.WriteString(value.Text)
+			} else {
+				builder.WriteString(strconv.FormatFloat(value.Number, 'f', -1, 64))
+			}
+		case WKT_VALUE_ENUM:
+			builder.WriteString(value.Text)
+		case WKT_VALUE_NODE:
+			value.Node.write(builder)
+		}
+	}
+	builder.WriteByte(']')
+}
+
+func isWKTKeyword(keyword string, keywords []string) bool {
+	for _, k := range keywords {
+		if keyword == k {
+			return true
+		}
+	}
+	return false
+}
+
+func isWKTKeywordChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// wktParser reads WKT text, accepting both square brackets and parentheses.
+type wktParser struct {
+	text  string
+	index int
+}
+
+func (p *wktParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid WKT at position %d: %s", p.index, fmt.Sprintf(format, args...))
+}
+
+func (p *wktParser) skipSpace() {
+	for p.index < len(p.text) && strings.IndexByte(" \t\r\n", p.text[p.index]) >= 0 {
+		p.index++
+	}
+}
+
+func (p *wktParser) peek() byte {
+	if p.index < len(p.text) {
+		return p.text[p.index]
+	}
+	return 0
+}
+
+func (p *wktParser) parseKeyword() string {
+	start := p.index
+	for p.index < len(p.text) && isWKTKeywordChar(p.text[p.index]) {
+		p.index++
+	}
+	return p.text[start:p.index]
+}
+
+func (p *wktParser) parseNode() (node *WKTNode, err error) {
+	p.skipSpace()
+	keyword := p.parseKeyword()
+	if keyword == "" {
+		err = p.errorf("expected a keyword")
+		return
+	}
+	node = &WKTNode{Keyword: strings.ToUpper(keyword)}
+	p.skipSpace()
+	if c := p.peek(); c != '[' && c != '(' {
+		err = p.errorf("expected [ after %s", keyword)
+		return
+	}
+	p.index++
+	p.skipSpace()
+	if c := p.peek(); c == ']' || c == ')' {
+		p.index++
+		return
+	}
+	for {
+		var value WKTValue
+		if value, err = p.parseValue(); err != nil {
+			return
+		}
+		node.Values = append(node.Values, value)
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.index++
+		case ']', ')':
+			p.index++
+			return
+		default:
+			err = p.errorf("expected , or ] in %s", node.Keyword)
+			return
+		}
+	}
+}
+
+func (p *wktParser) parseValue() (value WKTValue, err error) {
+	p.skipSpace()
+	c := p.peek()
+	switch {
+	case c == '"':
+		return p.parseString()
+	case c == '+' || c == '-' || c == '.' || ('0' <= c && c <= '9'):
+		start := p.index
+		for p.index < len(p.text) && strings.IndexByte("+-.eE0123456789", p.text[p.index]) >= 0 {
+			p.index++
+		}
+		value = WKTValue{Kind: WKT_VALUE_NUMBER, Text: p.text[start:p.index]}
+		if value.Number, err = strconv.ParseFloat(value.Text, 64); err != nil {
+			p.index = start
+			err = p.errorf("invalid number %s", value.Text)
+		}
+		return
+	case isWKTKeywordChar(c):
+		start := p.index
+		text := p.parseKeyword()
+		p.skipSpace()
+		if c = p.peek(); c == '[' || c == '(' {
+			p.index = start
+			value = WKTValue{Kind: WKT_VALUE_NODE}
+			value.Node, err = p.parseNode()
+			return
+		}
+		value = WKTValue{Kind: WKT_VALUE_ENUM, Text: text}
+		return
+	}
+	err = p.errorf("expected a value")
+	return
+}
+
+// parseString reads a quoted string, in which a doubled quote stands for a quote.
+func (p *wktParser) parseString() (value WKTValue, err error) {
+	start := p.index
+	p.index++
+	builder := &strings.Builder{}
+	for p.index < len(p.text) {
+		c := p.text[p.index]
+		p.index++
+		if c != '"' {
+			builder.WriteByte(c)
+			continue
+		}
+		if p.peek() != '"' {
+			value = WKTValue{Kind: WKT_VALUE_STRING, Text: builder.String()}
+			return
+		}
+		builder.WriteByte('"')
+		p.index++
+	}
+	p.index = start
+	err = p.errorf("unterminated string")
+	return
+}
+
+//   _____ _____   _____   _______
+//  / ____|  __ \ / ____| |__   __|
+// | |    | |__) | (___      | |_ __ ___  ___
+// | |    |  _  / \___ \     | | '__/ _ \/ _ \
+// | |____| | \ \ ____) |    | | | |  __/  __/
+//  \_____|_|  \_\_____/     |_|_|  \___|\___|
+//
+//
+
+// WKTCRS is a coordinate reference system read from WKT1 or WKT2. Which fields are set depends on Type: geographic,
+// geocentric, vertical and engineering systems have a Datum, projected systems a BaseCRS and a Projection, and
+// compound systems their Components, usually a horizontal and a vertical one.
+type WKTCRS struct {
+	Type          WKTCRSType
+	Name          string
+	Datum         *WKTDatum
+	PrimeMeridian *WKTPrimeMeridian
+	BaseCRS       *WKTCRS
+	Projection    *WKTProjection
+	// Unit is the unit of the coordinates; for WKT2 files it is the unit of the first axis unless the coordinate
+	// system gives one for all axes.
+	Unit *WKTUnit
+	// Axes are the axes in the order of the coordinates, empty if the WKT does not state them. See GetAxes.
+	Axes       []WKTAxis
+	Components []*WKTCRS
+	Authority  *WKTAuthority
+}
+
+// WKTDatum is a geodetic, vertical or engineering datum. ToWGS84 holds the 3 or 7 parameters of the Helmert
+// transformation to WGS 84 in the position vector convention, translations in metres, rotations in arc-seconds and
+// the scale difference in parts per million; it is read from TOWGS84 in WKT1 and from the abridged transformation of a
+// BOUNDCRS in WKT2.
+type WKTDatum struct {
+	Name      string
+	Ellipsoid *WKTEllipsoid
+	ToWGS84   []float64
+	Authority *WKTAuthority
+}
+
+// WKTEllipsoid is the ellipsoid of a geodetic datum. InverseFlattening is 0 for spheres.
+type WKTEllipsoid struct {
+	Name              string
+	SemiMajorAxis     float64
+	InverseFlattening float64
+	Unit              *WKTUnit
+	Authority         *WKTAuthority
+}
+
+type WKTPrimeMeridian struct {
+	Name      string
+	Longitude float64
+	Unit      *WKTUnit
+	Authority *WKTAuthority
+}
+
+// WKTProjection is the map projection of a projected CRS. MethodCode and the codes of the parameters are the EPSG
+// codes of the method and parameters, 0 if they are not known.
+type WKTProjection struct {
+	// Name is the name of the conversion, only given by WKT2.
+	Name            string
+	Method          string
+	MethodCode      int
+	MethodAuthority *WKTAuthority
+	Parameters      []WKTParameter
+	Authority       *WKTAuthority
+}
+
+// WKTParameter is a parameter of a projection. Unit is always set; for WKT1, which implies the units of parameters, it
+// is the angular unit of the base CRS, the linear unit of the projected CRS or unity.
+type WKTParameter struct {
+	Name      string
+	Code      int
+	Value     float64
+	Unit      *WKTUnit
+	Authority *WKTAuthority
+}
+
+// WKTUnit is a unit with its factor to metres, radians or unity. Type is one of WKT_UNIT_LENGTH, WKT_UNIT_ANGLE and
+// WKT_UNIT_SCALE, or another WKT2 unit keyword.
+type WKTUnit struct {
+	Type      string
+	Name      string
+	Factor    float64
+	Authority *WKTAuthority
+}
+
+// WKTAxis is an axis of a coordinate system. Direction is lower case, e.g. "east" or "up".
+type WKTAxis struct {
+	Name      string
+	Direction string
+	Unit      *WKTUnit
+}
+
+// WKTAuthority identifies an object in a register, e.g. Name "EPSG" and Code "4326".
+type WKTAuthority struct {
+	Name string
+	Code string
+}
+
+// ParseWKT parses a WKT1 or WKT2 coordinate reference system. A WKT2 BOUNDCRS is returned as its source CRS, with
+// the abridged transformation stored as the ToWGS84 of the geodetic datum.
+func ParseWKT(wkt string) (crs *WKTCRS, err error) {
+	node, err := ParseWKTNode(wkt)
+	if err != nil {
+		return
+	}
+	return NewWKTCRS(node)
+}
+
+// NewWKTCRS interprets a tree of WKT1 or WKT2 nodes as a coordinate reference system.
+func NewWKTCRS(node *WKTNode) (crs *WKTCRS, err error) {
+	crs = &WKTCRS{Name: node.GetName(), Authority: newWKTAuthority(node)}
+	unitType := WKT_UNIT_LENGTH
+	switch node.Keyword {
+	case "GEOGCS", "GEOGCRS", "GEOGRAPHICCRS", "BASEGEOGCRS":
+		crs.Type = WKT_CRS_GEOGRAPHIC
+		unitType = WKT_UNIT_ANGLE
+	case "GEODCRS", "GEODETICCRS", "BASEGEODCRS":
+		crs.Type = WKT_CRS_GEOGRAPHIC
+		unitType = WKT_UNIT_ANGLE
+		if cs := node.GetChild("CS"); cs != nil && strings.EqualFold(cs.getText(0), "Cartesian") {
+			crs.Type = WKT_CRS_GEOCENTRIC
+			unitType = WKT_UNIT_LENGTH
+		}
+	case "GEOCCS":
+		crs.Type = WKT_CRS_GEOCENTRIC
+	case "PROJCS", "PROJCRS", "PROJECTEDCRS":
+		crs.Type = WKT_CRS_PROJECTED
+	case "VERT_CS", "VERTCRS", "VERTICALCRS", "BASEVERTCRS":
+		crs.Type = WKT_CRS_VERTICAL
+	case "LOCAL_CS", "ENGCRS", "ENGINEERINGCRS", "BASEENGCRS":
+		crs.Type = WKT_CRS_ENGINEERING
+	case "COMPD_CS", "COMPOUNDCRS":
+		crs.Type = WKT_CRS_COMPOUND
+		for _, value := range node.Values {
+			if value.Kind != WKT_VALUE_NODE || isWKTKeyword(value.Node.Keyword, []string{"AUTHORITY", "ID", "USAGE", "REMARK"}) {
+				continue
+			}
+			var component *WKTCRS
+			if component, err = NewWKTCRS(value.Node); err != nil {
+				return
+			}
+			crs.Components = append(crs.Components, component)
+		}
+		return
+	case "BOUNDCRS":
+		return newWKTBoundCRS(node)
+	default:
+		err = fmt.Errorf("WKT %s is not a supported coordinate reference system", node.Keyword)
+		return
+	}
+
+	if datum := node.GetChild("DATUM", "GEODETICDATUM", "TRF", "ENSEMBLE", "VERT_DATUM", "VDATUM", "VERTICALDATUM", "VRF", "LOCAL_DATUM", "EDATUM", "ENGINEERINGDATUM"); datum != nil {
+		crs.Datum = newWKTDatum(datum)
+	}
+	if base := node.GetChild("GEOGCS", "BASEGEOGCRS", "BASEGEODCRS"); base != nil && crs.Type == WKT_CRS_PROJECTED {
+		if crs.BaseCRS, err = NewWKTCRS(base); err != nil {
+			return
+		}
+	}
+	if unit := node.GetChild("UNIT", "LENGTHUNIT", "ANGLEUNIT", "SCALEUNIT"); unit != nil {
+		crs.Unit = newWKTUnit(unit, unitType)
+	}
+	for _, axis := range node.GetChildren("AXIS") {
+		crs.Axes = append(crs.Axes, newWKTAxis(axis, unitType))
+	}
+	if crs.Unit == nil && len(crs.Axes) != 0 {
+		crs.Unit = crs.Axes[0].Unit
+	}
+	if primeMeridian := node.GetChild("PRIMEM", "PRIMEMERIDIAN"); primeMeridian != nil {
+		crs.PrimeMeridian = newWKTPrimeMeridian(primeMeridian, crs.Unit)
+	}
+	if crs.Type == WKT_CRS_PROJECTED {
+		crs.Projection = newWKTProjection(node, crs)
+	}
+	return
+}
+
+// newWKTBoundCRS reads the source CRS of a BOUNDCRS and stores its abridged transformation as ToWGS84.
+func newWKTBoundCRS(node *WKTNode) (crs *WKTCRS, err error) {
+	source := node.GetChild("SOURCECRS")
+	if source == nil || len(source.Values) == 0 || source.Values[0].Kind != WKT_VALUE_NODE {
+		err = fmt.Errorf("WKT BOUNDCRS has no source CRS")
+		return
+	}
+	if crs, err = NewWKTCRS(source.Values[0].Node); err != nil {
+		return
+	}
+	transformation := node.GetChild("ABRIDGEDTRANSFORMATION")
+	datum := crs.getGeodeticDatum()
+	if transformation == nil || datum == nil {
+		return
+	}
+	method := transformation.GetChild("METHOD", "PROJECTION")
+	coordinateFrame := method != nil && (strings.Contains(strings.ToLower(method.GetName()), "coordinate frame") || newWKTAuthority(method).getEPSG() == 9607)
+	parameters := make(map[int]float64)
+	for _, parameter := range transformation.GetChildren("PARAMETER") {
+		code := newWKTAuthority(parameter).getEPSG()
+		if code == 0 {
+			code = getWKTHelmertParameterCode(parameter.GetName())
+		}
+		value, _ := parameter.getNumber(1)
+		if unit := parameter.GetChild("UNIT", "LENGTHUNIT", "ANGLEUNIT", "SCALEUNIT"); unit != nil {
+			factor := newWKTUnit(unit, "").Factor
+			switch {
+			case code >= 8608 && code <= 8610:
+				value *= factor / ARC_SECOND
+			case code == 8611:
+				value *= factor / 1e-6
+			default:
+				value *= factor
+			}
+		}
+		if coordinateFrame && code >= 8608 && code <= 8610 {
+			value = -value
+		}
+		parameters[code] = value
+	}
+	toWGS84 := make([]float64, 7)
+	for index := range toWGS84 {
+		toWGS84[index] = parameters[8605+index]
+	}
+	if len(parameters) <= 3 {
+		toWGS84 = toWGS84[:3]
+	}
+	datum.ToWGS84 = toWGS84
+	return
+}
+
+// ARC_SECOND is an arc-second in radians.
+const ARC_SECOND = math.Pi / 648000
+
+// The EPSG parameters of the Helmert transformations, indexed from 8605.
+var wktHelmertParameterNames = []string{
+	"X-axis translation",
+	"Y-axis translation",
+	"Z-axis translation",
+	"X-axis rotation",
+	"Y-axis rotation",
+	"Z-axis rotation",
+	"Scale difference",
+}
+
+func getWKTHelmertParameterCode(name string) int {
+	for index, parameterName := range wktHelmertParameterNames {
+		if normalizeWKTName(name) == normalizeWKTName(parameterName) {
+			return 8605 + index
+		}
+	}
+	return 0
+}
+
+func newWKTAuthority(node *WKTNode) *WKTAuthority {
+	authority := node.GetChild("AUTHORITY", "ID")
+	if authority == nil {
+		return nil
+	}
+	return &WKTAuthority{Name: authority.getText(0), Code: authority.getText(1)}
+}
+
+// getEPSG returns the code of an EPSG authority, or 0.
+func (a *WKTAuthority) getEPSG() int {
+	if a == nil || !strings.EqualFold(a.Name, "EPSG") {
+		return 0
+	}
+	code, _ := strconv.Atoi(a.Code)
+	return code
+}
+
+func newWKTDatum(node *WKTNode) (datum *WKTDatum) {
+	datum = &WKTDatum{Name: node.GetName(), Authority: newWKTAuthority(node)}
+	if ellipsoid := node.GetChild("SPHEROID", "ELLIPSOID"); ellipsoid != nil {
+		datum.Ellipsoid = &WKTEllipsoid{Name: ellipsoid.GetName(), Authority: newWKTAuthority(ellipsoid), Unit: newMetreUnit()}
+		datum.Ellipsoid.SemiMajorAxis, _ = ellipsoid.getNumber(1)
+		datum.Ellipsoid.InverseFlattening, _ = ellipsoid.getNumber(2)
+		if unit := ellipsoid.GetChild("UNIT", "LENGTHUNIT"); unit != nil {
+			datum.Ellipsoid.Unit = newWKTUnit(unit, WKT_UNIT_LENGTH)
+		}
+	}
+	if toWGS84 := node.GetChild("TOWGS84"); toWGS84 != nil {
+		for index := range toWGS84.Values {
+			value, _ := toWGS84.getNumber(index)
+			datum.ToWGS84 = append(datum.ToWGS84, value)
+		}
+	}
+	return
+}
+
+func newWKTPrimeMeridian(node *WKTNode, unit *WKTUnit) (primeMeridian *WKTPrimeMeridian) {
+	primeMeridian = &WKTPrimeMeridian{Name: node.GetName(), Authority: newWKTAuthority(node), Unit: unit}
+	primeMeridian.Longitude, _ = node.getNumber(1)
+	if child := node.GetChild("UNIT", "ANGLEUNIT"); child != nil {
+		primeMeridian.Unit = newWKTUnit(child, WKT_UNIT_ANGLE)
+	}
+	if primeMeridian.Unit == nil {
+		primeMeridian.Unit = newDegreeUnit()
+	}
+	return
+}
+
+// newWKTUnit reads a unit, giving the WKT1 keyword UNIT the type unitType.
+func newWKTUnit(node *WKTNode, unitType string) (unit *WKTUnit) {
+	unit = &WKTUnit{Type: node.Keyword, Name: node.GetName(), Factor: 1, Authority: newWKTAuthority(node)}
+	if factor, ok := node.getNumber(1); ok {
+		unit.Factor = factor
+	}
+	if unit.Type == "UNIT" && unitType != "" {
+		unit.Type = unitType
+	}
+	return
+}
+
+func newWKTAxis(node *WKTNode, unitType string) (axis WKTAxis) {
+	axis = WKTAxis{Name: node.GetName(), Direction: strings.ToLower(node.getText(1))}
+	if unit := node.GetChild("UNIT", "LENGTHUNIT", "ANGLEUNIT", "SCALEUNIT"); unit != nil {
+		axis.Unit = newWKTUnit(unit, unitType)
+	}
+	return
+}
+
+// newWKTProjection reads the PROJECTION and PARAMETERs of a WKT1 PROJCS or the CONVERSION of a WKT2 PROJCRS, and
+// gives the parameters their EPSG codes and units.
+func newWKTProjection(node *WKTNode, crs *WKTCRS) (projection *WKTProjection) {
+	projection = &WKTProjection{}
+	method := node.GetChild("PROJECTION")
+	parameters := node
+	if conversion := node.GetChild("CONVERSION"); conversion != nil {
+		projection.Name = conversion.GetName()
+		projection.Authority = newWKTAuthority(conversion)
+		method = conversion.GetChild("METHOD", "PROJECTION")
+		parameters = conversion
+	}
+	if method != nil {
+		projection.Method = method.GetName()
+		projection.MethodAuthority = newWKTAuthority(method)
+		projection.MethodCode = projection.MethodAuthority.getEPSG()
+	}
+	known := getWKTMethod(projection.MethodCode, projection.Method)
+	if known != nil {
+		projection.MethodCode = known.code
+	}
+
+	for _, node := range parameters.GetChildren("PARAMETER") {
+		parameter := WKTParameter{Name: node.GetName(), Authority: newWKTAuthority(node)}
+		parameter.Value, _ = node.getNumber(1)
+		parameter.Code = parameter.Authority.getEPSG()
+		if parameter.Code == 0 && known != nil {
+			parameter.Code = known.getParameterCode(parameter.Name)
+		}
+		unitType := getWKTParameterUnitType(parameter.Code, parameter.Name)
+		if unit := node.GetChild("UNIT", "LENGTHUNIT", "ANGLEUNIT", "SCALEUNIT"); unit != nil {
+			parameter.Unit = newWKTUnit(unit, unitType)
+		} else {
+			parameter.Unit = crs.getImpliedParameterUnit(unitType)
+		}
+		projection.Parameters = append(projection.Parameters, parameter)
+	}
+	return
+}
+
+// getImpliedParameterUnit returns the unit of the WKT1 parameters of type unitType of the projected CRS c.
+func (c *WKTCRS) getImpliedParameterUnit(unitType string) *WKTUnit {
+	switch unitType {
+	case WKT_UNIT_ANGLE:
+		if c.BaseCRS != nil && c.BaseCRS.Unit != nil {
+			return c.BaseCRS.Unit
+		}
+		return newDegreeUnit()
+	case WKT_UNIT_LENGTH:
+		if c.Unit != nil {
+			return c.Unit
+		}
+		return newMetreUnit()
+	}
+	return newUnityUnit()
+}
+
+// GetParameter returns the parameter with the EPSG code, or nil.
+func (p *WKTProjection) GetParameter(code int) *WKTParameter {
+	for index := range p.Parameters {
+		if p.Parameters[index].Code == code {
+			return &p.Parameters[index]
+		}
+	}
+	return nil
+}
+
+// GetEPSG returns the EPSG code of c, or 0 if it has none.
+func (c *WKTCRS) GetEPSG() int {
+	return c.Authority.getEPSG()
+}
+
+// GetHorizontalCRS returns c or its horizontal component, or nil if c is vertical.
+func (c *WKTCRS) GetHorizontalCRS() *WKTCRS {
+	if c.Type != WKT_CRS_COMPOUND {
+		if c.Type == WKT_CRS_VERTICAL {
+			return nil
+		}
+		return c
+	}
+	for _, component := range c.Components {
+		if horizontal := component.GetHorizontalCRS(); horizontal != nil {
+			return horizontal
+		}
+	}
+	return nil
+}
+
+// GetVerticalCRS returns c or its vertical component, or nil if it has none.
+func (c *WKTCRS) GetVerticalCRS() *WKTCRS {
+	if c.Type == WKT_CRS_VERTICAL {
+		return c
+	}
+	for _, component := range c.Components {
+		if vertical := component.GetVerticalCRS(); vertical != nil {
+			return vertical
+		}
+	}
+	return nil
+}
+
+// GetGeographicCRS returns the geographic CRS of c, i.e. c itself, the base of a projected CRS or that of the
+// horizontal component of a compound CRS, or nil.
+func (c *WKTCRS) GetGeographicCRS() *WKTCRS {
+	horizontal := c.GetHorizontalCRS()
+	switch {
+	case horizontal == nil:
+		return nil
+	case horizontal.Type == WKT_CRS_PROJECTED:
+		return horizontal.BaseCRS
+	case horizontal.Type == WKT_CRS_GEOGRAPHIC || horizontal.Type == WKT_CRS_GEOCENTRIC:
+		return horizontal
+	}
+	return nil
+}
+
+func (c *WKTCRS) getGeodeticDatum() *WKTDatum {
+	if geographic := c.GetGeographicCRS(); geographic != nil {
+		return geographic.Datum
+	}
+	return nil
+}
+
+// GetAxes returns the axes of c, or if the WKT does not state them the default axes of WKT1: longitude and latitude
+// for geographic systems, east and north for projected systems, X, Y and Z for geocentric systems and up for vertical
+// systems.
+func (c *WKTCRS) GetAxes() []WKTAxis {
+	if len(c.Axes) != 0 {
+		return c.Axes
+	}
+	switch c.Type {
+	case WKT_CRS_GEOGRAPHIC:
+		return []WKTAxis{{Name: "Lon", Direction: "east"}, {Name: "Lat", Direction: "north"}}
+	case WKT_CRS_PROJECTED, WKT_CRS_ENGINEERING:
+		return []WKTAxis{{Name: "X", Direction: "east"}, {Name: "Y", Direction: "north"}}
+	case WKT_CRS_GEOCENTRIC:
+		return []WKTAxis{{Name: "X", Direction: "other"}, {Name: "Y", Direction: "other"}, {Name: "Z", Direction: "north"}}
+	case WKT_CRS_VERTICAL:
+		return []WKTAxis{{Name: "Gravity-related height", Direction: "up"}}
+	}
+	return nil
+}
+
+// NewCompoundWKTCRS returns the compound CRS of a horizontal and a vertical CRS.
+func NewCompoundWKTCRS(horizontal *WKTCRS, vertical *WKTCRS) *WKTCRS {
+	return &WKTCRS{Type: WKT_CRS_COMPOUND, Name: horizontal.Name + " + " + vertical.Name, Components: []*WKTCRS{horizontal, vertical}}
+}
+
+func newMetreUnit() *WKTUnit {
+	return &WKTUnit{Type: WKT_UNIT_LENGTH, Name: "metre", Factor: 1, Authority: &WKTAuthority{Name: "EPSG", Code: "9001"}}
+}
+
+func newDegreeUnit() *WKTUnit {
+	return &WKTUnit{Type: WKT_UNIT_ANGLE, Name: "degree", Factor: 0.0174532925199433, Authority: &WKTAuthority{Name: "EPSG", Code: "9122"}}
+}
+
+func newUnityUnit() *WKTUnit {
+	return &WKTUnit{Type: WKT_UNIT_SCALE, Name: "unity", Factor: 1, Authority: &WKTAuthority{Name: "EPSG", Code: "9201"}}
+}
+
+//  _____           _           _   _
+// |  __ \         (_)         | | (_)
+// | |__) | __ ___  _  ___  ___| |_ _  ___  _ __  ___
+// |  ___/ '__/ _ \| |/ _ \/ __| __| |/ _ \| '_ \/ __|
+// | |   | | | (_) | |  __/ (__| |_| | (_) | | | \__ \
+// |_|   |_|  \___/| |\___|\___|\__|_|\___/|_| |_|___/
+//                _/ |
+//               |__/
+
+// wktMethod is a projection method known by its EPSG name, used by WKT2, and its WKT1 names.
+type wktMethod struct {
+	code       int
+	name       string
+	wkt1Names  []string
+	parameters []wktMethodParameter
+}
+
+// wktMethodParameter is a parameter of a projection method with its WKT1 names, of which the first one is written.
+type wktMethodParameter struct {
+	code      int
+	wkt1Names []string
+}
+
+var wktParameterNames = map[int]string{
+	8801: "Latitude of natural origin",
+	8802: "Longitude of natural origin",
+	8805: "Scale factor at natural origin",
+	8806: "False easting",
+	8807: "False northing",
+	8811: "Latitude of projection centre",
+	8812: "Longitude of projection centre",
+	8813: "Azimuth of initial line",
+	8814: "Angle from Rectified to Skew Grid",
+	8815: "Scale factor on initial line",
+	8816: "Easting at projection centre",
+	8817: "Northing at projection centre",
+	8821: "Latitude of false origin",
+	8822: "Longitude of false origin",
+	8823: "Latitude of 1st standard parallel",
+	8824: "Latitude of 2nd standard parallel",
+	8826: "Easting at false origin",
+	8827: "Northing at false origin",
+}
+
+var (
+	wktLatitudeOfOrigin  = wktMethodParameter{8801, []string{"latitude_of_origin"}}
+	wktCentralMeridian   = wktMethodParameter{8802, []string{"central_meridian"}}
+	wktScaleFactor       = wktMethodParameter{8805, []string{"scale_factor"}}
+	wktFalseEasting      = wktMethodParameter{8806, []string{"false_easting"}}
+	wktFalseNorthing     = wktMethodParameter{8807, []string{"false_northing"}}
+	wktStandardParallel1 = wktMethodParameter{8823, []string{"standard_parallel_1"}}
+	wktStandardParallel2 = wktMethodParameter{8824, []string{"standard_parallel_2"}}
+)
+
+var wktMethods = []wktMethod{
+	{9807, "Transverse Mercator", []string{"Transverse_Mercator"}, []wktMethodParameter{
+		wktLatitudeOfOrigin, wktCentralMeridian, wktScaleFactor, wktFalseEasting, wktFalseNorthing}},
+	{9801, "Lambert Conic Conformal (1SP)", []string{"Lambert_Conformal_Conic_1SP"}, []wktMethodParameter{
+		wktLatitudeOfOrigin, wktCentralMeridian, wktScaleFactor, wktFalseEasting, wktFalseNorthing}},
+	{9802, "Lambert Conic Conformal (2SP)", []string{"Lambert_Conformal_Conic_2SP", "Lambert_Conformal_Conic"}, []wktMethodParameter{
+		{8821, []string{"latitude_of_origin"}}, {8822, []string{"central_meridian"}}, wktStandardParallel1, wktStandardParallel2,
+		{8826, []string{"false_easting"}}, {8827, []string{"false_northing"}}}},
+	{9822, "Albers Equal Area", []string{"Albers_Conic_Equal_Area", "Albers"}, []wktMethodParameter{
+		{8821, []string{"latitude_of_center", "latitude_of_origin"}}, {8822, []string{"longitude_of_center", "central_meridian"}},
+		wktStandardParallel1, wktStandardParallel2, {8826, []string{"false_easting"}}, {8827, []string{"false_northing"}}}},
+	{9804, "Mercator (variant A)", []string{"Mercator_1SP"}, []wktMethodParameter{
+		wktLatitudeOfOrigin, wktCentralMeridian, wktScaleFactor, wktFalseEasting, wktFalseNorthing}},
+	{9805, "Mercator (variant B)", []string{"Mercator_2SP", "Mercator"}, []wktMethodParameter{
+		wktStandardParallel1, wktCentralMeridian, wktFalseEasting, wktFalseNorthing}},
+	{1024, "Popular Visualisation Pseudo Mercator", []string{"Popular_Visualisation_Pseudo_Mercator", "Mercator_Auxiliary_Sphere"}, []wktMethodParameter{
+		wktLatitudeOfOrigin, wktCentralMeridian, wktFalseEasting, wktFalseNorthing}},
+	{9810, "Polar Stereographic (variant A)", []string{"Polar_Stereographic"}, []wktMethodParameter{
+		wktLatitudeOfOrigin, wktCentralMeridian, wktScaleFactor, wktFalseEasting, wktFalseNorthing}},
+	{9809, "Oblique Stereographic", []string{"Oblique_Stereographic", "Double_Stereographic"}, []wktMethodParameter{
+		wktLatitudeOfOrigin, wktCentralMeridian, wktScaleFactor, wktFalseEasting, wktFalseNorthing}},
+	{9820, "Lambert Azimuthal Equal Area", []string{"Lambert_Azimuthal_Equal_Area"}, []wktMethodParameter{
+		{8801, []string{"latitude_of_center", "latitude_of_origin"}}, {8802, []string{"longitude_of_center", "central_meridian"}},
+		wktFalseEasting, wktFalseNorthing}},
+	{1028, "Equidistant Cylindrical", []string{"Equirectangular", "Equidistant_Cylindrical"}, []wktMethodParameter{
+		wktStandardParallel1, wktLatitudeOfOrigin, wktCentralMeridian, wktFalseEasting, wktFalseNorthing}},
+	{9806, "Cassini-Soldner", []string{"Cassini_Soldner"}, []wktMethodParameter{
+		wktLatitudeOfOrigin, wktCentralMeridian, wktFalseEasting, wktFalseNorthing}},
+	{9815, "Hotine Oblique Mercator (variant B)", []string{"Hotine_Oblique_Mercator_Azimuth_Center"}, []wktMethodParameter{
+		{8811, []string{"latitude_of_center"}}, {8812, []string{"longitude_of_center"}}, {8813, []string{"azimuth"}},
+		{8814, []string{"rectified_grid_angle"}}, {8815, []string{"scale_factor"}}, {8816, []string{"false_easting"}},
+		{8817, []string{"false_northing"}}}},
+}
+
+// normalizeWKTName lowers the case of name and drops everything but letters and digits, so that WKT1 and EPSG
+// spellings such as "Transverse_Mercator" and "Transverse Mercator" compare equal.
+func normalizeWKTName(name string) string {
+	builder := &strings.Builder{}
+	for _, c := range strings.ToLower(name) {
+		if ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') {
+			builder.WriteRune(c)
+		}
+	}
+	return builder.String()
+}
+
+// getWKTMethod returns the known method with the EPSG code, or else with the WKT1 or EPSG name, or nil.
+func getWKTMethod(code int, name string) *wktMethod {
+	normalized := normalizeWKTName(name)
+	for index := range wktMethods {
+		method := &wktMethods[index]
+		if code != 0 {
+			if method.code == code {
+				return method
+			}
+			continue
+		}
+		if normalizeWKTName(method.name) == normalized {
+			return method
+		}
+		for _, wkt1Name := range method.wkt1Names {
+			if normalizeWKTName(wkt1Name) == normalized {
+				return method
+			}
+		}
+	}
+	return nil
+}
+
+// getParameterCode returns the EPSG code of the parameter of m with the WKT1 or EPSG name, or 0.
+func (m *wktMethod) getParameterCode(name string) int {
+	normalized := normalizeWKTName(name)
+	for _, parameter := range m.parameters {
+		if normalizeWKTName(wktParameterNames[parameter.code]) == normalized {
+			return parameter.code
+		}
+		for _, wkt1Name := range parameter.wkt1Names {
+			if normalizeWKTName(wkt1Name) == normalized {
+				return parameter.code
+			}
+		}
+	}
+	return 0
+}
+
+// getWKT1ParameterName returns the WKT1 name of the parameter of m with the EPSG code, or "".
+func (m *wktMethod) getWKT1ParameterName(code int) string {
+	for _, parameter := range m.parameters {
+		if parameter.code == code {
+			return parameter.wkt1Names[0]
+		}
+	}
+	return ""
+}
+
+// getWKTParameterUnitType returns the type of the unit of a parameter, guessed from its name if its code is unknown.
+func getWKTParameterUnitType(code int, name string) string {
+	switch code {
+	case 8805, 8815:
+		return WKT_UNIT_SCALE
+	case 8806, 8807, 8816, 8817, 8826, 8827:
+		return WKT_UNIT_LENGTH
+	}
+	if _, ok := wktParameterNames[code]; ok {
+		return WKT_UNIT_ANGLE
+	}
+	normalized := normalizeWKTName(name)
+	switch {
+	case strings.Contains(normalized, "scale"):
+		return WKT_UNIT_SCALE
+	case strings.Contains(normalized, "easting"), strings.Contains(normalized, "northing"), strings.Contains(normalized, "height"):
+		return WKT_UNIT_LENGTH
+	}
+	return WKT_UNIT_ANGLE
+}
+
+// __          ___  _________  __          __   _ _   _
+// \ \        / / |/ /__   __| \ \        / /  (_) | (_)
+//  \ \  /\  / /| ' /   | |     \ \  /\  / / __ _| |_ _ _ __   __ _
+//   \ \/  \/ / |  <    | |      \ \/  \/ / '__| | __| | '_ \ / _` |
+//    \  /\  /  | . \   | |       \  /\  /| |  | | |_| | | | | (_| |
+//     \/  \/   |_|\_\  |_|        \/  \/ |_|  |_|\__|_|_| |_|\__, |
+//                                                             __/ |
+//                                                            |___/
+
+// String returns c as WKT1.
+func (c *WKTCRS) String() string {
+	return c.WKT(WKT_VERSION_1)
+}
+
+// WKT returns c as WKT1 or WKT2. The names of known projection methods and parameters are translated between the
+// dialects, and the values of parameters converted to the units implied by WKT1. WKT2 states the axes, using GetAxes
+// if c has none, and writes ToWGS84 as a BOUNDCRS to WGS 84.
+func (c *WKTCRS) WKT(version WKTVersion) string {
+	if version == WKT_VERSION_2 {
+		node := c.wkt2Node(false)
+		if datum := c.getGeodeticDatum(); datum != nil && (len(datum.ToWGS84) == 3 || len(datum.ToWGS84) == 7) {
+			node = newWKTBoundCRSNode(node, datum)
+		}
+		return node.String()
+	}
+	return c.wkt1Node().String()
+}
+
+func (a *WKTAuthority) wkt1Node() *WKTNode {
+	if a == nil {
+		return nil
+	}
+	return newWKTNode("AUTHORITY", a.Name, a.Code)
+}
+
+func (a *WKTAuthority) wkt2Node() *WKTNode {
+	if a == nil {
+		return nil
+	}
+	if code, err := strconv.Atoi(a.Code); err == nil {
+		return newWKTNode("ID", a.Name, code)
+	}
+	return newWKTNode("ID", a.Name, a.Code)
+}
+
+func (u *WKTUnit) wkt1Node() *WKTNode {
+	if u == nil {
+		return nil
+	}
+	return newWKTNode("UNIT", u.Name, u.Factor, u.Authority.wkt1Node())
+}
+
+func (u *WKTUnit) wkt2Node() *WKTNode {
+	if u == nil {
+		return nil
+	}
+	return newWKTNode(u.Type, u.Name, u.Factor, u.Authority.wkt2Node())
+}
+
+// convert returns value, given in unit u, in unit target, rounded to 12 significant digits to drop the noise of the
+// conversion. Values are kept if either unit is missing or the units are equal.
+func (u *WKTUnit) convert(value float64, target *WKTUnit) float64 {
+	if u == nil || target == nil || target.Factor == 0 || u.Factor == target.Factor {
+		return value
+	}
+	converted, _ := strconv.ParseFloat(strconv.FormatFloat(value*u.Factor/target.Factor, 'g', 12, 64), 64)
+	return converted
+}
+
+func (c *WKTCRS) wkt1Node() (node *WKTNode) {
+	var values []interface{}
+	values = append(values, c.Name)
+	keyword := ""
+	switch c.Type {
+	case WKT_CRS_GEOGRAPHIC, WKT_CRS_GEOCENTRIC:
+		keyword = "GEOGCS"
+		if c.Type == WKT_CRS_GEOCENTRIC {
+			keyword = "GEOCCS"
+		}
+		values = append(values, c.Datum.wkt1Node("DATUM"))
+		if c.PrimeMeridian != nil {
+			values = append(values, newWKTNode("PRIMEM", c.PrimeMeridian.Name, c.PrimeMeridian.Unit.convert(c.PrimeMeridian.Longitude, c.getUnit(WKT_UNIT_ANGLE)), c.PrimeMeridian.Authority.wkt1Node()))
+		} else {
+			values = append(values, newWKTNode("PRIMEM", "Greenwich", 0, &WKTAuthority{Name: "EPSG", Code: "8901"}))
+		}
+	case WKT_CRS_PROJECTED:
+		keyword = "PROJCS"
+		if c.BaseCRS != nil {
+			values = append(values, c.BaseCRS.wkt1Node())
+		}
+		if c.Projection != nil {
+			values = append(values, c.Projection.wkt1Nodes(c)...)
+		}
+	case WKT_CRS_VERTICAL:
+		keyword = "VERT_CS"
+		if c.Datum != nil {
+			values = append(values, newWKTNode("VERT_DATUM", c.Datum.Name, 2005, c.Datum.Authority.wkt1Node()))
+		}
+	case WKT_CRS_ENGINEERING:
+		keyword = "LOCAL_CS"
+		if c.Datum != nil {
+			values = append(values, newWKTNode("LOCAL_DATUM", c.Datum.Name, 0, c.Datum.Authority.wkt1Node()))
+		}
+	case WKT_CRS_COMPOUND:
+		keyword = "COMPD_CS"
+		for _, component := range c.Components {
+			values = append(values, component.wkt1Node())
+		}
+	}
+	if c.Type != WKT_CRS_COMPOUND {
+		unit := c.Unit
+		if unit == nil && c.Type == WKT_CRS_GEOGRAPHIC {
+			unit = newDegreeUnit()
+		} else if unit == nil {
+			unit = newMetreUnit()
+		}
+		values = append(values, unit.wkt1Node())
+		for _, axis := range c.Axes {
+			values = append(values, newWKTNode("AXIS", axis.Name, wktEnum(strings.ToUpper(axis.Direction))))
+		}
+	}
+	values = append(values, c.Authority.wkt1Node())
+	return newWKTNode(keyword, values...)
+}
+
+func (d *WKTDatum) wkt1Node(keyword string) *WKTNode {
+	if d == nil {
+		return nil
+	}
+	var ellipsoid *WKTNode
+	if e := d.Ellipsoid; e != nil {
+		ellipsoid = newWKTNode("SPHEROID", e.Name, e.Unit.convert(e.SemiMajorAxis, newMetreUnit()), e.InverseFlattening, e.Authority.wkt1Node())
+	}
+	var toWGS84 *WKTNode
+	if len(d.ToWGS84) != 0 {
+		values := make([]interface{}, len(d.ToWGS84))
+		for index, value := range d.ToWGS84 {
+			values[index] = value
+		}
+		toWGS84 = newWKTNode("TOWGS84", values...)
+	}
+	return newWKTNode(keyword, d.Name, ellipsoid, toWGS84, d.Authority.wkt1Node())
+}
+
+// getUnit returns the unit of c if it is of type unitType, or else the default unit of that type.
+func (c *WKTCRS) getUnit(unitType string) *WKTUnit {
+	if c != nil && c.Unit != nil && c.Unit.Type == unitType {
+		return c.Unit
+	}
+	switch unitType {
+	case WKT_UNIT_ANGLE:
+		return newDegreeUnit()
+	case WKT_UNIT_LENGTH:
+		return newMetreUnit()
+	}
+	return newUnityUnit()
+}
+
+// wkt1Nodes returns the PROJECTION and PARAMETER nodes of the projected CRS c, with the values of the parameters in
+// the linear unit of c and the angular unit of its base CRS.
+func (p *WKTProjection) wkt1Nodes(c *WKTCRS) (nodes []interface{}) {
+	method := getWKTMethod(p.MethodCode, p.Method)
+	methodName := p.Method
+	if method != nil {
+		methodName = method.wkt1Names[0]
+	}
+	nodes = append(nodes, newWKTNode("PROJECTION", methodName, p.MethodAuthority.wkt1Node()))
+	for _, parameter := range p.Parameters {
+		name := parameter.Name
+		if method != nil && parameter.Code != 0 {
+			if wkt1Name := method.getWKT1ParameterName(parameter.Code); wkt1Name != "" {
+				name = wkt1Name
+			}
+		}
+		var target *WKTUnit
+		switch unitType := getWKTParameterUnitType(parameter.Code, parameter.Name); unitType {
+		case WKT_UNIT_ANGLE:
+			target = c.BaseCRS.getUnit(unitType)
+		default:
+			target = c.getUnit(unitType)
+		}
+		nodes = append(nodes, newWKTNode("PARAMETER", name, parameter.Unit.convert(parameter.Value, target)))
+	}
+	return
+}
+
+// wkt2Node returns c as WKT2; base selects the keywords of the base CRS of a projected CRS.
+func (c *WKTCRS) wkt2Node(base bool) (node *WKTNode) {
+	var values []interface{}
+	values = append(values, c.Name)
+	keyword := ""
+	csType := "Cartesian"
+	switch c.Type {
+	case WKT_CRS_GEOGRAPHIC, WKT_CRS_GEOCENTRIC:
+		keyword = "GEOGCRS"
+		csType = "ellipsoidal"
+		if c.Type == WKT_CRS_GEOCENTRIC {
+			keyword = "GEODCRS"
+			csType = "Cartesian"
+		}
+		if base {
+			keyword = "BASE" + keyword
+		}
+		values = append(values, c.Datum.wkt2Node())
+		if c.PrimeMeridian != nil {
+			values = append(values, newWKTNode("PRIMEM", c.PrimeMeridian.Name, c.PrimeMeridian.Longitude, c.PrimeMeridian.Unit.wkt2Node(), c.PrimeMeridian.Authority.wkt2Node()))
+		} else {
+			values = append(values, newWKTNode("PRIMEM", "Greenwich", 0, newDegreeUnit().wkt2Node(), &WKTAuthority{Name: "EPSG", Code: "8901"}))
+		}
+	case WKT_CRS_PROJECTED:
+		keyword = "PROJCRS"
+		if c.BaseCRS != nil {
+			values = append(values, c.BaseCRS.wkt2Node(true))
+		}
+		if c.Projection != nil {
+			values = append(values, c.Projection.wkt2Node())
+		}
+	case WKT_CRS_VERTICAL:
+		keyword = "VERTCRS"
+		csType = "vertical"
+		if c.Datum != nil {
+			values = append(values, newWKTNode("VDATUM", c.Datum.Name, c.Datum.Authority.wkt2Node()))
+		}
+	case WKT_CRS_ENGINEERING:
+		keyword = "ENGCRS"
+		if c.Datum != nil {
+			values = append(values, newWKTNode("EDATUM", c.Datum.Name, c.Datum.Authority.wkt2Node()))
+		}
+	case WKT_CRS_COMPOUND:
+		keyword = "COMPOUNDCRS"
+		for _, component := range c.Components {
+			values = append(values, component.wkt2Node(false))
+		}
+	}
+	if c.Type != WKT_CRS_COMPOUND && !base {
+		axes := c.GetAxes()
+		values = append(values, newWKTNode("CS", wktEnum(csType), len(axes)))
+		unitType := WKT_UNIT_LENGTH
+		if c.Type == WKT_CRS_GEOGRAPHIC {
+			unitType = WKT_UNIT_ANGLE
+		}
+		for index, axis := range axes {
+			unit := axis.Unit
+			if unit == nil {
+				unit = c.getUnit(unitType)
+			}
+			values = append(values, newWKTNode("AXIS", axis.Name, wktEnum(axis.Direction), newWKTNode("ORDER", index+1), unit.wkt2Node()))
+		}
+	}
+	values = append(values, c.Authority.wkt2Node())
+	return newWKTNode(keyword, values...)
+}
+
+func (d *WKTDatum) wkt2Node() *WKTNode {
+	if d == nil {
+		return nil
+	}
+	var ellipsoid *WKTNode
+	if e := d.Ellipsoid; e != nil {
+		ellipsoid = newWKTNode("ELLIPSOID", e.Name, e.SemiMajorAxis, e.InverseFlattening, e.Unit.wkt2Node(), e.Authority.wkt2Node())
+	}
+	return newWKTNode("DATUM", d.Name, ellipsoid, d.Authority.wkt2Node())
+}
+
+func (p *WKTProjection) wkt2Node() *WKTNode {
+	name := p.Name
+	if name == "" {
+		name = "unnamed"
+	}
+	method := getWKTMethod(p.MethodCode, p.Method)
+	methodName, methodAuthority := p.Method, p.MethodAuthority
+	if method != nil {
+		methodName = method.name
+		methodAuthority = &WKTAuthority{Name: "EPSG", Code: strconv.Itoa(method.code)}
+	}
+	values := []interface{}{name, newWKTNode("METHOD", methodName, methodAuthority.wkt2Node())}
+	for _, parameter := range p.Parameters {
+		parameterName, authority := parameter.Name, parameter.Authority
+		if epsgName, ok := wktParameterNames[parameter.Code]; ok {
+			parameterName = epsgName
+			authority = &WKTAuthority{Name: "EPSG", Code: strconv.Itoa(parameter.Code)}
+		}
+		values = append(values, newWKTNode("PARAMETER", parameterName, parameter.Value, parameter.Unit.wkt2Node(), authority.wkt2Node()))
+	}
+	values = append(values, p.Authority.wkt2Node())
+	return newWKTNode("CONVERSION", values...)
+}
+
+// newWKTBoundCRSNode wraps the WKT2 source in a BOUNDCRS to WGS 84 with the ToWGS84 parameters of datum.
+func newWKTBoundCRSNode(source *WKTNode, datum *WKTDatum) *WKTNode {
+	method := newWKTNode("METHOD", "Geocentric translations (geog2D domain)", newWKTNode("ID", "EPSG", 9603))
+	if len(datum.ToWGS84) == 7 {
+		method = newWKTNode("METHOD", "Position Vector transformation (geog2D domain)", newWKTNode("ID", "EPSG", 9606))
+	}
+	values := []interface{}{"Transformation from " + datum.Name + " to WGS84", method}
+	for index, value := range datum.ToWGS84 {
+		var unit *WKTUnit
+		switch {
+		case index < 3:
+			unit = newMetreUnit()
+		case index < 6:
+			unit = &WKTUnit{Type: WKT_UNIT_ANGLE, Name: "arc-second", Factor: ARC_SECOND, Authority: &WKTAuthority{Name: "EPSG", Code: "9104"}}
+		default:
+			unit = &WKTUnit{Type: WKT_UNIT_SCALE, Name: "parts per million", Factor: 1e-6, Authority: &WKTAuthority{Name: "EPSG", Code: "9202"}}
+		}
+		values = append(values, newWKTNode("PARAMETER", wktHelmertParameterNames[index], value, unit.wkt2Node(), newWKTNode("ID", "EPSG", 8605+index)))
+	}
+	return newWKTNode("BOUNDCRS",
+		newWKTNode("SOURCECRS", source),
+		newWKTNode("TARGETCRS", newWGS84CRS().wkt2Node(false)),
+		newWKTNode("ABRIDGEDTRANSFORMATION", values...))
+}
+
+// newWGS84CRS returns the geographic CRS WGS 84, EPSG 4326.
+func newWGS84CRS() *WKTCRS {
+	return &WKTCRS{
+		Type: WKT_CRS_GEOGRAPHIC,
+		Name: "WGS 84",
+		Datum: &WKTDatum{
+			Name: "WGS_1984",
+			Ellipsoid: &WKTEllipsoid{Name: "WGS 84", SemiMajorAxis: 6378137, InverseFlattening: 298.257223563, Unit: newMetreUnit(),
+				Authority: &WKTAuthority{Name: "EPSG", Code: "7030"}},
+			Authority: &WKTAuthority{Name: "EPSG", Code: "6326"},
+		},
+		PrimeMeridian: &WKTPrimeMeridian{Name: "Greenwich", Unit: newDegreeUnit(), Authority: &WKTAuthority{Name: "EPSG", Code: "8901"}},
+		Unit:          newDegreeUnit(),
+		Authority:     &WKTAuthority{Name: "EPSG", Code: "4326"},
+	}
+}
+
+//  _               _____   _____ _____   _____
+// | |        /\   / ____| / ____|  __ \ / ____|
+// | |       /  \ | (___  | |    | |__) | (___
+// | |      / /\ \ \___ \ | |    |  _  / \___ \
+// | |____ / ____ \____) || |____| | \ \ ____) |
+// |______/_/    \_\_____/  \_____|_|  \_\_____/
+//
+//
+
+// Parse parses the coordinate reference system of the record.
+func (c *CoordinateSystemWKT) Parse() (crs *WKTCRS, err error) {
+	if len(*c) == 0 {
+		err = fmt.Errorf("%w: coordinate system WKT record is empty", ErrNotFound)
+		return
+	}
+	return ParseWKT(strings.Join(*c, ""))
+}
+
+// Parse parses the math transform of the record into its tree of nodes.
+func (m *MathTransformWKT) Parse() (node *WKTNode, err error) {
+	if len(*m) == 0 {
+		err = fmt.Errorf("%w: math transform WKT record is empty", ErrNotFound)
+		return
+	}
+	return ParseWKTNode(strings.Join(*m, ""))
+}
+
+// GetCoordinateSystemWKT parses the OGC coordinate system WKT VLR or EVLR of l. It fails with ErrNotFound if l holds
+// none.
+func (l *Las) GetCoordinateSystemWKT() (crs *WKTCRS, err error) {
+	payload, ok := l.getRecordPayload(PROJECTION_USER_ID, COORDINATE_SYSTEM_WKT_RECORD_ID)
+	if !ok {
+		err = fmt.Errorf("%w: no coordinate system WKT record", ErrNotFound)
+		return
+	}
+	return ParseWKT(string(payload))
+}
+
+// SetCoordinateSystemWKT stores crs as the OGC coordinate system WKT record of l and sets the WKT bit of the global
+// encoding. The record is a VLR, or an EVLR if it exceeds the size of a VLR. As LAS 1.4 files use either WKT or
+// GeoTIFF, the GeoKey records are removed. Only LAS 1.4 files can hold WKT; LAS 1.4 requires version WKT_VERSION_1,
+// but WKT_VERSION_2 is read by most current software.
+func (l *Las) SetCoordinateSystemWKT(crs *WKTCRS, version WKTVersion) (err error) {
+	if l.Header.GetVersion() != V1_4 {
+		err = fmt.Errorf("las files with version %s cannot hold a coordinate system WKT record", l.Header.GetVersion())
+		return
+	}
+	payload := append([]byte(crs.WKT(version)), 0)
+	l.RemoveVLRs(PROJECTION_USER_ID, COORDINATE_SYSTEM_WKT_RECORD_ID)
+	l.RemoveEVLRs(PROJECTION_USER_ID, COORDINATE_SYSTEM_WKT_RECORD_ID)
+	l.removeGeoKeyRecords()
+	if len(payload) <= math.MaxUint16 {
+		var vlr VLR
+		if vlr, err = NewVLR(PROJECTION_USER_ID, COORDINATE_SYSTEM_WKT_RECORD_ID, "OGC Coordinate System WKT", payload); err != nil {
+			return
+		}
+		l.AddVLR(vlr)
+	} else {
+		var evlr EVLR
+		if evlr, err = NewEVLR(PROJECTION_USER_ID, COORDINATE_SYSTEM_WKT_RECORD_ID, "OGC Coordinate System WKT", payload); err != nil {
+			return
+		}
+		l.AddEVLR(evlr)
+	}
+	l.Header.GlobalEncoding |= GLOBAL_ENCODING_WKT
+	l.updateCRSInfo()
+	return
+}